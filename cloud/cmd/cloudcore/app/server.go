@@ -42,7 +42,9 @@ import (
 	"github.com/kubeedge/kubeedge/cloud/pkg/cloudstream/iptables"
 	"github.com/kubeedge/kubeedge/cloud/pkg/common/client"
 	"github.com/kubeedge/kubeedge/cloud/pkg/common/informers"
+	"github.com/kubeedge/kubeedge/cloud/pkg/common/leaderelection"
 	"github.com/kubeedge/kubeedge/cloud/pkg/common/modules"
+	"github.com/kubeedge/kubeedge/cloud/pkg/common/sharding"
 	"github.com/kubeedge/kubeedge/cloud/pkg/devicecontroller"
 	"github.com/kubeedge/kubeedge/cloud/pkg/dynamiccontroller"
 	"github.com/kubeedge/kubeedge/cloud/pkg/edgecontroller"
@@ -102,11 +104,17 @@ kubernetes controller which manages devices so that the device metadata/status d
 
 			config.CommonConfig.TunnelPort = *tunnelport
 
+			// join the replicas sharding the edge nodes before the controllers start
+			if err := sharding.Init(config.Modules.CloudHub, client.GetKubeClient()); err != nil {
+				klog.Exit(err)
+			}
+
 			gis := informers.GetInformersManager()
 
 			registerModules(config)
 
 			ctx := beehiveContext.GetContext()
+			go sharding.Run(ctx)
 			if config.Modules.IptablesManager == nil || config.Modules.IptablesManager.Enable && config.Modules.IptablesManager.Mode == v1alpha1.InternalMode {
 				// By default, IptablesManager manages tunnel port related iptables rules
 				// The internal mode will share the host network, forward to the stream port.
@@ -153,6 +161,8 @@ func registerModules(c *v1alpha1.CloudCoreConfig) {
 	cloudstream.Register(c.Modules.CloudStream, c.CommonConfig)
 	router.Register(c.Modules.Router)
 	dynamiccontroller.Register(c.Modules.DynamicController)
+	// the singleton modules are replaced by the elected ones, so they run on only one replica
+	leaderelection.Register(c.Modules.LeaderElection, client.GetKubeClient())
 }
 
 func NegotiateTunnelPort() (*int, error) {
@@ -23,7 +23,7 @@ func NewControllerManagerCommand(ctx context.Context) *cobra.Command {
 		Use:  "controller-manager",
 		Long: `The node group controller manager run a bunch of controllers`,
 		Run: func(cmd *cobra.Command, args []string) {
-			Run(ctx)
+			Run(ctx, opts)
 		},
 	}
 
@@ -39,8 +39,12 @@ func NewControllerManagerCommand(ctx context.Context) *cobra.Command {
 	return cmd
 }
 
-func Run(ctx context.Context) {
-	mgr, err := controllermanager.NewAppsControllerManager(ctx)
+func Run(ctx context.Context, opts *options.ControllerManagerOptions) {
+	mgr, err := controllermanager.NewAppsControllerManager(ctx, controllermanager.LeaderElection{
+		Enable:    opts.LeaderElect,
+		Namespace: opts.LeaderElectResourceNamespace,
+		Name:      opts.LeaderElectResourceName,
+	})
 	if err != nil {
 		klog.Fatalf("failed to get controller manager, %v", err)
 	}
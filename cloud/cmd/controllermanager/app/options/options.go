@@ -2,18 +2,30 @@ package options
 
 import (
 	cliflag "k8s.io/component-base/cli/flag"
+
+	"github.com/kubeedge/kubeedge/common/constants"
 )
 
 type ControllerManagerOptions struct {
 	UseServerSideApply bool
+	// LeaderElect runs the controllers on only one of the replicas holding the Lease
+	LeaderElect                  bool
+	LeaderElectResourceNamespace string
+	LeaderElectResourceName      string
 }
 
 func NewControllerManagerOptions() *ControllerManagerOptions {
-	return &ControllerManagerOptions{}
+	return &ControllerManagerOptions{
+		LeaderElectResourceNamespace: constants.SystemNamespace,
+		LeaderElectResourceName:      "kubeedge-controller-manager",
+	}
 }
 
 func (o *ControllerManagerOptions) Flags() (fss cliflag.NamedFlagSets) {
 	fs := fss.FlagSet("ControllerManager")
 	fs.BoolVar(&o.UseServerSideApply, "use-server-side-apply", o.UseServerSideApply, "If use server-side apply when updating templates")
+	fs.BoolVar(&o.LeaderElect, "leader-elect", o.LeaderElect, "Start a leader election client and gain leadership before running the controllers, enable it when running replicated controller manager for high availability")
+	fs.StringVar(&o.LeaderElectResourceNamespace, "leader-elect-resource-namespace", o.LeaderElectResourceNamespace, "The namespace of the Lease object used for leader election")
+	fs.StringVar(&o.LeaderElectResourceName, "leader-elect-resource-name", o.LeaderElectResourceName, "The name of the Lease object used for leader election")
 	return
 }
@@ -0,0 +1,237 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package admin serves the admin API of the sessions and the message pools of the edge
+// nodes connected to CloudHub, so the operators can see what is stuck for a node.
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/emicklei/go-restful"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+
+	beehivemodel "github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/session"
+	"github.com/kubeedge/kubeedge/pkg/hubadmin"
+)
+
+const nodeParam = "node"
+
+// Admin serves the admin API on the sessions of the session manager
+type Admin struct {
+	sessionManager *session.Manager
+	// kubeClient reviews the bearer tokens and the access of the admin requests
+	kubeClient kubernetes.Interface
+}
+
+// NewAdmin returns the admin of the sessions authenticating and authorizing the requests
+// by the kube-apiserver, like the requests of the kubeconfig users to it
+func NewAdmin(sessionManager *session.Manager, kubeClient kubernetes.Interface) *Admin {
+	return &Admin{
+		sessionManager: sessionManager,
+		kubeClient:     kubeClient,
+	}
+}
+
+// WebService returns the web service of the admin API, all routes require an authorized bearer token
+func (a *Admin) WebService() *restful.WebService {
+	ws := new(restful.WebService)
+	ws.Path(hubadmin.NodesPath).Produces(restful.MIME_JSON)
+	ws.Filter(a.authenticate)
+	ws.Route(ws.GET("").To(a.listNodes))
+	ws.Route(ws.GET(fmt.Sprintf("/{%s}/%s", nodeParam, hubadmin.MessagesSubPath)).To(a.listMessages))
+	ws.Route(ws.POST(fmt.Sprintf("/{%s}/%s", nodeParam, hubadmin.PurgeSubPath)).To(a.purge))
+	ws.Route(ws.POST(fmt.Sprintf("/{%s}/%s", nodeParam, hubadmin.ResendSubPath)).To(a.resend))
+	return ws
+}
+
+// MetricsWebService returns the web service of the metrics registered, it requires an authorized bearer token
+func (a *Admin) MetricsWebService() *restful.WebService {
+	ws := new(restful.WebService)
+	ws.Path(hubadmin.MetricsPath)
+	ws.Filter(a.authenticate)
+	ws.Route(ws.GET("").To(func(request *restful.Request, response *restful.Response) {
+		legacyregistry.Handler().ServeHTTP(response.ResponseWriter, request.Request)
+	}))
+	return ws
+}
+
+// authenticate rejects the requests whose bearer token is not authenticated by TokenReview, or whose
+// user is not allowed the verb on the non-resource URL of the request by SubjectAccessReview
+func (a *Admin) authenticate(request *restful.Request, response *restful.Response, chain *restful.FilterChain) {
+	bearerToken := strings.Split(request.HeaderParameter("authorization"), " ")
+	if len(bearerToken) != 2 || !strings.EqualFold(bearerToken[0], "bearer") {
+		writeError(response, http.StatusUnauthorized, "invalid authorization token")
+		return
+	}
+	ctx := request.Request.Context()
+	review, err := a.kubeClient.AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: bearerToken[1]},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		klog.Errorf("failed to review the token of admin request from %s: %v", request.Request.RemoteAddr, err)
+		writeError(response, http.StatusInternalServerError, "failed to authenticate")
+		return
+	}
+	if !review.Status.Authenticated {
+		klog.Warningf("reject admin request %s %s from %s: %s", request.Request.Method,
+			request.Request.URL.Path, request.Request.RemoteAddr, review.Status.Error)
+		writeError(response, http.StatusUnauthorized, "invalid authorization token")
+		return
+	}
+
+	user := review.Status.User
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for k, v := range user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	access, err := a.kubeClient.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			NonResourceAttributes: &authorizationv1.NonResourceAttributes{
+				Path: request.Request.URL.Path,
+				Verb: strings.ToLower(request.Request.Method),
+			},
+			User:   user.Username,
+			Groups: user.Groups,
+			UID:    user.UID,
+			Extra:  extra,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		klog.Errorf("failed to review the access of admin request from %s: %v", request.Request.RemoteAddr, err)
+		writeError(response, http.StatusInternalServerError, "failed to authorize")
+		return
+	}
+	if !access.Status.Allowed {
+		klog.Warningf("reject admin request %s %s of user %s: %s", request.Request.Method,
+			request.Request.URL.Path, user.Username, access.Status.Reason)
+		writeError(response, http.StatusForbidden, fmt.Sprintf("user %s is not allowed to %s %s",
+			user.Username, strings.ToLower(request.Request.Method), request.Request.URL.Path))
+		return
+	}
+	chain.ProcessFilter(request, response)
+}
+
+// listNodes shows the sessions and the message pools of the connected nodes
+func (a *Admin) listNodes(request *restful.Request, response *restful.Response) {
+	var nodes []hubadmin.NodeStatus
+	a.sessionManager.NodeSessions.Range(func(_, value interface{}) bool {
+		if nodeSession, ok := value.(*session.NodeSession); ok {
+			nodes = append(nodes, nodeSession.Status())
+		}
+		return true
+	})
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].NodeID < nodes[j].NodeID })
+	writeJSON(response, nodes)
+}
+
+// listMessages shows the messages held in the message pool of the node by resource and operation
+func (a *Admin) listMessages(request *restful.Request, response *restful.Response) {
+	nodeSession, ok := a.getSession(request, response)
+	if !ok {
+		return
+	}
+	ack, noAck := nodeSession.MessagePool().Messages()
+	messages := make([]hubadmin.Message, 0, len(ack)+len(noAck))
+	for _, msg := range ack {
+		messages = append(messages, summarize(hubadmin.AckQueue, msg))
+	}
+	for _, msg := range noAck {
+		messages = append(messages, summarize(hubadmin.NoAckQueue, msg))
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		if messages[i].Resource != messages[j].Resource {
+			return messages[i].Resource < messages[j].Resource
+		}
+		if messages[i].Operation != messages[j].Operation {
+			return messages[i].Operation < messages[j].Operation
+		}
+		return messages[i].Timestamp < messages[j].Timestamp
+	})
+	writeJSON(response, messages)
+}
+
+// purge deletes the messages held in the message pool of the node
+func (a *Admin) purge(request *restful.Request, response *restful.Response) {
+	nodeSession, ok := a.getSession(request, response)
+	if !ok {
+		return
+	}
+	result := hubadmin.Result{
+		NodeID:   nodeSession.Status().NodeID,
+		Messages: nodeSession.MessagePool().Purge(),
+	}
+	klog.Infof("admin from %s purged %d messages of node %s", request.Request.RemoteAddr, result.Messages, result.NodeID)
+	writeJSON(response, result)
+}
+
+// resend queues the messages held in the message pool of the node to send again
+func (a *Admin) resend(request *restful.Request, response *restful.Response) {
+	nodeSession, ok := a.getSession(request, response)
+	if !ok {
+		return
+	}
+	result := hubadmin.Result{
+		NodeID:   nodeSession.Status().NodeID,
+		Messages: nodeSession.MessagePool().Resend(),
+	}
+	klog.Infof("admin from %s queued %d messages of node %s to send again", request.Request.RemoteAddr, result.Messages, result.NodeID)
+	writeJSON(response, result)
+}
+
+// getSession returns the session of the node in the path, a not found error is responded if the node isn't connected
+func (a *Admin) getSession(request *restful.Request, response *restful.Response) (*session.NodeSession, bool) {
+	nodeID := request.PathParameter(nodeParam)
+	nodeSession, ok := a.sessionManager.GetSession(nodeID)
+	if !ok {
+		writeError(response, http.StatusNotFound, fmt.Sprintf("node %s is not connected", nodeID))
+		return nil, false
+	}
+	return nodeSession, true
+}
+
+func summarize(queue string, msg *beehivemodel.Message) hubadmin.Message {
+	return hubadmin.Message{
+		Queue:           queue,
+		ID:              msg.GetID(),
+		Group:           msg.GetGroup(),
+		Resource:        msg.GetResource(),
+		Operation:       msg.GetOperation(),
+		ResourceVersion: msg.GetResourceVersion(),
+		Timestamp:       msg.GetTimestamp(),
+	}
+}
+
+func writeJSON(response *restful.Response, value interface{}) {
+	if err := response.WriteAsJson(value); err != nil {
+		klog.Errorf("failed to write admin response, err: %v", err)
+	}
+}
+
+func writeError(response *restful.Response, status int, message string) {
+	if err := response.WriteErrorString(status, message); err != nil {
+		klog.Errorf("failed to write admin response, err: %v", err)
+	}
+}
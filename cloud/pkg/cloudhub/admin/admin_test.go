@@ -0,0 +1,168 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	beehivemodel "github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/common"
+	"github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/session"
+	edgeconst "github.com/kubeedge/kubeedge/cloud/pkg/edgecontroller/constants"
+	"github.com/kubeedge/kubeedge/pkg/hubadmin"
+)
+
+// newFakeKubeClient returns the client authenticating the tokens given to the users,
+// and allowing the admin user only
+func newFakeKubeClient(tokens map[string]string) *fake.Clientset {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		user, ok := tokens[review.Spec.Token]
+		review.Status = authenticationv1.TokenReviewStatus{
+			Authenticated: ok,
+			User:          authenticationv1.UserInfo{Username: user},
+		}
+		return true, review, nil
+	})
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		attrs := review.Spec.NonResourceAttributes
+		review.Status.Allowed = review.Spec.User == "admin" && attrs != nil &&
+			strings.HasPrefix(attrs.Path, "/admin/") && (attrs.Verb == "get" || attrs.Verb == "post")
+		return true, review, nil
+	})
+	return client
+}
+
+func TestAdmin(t *testing.T) {
+	token, viewerToken := "admin-token", "viewer-token"
+	kubeClient := newFakeKubeClient(map[string]string{token: "admin", viewerToken: "viewer"})
+
+	pool := common.InitNodeMessagePool("edge-1")
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default", UID: "uid-1"}}
+	ackMsg := beehivemodel.NewMessage("").
+		BuildRouter("edgecontroller", edgeconst.GroupResource, "node/edge-1/default/pod/nginx", beehivemodel.UpdateOperation).
+		SetResourceVersion("1").FillBody(pod)
+	noAckMsg := beehivemodel.NewMessage("").
+		BuildRouter("edgecontroller", edgeconst.GroupResource, "node/edge-1/default/configmap/cm", beehivemodel.ResponseOperation)
+	if err := pool.AckMessageStore.Add(ackMsg); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := pool.NoAckMessageStore.Add(noAckMsg); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	sessionManager := session.NewSessionManager(10)
+	sessionManager.AddSession(session.NewNodeSession("edge-1", "project", nil, time.Minute, pool, nil))
+
+	container := restful.NewContainer()
+	hubAdmin := NewAdmin(sessionManager, kubeClient)
+	container.Add(hubAdmin.WebService())
+	container.Add(hubAdmin.MetricsWebService())
+	server := httptest.NewServer(container)
+	defer server.Close()
+
+	do := func(method, path, token string, out interface{}) int {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+path, nil)
+		if err != nil {
+			t.Fatalf("NewRequest() error = %v", err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+		defer resp.Body.Close()
+		if out != nil && resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+		}
+		return resp.StatusCode
+	}
+
+	if code := do(http.MethodGet, hubadmin.NodesPath, "", nil); code != http.StatusUnauthorized {
+		t.Errorf("expected unauthorized without token, but got %d", code)
+	}
+	if code := do(http.MethodGet, hubadmin.NodesPath, "unknown-token", nil); code != http.StatusUnauthorized {
+		t.Errorf("expected unauthorized with the token not authenticated, but got %d", code)
+	}
+	if code := do(http.MethodGet, hubadmin.NodesPath, viewerToken, nil); code != http.StatusForbidden {
+		t.Errorf("expected forbidden for the user not allowed, but got %d", code)
+	}
+
+	var nodes []hubadmin.NodeStatus
+	if code := do(http.MethodGet, hubadmin.NodesPath, token, &nodes); code != http.StatusOK {
+		t.Fatalf("expected nodes listed, but got %d", code)
+	}
+	if len(nodes) != 1 || nodes[0].NodeID != "edge-1" || nodes[0].State != hubadmin.StateSyncing ||
+		nodes[0].AckStoreSize != 1 || nodes[0].NoAckStoreSize != 1 {
+		t.Errorf("unexpected nodes %+v", nodes)
+	}
+
+	var messages []hubadmin.Message
+	if code := do(http.MethodGet, hubadmin.NodesPath+"/edge-1/"+hubadmin.MessagesSubPath, token, &messages); code != http.StatusOK {
+		t.Fatalf("expected messages listed, but got %d", code)
+	}
+	if len(messages) != 2 || messages[0].Queue != hubadmin.NoAckQueue || messages[1].Queue != hubadmin.AckQueue ||
+		messages[1].Operation != beehivemodel.UpdateOperation || messages[1].ResourceVersion != "1" {
+		t.Errorf("unexpected messages %+v", messages)
+	}
+	if code := do(http.MethodGet, hubadmin.NodesPath+"/edge-2/"+hubadmin.MessagesSubPath, token, nil); code != http.StatusNotFound {
+		t.Errorf("expected not found for the node not connected, but got %d", code)
+	}
+
+	var result hubadmin.Result
+	if code := do(http.MethodPost, hubadmin.NodesPath+"/edge-1/"+hubadmin.ResendSubPath, token, &result); code != http.StatusOK {
+		t.Fatalf("expected messages resent, but got %d", code)
+	}
+	if result.Messages != 2 || pool.AckMessageQueue.Len() != 1 || pool.NoAckMessageQueue.Len() != 1 {
+		t.Errorf("unexpected resend result %+v", result)
+	}
+
+	if code := do(http.MethodPost, hubadmin.NodesPath+"/edge-1/"+hubadmin.PurgeSubPath, token, &result); code != http.StatusOK {
+		t.Fatalf("expected messages purged, but got %d", code)
+	}
+	if ack, noAck := pool.Messages(); result.Messages != 2 || len(ack) != 0 || len(noAck) != 0 {
+		t.Errorf("unexpected purge result %+v", result)
+	}
+
+	if code := do(http.MethodGet, hubadmin.MetricsPath, "", nil); code != http.StatusUnauthorized {
+		t.Errorf("expected unauthorized metrics without token, but got %d", code)
+	}
+	if code := do(http.MethodGet, hubadmin.MetricsPath, token, nil); code != http.StatusOK {
+		t.Errorf("expected metrics served, but got %d", code)
+	}
+}
@@ -2,15 +2,21 @@ package cloudhub
 
 import (
 	"os"
+	"time"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
 	"github.com/kubeedge/beehive/pkg/core"
 	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
+	"github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/admin"
+	"github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/common"
 	hubconfig "github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/config"
 	"github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/dispatcher"
 	"github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/handler"
+	"github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/revocation"
 	"github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/servers"
 	"github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/servers/httpserver"
 	"github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/servers/udsserver"
@@ -18,17 +24,32 @@ import (
 	"github.com/kubeedge/kubeedge/cloud/pkg/common/client"
 	"github.com/kubeedge/kubeedge/cloud/pkg/common/informers"
 	"github.com/kubeedge/kubeedge/cloud/pkg/common/modules"
+	"github.com/kubeedge/kubeedge/cloud/pkg/common/sharding"
 	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/cloudcore/v1alpha1"
 )
 
 var DoneTLSTunnelCerts = make(chan bool, 1)
 
+// hubSessions is the session manager of the CloudHub module, it is nil until the module is registered
+var hubSessions *session.Manager
+
+// HasSession returns whether the node is connected to this CloudHub
+func HasSession(nodeID string) bool {
+	if hubSessions == nil {
+		return false
+	}
+	_, ok := hubSessions.GetSession(nodeID)
+	return ok
+}
+
 type cloudHub struct {
 	enable               bool
 	informersSyncedFuncs []cache.InformerSynced
+	nodeLister           corelisters.NodeLister
 
 	messageHandler handler.Handler
 	dispatcher     dispatcher.MessageDispatcher
+	sessionManager *session.Manager
 }
 
 var _ core.Module = (*cloudHub)(nil)
@@ -40,6 +61,11 @@ func newCloudHub(enable bool) *cloudHub {
 	objectSyncInformer := crdFactory.Reliablesyncs().V1alpha1().ObjectSyncs()
 
 	sessionManager := session.NewSessionManager(hubconfig.Config.NodeLimit)
+	hubSessions = sessionManager
+	if a := hubconfig.Config.Admission; a != nil && a.Enable {
+		sessionManager.Admission = session.NewAdmission(int(a.MaxConcurrentSyncs),
+			time.Duration(a.InitialSyncTimeout)*time.Second, time.Duration(a.RetryAfter)*time.Second)
+	}
 
 	messageDispatcher := dispatcher.NewMessageDispatcher(
 		sessionManager, objectSyncInformer.Lister(),
@@ -53,11 +79,18 @@ func newCloudHub(enable bool) *cloudHub {
 		enable:         enable,
 		dispatcher:     messageDispatcher,
 		messageHandler: messageHandler,
+		sessionManager: sessionManager,
 	}
 
 	ch.informersSyncedFuncs = append(ch.informersSyncedFuncs, clusterObjectSyncInformer.Informer().HasSynced)
 	ch.informersSyncedFuncs = append(ch.informersSyncedFuncs, objectSyncInformer.Informer().HasSynced)
 
+	// the messages kept on disk for the nodes deleted are removed
+	nodeInformer := informers.GetInformersManager().GetK8sInformerFactory().Core().V1().Nodes()
+	nodeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{DeleteFunc: onNodeDelete})
+	ch.nodeLister = nodeInformer.Lister()
+	ch.informersSyncedFuncs = append(ch.informersSyncedFuncs, nodeInformer.Informer().HasSynced)
+
 	return ch
 }
 
@@ -85,6 +118,19 @@ func (ch *cloudHub) Start() {
 		os.Exit(1)
 	}
 
+	// the message pools initialized after it keep the messages on disk
+	if err := common.InitSpill(hubconfig.Config.Spill); err != nil {
+		klog.Exit(err)
+	}
+	common.RemoveSpilledNodes(func(nodeID string) bool {
+		_, err := ch.nodeLister.Get(nodeID)
+		return !apierrors.IsNotFound(err)
+	})
+	go func() {
+		<-beehiveContext.Done()
+		common.CloseSpill()
+	}()
+
 	// start dispatch message from the cloud to edge node
 	go ch.dispatcher.DispatchDownstream()
 
@@ -97,13 +143,25 @@ func (ch *cloudHub) Start() {
 	DoneTLSTunnelCerts <- true
 	close(DoneTLSTunnelCerts)
 
+	// the revoked edge certificates are rejected and the sessions holding them are terminated
+	if err := revocation.Watch(client.GetKubeClient(), beehiveContext.Done(), ch.sessionManager.TerminateRevokedSessions); err != nil {
+		klog.Exit(err)
+	}
+
+	// the nodes moved to other cloudcore replicas are redirected to them when they connect again
+	sharding.OnRebalance(ch.sessionManager.TerminateMovedSessions)
+
 	// generate Token
 	if err := httpserver.GenerateToken(); err != nil {
 		klog.Exit(err)
 	}
 
-	// HttpServer mainly used to issue certificates for the edge
-	go httpserver.StartHTTPServer()
+	registerMetrics()
+
+	// HttpServer mainly used to issue certificates for the edge, it serves the admin API
+	// of the node sessions and message pools and the metrics as well
+	hubAdmin := admin.NewAdmin(ch.sessionManager, client.GetKubeClient())
+	go httpserver.StartHTTPServer(hubAdmin.WebService(), hubAdmin.MetricsWebService())
 
 	servers.StartCloudHub(ch.messageHandler)
 
@@ -113,3 +171,12 @@ func (ch *cloudHub) Start() {
 		go udsserver.StartServer(hubconfig.Config.UnixSocket.Address)
 	}
 }
+
+func onNodeDelete(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		klog.Errorf("failed to get key of node deleted: %v", err)
+		return
+	}
+	common.RemoveSpilledNode(key)
+}
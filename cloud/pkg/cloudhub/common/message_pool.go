@@ -21,6 +21,7 @@ import (
 
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
 
 	beehivemodel "github.com/kubeedge/beehive/pkg/core/model"
 )
@@ -44,8 +45,12 @@ type NodeMessagePool struct {
 	NoAckMessageQueue workqueue.RateLimitingInterface
 }
 
-// InitNodeMessagePool init node message pool for node
+// InitNodeMessagePool init node message pool for node, if the spill is initialized, the messages
+// are kept on disk and the messages kept before for the node are queued again
 func InitNodeMessagePool(nodeID string) *NodeMessagePool {
+	if spill != nil {
+		return initSpilledNodeMessagePool(spill, nodeID)
+	}
 	return &NodeMessagePool{
 		AckMessageStore:   cache.NewStore(AckMessageKeyFunc),
 		AckMessageQueue:   workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), nodeID),
@@ -54,6 +59,40 @@ func InitNodeMessagePool(nodeID string) *NodeMessagePool {
 	}
 }
 
+func initSpilledNodeMessagePool(s *Spill, nodeID string) *NodeMessagePool {
+	ackStore := newSpilledStore(s, nodeID, bucketAck, AckMessageKeyFunc, nil)
+	noAckStore := newSpilledStore(s, nodeID, bucketNoAck, NoAckMessageKeyFunc, spillsNoAckMessage)
+	nsp := &NodeMessagePool{
+		AckMessageStore:   ackStore,
+		AckMessageQueue:   workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), nodeID),
+		NoAckMessageStore: noAckStore,
+		NoAckMessageQueue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), nodeID),
+	}
+
+	for _, restore := range []struct {
+		store  *spilledStore
+		queue  workqueue.RateLimitingInterface
+		bucket []byte
+	}{
+		{store: ackStore, queue: nsp.AckMessageQueue, bucket: bucketAck},
+		{store: noAckStore, queue: nsp.NoAckMessageQueue, bucket: bucketNoAck},
+	} {
+		messages, err := s.load(nodeID, restore.bucket)
+		if err != nil {
+			klog.Errorf("failed to restore message pool of node %s: %v", nodeID, err)
+			continue
+		}
+		keys := restore.store.restore(messages)
+		for _, key := range keys {
+			restore.queue.Add(key)
+		}
+		if len(keys) != 0 {
+			klog.Infof("restored %d %s messages of node %s from spill", len(keys), restore.bucket, nodeID)
+		}
+	}
+	return nsp
+}
+
 // GetAckMessage get message that requires ack with the key
 func (nsp *NodeMessagePool) GetAckMessage(key string) (*beehivemodel.Message, error) {
 	obj, exist, err := nsp.AckMessageStore.GetByKey(key)
@@ -100,6 +139,63 @@ func (nsp *NodeMessagePool) GetNoAckMessage(key string) (*beehivemodel.Message,
 	return msg, nil
 }
 
+// AckMessageDelivered is called when the message is acknowledged by the node, the message
+// stays in the store but it is not kept on disk anymore
+func (nsp *NodeMessagePool) AckMessageDelivered(msg *beehivemodel.Message) {
+	store, ok := nsp.AckMessageStore.(*spilledStore)
+	if !ok {
+		return
+	}
+	if err := store.acknowledged(msg); err != nil {
+		klog.Errorf("failed to remove message %s acknowledged from spill: %v", msg.GetID(), err)
+	}
+}
+
+// Messages returns the messages held in the message pool that require ack and that don't
+func (nsp *NodeMessagePool) Messages() (ack, noAck []*beehivemodel.Message) {
+	return storedMessages(nsp.AckMessageStore), storedMessages(nsp.NoAckMessageStore)
+}
+
+// Purge deletes the messages held in the message pool and returns the number of them,
+// the keys of the messages left in the queues are skipped when they are sent
+func (nsp *NodeMessagePool) Purge() int {
+	purged := 0
+	for _, store := range []cache.Store{nsp.AckMessageStore, nsp.NoAckMessageStore} {
+		for _, msg := range storedMessages(store) {
+			if err := store.Delete(msg); err != nil {
+				continue
+			}
+			purged++
+		}
+	}
+	return purged
+}
+
+// Resend queues the messages held in the message pool to send again and returns the number
+// of them, the messages already queued are not queued twice
+func (nsp *NodeMessagePool) Resend() int {
+	resent := 0
+	for _, key := range nsp.AckMessageStore.ListKeys() {
+		nsp.AckMessageQueue.Add(key)
+		resent++
+	}
+	for _, key := range nsp.NoAckMessageStore.ListKeys() {
+		nsp.NoAckMessageQueue.Add(key)
+		resent++
+	}
+	return resent
+}
+
+func storedMessages(store cache.Store) []*beehivemodel.Message {
+	var messages []*beehivemodel.Message
+	for _, obj := range store.List() {
+		if msg, ok := obj.(*beehivemodel.Message); ok && msg != nil {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
 // ShutDown will close all the message queue in the message pool
 func (nsp *NodeMessagePool) ShutDown() {
 	nsp.AckMessageQueue.ShutDown()
@@ -0,0 +1,524 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	beehivemodel "github.com/kubeedge/beehive/pkg/core/model"
+	deviceconst "github.com/kubeedge/kubeedge/cloud/pkg/devicecontroller/constants"
+	edgecon "github.com/kubeedge/kubeedge/cloud/pkg/edgecontroller/constants"
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/cloudcore/v1alpha1"
+)
+
+// ErrSpillFull means the message is rejected since the messages kept on disk for the node reach the limit
+var ErrSpillFull = errors.New("messages kept on disk for the node reach the limit")
+
+// the buckets of a node in the spill, each node has a top-level bucket named by the node ID
+var (
+	bucketAck   = []byte("ack")
+	bucketNoAck = []byte("noack")
+)
+
+// spillCommitInterval is the interval the changes of the messages are committed to disk in one
+// transaction, so the dispatching doesn't wait for a sync of the disk for each message. The changes
+// in the last interval are lost if cloudcore crashes, the messages are sent again by the controllers
+// after restarts as they are not acknowledged.
+const spillCommitInterval = 100 * time.Millisecond
+
+// spill is the disk storage of the message pools, nil if the messages are kept in memory only
+var spill *Spill
+
+// spilledMessage is the stored form of a message
+type spilledMessage struct {
+	Header  beehivemodel.MessageHeader `json:"header"`
+	Router  beehivemodel.MessageRoute  `json:"route,omitempty"`
+	Content json.RawMessage            `json:"content,omitempty"`
+	// Raw is the content of the message if it is bytes, which is encoded in base64 by json otherwise
+	Raw []byte `json:"raw,omitempty"`
+}
+
+// Spill keeps the messages held in the message pools on disk. A message is written when it is added
+// to the store of the pool, and removed when it is deleted from the store, or when it is acknowledged
+// by the node if it requires acknowledgment. The messages acknowledged stay in the ack store in memory
+// only, so they are compared with the messages arriving later but not sent again after restarts.
+type Spill struct {
+	lock sync.Mutex
+	db   *bolt.DB
+	// limits is the max number of messages kept for each node by bucket
+	limits map[string]int
+	// ids is the IDs of the messages kept by node, bucket and key, including the changes not committed
+	ids map[string]map[string]map[string]string
+	// pending is the changes not committed by node, bucket and key, the nil value deletes the key
+	pending map[string]map[string]map[string][]byte
+	// removed is the nodes whose buckets are deleted on the next commit
+	removed map[string]bool
+
+	// commitLock serializes the commits, so the changes taken by a commit are written before
+	// the messages are loaded
+	commitLock sync.Mutex
+	stopCh     chan struct{}
+	stopped    chan struct{}
+}
+
+// InitSpill opens the spill configured, the message pools initialized after it keep their messages
+// on disk and load the messages kept before for their nodes
+func InitSpill(config *v1alpha1.CloudHubSpill) error {
+	if config == nil || !config.Enable {
+		return nil
+	}
+	s, err := OpenSpill(config.DBPath, config.AckMessageLimit, config.NoAckMessageLimit)
+	if err != nil {
+		return err
+	}
+	spill = s
+	return nil
+}
+
+// OpenSpill opens the spill stored in the file of path, the messages kept before are loaded by
+// the message pools of their nodes
+func OpenSpill(path string, ackLimit, noAckLimit int32) (*Spill, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 10 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spill %s: %v", path, err)
+	}
+	s := &Spill{
+		db: db,
+		limits: map[string]int{
+			string(bucketAck):   int(ackLimit),
+			string(bucketNoAck): int(noAckLimit),
+		},
+		ids:     make(map[string]map[string]map[string]string),
+		pending: make(map[string]map[string]map[string][]byte),
+		removed: make(map[string]bool),
+		stopCh:  make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	total := 0
+	err = db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(nodeID []byte, node *bolt.Bucket) error {
+			for _, name := range [][]byte{bucketAck, bucketNoAck} {
+				b := node.Bucket(name)
+				if b == nil {
+					continue
+				}
+				ids := s.bucketIDs(string(nodeID), name)
+				err := b.ForEach(func(key, value []byte) error {
+					var stored spilledMessage
+					if err := json.Unmarshal(value, &stored); err != nil {
+						return err
+					}
+					ids[string(key)] = stored.Header.ID
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+				total += len(ids)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to load spill %s: %v", path, err)
+	}
+	klog.Infof("spill %s opened with %d messages of %d nodes", path, total, len(s.ids))
+	go s.run()
+	return s, nil
+}
+
+// RemoveSpilledNode deletes the messages kept on disk for the node, it is called when the node is deleted
+func RemoveSpilledNode(nodeID string) {
+	if spill != nil {
+		spill.removeNode(nodeID)
+	}
+}
+
+// RemoveSpilledNodes deletes the messages kept on disk for the nodes not existing,
+// which may be deleted while cloudcore is down
+func RemoveSpilledNodes(exists func(nodeID string) bool) {
+	if spill == nil {
+		return
+	}
+	for _, nodeID := range spill.nodes() {
+		if !exists(nodeID) {
+			klog.Infof("remove spilled messages of node %s deleted", nodeID)
+			spill.removeNode(nodeID)
+		}
+	}
+}
+
+// CloseSpill commits the changes of the messages and closes the spill
+func CloseSpill() {
+	if spill == nil {
+		return
+	}
+	if err := spill.Close(); err != nil {
+		klog.Errorf("failed to close spill: %v", err)
+	}
+}
+
+// Close commits the changes of the messages and closes the spill
+func (s *Spill) Close() error {
+	close(s.stopCh)
+	<-s.stopped
+	if err := s.commit(); err != nil {
+		klog.Error(err)
+	}
+	return s.db.Close()
+}
+
+// run commits the changes of the messages periodically until the spill is closed
+func (s *Spill) run() {
+	defer close(s.stopped)
+	ticker := time.NewTicker(spillCommitInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.commit(); err != nil {
+				klog.Error(err)
+			}
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// commit writes the changes of the messages since the last commit in one transaction
+func (s *Spill) commit() error {
+	s.commitLock.Lock()
+	defer s.commitLock.Unlock()
+
+	s.lock.Lock()
+	pending, removed := s.pending, s.removed
+	s.pending = make(map[string]map[string]map[string][]byte)
+	s.removed = make(map[string]bool)
+	s.lock.Unlock()
+	if len(pending) == 0 && len(removed) == 0 {
+		return nil
+	}
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		for nodeID := range removed {
+			if tx.Bucket([]byte(nodeID)) == nil {
+				continue
+			}
+			if err := tx.DeleteBucket([]byte(nodeID)); err != nil {
+				return err
+			}
+		}
+		for nodeID, buckets := range pending {
+			for name, changes := range buckets {
+				b, err := nodeBucket(tx, nodeID, []byte(name))
+				if err != nil {
+					return err
+				}
+				for key, value := range changes {
+					if value == nil {
+						err = b.Delete([]byte(key))
+					} else {
+						err = b.Put([]byte(key), value)
+					}
+					if err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to commit spilled messages of %d nodes: %v", len(pending), err)
+	}
+	return nil
+}
+
+// put writes the message of the key to the bucket of the node on the next commit, ErrSpillFull
+// is returned if the message is new and the bucket reaches the limit
+func (s *Spill) put(nodeID string, bucket []byte, key string, msg *beehivemodel.Message) error {
+	value, err := marshalSpilledMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	ids := s.bucketIDs(nodeID, bucket)
+	if _, ok := ids[key]; !ok && len(ids) >= s.limits[string(bucket)] {
+		return ErrSpillFull
+	}
+	ids[key] = msg.GetID()
+	s.bucketChanges(nodeID, bucket)[key] = value
+	return nil
+}
+
+// delete removes the message of the key from the bucket of the node on the next commit, the message
+// is removed only if its ID is id unless id is empty, so the newer message of the key is kept
+func (s *Spill) delete(nodeID string, bucket []byte, key, id string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	ids := s.bucketIDs(nodeID, bucket)
+	stored, ok := ids[key]
+	if !ok || (id != "" && stored != id) {
+		return
+	}
+	delete(ids, key)
+	s.bucketChanges(nodeID, bucket)[key] = nil
+}
+
+// removeNode deletes the buckets of the node on the next commit
+func (s *Spill) removeNode(nodeID string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.ids, nodeID)
+	delete(s.pending, nodeID)
+	s.removed[nodeID] = true
+}
+
+// nodes returns the nodes whose messages are kept
+func (s *Spill) nodes() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	nodes := make([]string, 0, len(s.ids))
+	for nodeID := range s.ids {
+		nodes = append(nodes, nodeID)
+	}
+	return nodes
+}
+
+// load returns the messages kept in the bucket of the node in order of their timestamps,
+// the changes not committed are committed before
+func (s *Spill) load(nodeID string, bucket []byte) ([]*beehivemodel.Message, error) {
+	if err := s.commit(); err != nil {
+		return nil, err
+	}
+	var messages []*beehivemodel.Message
+	err := s.db.View(func(tx *bolt.Tx) error {
+		node := tx.Bucket([]byte(nodeID))
+		if node == nil || node.Bucket(bucket) == nil {
+			return nil
+		}
+		return node.Bucket(bucket).ForEach(func(_, value []byte) error {
+			msg, err := unmarshalSpilledMessage(value, bytes.Equal(bucket, bucketAck))
+			if err != nil {
+				return err
+			}
+			messages = append(messages, msg)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load spilled messages of node %s: %v", nodeID, err)
+	}
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].GetTimestamp() < messages[j].GetTimestamp()
+	})
+	return messages, nil
+}
+
+// bucketIDs returns the IDs of the messages kept in the bucket of the node by key, s.lock must be held
+func (s *Spill) bucketIDs(nodeID string, bucket []byte) map[string]string {
+	if s.ids[nodeID] == nil {
+		s.ids[nodeID] = make(map[string]map[string]string)
+	}
+	if s.ids[nodeID][string(bucket)] == nil {
+		s.ids[nodeID][string(bucket)] = make(map[string]string)
+	}
+	return s.ids[nodeID][string(bucket)]
+}
+
+// bucketChanges returns the changes not committed of the bucket of the node by key, s.lock must be held
+func (s *Spill) bucketChanges(nodeID string, bucket []byte) map[string][]byte {
+	if s.pending[nodeID] == nil {
+		s.pending[nodeID] = make(map[string]map[string][]byte)
+	}
+	if s.pending[nodeID][string(bucket)] == nil {
+		s.pending[nodeID][string(bucket)] = make(map[string][]byte)
+	}
+	return s.pending[nodeID][string(bucket)]
+}
+
+func nodeBucket(tx *bolt.Tx, nodeID string, bucket []byte) (*bolt.Bucket, error) {
+	node, err := tx.CreateBucketIfNotExists([]byte(nodeID))
+	if err != nil {
+		return nil, err
+	}
+	return node.CreateBucketIfNotExists(bucket)
+}
+
+func marshalSpilledMessage(msg *beehivemodel.Message) ([]byte, error) {
+	stored := spilledMessage{Header: msg.Header, Router: msg.Router}
+	if raw, ok := msg.GetContent().([]byte); ok {
+		stored.Raw = raw
+	} else if msg.GetContent() != nil {
+		content, err := json.Marshal(msg.GetContent())
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal content of message %s: %v", msg.GetID(), err)
+		}
+		stored.Content = content
+	}
+	return json.Marshal(stored)
+}
+
+// unmarshalSpilledMessage returns the message stored, the content of the message requiring
+// acknowledgment is the object decoded as unstructured, so its metadata can be accessed
+func unmarshalSpilledMessage(value []byte, ack bool) (*beehivemodel.Message, error) {
+	var stored spilledMessage
+	if err := json.Unmarshal(value, &stored); err != nil {
+		return nil, err
+	}
+	msg := &beehivemodel.Message{Header: stored.Header, Router: stored.Router}
+	switch {
+	case stored.Raw != nil:
+		msg.Content = stored.Raw
+	case ack && len(stored.Content) != 0:
+		// the objects sent to the edge nodes may have no kind, which the unstructured json scheme requires
+		object := map[string]interface{}{}
+		if err := json.Unmarshal(stored.Content, &object); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal object of message %s: %v", stored.Header.ID, err)
+		}
+		msg.Content = &unstructured.Unstructured{Object: object}
+	case len(stored.Content) != 0:
+		msg.Content = stored.Content
+	}
+	return msg, nil
+}
+
+// spillsNoAckMessage returns whether the message not requiring acknowledgment is kept on disk. Only the
+// resource and twin updates are delivered again after restarts, the stream tunnel data, the responses to
+// the queries of the edge nodes and the other transient messages are kept in memory only and not counted
+// in the limit, since they are useless once the connections or queries they belong to are gone.
+func spillsNoAckMessage(msg *beehivemodel.Message) bool {
+	if msg.GetGroup() != edgecon.GroupResource && msg.GetGroup() != deviceconst.GroupTwin {
+		return false
+	}
+	switch msg.GetOperation() {
+	case beehivemodel.ResponseOperation, beehivemodel.ResponseErrorOperation:
+		return false
+	}
+	return true
+}
+
+// spilledStore is the store of a message pool writing the messages through to the spill
+type spilledStore struct {
+	cache.Store
+	spill   *Spill
+	nodeID  string
+	bucket  []byte
+	keyFunc cache.KeyFunc
+	// spills returns whether the message is kept on disk, the messages not kept are in memory only
+	spills func(msg *beehivemodel.Message) bool
+}
+
+func newSpilledStore(s *Spill, nodeID string, bucket []byte, keyFunc cache.KeyFunc,
+	spills func(msg *beehivemodel.Message) bool) *spilledStore {
+	return &spilledStore{
+		Store:   cache.NewStore(keyFunc),
+		spill:   s,
+		nodeID:  nodeID,
+		bucket:  bucket,
+		keyFunc: keyFunc,
+		spills:  spills,
+	}
+}
+
+// Add writes the message to the spill before adding it to the store
+func (s *spilledStore) Add(obj interface{}) error {
+	if err := s.write(obj); err != nil {
+		return err
+	}
+	return s.Store.Add(obj)
+}
+
+// Update writes the message to the spill before updating it in the store
+func (s *spilledStore) Update(obj interface{}) error {
+	if err := s.write(obj); err != nil {
+		return err
+	}
+	return s.Store.Update(obj)
+}
+
+// Delete removes the message from the spill and the store
+func (s *spilledStore) Delete(obj interface{}) error {
+	key, err := s.keyFunc(obj)
+	if err != nil {
+		return cache.KeyError{Obj: obj, Err: err}
+	}
+	s.spill.delete(s.nodeID, s.bucket, key, "")
+	return s.Store.Delete(obj)
+}
+
+// restore adds the messages loaded from the spill to the store, they are not written again. The transient
+// messages kept by the former versions are removed instead of being sent again
+func (s *spilledStore) restore(messages []*beehivemodel.Message) []string {
+	keys := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		key, err := s.keyFunc(msg)
+		if err != nil {
+			klog.Errorf("failed to get key of spilled message %s of node %s: %v", msg.GetID(), s.nodeID, err)
+			continue
+		}
+		if s.spills != nil && !s.spills(msg) {
+			s.spill.delete(s.nodeID, s.bucket, key, "")
+			continue
+		}
+		if err := s.Store.Add(msg); err != nil {
+			klog.Errorf("failed to restore spilled message %s of node %s: %v", msg.GetID(), s.nodeID, err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// acknowledged removes the message acknowledged from the spill, it stays in the store
+func (s *spilledStore) acknowledged(msg *beehivemodel.Message) error {
+	key, err := s.keyFunc(msg)
+	if err != nil {
+		return cache.KeyError{Obj: msg, Err: err}
+	}
+	s.spill.delete(s.nodeID, s.bucket, key, msg.GetID())
+	return nil
+}
+
+func (s *spilledStore) write(obj interface{}) error {
+	msg, ok := obj.(*beehivemodel.Message)
+	if !ok {
+		return fmt.Errorf("object type %T is not message type", obj)
+	}
+	if s.spills != nil && !s.spills(msg) {
+		return nil
+	}
+	key, err := s.keyFunc(obj)
+	if err != nil {
+		return cache.KeyError{Obj: obj, Err: err}
+	}
+	return s.spill.put(s.nodeID, s.bucket, key, msg)
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	beehivemodel "github.com/kubeedge/beehive/pkg/core/model"
+	deviceconst "github.com/kubeedge/kubeedge/cloud/pkg/devicecontroller/constants"
+	edgeconst "github.com/kubeedge/kubeedge/cloud/pkg/edgecontroller/constants"
+	"github.com/kubeedge/kubeedge/pkg/stream"
+)
+
+func newPodMessage(uid types.UID, rv string, timestamp int64) *beehivemodel.Message {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default", UID: uid, ResourceVersion: rv}}
+	msg := beehivemodel.NewMessage("").
+		BuildRouter("edgecontroller", edgeconst.GroupResource, "node/edge-1/default/pod/nginx", beehivemodel.UpdateOperation).
+		SetResourceVersion(rv).FillBody(pod)
+	msg.Header.Timestamp = timestamp
+	return msg
+}
+
+func TestSpillRestore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spill.db")
+	s, err := OpenSpill(path, 2, 2)
+	if err != nil {
+		t.Fatalf("OpenSpill() error = %v", err)
+	}
+	spill = s
+	defer func() { spill = nil }()
+
+	pool := InitNodeMessagePool("edge-1")
+	sent := newPodMessage("uid-1", "1", 1)
+	pending := newPodMessage("uid-2", "2", 2)
+	for _, msg := range []*beehivemodel.Message{sent, pending} {
+		if err := pool.AckMessageStore.Add(msg); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	if err := pool.AckMessageStore.Add(newPodMessage("uid-3", "3", 3)); err != ErrSpillFull {
+		t.Errorf("expected the message rejected by the limit, but got %v", err)
+	}
+	// the newer message of the key replaces the one kept, it is not limited
+	newer := newPodMessage("uid-2", "4", 4)
+	if err := pool.AckMessageStore.Update(newer); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	// the message acknowledged is not kept on disk, and the older one of the key doesn't remove the newer
+	pool.AckMessageDelivered(sent)
+	pool.AckMessageDelivered(pending)
+
+	raw := beehivemodel.NewMessage("").
+		BuildRouter("devicecontroller", deviceconst.GroupTwin, "node/edge-1/membership/updated", beehivemodel.UpdateOperation).
+		FillBody([]byte("raw content"))
+	raw.Header.Timestamp = 5
+	sentNoAck := beehivemodel.NewMessage("").
+		BuildRouter("edgecontroller", edgeconst.GroupResource, "node/edge-1/default/configmap/foo", beehivemodel.UpdateOperation).
+		FillBody(map[string]string{"node": "edge-1"})
+	for _, msg := range []*beehivemodel.Message{raw, sentNoAck} {
+		if err := pool.NoAckMessageStore.Add(msg); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	if err := pool.NoAckMessageStore.Delete(sentNoAck); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if spill, err = OpenSpill(path, 2, 2); err != nil {
+		t.Fatalf("OpenSpill() error = %v", err)
+	}
+	defer spill.Close()
+
+	restored := InitNodeMessagePool("edge-1")
+	if restored.AckMessageQueue.Len() != 1 || restored.NoAckMessageQueue.Len() != 1 {
+		t.Fatalf("expected 1 ack and 1 noack message queued, but got %d and %d",
+			restored.AckMessageQueue.Len(), restored.NoAckMessageQueue.Len())
+	}
+
+	msg, err := restored.GetAckMessage("uid-2")
+	if err != nil {
+		t.Fatalf("GetAckMessage() error = %v", err)
+	}
+	if msg.GetID() != newer.GetID() || msg.GetResourceVersion() != "4" {
+		t.Errorf("expected the newer message restored, but got %+v", msg)
+	}
+	if uid, err := GetMessageUID(*msg); err != nil || uid != "uid-2" {
+		t.Errorf("expected the object of the message restored, but got %q, err: %v", uid, err)
+	}
+
+	msg, err = restored.GetNoAckMessage(raw.GetID())
+	if err != nil {
+		t.Fatalf("GetNoAckMessage() error = %v", err)
+	}
+	if !reflect.DeepEqual(msg.GetContent(), []byte("raw content")) {
+		t.Errorf("expected the raw content restored, but got %v", msg.GetContent())
+	}
+
+	// the messages removed are not counted in the limit
+	if err := restored.AckMessageStore.Add(newPodMessage("uid-3", "3", 6)); err != nil {
+		t.Errorf("Add() error = %v", err)
+	}
+	if err := restored.AckMessageStore.Add(newPodMessage("uid-4", "5", 7)); err != ErrSpillFull {
+		t.Errorf("expected the message rejected by the limit, but got %v", err)
+	}
+}
+
+func TestRemoveSpilledNodes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spill.db")
+	s, err := OpenSpill(path, 2, 2)
+	if err != nil {
+		t.Fatalf("OpenSpill() error = %v", err)
+	}
+	spill = s
+	defer func() { spill = nil }()
+
+	for _, nodeID := range []string{"edge-1", "edge-2", "edge-3"} {
+		pool := InitNodeMessagePool(nodeID)
+		if err := pool.AckMessageStore.Add(newPodMessage("uid-1", "1", 1)); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	// the messages of edge-2 are committed before it is deleted, and the ones of edge-3 are not
+	if err := s.commit(); err != nil {
+		t.Fatalf("commit() error = %v", err)
+	}
+	RemoveSpilledNode("edge-3")
+	RemoveSpilledNodes(func(nodeID string) bool { return nodeID != "edge-2" })
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if spill, err = OpenSpill(path, 2, 2); err != nil {
+		t.Fatalf("OpenSpill() error = %v", err)
+	}
+	defer spill.Close()
+	if nodes := spill.nodes(); len(nodes) != 1 || nodes[0] != "edge-1" {
+		t.Errorf("expected the messages of [edge-1] kept, but got %v", nodes)
+	}
+}
+
+func TestSpillTransientNoAckMessages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spill.db")
+	s, err := OpenSpill(path, 1, 1)
+	if err != nil {
+		t.Fatalf("OpenSpill() error = %v", err)
+	}
+	spill = s
+	defer func() { spill = nil }()
+
+	pool := InitNodeMessagePool("edge-1")
+	update := beehivemodel.NewMessage("").
+		BuildRouter("devicecontroller", deviceconst.GroupTwin, "node/edge-1/twin/cloud_updated", beehivemodel.UpdateOperation).
+		FillBody([]byte("twin"))
+	if err := pool.NoAckMessageStore.Add(update); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	// the transient messages are neither limited nor kept on disk
+	transient := []*beehivemodel.Message{
+		beehivemodel.NewMessage("").
+			BuildRouter(stream.HubGroup, stream.HubGroup, "node/edge-1/logs", "connect").
+			FillBody([]byte("tunnel data")),
+		beehivemodel.NewMessage("").
+			BuildRouter("edgecontroller", edgeconst.GroupResource, "node/edge-1/default/podlist", beehivemodel.ResponseOperation).
+			FillBody([]byte("pod list")),
+	}
+	for _, msg := range transient {
+		if err := pool.NoAckMessageStore.Add(msg); err != nil {
+			t.Errorf("expected transient message %s added, but got %v", msg.GetResource(), err)
+		}
+	}
+	if err := pool.NoAckMessageStore.Add(beehivemodel.NewMessage("").
+		BuildRouter("edgecontroller", edgeconst.GroupResource, "node/edge-1/default/configmap/foo", beehivemodel.UpdateOperation).
+		FillBody([]byte("configmap"))); err != ErrSpillFull {
+		t.Errorf("expected the resource message rejected by the limit, but got %v", err)
+	}
+	if len(pool.NoAckMessageStore.ListKeys()) != 3 {
+		t.Errorf("expected 3 messages held in memory, but got %d", len(pool.NoAckMessageStore.ListKeys()))
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if spill, err = OpenSpill(path, 1, 1); err != nil {
+		t.Fatalf("OpenSpill() error = %v", err)
+	}
+	defer spill.Close()
+
+	restored := InitNodeMessagePool("edge-1")
+	if keys := restored.NoAckMessageStore.ListKeys(); len(keys) != 1 || keys[0] != update.GetID() {
+		t.Errorf("expected only the twin update restored, but got %v", keys)
+	}
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/session"
 	"github.com/kubeedge/kubeedge/cloud/pkg/common/messagelayer"
 	"github.com/kubeedge/kubeedge/cloud/pkg/common/modules"
+	"github.com/kubeedge/kubeedge/cloud/pkg/common/sharding"
 	"github.com/kubeedge/kubeedge/cloud/pkg/dynamiccontroller/application"
 	"github.com/kubeedge/kubeedge/cloud/pkg/synccontroller"
 	commonconst "github.com/kubeedge/kubeedge/common/constants"
@@ -40,6 +41,7 @@ import (
 	reliableclient "github.com/kubeedge/kubeedge/pkg/client/clientset/versioned"
 	synclisters "github.com/kubeedge/kubeedge/pkg/client/listers/reliablesyncs/v1alpha1"
 	"github.com/kubeedge/kubeedge/pkg/metaserver/util"
+	"github.com/kubeedge/kubeedge/pkg/stream"
 )
 
 // There are two `AcknowledgeMode` for message that send to edge node
@@ -146,6 +148,15 @@ func (md *messageDispatcher) DispatchDownstream() {
 				continue
 			}
 
+			// the node owned by another cloudcore is served by it, unless it is still
+			// connected here before being redirected and the lease of this one is not expired
+			if !sharding.Owns(nodeID) {
+				if _, connected := md.SessionManager.GetSession(nodeID); !connected || sharding.Expired() {
+					klog.V(4).Infof("skip message to node %s owned by another cloudcore: %s", nodeID, msg.GetID())
+					continue
+				}
+			}
+
 			switch {
 			case noAckRequired(&msg):
 				md.enqueueNoAckMessage(nodeID, &msg)
@@ -166,6 +177,10 @@ func (md *messageDispatcher) DispatchUpstream(message *beehivemodel.Message, inf
 			klog.Errorf("node %s receive keep alive message err: %v", info.NodeID, err)
 		}
 
+	case message.GetGroup() == stream.HubGroup:
+		message.Router.Resource = fmt.Sprintf("node/%s/%s", info.NodeID, message.Router.Resource)
+		beehivecontext.Send(modules.CloudStreamModuleName, *message)
+
 	case common.IsVolumeResource(message.GetResource()):
 		beehivecontext.SendResp(*message)
 
@@ -234,6 +249,27 @@ func (md *messageDispatcher) enqueueAckMessage(nodeID string, msg *beehivemodel.
 		}
 	}()
 
+	// The store holds one message for each object, the message arrived is coalesced with it,
+	// so the node doesn't replay the superseded states of the object.
+	item, exist, _ := nodeStore.GetByKey(messageKey)
+	if exist {
+		held := item.(*beehivemodel.Message)
+		switch coalesce(msg, held) {
+		case replaceHeld:
+			shouldEnqueue = true
+		case respondWithHeld:
+			klog.V(4).Infof("response %s to node %s carries the newer message %s held", msg.GetID(), nodeID, held.GetID())
+			msg = responseWith(msg, held)
+			shouldEnqueue = true
+		case respondNotFound:
+			klog.V(4).Infof("response %s to node %s is answered not found since the delete %s is held", msg.GetID(), nodeID, held.GetID())
+			md.enqueueNoAckMessage(nodeID, notFoundResponse(msg))
+		default:
+			klog.V(4).Infof("message %s to node %s is superseded, discard it", msg.GetID(), nodeID)
+		}
+		return
+	}
+
 	// If the message operation is delete, force to sync the resource message
 	// If the message operation is response, force to sync the resource message,
 	// since the edgeCore requests it.
@@ -242,20 +278,6 @@ func (md *messageDispatcher) enqueueAckMessage(nodeID string, msg *beehivemodel.
 		return
 	}
 
-	item, exist, _ := nodeStore.GetByKey(messageKey)
-	if exist {
-		msgInStore := item.(*beehivemodel.Message)
-
-		if isDeleteMessage(msgInStore) ||
-			synccontroller.CompareResourceVersion(msg.GetResourceVersion(), msgInStore.GetResourceVersion()) <= 0 {
-			// If the message resource version is older than the message in store or the operation
-			// for the message in store is delete, The message will be discarded directly.
-			return
-		}
-		shouldEnqueue = true
-		return
-	}
-
 	// If the message doesn't exist in the store, then compare it with the version stored in the objectSync.
 	resourceNamespace, _ := messagelayer.GetNamespace(*msg)
 	resourceName, _ := messagelayer.GetResourceName(*msg)
@@ -322,6 +344,73 @@ func (md *messageDispatcher) enqueueAckMessage(nodeID string, msg *beehivemodel.
 	}
 }
 
+// coalesceAction is how the message arrived is coalesced with the message of the same object held in the store
+type coalesceAction int
+
+const (
+	// discardArrived discards the message arrived, which is superseded by the message held
+	discardArrived coalesceAction = iota
+	// replaceHeld queues the message arrived in place of the message held
+	replaceHeld
+	// respondWithHeld queues the response arrived in place of the message held, carrying the content
+	// of the message held, so the node querying the object gets the response with the newer state
+	respondWithHeld
+	// respondNotFound answers the response arrived with NotFound and keeps the delete held,
+	// so the node querying the object deleted doesn't store it again
+	respondNotFound
+)
+
+// coalesce decides how the message arrived is coalesced with the message of the same object
+// held in the store. A newer resource version replaces the older one pending, and a delete
+// cancels any pending update. The message held is never replaced by an older one, so the
+// resource versions saved in the ObjectSync after the acknowledgments never go backwards,
+// and a pending delete is never replaced by an update, so the object is not resurrected on the node.
+// The response is always answered since the node is waiting for it.
+func coalesce(arrived, held *beehivemodel.Message) coalesceAction {
+	if arrived.GetOperation() == beehivemodel.ResponseOperation {
+		switch {
+		case held.GetOperation() == beehivemodel.DeleteOperation:
+			return respondNotFound
+		case isDeleteMessage(held) ||
+			synccontroller.CompareResourceVersion(arrived.GetResourceVersion(), held.GetResourceVersion()) < 0:
+			return respondWithHeld
+		}
+		return replaceHeld
+	}
+
+	switch {
+	case arrived.GetOperation() == beehivemodel.DeleteOperation:
+		return replaceHeld
+	case isDeleteMessage(held):
+		return discardArrived
+	case isDeleteMessage(arrived):
+		// the update setting the deletion timestamp is forced to sync like the delete
+		return replaceHeld
+	case synccontroller.CompareResourceVersion(arrived.GetResourceVersion(), held.GetResourceVersion()) > 0:
+		return replaceHeld
+	default:
+		return discardArrived
+	}
+}
+
+// responseWith returns the response carrying the content and the resource version of the message held instead
+func responseWith(response, held *beehivemodel.Message) *beehivemodel.Message {
+	merged := *response
+	if rv := held.GetResourceVersion(); rv != "" {
+		merged.SetResourceVersion(rv)
+	}
+	merged.Content = held.GetContent()
+	return &merged
+}
+
+// notFoundResponse returns the error response answering the query of the response that the object
+// is not found, in the form edge recognizes as the NotFound answered by cloud
+func notFoundResponse(response *beehivemodel.Message) *beehivemodel.Message {
+	return beehivemodel.NewMessage(response.GetParentID()).
+		FillBody(fmt.Sprintf("%s: the object is deleted", metav1.StatusReasonNotFound)).
+		BuildRouter(response.GetSource(), response.GetGroup(), response.GetResource(), beehivemodel.ResponseErrorOperation)
+}
+
 func isDeleteMessage(msg *beehivemodel.Message) bool {
 	if msg.GetOperation() == beehivemodel.DeleteOperation {
 		return true
@@ -368,8 +457,14 @@ func noAckRequired(msg *beehivemodel.Message) bool {
 		return true
 	case msg.GetGroup() == modules.UserGroup:
 		return true
+	case msg.GetGroup() == stream.HubGroup:
+		// the stream tunnel is flow controlled by itself
+		return true
 	case msg.GetSource() == modules.NodeUpgradeJobControllerModuleName:
 		return true
+	case msg.GetOperation() == beehivemodel.ResponseErrorOperation:
+		// error responses carry no resource version, they are answers of the sync queries from edge
+		return true
 	case msg.GetOperation() == beehivemodel.ResponseOperation:
 		content, ok := msg.Content.(string)
 		if ok && content == commonconst.MessageSuccessfulContent {
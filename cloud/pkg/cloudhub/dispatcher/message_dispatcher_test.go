@@ -18,9 +18,11 @@ package dispatcher
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
@@ -163,6 +165,7 @@ func TestEnqueueAckMessage(t *testing.T) {
 	normalMsg3 := tf.NewPodMessage(tf.NewTestPodResource(tf.TestPodName, tf.TestDiffPodUID, "3"), "update")
 	deleteMsg := tf.NewPodMessage(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, "4"), "delete")
 	respMsg := tf.NewPodMessage(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, "5"), "response")
+	oldRespMsg := tf.NewPodMessage(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, "1"), "response")
 	invalidMsg := tf.NewPodMessage(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, ""), "update")
 
 	tests := []tf.TestCase{
@@ -291,6 +294,84 @@ func TestEnqueueAckMessage(t *testing.T) {
 			},
 			ExpectedStoreMessage: normalMsg3,
 		},
+		{
+			Name: "message already exist in store and new message with same resource version arrives",
+			InitialObjectSyncs: []*v1alpha1.ObjectSync{
+				tf.NewObjectSync(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, "1"), "Pod"),
+			},
+			ReactorErrors:        tf.NoErrors,
+			InitialMessages:      []*beehivemodel.Message{normalMsg2},
+			CurrentArriveMessage: tf.NewPodMessage(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, "2"), "update"),
+			ExpectedObjectSyncs: []*v1alpha1.ObjectSync{
+				tf.NewObjectSync(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, "1"), "Pod"),
+			},
+			ExpectedStoreMessage: normalMsg2,
+		},
+		{
+			Name: "delete message already exist in store and new message arrives",
+			InitialObjectSyncs: []*v1alpha1.ObjectSync{
+				tf.NewObjectSync(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, "2"), "Pod"),
+			},
+			ReactorErrors:        tf.NoErrors,
+			InitialMessages:      []*beehivemodel.Message{deleteMsg},
+			CurrentArriveMessage: tf.NewPodMessage(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, "6"), "update"),
+			ExpectedObjectSyncs: []*v1alpha1.ObjectSync{
+				tf.NewObjectSync(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, "2"), "Pod"),
+			},
+			ExpectedStoreMessage: deleteMsg,
+		},
+		{
+			Name: "delete message already exist in store and response message arrives",
+			InitialObjectSyncs: []*v1alpha1.ObjectSync{
+				tf.NewObjectSync(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, "2"), "Pod"),
+			},
+			ReactorErrors:        tf.NoErrors,
+			InitialMessages:      []*beehivemodel.Message{deleteMsg},
+			CurrentArriveMessage: respMsg,
+			ExpectedObjectSyncs: []*v1alpha1.ObjectSync{
+				tf.NewObjectSync(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, "2"), "Pod"),
+			},
+			ExpectedStoreMessage: deleteMsg,
+		},
+		{
+			Name: "message already exist in store and response message with newer resource version arrives",
+			InitialObjectSyncs: []*v1alpha1.ObjectSync{
+				tf.NewObjectSync(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, "2"), "Pod"),
+			},
+			ReactorErrors:        tf.NoErrors,
+			InitialMessages:      []*beehivemodel.Message{normalMsg2},
+			CurrentArriveMessage: respMsg,
+			ExpectedObjectSyncs: []*v1alpha1.ObjectSync{
+				tf.NewObjectSync(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, "2"), "Pod"),
+			},
+			ExpectedStoreMessage: respMsg,
+		},
+		{
+			Name: "message already exist in store and response message with older resource version arrives",
+			InitialObjectSyncs: []*v1alpha1.ObjectSync{
+				tf.NewObjectSync(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, "2"), "Pod"),
+			},
+			ReactorErrors:        tf.NoErrors,
+			InitialMessages:      []*beehivemodel.Message{normalMsg2},
+			CurrentArriveMessage: oldRespMsg,
+			ExpectedObjectSyncs: []*v1alpha1.ObjectSync{
+				tf.NewObjectSync(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, "2"), "Pod"),
+			},
+			ExpectedStoreMessage: responseWith(oldRespMsg, normalMsg2),
+		},
+		{
+			Name: "message already exist in store and delete message with older resource version arrives",
+			InitialObjectSyncs: []*v1alpha1.ObjectSync{
+				tf.NewObjectSync(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, "2"), "Pod"),
+			},
+			ReactorErrors:        tf.NoErrors,
+			InitialMessages:      []*beehivemodel.Message{respMsg},
+			CurrentArriveMessage: deleteMsg,
+			ExpectedObjectSyncs: []*v1alpha1.ObjectSync{
+				tf.NewObjectSync(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, "2"), "Pod"),
+			},
+			ExpectedStoreMessage: deleteMsg,
+		},
 	}
 
 	executeTest := func(t *testing.T, test tf.TestCase) {
@@ -365,6 +446,114 @@ func TestEnqueueAckMessage(t *testing.T) {
 	}
 }
 
+func TestCoalesce(t *testing.T) {
+	update := func(rv string) *beehivemodel.Message {
+		return tf.NewPodMessage(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, rv), beehivemodel.UpdateOperation)
+	}
+	response := func(rv string) *beehivemodel.Message {
+		return tf.NewPodMessage(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, rv), beehivemodel.ResponseOperation)
+	}
+	// the resource version of the delete message may be empty
+	deleteMsg := tf.NewPodMessage(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, ""), beehivemodel.DeleteOperation)
+	deletingPod := tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, "4")
+	now := metav1.Now()
+	deletingPod.DeletionTimestamp = &now
+	deletion := tf.NewPodMessage(deletingPod, beehivemodel.UpdateOperation)
+
+	tests := []struct {
+		name    string
+		arrived *beehivemodel.Message
+		held    *beehivemodel.Message
+		want    coalesceAction
+	}{
+		{name: "newer update replaces the pending one", arrived: update("3"), held: update("2"), want: replaceHeld},
+		{name: "update with same resource version is discarded", arrived: update("2"), held: update("2"), want: discardArrived},
+		{name: "older update is discarded", arrived: update("1"), held: update("2"), want: discardArrived},
+		{name: "delete cancels the pending update", arrived: deleteMsg, held: update("2"), want: replaceHeld},
+		{name: "delete replaces the pending delete", arrived: deleteMsg, held: deleteMsg, want: replaceHeld},
+		{name: "update after the pending delete is discarded", arrived: update("3"), held: deleteMsg, want: discardArrived},
+		{name: "response after the pending delete is answered not found", arrived: response("3"), held: deleteMsg, want: respondNotFound},
+		{name: "response after the pending deletion carries the deletion", arrived: response("3"), held: deletion, want: respondWithHeld},
+		{name: "newer response replaces the pending update", arrived: response("3"), held: update("2"), want: replaceHeld},
+		{name: "response with same resource version replaces the update", arrived: response("2"), held: update("2"), want: replaceHeld},
+		{name: "older response carries the update held", arrived: response("1"), held: update("2"), want: respondWithHeld},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := coalesce(test.arrived, test.held); got != test.want {
+				t.Errorf("coalesce() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestEnqueueAckMessageCoalesced(t *testing.T) {
+	client := &fake.Clientset{}
+	reactor := tf.NewObjectSyncReactor(client, tf.NoErrors)
+	objectSync := tf.NewObjectSync(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, "1"), "Pod")
+	reactor.AddObjectSyncs([]*v1alpha1.ObjectSync{objectSync})
+
+	objectSyncIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	_ = objectSyncIndexer.Add(objectSync)
+	dispatcher := &messageDispatcher{
+		reliableClient:          client,
+		objectSyncLister:        synclisters.NewObjectSyncLister(objectSyncIndexer),
+		clusterObjectSyncLister: synclisters.NewClusterObjectSyncLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})),
+	}
+	nmp := common.InitNodeMessagePool(tf.TestNodeID)
+	dispatcher.AddNodeMessagePool(tf.TestNodeID, nmp)
+
+	// the object is updated frequently while the node is slow to receive the messages
+	var latest *beehivemodel.Message
+	for _, rv := range []string{"2", "3", "5", "4"} {
+		msg := tf.NewPodMessage(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, rv), beehivemodel.UpdateOperation)
+		if rv == "5" {
+			latest = msg
+		}
+		dispatcher.enqueueAckMessage(tf.TestNodeID, msg)
+	}
+
+	if nmp.AckMessageQueue.Len() != 1 {
+		t.Fatalf("expected the updates queued once, but got %d", nmp.AckMessageQueue.Len())
+	}
+	item, _, _ := nmp.AckMessageStore.Get(latest)
+	if !reflect.DeepEqual(item, latest) {
+		t.Errorf("expected the latest update held, but got %+v", item)
+	}
+
+	deleteMsg := tf.NewPodMessage(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, "6"), beehivemodel.DeleteOperation)
+	dispatcher.enqueueAckMessage(tf.TestNodeID, deleteMsg)
+	dispatcher.enqueueAckMessage(tf.TestNodeID, tf.NewPodMessage(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, "7"), beehivemodel.UpdateOperation))
+
+	if nmp.AckMessageQueue.Len() != 1 {
+		t.Fatalf("expected the delete queued once, but got %d", nmp.AckMessageQueue.Len())
+	}
+	item, _, _ = nmp.AckMessageStore.Get(deleteMsg)
+	if !reflect.DeepEqual(item, deleteMsg) {
+		t.Errorf("expected the delete held, but got %+v", item)
+	}
+
+	// the query of the node is answered not found while the delete is held
+	response := tf.NewPodMessage(tf.NewTestPodResource(tf.TestPodName, tf.TestPodUID, "5"), beehivemodel.ResponseOperation)
+	response.Header.ParentID = "query"
+	dispatcher.enqueueAckMessage(tf.TestNodeID, response)
+
+	item, _, _ = nmp.AckMessageStore.Get(deleteMsg)
+	if !reflect.DeepEqual(item, deleteMsg) {
+		t.Errorf("expected the delete held, but got %+v", item)
+	}
+	_, noAck := nmp.Messages()
+	if len(noAck) != 1 || nmp.NoAckMessageQueue.Len() != 1 {
+		t.Fatalf("expected the NotFound response queued, but got %+v", noAck)
+	}
+	got := noAck[0]
+	content, _ := got.GetContent().(string)
+	if got.GetParentID() != "query" || got.GetOperation() != beehivemodel.ResponseErrorOperation ||
+		got.GetResource() != response.GetResource() || !strings.HasPrefix(content, string(metav1.StatusReasonNotFound)) {
+		t.Errorf("expected the NotFound response to the query, but got %+v", got)
+	}
+}
+
 func TestGetAddNodeMessagePool(t *testing.T) {
 	// Initialize the dispatcher
 	client := &fake.Clientset{}
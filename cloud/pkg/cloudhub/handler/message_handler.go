@@ -17,6 +17,9 @@ limitations under the License.
 package handler
 
 import (
+	"net"
+	"net/http"
+	"strconv"
 	"time"
 
 	"k8s.io/klog/v2"
@@ -24,8 +27,11 @@ import (
 	"github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/common"
 	"github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/common/model"
 	"github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/dispatcher"
+	"github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/revocation"
 	"github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/session"
+	"github.com/kubeedge/kubeedge/cloud/pkg/common/sharding"
 	reliableclient "github.com/kubeedge/kubeedge/pkg/client/clientset/versioned"
+	"github.com/kubeedge/viaduct/pkg/api"
 	"github.com/kubeedge/viaduct/pkg/conn"
 	"github.com/kubeedge/viaduct/pkg/mux"
 )
@@ -45,6 +51,16 @@ type Handler interface {
 
 	// OnReadTransportErr is invoked when the connection read message err
 	OnReadTransportErr(nodeID, projectID string)
+
+	// Admit is invoked when a node handshakes, the retry-after hint is returned if it is not admitted
+	Admit(header http.Header) (time.Duration, bool)
+
+	// Release is invoked when a node admitted fails to connect before HandleConnection
+	Release(header http.Header)
+
+	// Redirect returns the func invoked when a node handshakes by the protocol, the address of
+	// the cloudcore owning the node is returned if it is not this one
+	Redirect(protocol string) api.RedirectFunc
 }
 
 func NewMessageHandler(
@@ -107,6 +123,16 @@ func (mh *messageHandler) HandleConnection(connection conn.Connection) {
 
 	if mh.SessionManager.ReachLimit() {
 		klog.Errorf("Fail to serve node %s, reach node limit", nodeID)
+		mh.SessionManager.Admission.Done(nodeID)
+		return
+	}
+
+	// the certificate carrying a node name is rejected unless it is issued to the node
+	if err := revocation.Current().CheckNode(nodeID, session.PeerCertificate(connection)); err != nil {
+		klog.Errorf("Fail to serve node %s: %v", nodeID, err)
+		mh.SessionManager.Admission.Done(nodeID)
+		// ignore close error
+		_ = connection.Close()
 		return
 	}
 
@@ -114,6 +140,7 @@ func (mh *messageHandler) HandleConnection(connection conn.Connection) {
 
 	if err := mh.OnEdgeNodeConnect(nodeInfo, connection); err != nil {
 		klog.Errorf("publish connect event for node %s, err %v", nodeInfo.NodeID, err)
+		mh.SessionManager.Admission.Done(nodeID)
 		return
 	}
 
@@ -132,12 +159,23 @@ func (mh *messageHandler) HandleConnection(connection conn.Connection) {
 		// add node session to the session manager
 		mh.SessionManager.AddSession(nodeSession)
 
+		// the node is admitted to handshake until it synced initially or disconnected
+		go func() {
+			nodeSession.WaitInitialSync()
+			mh.SessionManager.Admission.Done(nodeID)
+		}()
+
 		// start session for each edge node and it will keep running until
 		// it encounters some Transport Error from underlying connection.
 		nodeSession.Start()
 
 		klog.Infof("edge node %s for project %s disConnected", nodeInfo.NodeID, nodeInfo.ProjectID)
 
+		// the messages held for the node deleted are not kept anymore
+		if nodeSession.GetTerminateErr() == session.NodeStopErr {
+			nodeMessagePool.Purge()
+		}
+
 		// clean node message pool and session
 		mh.MessageDispatcher.DeleteNodeMessagePool(nodeInfo.NodeID, nodeMessagePool)
 		mh.SessionManager.DeleteSession(nodeSession)
@@ -162,6 +200,32 @@ func (mh *messageHandler) OnEdgeNodeDisconnect(info *model.HubInfo, connection c
 	}
 }
 
+func (mh *messageHandler) Admit(header http.Header) (time.Duration, bool) {
+	return mh.SessionManager.Admission.Admit(header.Get("node_id"))
+}
+
+func (mh *messageHandler) Release(header http.Header) {
+	mh.SessionManager.Admission.Done(header.Get("node_id"))
+}
+
+func (mh *messageHandler) Redirect(protocol string) api.RedirectFunc {
+	return func(header http.Header) (string, bool) {
+		owner, ok := sharding.OwnerOf(header.Get("node_id"))
+		if !ok {
+			return "", false
+		}
+		port := owner.WebSocketPort
+		if protocol == api.ProtocolTypeQuic {
+			port = owner.QuicPort
+		}
+		// the node is served here if the owner doesn't serve the protocol
+		if port == 0 {
+			return "", false
+		}
+		return net.JoinHostPort(owner.Address, strconv.Itoa(int(port))), true
+	}
+}
+
 func (mh *messageHandler) OnReadTransportErr(nodeID, projectID string) {
 	klog.Errorf("projectID %s node %s read message err", projectID, nodeID)
 
@@ -0,0 +1,34 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudhub
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics/legacyregistry"
+
+	"github.com/kubeedge/kubeedge/pkg/util"
+)
+
+var registerMetricsOnce sync.Once
+
+// registerMetrics registers the metrics of payload compression, they are served by the admin API
+func registerMetrics() {
+	registerMetricsOnce.Do(func() {
+		legacyregistry.RawMustRegister(util.CompressionCollectors("cloudhub")...)
+	})
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revocation
+
+import (
+	"crypto/x509"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/kubeedge/kubeedge/common/constants"
+)
+
+// List is the revocation list of the edge certificates. A node is revoked with all the
+// certificates issued to it, and it is locked out until the entry of it is removed, so it
+// can't join again with the token kept on it.
+type List struct {
+	// nodes maps the revoked node names to the revocation times
+	nodes map[string]time.Time
+	// serials maps the revoked certificate serial numbers in hex to the revocation times
+	serials map[string]time.Time
+}
+
+// current is the revocation list in use
+var current atomic.Value
+
+// Parse returns the revocation list stored in the secret, the invalid entries are ignored
+func Parse(secret *corev1.Secret) *List {
+	l := &List{
+		nodes:   make(map[string]time.Time),
+		serials: make(map[string]time.Time),
+	}
+	if secret == nil {
+		return l
+	}
+	for key, value := range secret.Data {
+		revokedAt, err := time.Parse(time.RFC3339, string(value))
+		if err != nil {
+			klog.Warningf("invalid revocation time of %s: %v", key, err)
+			continue
+		}
+		switch {
+		case strings.HasPrefix(key, constants.RevokedNodeKeyPrefix):
+			l.nodes[strings.TrimPrefix(key, constants.RevokedNodeKeyPrefix)] = revokedAt
+		case strings.HasPrefix(key, constants.RevokedSerialKeyPrefix):
+			l.serials[strings.ToLower(strings.TrimPrefix(key, constants.RevokedSerialKeyPrefix))] = revokedAt
+		default:
+			klog.Warningf("unknown revocation entry %s", key)
+		}
+	}
+	return l
+}
+
+// SerialKey returns the data key of the revoked certificate serial number
+func SerialKey(serial *big.Int) string {
+	return constants.RevokedSerialKeyPrefix + serial.Text(16)
+}
+
+// NodeKey returns the data key of the revoked node
+func NodeKey(nodeName string) string {
+	return constants.RevokedNodeKeyPrefix + nodeName
+}
+
+// CheckCertificate returns an error if the certificate is revoked by its serial number, or it is
+// issued to a revoked node. The node name of the certificates signed by CloudHub is the common name.
+func (l *List) CheckCertificate(cert *x509.Certificate) error {
+	serial := cert.SerialNumber.Text(16)
+	if revokedAt, ok := l.serials[serial]; ok {
+		return fmt.Errorf("certificate %s is revoked at %s", serial, revokedAt.Format(time.RFC3339))
+	}
+	nodeName := cert.Subject.CommonName
+	if revokedAt, ok := l.nodes[nodeName]; ok {
+		return fmt.Errorf("certificate %s of node %s is revoked at %s", serial, nodeName, revokedAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// legacyCommonName is the common name of the edge certificates signed before they carry the node name
+const legacyCommonName = "kubeedge.io"
+
+// CertificateNodeName returns the node name the certificate is issued to, it is empty if the
+// certificate doesn't carry the node name
+func CertificateNodeName(cert *x509.Certificate) string {
+	if cn := cert.Subject.CommonName; cn != legacyCommonName && cn != constants.ProjectName {
+		return cn
+	}
+	return ""
+}
+
+// CheckNode returns an error if the node is revoked, the certificate is revoked, or the
+// certificate carrying a node name is not issued to the node. The certificate may be nil
+// to check the node only, such as before a certificate is issued to it.
+func (l *List) CheckNode(nodeName string, cert *x509.Certificate) error {
+	if revokedAt, ok := l.nodes[nodeName]; ok {
+		return fmt.Errorf("node %s is revoked at %s", nodeName, revokedAt.Format(time.RFC3339))
+	}
+	if cert == nil {
+		return nil
+	}
+	if err := l.CheckCertificate(cert); err != nil {
+		return err
+	}
+	if certNodeName := CertificateNodeName(cert); certNodeName != "" && certNodeName != nodeName {
+		return fmt.Errorf("certificate %s is issued to node %s, not node %s",
+			cert.SerialNumber.Text(16), certNodeName, nodeName)
+	}
+	return nil
+}
+
+// Current returns the revocation list in use
+func Current() *List {
+	if l, ok := current.Load().(*List); ok {
+		return l
+	}
+	return Parse(nil)
+}
+
+// VerifyPeerCertificate rejects the revoked peer certificate during the TLS handshake,
+// it is used as the VerifyPeerCertificate of tls.Config
+func VerifyPeerCertificate(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	if len(rawCerts) == 0 {
+		return nil
+	}
+	cert, err := x509.ParseCertificate(rawCerts[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse peer certificate: %v", err)
+	}
+	return Current().CheckCertificate(cert)
+}
+
+// Watch keeps the revocation list in sync with the secret until stop is closed, onUpdate is called
+// with the new list after each change. It returns after the list is synced for the first time.
+func Watch(cli kubernetes.Interface, stop <-chan struct{}, onUpdate func(*List)) error {
+	update := func(secret *corev1.Secret) {
+		l := Parse(secret)
+		current.Store(l)
+		klog.Infof("revocation list updated, %d nodes and %d certificates revoked", len(l.nodes), len(l.serials))
+		if onUpdate != nil {
+			onUpdate(l)
+		}
+	}
+
+	lw := cache.NewListWatchFromClient(cli.CoreV1().RESTClient(), "secrets", constants.SystemNamespace,
+		fields.OneTermEqualSelector("metadata.name", constants.RevokedCertsSecretName))
+	_, informer := cache.NewInformer(lw, &corev1.Secret{}, 0, cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if secret, ok := obj.(*corev1.Secret); ok {
+				update(secret)
+			}
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			if secret, ok := obj.(*corev1.Secret); ok {
+				update(secret)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			update(nil)
+		},
+	})
+	go informer.Run(stop)
+	if !cache.WaitForCacheSync(stop, informer.HasSynced) {
+		return fmt.Errorf("failed to sync revocation list")
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revocation
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func newCert(serial int64, commonName string, notBefore time.Time) *x509.Certificate {
+	return &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    notBefore,
+	}
+}
+
+func TestList(t *testing.T) {
+	revokedAt := time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+	list := Parse(&corev1.Secret{
+		Data: map[string][]byte{
+			NodeKey("edge-1"):          []byte(revokedAt.Format(time.RFC3339)),
+			SerialKey(big.NewInt(255)): []byte(revokedAt.Format(time.RFC3339)),
+			NodeKey("edge-3"):          []byte("invalid"),
+		},
+	})
+	before, after := revokedAt.Add(-time.Hour), revokedAt.Add(time.Hour)
+
+	cases := []struct {
+		name     string
+		nodeName string
+		cert     *x509.Certificate
+		revoked  bool
+	}{
+		{name: "certificate of revoked node", nodeName: "edge-1", cert: newCert(1, "edge-1", before), revoked: true},
+		{name: "certificate issued after revocation", nodeName: "edge-1", cert: newCert(2, "edge-1", after), revoked: true},
+		{name: "certificate of revoked node for other node", nodeName: "edge-2", cert: newCert(8, "edge-1", after), revoked: true},
+		{name: "certificate without node name", nodeName: "edge-1", cert: newCert(3, "kubeedge.io", after), revoked: true},
+		{name: "revoked serial", nodeName: "edge-2", cert: newCert(255, "edge-2", after), revoked: true},
+		{name: "certificate of other node", nodeName: "edge-2", cert: newCert(4, "edge-2", before)},
+		{name: "certificate issued to another node", nodeName: "edge-2", cert: newCert(6, "edge-4", after), revoked: true},
+		{name: "certificate without node name of other node", nodeName: "edge-2", cert: newCert(7, "kubeedge.io", before)},
+		{name: "invalid entry", nodeName: "edge-3", cert: newCert(5, "edge-3", before)},
+		{name: "revoked node without certificate", nodeName: "edge-1", revoked: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := list.CheckNode(c.nodeName, c.cert)
+			if (err != nil) != c.revoked {
+				t.Errorf("CheckNode() error = %v, expected revoked %v", err, c.revoked)
+			}
+		})
+	}
+}
+
+func TestVerifyPeerCertificate(t *testing.T) {
+	current.Store(Parse(&corev1.Secret{
+		Data: map[string][]byte{
+			NodeKey("edge-1"): []byte(time.Now().UTC().Format(time.RFC3339)),
+		},
+	}))
+	defer current.Store(Parse(nil))
+
+	if err := VerifyPeerCertificate(nil, nil); err != nil {
+		t.Errorf("expected no error without peer certificate, but got %v", err)
+	}
+	if err := VerifyPeerCertificate([][]byte{[]byte("invalid")}, nil); err == nil {
+		t.Errorf("expected error of invalid peer certificate")
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/kubeedge/kubeedge/cloud/pkg/common/client"
+	"github.com/kubeedge/kubeedge/common/constants"
+	"github.com/kubeedge/kubeedge/pkg/util/bootstraptoken"
+)
+
+// enrollRetries is the number of attempts to record an enrollment on conflicts
+const enrollRetries = 5
+
+// getBootstrapToken returns the bootstrap token of the id and its secret
+func getBootstrapToken(id string) (*bootstraptoken.Token, *corev1.Secret, error) {
+	secret, err := client.GetKubeClient().CoreV1().Secrets(constants.SystemNamespace).
+		Get(context.Background(), bootstraptoken.SecretName(id), metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get bootstrap token %s: %v", id, err)
+	}
+	token, err := bootstraptoken.FromSecret(secret)
+	if err != nil {
+		return nil, nil, err
+	}
+	return token, secret, nil
+}
+
+// validateBootstrapToken returns an error if the node is not allowed to join by the bootstrap token,
+// the usage of the token is not counted
+func validateBootstrapToken(id, nodeName string) error {
+	token, _, err := getBootstrapToken(id)
+	if err != nil {
+		return err
+	}
+	return token.Validate(nodeName, time.Now())
+}
+
+// enrollBootstrapToken validates the bootstrap token for the node and records the enrollment,
+// the usage of the token is counted once the enrollment is recorded, so it is called after
+// the certificate of the node is signed
+func enrollBootstrapToken(id, nodeName, remoteAddr string) error {
+	secrets := client.GetKubeClient().CoreV1().Secrets(constants.SystemNamespace)
+	for i := 0; i < enrollRetries; i++ {
+		token, secret, err := getBootstrapToken(id)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if err := token.Validate(nodeName, now); err != nil {
+			return err
+		}
+		token.Enroll(nodeName, remoteAddr, now)
+		if secret, err = token.ToSecret(secret); err != nil {
+			return err
+		}
+		// the resource version of the secret guarantees the usage limit with concurrent enrollments
+		_, err = secrets.Update(context.Background(), secret, metav1.UpdateOptions{})
+		if errors.IsConflict(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to record enrollment of bootstrap token %s: %v", id, err)
+		}
+		klog.Infof("node %s from %s is enrolled by bootstrap token %s, usage %d/%d",
+			nodeName, remoteAddr, id, token.UsageCount, token.UsageLimit)
+		return nil
+	}
+	return fmt.Errorf("failed to record enrollment of bootstrap token %s: too many conflicts", id)
+}
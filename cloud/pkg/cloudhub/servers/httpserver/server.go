@@ -35,11 +35,13 @@ import (
 	"k8s.io/klog/v2"
 
 	hubconfig "github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/config"
+	"github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/revocation"
 	"github.com/kubeedge/kubeedge/common/constants"
+	"github.com/kubeedge/kubeedge/pkg/util/bootstraptoken"
 )
 
-// StartHTTPServer starts the http service
-func StartHTTPServer() {
+// StartHTTPServer starts the http service, the services like the admin API are served besides
+func StartHTTPServer(services ...*restful.WebService) {
 	serverContainer := restful.NewContainer()
 	ws := new(restful.WebService)
 	ws.Path("/")
@@ -47,6 +49,9 @@ func StartHTTPServer() {
 	ws.Route(ws.GET(constants.DefaultCAURL).To(getCA))
 	ws.Route(ws.POST(constants.DefaultNodeUpgradeURL).To(upgradeEdge))
 	serverContainer.Add(ws)
+	for _, service := range services {
+		serverContainer.Add(service)
+	}
 
 	addr := fmt.Sprintf("%s:%d", hubconfig.Config.HTTPS.Address, hubconfig.Config.HTTPS.Port)
 
@@ -62,6 +67,8 @@ func StartHTTPServer() {
 		TLSConfig: &tls.Config{
 			Certificates: []tls.Certificate{cert},
 			ClientAuth:   tls.RequestClientCert,
+			// the revoked edge certificates are rejected during the handshake
+			VerifyPeerCertificate: revocation.VerifyPeerCertificate,
 		},
 	}
 	klog.Exit(server.ListenAndServeTLS("", ""))
@@ -86,27 +93,53 @@ func EncodeCertPEM(cert *x509.Certificate) []byte {
 
 // edgeCoreClientCert will verify the certificate of EdgeCore or token then create EdgeCoreCert and return it
 func edgeCoreClientCert(request *restful.Request, response *restful.Response) {
+	nodeName := request.Request.Header.Get(constants.NodeName)
+	// the revoked node is locked out until the revocation is removed, even if it holds a valid token
+	if err := revocation.Current().CheckNode(nodeName, nil); err != nil {
+		klog.Errorf("failed to sign the certificate for edgenode: %s, %v", nodeName, err)
+		response.WriteHeader(http.StatusUnauthorized)
+		if _, err := response.Write([]byte(err.Error())); err != nil {
+			klog.Errorf("failed to write response, err: %v", err)
+		}
+		return
+	}
 	if cert := request.Request.TLS.PeerCertificates; len(cert) > 0 {
-		if err := verifyCert(cert[0]); err != nil {
-			klog.Errorf("failed to sign the certificate for edgenode: %s, failed to verify the certificate", request.Request.Header.Get(constants.NodeName))
+		if err := verifyCert(cert[0], nodeName); err != nil {
+			klog.Errorf("failed to sign the certificate for edgenode: %s, failed to verify the certificate", nodeName)
 			response.WriteHeader(http.StatusUnauthorized)
 			if _, err := response.Write([]byte(err.Error())); err != nil {
 				klog.Errorf("failed to write response, err: %v", err)
 			}
-		} else {
-			signEdgeCert(response, request.Request)
+		} else if clientCertDER := signEdgeCert(response, request.Request); clientCertDER != nil {
+			writeEdgeCert(response, clientCertDER)
 		}
 		return
 	}
-	if verifyAuthorization(response, request.Request) {
-		signEdgeCert(response, request.Request)
-	} else {
-		klog.Errorf("failed to sign the certificate for edgenode: %s, invalid token", request.Request.Header.Get(constants.NodeName))
+	bootstrapTokenID, ok := verifyAuthorization(response, request.Request)
+	if !ok {
+		klog.Errorf("failed to sign the certificate for edgenode: %s, invalid token", nodeName)
+		return
 	}
+	clientCertDER := signEdgeCert(response, request.Request)
+	if clientCertDER == nil {
+		return
+	}
+	// the usage of the bootstrap token is consumed only when the certificate is signed
+	if bootstrapTokenID != "" {
+		if err := enrollBootstrapToken(bootstrapTokenID, nodeName, request.Request.RemoteAddr); err != nil {
+			klog.Errorf("failed to enroll node %s: %v", nodeName, err)
+			response.WriteHeader(http.StatusUnauthorized)
+			if _, err := response.Write([]byte("Invalid authorization token")); err != nil {
+				klog.Errorf("Write body error %v", err)
+			}
+			return
+		}
+	}
+	writeEdgeCert(response, clientCertDER)
 }
 
-// verifyCert verifies the edge certificate by CA certificate when edge certificates rotate.
-func verifyCert(cert *x509.Certificate) error {
+// verifyCert verifies the edge certificate by CA certificate and the revocation list when edge certificates rotate.
+func verifyCert(cert *x509.Certificate, nodeName string) error {
 	roots := x509.NewCertPool()
 	ok := roots.AppendCertsFromPEM(pem.EncodeToMemory(&pem.Block{Type: certutil.CertificateBlockType, Bytes: hubconfig.Config.Ca}))
 	if !ok {
@@ -119,18 +152,19 @@ func verifyCert(cert *x509.Certificate) error {
 	if _, err := cert.Verify(opts); err != nil {
 		return fmt.Errorf("failed to verify edge certificate: %v", err)
 	}
-	return nil
+	return revocation.Current().CheckNode(nodeName, cert)
 }
 
-// verifyAuthorization verifies the token from EdgeCore CSR
-func verifyAuthorization(w http.ResponseWriter, r *http.Request) bool {
+// verifyAuthorization verifies the token from EdgeCore CSR, the ID of the bootstrap token
+// is returned if the token is a bootstrap token
+func verifyAuthorization(w http.ResponseWriter, r *http.Request) (string, bool) {
 	authorizationHeader := r.Header.Get("authorization")
 	if authorizationHeader == "" {
 		w.WriteHeader(http.StatusUnauthorized)
 		if _, err := w.Write([]byte("Invalid authorization token")); err != nil {
 			klog.Errorf("failed to write http response, err: %v", err)
 		}
-		return false
+		return "", false
 	}
 	bearerToken := strings.Split(authorizationHeader, " ")
 	if len(bearerToken) != 2 {
@@ -138,7 +172,7 @@ func verifyAuthorization(w http.ResponseWriter, r *http.Request) bool {
 		if _, err := w.Write([]byte("Invalid authorization token")); err != nil {
 			klog.Errorf("failed to write http response, err: %v", err)
 		}
-		return false
+		return "", false
 	}
 	token, err := jwt.Parse(bearerToken[1], func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -153,37 +187,58 @@ func verifyAuthorization(w http.ResponseWriter, r *http.Request) bool {
 			if _, err := w.Write([]byte("Invalid authorization token")); err != nil {
 				klog.Errorf("Write body error %v", err)
 			}
-			return false
+			return "", false
 		}
 		w.WriteHeader(http.StatusBadRequest)
 		if _, err := w.Write([]byte("Invalid authorization token")); err != nil {
 			klog.Errorf("Write body error %v", err)
 		}
 
-		return false
+		return "", false
 	}
 	if !token.Valid {
 		w.WriteHeader(http.StatusUnauthorized)
 		if _, err := w.Write([]byte("Invalid authorization token")); err != nil {
 			klog.Errorf("Write body error %v", err)
 		}
-		return false
+		return "", false
 	}
-	return true
+	id := bootstraptoken.ID(token)
+	if err := checkToken(id, r.Header.Get(constants.NodeName)); err != nil {
+		klog.Errorf("failed to validate token for node %s: %v", r.Header.Get(constants.NodeName), err)
+		w.WriteHeader(http.StatusUnauthorized)
+		if _, err := w.Write([]byte("Invalid authorization token")); err != nil {
+			klog.Errorf("Write body error %v", err)
+		}
+		return "", false
+	}
+	return id, true
+}
+
+// checkToken returns an error if the node is not allowed to join by the token of id, the empty id is
+// the cluster token. The bootstrap token is scoped to node names and limited in usage, unlike the cluster token
+func checkToken(id, nodeName string) error {
+	if id != "" {
+		return validateBootstrapToken(id, nodeName)
+	}
+	if hubconfig.Config.DisableClusterToken {
+		return fmt.Errorf("the cluster token is disabled, a bootstrap token is required")
+	}
+	return nil
 }
 
-// signEdgeCert signs the CSR from EdgeCore
-func signEdgeCert(w http.ResponseWriter, r *http.Request) {
+// signEdgeCert signs the CSR from EdgeCore, nil is returned if it fails
+func signEdgeCert(w http.ResponseWriter, r *http.Request) []byte {
 	r.Body = http.MaxBytesReader(w, r.Body, constants.MaxRespBodyLength)
 	csrContent, err := io.ReadAll(r.Body)
 	if err != nil {
 		klog.Errorf("fail to read file when signing the cert for edgenode:%s! error:%v", r.Header.Get(constants.NodeName), err)
-		return
+		return nil
 	}
 	csr, err := x509.ParseCertificateRequest(csrContent)
 	if err != nil {
 		klog.Errorf("fail to ParseCertificateRequest of edgenode: %s! error:%v", r.Header.Get(constants.NodeName), err)
-		return
+		return nil
 	}
 	usagesStr := r.Header.Get("ExtKeyUsages")
 	var usages []x509.ExtKeyUsage
@@ -193,16 +248,25 @@ func signEdgeCert(w http.ResponseWriter, r *http.Request) {
 		err := json.Unmarshal([]byte(usagesStr), &usages)
 		if err != nil {
 			klog.Errorf("unmarshal http header ExtKeyUsages fail, err: %v", err)
-			return
+			return nil
 		}
 	}
 	klog.V(4).Infof("receive sign crt request, ExtKeyUsages: %v", usages)
-	clientCertDER, err := signCerts(csr.Subject, csr.PublicKey, usages)
+	subject := csr.Subject
+	// the node name in the common name identifies the certificates of the node when it is revoked
+	if nodeName := r.Header.Get(constants.NodeName); nodeName != "" {
+		subject.CommonName = nodeName
+	}
+	clientCertDER, err := signCerts(subject, csr.PublicKey, usages)
 	if err != nil {
 		klog.Errorf("fail to signCerts for edgenode:%s! error:%v", r.Header.Get(constants.NodeName), err)
-		return
+		return nil
 	}
+	return clientCertDER
+}
 
+// writeEdgeCert writes the certificate signed for EdgeCore
+func writeEdgeCert(w http.ResponseWriter, clientCertDER []byte) {
 	if _, err := w.Write(clientCertDER); err != nil {
 		klog.Errorf("write error %v", err)
 	}
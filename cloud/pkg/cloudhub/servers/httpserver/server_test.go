@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+
+	hubconfig "github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/config"
+	"github.com/kubeedge/kubeedge/common/constants"
+)
+
+func TestVerifyAuthorizationClusterToken(t *testing.T) {
+	caKey := []byte("ca-key")
+	origin := hubconfig.Config
+	defer func() { hubconfig.Config = origin }()
+	hubconfig.Config.CaKey = caKey
+
+	clusterToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	}).SignedString(caKey)
+	if err != nil {
+		t.Fatalf("failed to sign the cluster token: %v", err)
+	}
+
+	tests := []struct {
+		name                string
+		disableClusterToken bool
+		wantOK              bool
+		wantCode            int
+	}{
+		{
+			name:     "cluster token enabled",
+			wantOK:   true,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:                "cluster token disabled",
+			disableClusterToken: true,
+			wantOK:              false,
+			wantCode:            http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hubconfig.Config.DisableClusterToken = tt.disableClusterToken
+			r := httptest.NewRequest(http.MethodGet, constants.DefaultCertURL, nil)
+			r.Header.Set("authorization", "Bearer "+clusterToken)
+			r.Header.Set(constants.NodeName, "edge-node")
+			w := httptest.NewRecorder()
+
+			id, ok := verifyAuthorization(w, r)
+			if ok != tt.wantOK {
+				t.Errorf("expected ok %v, but got %v", tt.wantOK, ok)
+			}
+			if id != "" {
+				t.Errorf("expected no bootstrap token id for the cluster token, but got %s", id)
+			}
+			if w.Code != tt.wantCode {
+				t.Errorf("expected status code %d, but got %d", tt.wantCode, w.Code)
+			}
+		})
+	}
+}
@@ -11,6 +11,8 @@ import (
 
 	hubconfig "github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/config"
 	"github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/handler"
+	"github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/revocation"
+	"github.com/kubeedge/kubeedge/pkg/util"
 	"github.com/kubeedge/viaduct/pkg/api"
 	"github.com/kubeedge/viaduct/pkg/server"
 )
@@ -46,9 +48,19 @@ func createTLSConfig(ca, cert, key []byte) tls.Config {
 		MinVersion:   tls.VersionTLS12,
 		// has to match cipher used by NewPrivateKey method, currently is ECDSA
 		CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+		// the revoked edge certificates are rejected during the handshake
+		VerifyPeerCertificate: revocation.VerifyPeerCertificate,
 	}
 }
 
+// compressThreshold returns the min size of the payloads to compress, 0 disables compression
+func compressThreshold() int {
+	if c := hubconfig.Config.Compression; c != nil && c.Enable {
+		return int(c.Threshold)
+	}
+	return 0
+}
+
 func startWebsocketServer(messageHandler handler.Handler) {
 	tlsConfig := createTLSConfig(hubconfig.Config.Ca, hubconfig.Config.Cert, hubconfig.Config.Key)
 	svc := server.Server{
@@ -59,6 +71,11 @@ func startWebsocketServer(messageHandler handler.Handler) {
 		OnReadTransportErr: messageHandler.OnReadTransportErr,
 		Addr:               fmt.Sprintf("%s:%d", hubconfig.Config.WebSocket.Address, hubconfig.Config.WebSocket.Port),
 		ExOpts:             api.WSServerOption{Path: "/"},
+		CompressThreshold:  compressThreshold(),
+		Classify:           util.ClassifyMessage,
+		Admit:              messageHandler.Admit,
+		Release:            messageHandler.Release,
+		Redirect:           messageHandler.Redirect(api.ProtocolTypeWS),
 	}
 	klog.Infof("Starting cloudhub %s server", api.ProtocolTypeWS)
 	klog.Exit(svc.ListenAndServeTLS("", ""))
@@ -74,6 +91,11 @@ func startQuicServer(messageHandler handler.Handler) {
 		OnReadTransportErr: messageHandler.OnReadTransportErr,
 		Addr:               fmt.Sprintf("%s:%d", hubconfig.Config.Quic.Address, hubconfig.Config.Quic.Port),
 		ExOpts:             api.QuicServerOption{MaxIncomingStreams: int(hubconfig.Config.Quic.MaxIncomingStreams)},
+		CompressThreshold:  compressThreshold(),
+		Classify:           util.ClassifyMessage,
+		Admit:              messageHandler.Admit,
+		Release:            messageHandler.Release,
+		Redirect:           messageHandler.Redirect(api.ProtocolTypeQuic),
 	}
 
 	klog.Infof("Starting cloudhub %s server", api.ProtocolTypeQuic)
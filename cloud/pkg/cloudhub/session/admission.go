@@ -0,0 +1,88 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Admission limits the edge nodes handshaking and syncing initially at the same time, so the
+// nodes reconnecting at once after cloudcore restarts don't overload it with the full resyncs.
+// A nil Admission admits all nodes.
+type Admission struct {
+	lock sync.Mutex
+	// syncing maps the nodes admitted and not synced initially yet to the time they were admitted
+	syncing     map[string]time.Time
+	limit       int
+	syncTimeout time.Duration
+	retryAfter  time.Duration
+	now         func() time.Time
+}
+
+// NewAdmission returns the admission admitting limit nodes at the same time, a node admitted
+// is counted until it synced initially, disconnected, or syncTimeout elapsed
+func NewAdmission(limit int, syncTimeout, retryAfter time.Duration) *Admission {
+	return &Admission{
+		syncing:     make(map[string]time.Time),
+		limit:       limit,
+		syncTimeout: syncTimeout,
+		retryAfter:  retryAfter,
+		now:         time.Now,
+	}
+}
+
+// Admit admits the node if the nodes syncing initially are under the limit, otherwise
+// the hint how long the node should wait before connecting again is returned
+func (a *Admission) Admit(nodeID string) (time.Duration, bool) {
+	if a == nil {
+		return 0, true
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	now := a.now()
+	for id, admittedAt := range a.syncing {
+		if now.Sub(admittedAt) >= a.syncTimeout {
+			klog.Warningf("node %s did not sync initially in %s", id, a.syncTimeout)
+			delete(a.syncing, id)
+		}
+	}
+	// the node reconnecting during the initial sync keeps its place and the time it was admitted,
+	// so that it can't hold the place beyond syncTimeout by reconnecting
+	if _, ok := a.syncing[nodeID]; ok {
+		return 0, true
+	}
+	if len(a.syncing) >= a.limit {
+		klog.V(2).Infof("node %s is not admitted, %d nodes are syncing initially", nodeID, len(a.syncing))
+		return a.retryAfter, false
+	}
+	a.syncing[nodeID] = now
+	return 0, true
+}
+
+// Done releases the place of the node after it synced initially, disconnected, or failed to connect
+func (a *Admission) Done(nodeID string) {
+	if a == nil {
+		return
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	delete(a.syncing, nodeID)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdmission(t *testing.T) {
+	a := NewAdmission(2, time.Minute, 10*time.Second)
+	now := time.Now()
+	a.now = func() time.Time { return now }
+
+	expect := func(nodeID string, admitted bool) {
+		t.Helper()
+		retryAfter, ok := a.Admit(nodeID)
+		if ok != admitted {
+			t.Fatalf("expected node %s admitted %v, but got %v", nodeID, admitted, ok)
+		}
+		if !ok && retryAfter != 10*time.Second {
+			t.Errorf("expected retry after 10s, but got %s", retryAfter)
+		}
+	}
+
+	expect("edge-1", true)
+	expect("edge-2", true)
+	expect("edge-3", false)
+	// the node reconnecting during the initial sync keeps its place
+	expect("edge-1", true)
+
+	a.Done("edge-2")
+	expect("edge-3", true)
+
+	// the node reconnecting keeps the time it was admitted
+	now = now.Add(30 * time.Second)
+	expect("edge-3", true)
+
+	// the nodes not synced in time are released
+	now = now.Add(30 * time.Second)
+	expect("edge-4", true)
+	expect("edge-5", true)
+	expect("edge-6", false)
+}
+
+func TestNilAdmission(t *testing.T) {
+	var a *Admission
+	if _, ok := a.Admit("edge-1"); !ok {
+		t.Errorf("expected nil admission admits all nodes")
+	}
+	a.Done("edge-1")
+}
@@ -37,18 +37,24 @@ import (
 	"github.com/kubeedge/kubeedge/cloud/pkg/synccontroller"
 	"github.com/kubeedge/kubeedge/pkg/apis/reliablesyncs/v1alpha1"
 	reliableclient "github.com/kubeedge/kubeedge/pkg/client/clientset/versioned"
+	"github.com/kubeedge/kubeedge/pkg/hubadmin"
 	"github.com/kubeedge/kubeedge/pkg/metaserver/util"
 	"github.com/kubeedge/viaduct/pkg/conn"
 )
 
 var sendRetryInterval = 5 * time.Second
 
+// initialSyncQuietPeriod is how long the queues of a node stay drained before it is considered synced initially
+var initialSyncQuietPeriod = 2 * time.Second
+
 // session termination error type
 const (
 	NoErr = iota
 	TransportErr
 	NodeStopErr
 	QueueShutdownErr
+	RevokedErr
+	MovedErr
 )
 
 // ErrWaitTimeout is returned when the condition exited without success.
@@ -72,6 +78,13 @@ type NodeSession struct {
 	// keepaliveChan defines a chan which will receive the keepalive message
 	keepaliveChan chan struct{}
 
+	// lastKeepalive records the time in unix nanoseconds the last keepalive
+	// message was received, or the session was created
+	lastKeepalive int64
+
+	// synced marks that the node synced initially
+	synced int32
+
 	// nodeMessagePool stores all the message that will send to an single edge node
 	nodeMessagePool *common.NodeMessagePool
 
@@ -107,6 +120,7 @@ func NewNodeSession(
 		connection:        connection,
 		keepaliveInterval: keepaliveInterval,
 		keepaliveChan:     make(chan struct{}, 1),
+		lastKeepalive:     time.Now().UnixNano(),
 		nodeMessagePool:   nodeMessagePool,
 		reliableClient:    reliableClient,
 		terminateErr:      NoErr,
@@ -115,6 +129,7 @@ func NewNodeSession(
 
 // KeepAliveMessage receive keepalive message from edge node
 func (ns *NodeSession) KeepAliveMessage() {
+	atomic.StoreInt64(&ns.lastKeepalive, time.Now().UnixNano())
 	select {
 	case ns.keepaliveChan <- struct{}{}:
 	default:
@@ -142,6 +157,74 @@ func (ns *NodeSession) Start() {
 	<-ns.ctx.Done()
 }
 
+// WaitInitialSync waits until the messages to the node are all sent and acknowledged and
+// no more messages are queued for a quiet period, or the session is terminated
+func (ns *NodeSession) WaitInitialSync() {
+	ticker := time.NewTicker(initialSyncQuietPeriod / 2)
+	defer ticker.Stop()
+
+	quiet := 0
+	for quiet < 2 {
+		select {
+		case <-ns.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if ns.pending() {
+			quiet = 0
+			continue
+		}
+		quiet++
+	}
+	atomic.StoreInt32(&ns.synced, 1)
+	klog.V(2).Infof("edge node %s synced initially", ns.nodeID)
+}
+
+// Status returns the status of the session and the message pool shown by the admin API
+func (ns *NodeSession) Status() hubadmin.NodeStatus {
+	state := hubadmin.StateSyncing
+	switch {
+	case ns.ctx.Err() != nil:
+		state = hubadmin.StateTerminating
+	case atomic.LoadInt32(&ns.synced) == 1:
+		state = hubadmin.StateSynced
+	}
+	waitingAck := 0
+	ns.ackMessageCache.Range(func(_, _ interface{}) bool {
+		waitingAck++
+		return true
+	})
+	return hubadmin.NodeStatus{
+		NodeID:          ns.nodeID,
+		ProjectID:       ns.projectID,
+		State:           state,
+		LastKeepalive:   time.Unix(0, atomic.LoadInt64(&ns.lastKeepalive)),
+		AckQueueDepth:   ns.nodeMessagePool.AckMessageQueue.Len(),
+		NoAckQueueDepth: ns.nodeMessagePool.NoAckMessageQueue.Len(),
+		AckStoreSize:    len(ns.nodeMessagePool.AckMessageStore.ListKeys()),
+		NoAckStoreSize:  len(ns.nodeMessagePool.NoAckMessageStore.ListKeys()),
+		WaitingAck:      waitingAck,
+	}
+}
+
+// MessagePool returns the message pool of the messages sent to the node
+func (ns *NodeSession) MessagePool() *common.NodeMessagePool {
+	return ns.nodeMessagePool
+}
+
+// pending returns whether any message to the node is queued or waiting for the acknowledgment
+func (ns *NodeSession) pending() bool {
+	if ns.nodeMessagePool.AckMessageQueue.Len() > 0 || ns.nodeMessagePool.NoAckMessageQueue.Len() > 0 {
+		return true
+	}
+	waiting := false
+	ns.ackMessageCache.Range(func(_, _ interface{}) bool {
+		waiting = true
+		return false
+	})
+	return waiting
+}
+
 // KeepAliveCheck
 // A goroutine running KeepAliveCheck is started for each connection.
 func (ns *NodeSession) KeepAliveCheck() {
@@ -320,6 +403,7 @@ func (ns *NodeSession) syncAckMessage() (bool, error) {
 	case err == nil:
 		// no err, forget this key and return
 		ns.nodeMessagePool.AckMessageQueue.Forget(key)
+		ns.nodeMessagePool.AckMessageDelivered(msg)
 		return false, nil
 
 	case err == ErrWaitTimeout:
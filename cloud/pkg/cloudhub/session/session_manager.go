@@ -17,11 +17,16 @@ limitations under the License.
 package session
 
 import (
+	"crypto/x509"
 	"fmt"
 	"sync"
 	"sync/atomic"
 
 	"k8s.io/klog/v2"
+
+	"github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/revocation"
+	"github.com/kubeedge/kubeedge/cloud/pkg/common/sharding"
+	"github.com/kubeedge/viaduct/pkg/conn"
 )
 
 type Manager struct {
@@ -33,6 +38,8 @@ type Manager struct {
 	NodeLimit int32
 	// NodeSessions maps a node ID to NodeSession
 	NodeSessions sync.Map
+	// Admission limits the nodes handshaking and syncing initially, nil admits all nodes
+	Admission *Admission
 }
 
 // NewSessionManager initializes a new SessionManager
@@ -114,3 +121,45 @@ func (sm *Manager) ReceiveMessageAck(nodeID, parentID string) error {
 	session.ReceiveMessageAck(parentID)
 	return nil
 }
+
+// TerminateRevokedSessions terminates the sessions of the revoked nodes and certificates
+func (sm *Manager) TerminateRevokedSessions(list *revocation.List) {
+	sm.NodeSessions.Range(func(_, value interface{}) bool {
+		session, ok := value.(*NodeSession)
+		if !ok {
+			return true
+		}
+		if err := list.CheckNode(session.nodeID, PeerCertificate(session.connection)); err != nil {
+			klog.Warningf("terminate session of node %s: %v", session.nodeID, err)
+			session.SetTerminateErr(RevokedErr)
+			session.Terminating()
+		}
+		return true
+	})
+}
+
+// TerminateMovedSessions terminates the sessions of the nodes owned by another cloudcore after
+// the membership changed, the nodes are redirected to the owners when they connect again
+func (sm *Manager) TerminateMovedSessions() {
+	sm.NodeSessions.Range(func(_, value interface{}) bool {
+		session, ok := value.(*NodeSession)
+		if !ok {
+			return true
+		}
+		if !sharding.Owns(session.nodeID) {
+			klog.Infof("terminate session of node %s moved to another cloudcore", session.nodeID)
+			session.SetTerminateErr(MovedErr)
+			session.Terminating()
+		}
+		return true
+	})
+}
+
+// PeerCertificate returns the certificate the peer of the connection presented, nil is returned if none
+func PeerCertificate(connection conn.Connection) *x509.Certificate {
+	state := connection.ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return nil
+	}
+	return state.PeerCertificates[0]
+}
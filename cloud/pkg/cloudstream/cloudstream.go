@@ -61,6 +61,8 @@ func (s *cloudStream) Start() {
 
 		// start new tunnel server
 		go ts.Start()
+		// serve the tunnels over the CloudHub connections
+		go ts.receiveFromHub()
 
 		server := newStreamServer(ts)
 		// start stream server to accept kube-apiserver connection
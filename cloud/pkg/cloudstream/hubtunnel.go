@@ -0,0 +1,169 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudstream
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
+	"github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/kubeedge/cloud/pkg/cloudhub"
+	"github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/dispatcher"
+	"github.com/kubeedge/kubeedge/cloud/pkg/common/modules"
+	"github.com/kubeedge/kubeedge/pkg/stream"
+)
+
+// sendToHub sends the stream tunnel message to the edge node by CloudHub,
+// an error is returned if the node is not connected to CloudHub
+func sendToHub(nodeID string, m *stream.Message) error {
+	if !cloudhub.HasSession(nodeID) {
+		return fmt.Errorf("node %s is not connected to cloudhub", nodeID)
+	}
+	message := model.NewMessage("").
+		BuildRouter(modules.CloudStreamModuleName, stream.HubGroup,
+			fmt.Sprintf("node/%s/%s", nodeID, stream.HubResourceTunnel), stream.HubOperationTunnel).
+		FillBody(m.Bytes())
+	beehiveContext.Send(modules.CloudHubModuleName, *message)
+	return nil
+}
+
+// hubTunnels holds the tunnels over the CloudHub connections of the edge nodes
+type hubTunnels struct {
+	sync.Mutex
+	tunnels map[string]*stream.HubTunnel
+}
+
+// replace registers the tunnel of the node, the previous one is closed
+func (h *hubTunnels) replace(nodeID string, tunnel *stream.HubTunnel) {
+	h.Lock()
+	defer h.Unlock()
+	if old, ok := h.tunnels[nodeID]; ok {
+		old.Close()
+	}
+	h.tunnels[nodeID] = tunnel
+}
+
+func (h *hubTunnels) get(nodeID string) (*stream.HubTunnel, bool) {
+	h.Lock()
+	defer h.Unlock()
+	tunnel, ok := h.tunnels[nodeID]
+	return tunnel, ok
+}
+
+// remove unregisters the tunnel of the node if it is not replaced
+func (h *hubTunnels) remove(nodeID string, tunnel *stream.HubTunnel) {
+	h.Lock()
+	defer h.Unlock()
+	if h.tunnels[nodeID] == tunnel {
+		delete(h.tunnels, nodeID)
+	}
+}
+
+// receiveFromHub serves the tunnels the edge nodes open over the CloudHub connections
+func (s *TunnelServer) receiveFromHub() {
+	for {
+		select {
+		case <-beehiveContext.Done():
+			klog.Warning("CloudStream receive from cloudhub stop")
+			return
+		default:
+		}
+		message, err := beehiveContext.Receive(modules.CloudStreamModuleName)
+		if err != nil {
+			klog.Errorf("failed to receive stream message: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		nodeID, err := dispatcher.GetNodeID(&message)
+		if err != nil {
+			klog.Errorf("failed to get node of stream message %s: %v", message.GetID(), err)
+			continue
+		}
+		content, err := message.GetContentData()
+		if err != nil {
+			klog.Errorf("failed to get content of stream message %s: %v", message.GetID(), err)
+			continue
+		}
+
+		switch message.GetOperation() {
+		case stream.HubOperationConnect:
+			s.hubConnect(nodeID, content)
+		case stream.HubOperationTunnel:
+			m, err := stream.ReadMessageFromTunnel(bytes.NewReader(content))
+			if err != nil {
+				klog.Errorf("failed to read stream message %s: %v", message.GetID(), err)
+				continue
+			}
+			s.deliverFromHub(nodeID, m)
+		default:
+			klog.Warningf("unknown stream operation %s of node %s", message.GetOperation(), nodeID)
+		}
+	}
+}
+
+// hubConnect registers the tunnel of the node before serving it, so the following
+// messages are delivered to it in order
+func (s *TunnelServer) hubConnect(nodeID string, content []byte) {
+	var info stream.ConnectInfo
+	if err := json.Unmarshal(content, &info); err != nil {
+		klog.Errorf("failed to unmarshal connect info of node %s: %v", nodeID, err)
+		return
+	}
+	if info.HostNameOverride != nodeID {
+		klog.Warningf("node %s opens tunnel as %s, use node name instead", nodeID, info.HostNameOverride)
+	}
+	klog.Infof("get a new tunnel agent over cloudhub hostname %v, internalIP %v", nodeID, info.InternalIP)
+
+	tunnel := stream.NewHubTunnel(func(m *stream.Message) error {
+		return sendToHub(nodeID, m)
+	})
+	s.hubTunnels.replace(nodeID, tunnel)
+
+	session := &Session{
+		tunnel:        tunnel,
+		apiServerConn: make(map[uint64]APIServerConnection),
+		apiConnlock:   &sync.RWMutex{},
+		sessionID:     nodeID,
+	}
+	go func() {
+		defer s.hubTunnels.remove(nodeID, tunnel)
+		s.serveSession(session, nodeID, info.InternalIP)
+	}()
+}
+
+// deliverFromHub delivers the message to the tunnel of the node,
+// the node is asked to open the tunnel again if it is not found
+func (s *TunnelServer) deliverFromHub(nodeID string, m *stream.Message) {
+	if tunnel, ok := s.hubTunnels.get(nodeID); ok {
+		tunnel.Deliver(m)
+		return
+	}
+	switch m.MessageType {
+	case stream.MessageTypeCloseConnect, stream.MessageTypeRemoveConnect, stream.MessageTypePong, stream.MessageTypeWindowUpdate:
+		return
+	}
+	msg := stream.NewMessage(0, stream.MessageTypeCloseConnect, []byte("tunnel over cloudhub is not found"))
+	if err := sendToHub(nodeID, msg); err != nil {
+		klog.Errorf("failed to close tunnel of node %s: %v", nodeID, err)
+	}
+}
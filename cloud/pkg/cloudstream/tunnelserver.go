@@ -53,6 +53,8 @@ type TunnelServer struct {
 	sessions   map[string]*Session
 	nodeNameIP sync.Map
 	tunnelPort int
+	// hubTunnels holds the tunnels over the CloudHub connections
+	hubTunnels hubTunnels
 }
 
 func newTunnelServer(tunnelPort int) *TunnelServer {
@@ -60,6 +62,7 @@ func newTunnelServer(tunnelPort int) *TunnelServer {
 		container:  restful.NewContainer(),
 		sessions:   make(map[string]*Session),
 		tunnelPort: tunnelPort,
+		hubTunnels: hubTunnels{tunnels: make(map[string]*stream.HubTunnel)},
 		upgrader: websocket.Upgrader{
 			HandshakeTimeout: time.Second * 2,
 			ReadBufferSize:   1024,
@@ -126,8 +129,12 @@ func (s *TunnelServer) connect(r *restful.Request, w *restful.Response) {
 		apiConnlock:   &sync.RWMutex{},
 		sessionID:     hostNameOverride,
 	}
+	s.serveSession(session, hostNameOverride, internalIP)
+}
 
-	err = s.updateNodeKubeletEndpoint(hostNameOverride)
+// serveSession serves the tunnel session of the edge node after the kubelet endpoint of the node is updated
+func (s *TunnelServer) serveSession(session *Session, hostNameOverride, internalIP string) {
+	err := s.updateNodeKubeletEndpoint(hostNameOverride)
 	if err != nil {
 		msg := stream.NewMessage(0, stream.MessageTypeCloseConnect, []byte(err.Error()))
 		if err := session.tunnel.WriteMessage(msg); err == nil {
@@ -135,6 +142,7 @@ func (s *TunnelServer) connect(r *restful.Request, w *restful.Response) {
 		} else {
 			klog.Errorf("CloudStream failed to send close connection message to edge, error: %v", err)
 		}
+		session.tunnel.Close()
 		return
 	}
 	s.addSession(hostNameOverride, session)
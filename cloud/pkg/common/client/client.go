@@ -36,6 +36,8 @@ var (
 	kubeClient    kubernetes.Interface
 	crdClient     crdClientset.Interface
 	dynamicClient dynamic.Interface
+	// baseKubeConfig is the config of cloudcore itself, it is used for the requests not covered by clients above
+	baseKubeConfig *rest.Config
 	// authKubeConfig only contains master address and CA cert when init, it is used for
 	// generating a temporary kubeclient and validating user token once receive an application message.
 	authKubeConfig *rest.Config
@@ -52,6 +54,7 @@ func InitKubeEdgeClient(config *cloudcoreConfig.KubeAPIConfig) {
 		kubeConfig.Burst = int(config.Burst)
 
 		dynamicClient = dynamic.NewForConfigOrDie(kubeConfig)
+		baseKubeConfig = rest.CopyConfig(kubeConfig)
 
 		kubeConfig.ContentType = runtime.ContentTypeProtobuf
 		kubeClient = kubernetes.NewForConfigOrDie(kubeConfig)
@@ -83,6 +86,11 @@ func GetDynamicClient() dynamic.Interface {
 	return dynamicClient
 }
 
+// GetKubeConfig returns a copy of the config used by cloudcore to access kube-apiserver
+func GetKubeConfig() *rest.Config {
+	return rest.CopyConfig(baseKubeConfig)
+}
+
 func GetAuthConfig() *rest.Config {
 	return authKubeConfig
 }
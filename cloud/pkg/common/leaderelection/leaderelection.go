@@ -0,0 +1,145 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package leaderelection runs the singleton modules of cloudcore on only one of the replicas.
+// Each elected module has a Lease of its own, so the modules may be led by different replicas,
+// and the modules bound to the sessions of the edge nodes keep running on all replicas.
+package leaderelection
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	kubeleaderelection "k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/klog/v2"
+
+	"github.com/kubeedge/beehive/pkg/core"
+	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
+	"github.com/kubeedge/kubeedge/cloud/pkg/common/modules"
+	"github.com/kubeedge/kubeedge/cloud/pkg/common/sharding"
+	"github.com/kubeedge/kubeedge/common/constants"
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/cloudcore/v1alpha1"
+	"github.com/kubeedge/kubeedge/pkg/util"
+)
+
+const leaseNamePrefix = "cloudcore-"
+
+// shardedModules act only on the edge nodes owned by the replica if sharding is enabled,
+// so they run on all replicas instead of being elected
+var shardedModules = sets.NewString(modules.SyncControllerModuleName, modules.NodeUpgradeJobControllerModuleName)
+
+// Module is the module which can be elected. It runs until the context is done, so it is stopped when
+// the replica loses the leadership of it, and started again once the replica becomes the leader again.
+type Module interface {
+	core.Module
+	StartWithContext(ctx context.Context)
+}
+
+// Follower is the elected module which has work to do while the replica is not the leader of it,
+// like consuming the messages sent to it. Follow is called each time the replica joins the election.
+type Follower interface {
+	Follow(ctx context.Context)
+}
+
+// Register replaces the registered modules listed in the config with the elected ones,
+// it must be called after all modules are registered
+func Register(config *v1alpha1.ModuleLeaderElection, client kubernetes.Interface) {
+	if config == nil || !config.Enable {
+		return
+	}
+	registered := core.GetModules()
+	for _, name := range config.Modules {
+		if sharding.Enabled() && shardedModules.Has(name) {
+			klog.Infof("module %s is sharded by the edge nodes, it runs on all replicas without leader election", name)
+			continue
+		}
+		info, ok := registered[name]
+		if !ok {
+			// the module is disabled
+			continue
+		}
+		m, ok := info.GetModule().(Module)
+		if !ok {
+			// the config validation rejects the modules can't be elected
+			klog.Errorf("module %s can't be stopped when the leadership is lost, it runs without leader election", name)
+			continue
+		}
+		core.Register(newElectedModule(m, config, client))
+	}
+}
+
+// electedModule starts the module only while the replica is the leader of it
+type electedModule struct {
+	Module
+	config *v1alpha1.ModuleLeaderElection
+	client kubernetes.Interface
+}
+
+func newElectedModule(m Module, config *v1alpha1.ModuleLeaderElection, client kubernetes.Interface) *electedModule {
+	return &electedModule{
+		Module: m,
+		config: config,
+		client: client,
+	}
+}
+
+// Start runs the leader election of the module until beehive is done, the module is stopped
+// when the leadership is lost and the replica rejoins the election as a follower
+func (m *electedModule) Start() {
+	identity := util.GetHostname() + "_" + uuid.New().String()
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      leaseNamePrefix + m.Name(),
+			Namespace: constants.SystemNamespace,
+		},
+		Client:     m.client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+	}
+	for {
+		if follower, ok := m.Module.(Follower); ok {
+			follower.Follow(beehiveContext.GetContext())
+		}
+		kubeleaderelection.RunOrDie(beehiveContext.GetContext(), kubeleaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			LeaseDuration:   time.Duration(m.config.LeaseDuration) * time.Second,
+			RenewDeadline:   time.Duration(m.config.RenewDeadline) * time.Second,
+			RetryPeriod:     time.Duration(m.config.RetryPeriod) * time.Second,
+			ReleaseOnCancel: true,
+			Name:            m.Name(),
+			Callbacks: kubeleaderelection.LeaderCallbacks{
+				// the context is canceled once the leadership is lost
+				OnStartedLeading: func(ctx context.Context) {
+					klog.Infof("%s became the leader of module %s", identity, m.Name())
+					m.Module.StartWithContext(ctx)
+				},
+				OnStoppedLeading: func() {
+					klog.Infof("%s stopped leading module %s", identity, m.Name())
+				},
+			},
+		})
+
+		select {
+		case <-beehiveContext.Done():
+			return
+		default:
+		}
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leaderelection
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kubeedge/beehive/pkg/core"
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/cloudcore/v1alpha1"
+)
+
+type fakeModule struct {
+	name string
+}
+
+func (m *fakeModule) Name() string  { return m.name }
+func (m *fakeModule) Group() string { return m.name }
+func (m *fakeModule) Start()        {}
+func (m *fakeModule) Enable() bool  { return true }
+
+type fakeElectableModule struct {
+	fakeModule
+}
+
+func (m *fakeElectableModule) StartWithContext(context.Context) {}
+
+func TestRegister(t *testing.T) {
+	core.Register(&fakeElectableModule{fakeModule{name: "singleton"}})
+	core.Register(&fakeModule{name: "session"})
+	core.Register(&fakeModule{name: "unstoppable"})
+	client := fake.NewSimpleClientset()
+
+	Register(&v1alpha1.ModuleLeaderElection{Enable: false, Modules: []string{"singleton"}}, client)
+	if _, ok := core.GetModules()["singleton"].GetModule().(*electedModule); ok {
+		t.Fatalf("expected no module elected if leader election is disabled")
+	}
+
+	Register(&v1alpha1.ModuleLeaderElection{Enable: true, Modules: []string{"singleton", "unstoppable", "disabled"}}, client)
+	registered := core.GetModules()
+	if _, ok := registered["singleton"].GetModule().(*electedModule); !ok {
+		t.Errorf("expected module singleton elected")
+	}
+	if _, ok := registered["session"].GetModule().(*electedModule); ok {
+		t.Errorf("expected module session not elected")
+	}
+	if _, ok := registered["unstoppable"].GetModule().(*electedModule); ok {
+		t.Errorf("expected the module can't be stopped not elected")
+	}
+	if _, ok := registered["disabled"]; ok {
+		t.Errorf("expected the disabled module not registered")
+	}
+}
@@ -19,9 +19,12 @@ package messagelayer
 import (
 	"strings"
 
+	"k8s.io/klog/v2"
+
 	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
 	"github.com/kubeedge/beehive/pkg/core/model"
 	"github.com/kubeedge/kubeedge/cloud/pkg/common/modules"
+	"github.com/kubeedge/kubeedge/cloud/pkg/common/sharding"
 	"github.com/kubeedge/kubeedge/common/constants"
 )
 
@@ -51,6 +54,12 @@ func (cml *ContextMessageLayer) Send(message model.Message) error {
 	if len(cml.SendRouterModuleName) != 0 && isRouterMsg(message) {
 		module = cml.SendRouterModuleName
 	}
+	// the messages to the edge nodes owned by the other cloudcore replicas are sent by them,
+	// but the responses go back to the nodes asking wherever they are connected
+	if module == cml.SendModuleName && message.GetParentID() == "" && !isOwnedNodeMsg(message) {
+		klog.V(4).Infof("skip message %s to node owned by another cloudcore, resource: %s", message.GetID(), message.GetResource())
+		return nil
+	}
 	beehiveContext.Send(module, message)
 	return nil
 }
@@ -71,6 +80,16 @@ func isRouterMsg(message model.Message) bool {
 	return len(resourceArray) == 2 && (resourceArray[0] == model.ResourceTypeRule || resourceArray[0] == model.ResourceTypeRuleEndpoint)
 }
 
+// isOwnedNodeMsg returns whether the message is to an edge node owned by this cloudcore
+// or not to a specific node
+func isOwnedNodeMsg(message model.Message) bool {
+	if getElementByIndex(message, 0) != ResourceNode {
+		return true
+	}
+	nodeID := getElementByIndex(message, ResourceNodeIDIndex)
+	return nodeID == "" || sharding.Owns(nodeID)
+}
+
 func EdgeControllerMessageLayer() MessageLayer {
 	return &ContextMessageLayer{
 		SendModuleName:       modules.CloudHubModuleName,
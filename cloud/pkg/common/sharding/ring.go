@@ -0,0 +1,70 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+	"strconv"
+)
+
+// Ring is the consistent hash ring of the members, a key is owned by the member of the first
+// point clockwise from the hash of the key. Only the keys of the points a member adds or
+// removes move when it joins or leaves.
+type Ring struct {
+	points []uint32
+	owners map[uint32]string
+}
+
+// NewRing returns the ring on which each member has virtualNodes points
+func NewRing(members []string, virtualNodes int) *Ring {
+	r := &Ring{owners: make(map[uint32]string)}
+	for _, member := range members {
+		for i := 0; i < virtualNodes; i++ {
+			point := hash(member + "#" + strconv.Itoa(i))
+			// the collided point is owned by the member sorted first, so it's the same on all replicas
+			if owner, ok := r.owners[point]; ok && owner < member {
+				continue
+			} else if !ok {
+				r.points = append(r.points, point)
+			}
+			r.owners[point] = member
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i] < r.points[j] })
+	return r
+}
+
+// Owner returns the member owning the key, "" is returned if the ring is empty
+func (r *Ring) Owner(key string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+	h := hash(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.owners[r.points[i]]
+}
+
+// hash spreads the similar keys like the points of a member evenly on the ring
+func hash(key string) uint32 {
+	sum := sha256.Sum256([]byte(key))
+	return binary.BigEndian.Uint32(sum[:4])
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRingOwner(t *testing.T) {
+	if owner := NewRing(nil, 100).Owner("edge-1"); owner != "" {
+		t.Errorf("expected no owner on empty ring, but got %s", owner)
+	}
+
+	// the owners don't depend on the order of the members
+	a := NewRing([]string{"cloudcore-1", "cloudcore-2", "cloudcore-3"}, 100)
+	b := NewRing([]string{"cloudcore-3", "cloudcore-1", "cloudcore-2"}, 100)
+	counts := make(map[string]int)
+	for i := 0; i < 3000; i++ {
+		node := fmt.Sprintf("edge-%d", i)
+		if a.Owner(node) != b.Owner(node) {
+			t.Fatalf("expected the same owner of %s, but got %s and %s", node, a.Owner(node), b.Owner(node))
+		}
+		counts[a.Owner(node)]++
+	}
+	for member, count := range counts {
+		if count < 500 || count > 1500 {
+			t.Errorf("expected the nodes spread evenly, but %s owns %d of 3000", member, count)
+		}
+	}
+}
+
+func TestRingRebalance(t *testing.T) {
+	before := NewRing([]string{"cloudcore-1", "cloudcore-2"}, 100)
+	after := NewRing([]string{"cloudcore-1", "cloudcore-2", "cloudcore-3"}, 100)
+	moved := 0
+	for i := 0; i < 3000; i++ {
+		node := fmt.Sprintf("edge-%d", i)
+		if owner := after.Owner(node); owner != before.Owner(node) {
+			// only the nodes taken over by the replica joined move
+			if owner != "cloudcore-3" {
+				t.Fatalf("expected %s moved to cloudcore-3, but got %s", node, owner)
+			}
+			moved++
+		}
+	}
+	if moved == 0 || moved > 1500 {
+		t.Errorf("expected about a third of the nodes moved, but got %d of 3000", moved)
+	}
+}
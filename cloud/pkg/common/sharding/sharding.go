@@ -0,0 +1,449 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sharding shards the edge nodes among the cloudcore replicas. The replicas register
+// in the membership of Leases and own the nodes by consistent hashing, so each of them serves
+// and controls only a part of the nodes and the nodes move as few as possible when a replica
+// joins or leaves.
+package sharding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"github.com/kubeedge/kubeedge/common/constants"
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/cloudcore/v1alpha1"
+	"github.com/kubeedge/kubeedge/pkg/util"
+)
+
+const (
+	// LeaseLabelKey labels the Leases of the cloudcore replicas in the membership
+	LeaseLabelKey = "kubeedge.io/cloudcore-sharding"
+	// MemberAnnotationKey annotates the Lease with the member info of the replica
+	MemberAnnotationKey = "kubeedge.io/cloudcore-member"
+
+	leaseNamePrefix = "cloudcore-sharding-"
+	// podNameEnv is the env of the pod name of cloudcore, the hostname is used if it is not set
+	podNameEnv = "CLOUDCORE_POD_NAME"
+)
+
+// Member is a cloudcore replica in the membership
+type Member struct {
+	Name string `json:"name"`
+	// Address is the address the edge nodes redirected to the replica connect
+	Address string `json:"address"`
+	// WebSocketPort and QuicPort are the ports of the CloudHub servers, 0 if the server is disabled
+	WebSocketPort int32 `json:"webSocketPort,omitempty"`
+	QuicPort      int32 `json:"quicPort,omitempty"`
+}
+
+// observation is the renew time of a Lease and the local time it was observed at, the replicas
+// are judged alive by the local clock so the clock skew among them doesn't matter
+type observation struct {
+	renewTime  time.Time
+	observedAt time.Time
+}
+
+// Sharding keeps the membership of the cloudcore replicas and the ring of the edge nodes
+type Sharding struct {
+	client        kubernetes.Interface
+	self          Member
+	leaseDuration time.Duration
+	renewInterval time.Duration
+	virtualNodes  int
+	now           func() time.Time
+
+	lock    sync.RWMutex
+	members map[string]Member
+	ring    *Ring
+	// previous is the ring before the last change of the membership, nil if it never changed
+	previous *Ring
+	observed map[string]observation
+	handlers []func()
+	// renewed is the last time the Lease was renewed and the membership refreshed, the replica owns
+	// no nodes once it is older than the lease duration, as the other replicas may have taken them over
+	renewed time.Time
+	// lost is whether the handlers were notified that the replica owns no nodes
+	lost bool
+}
+
+// NewSharding returns the sharding in which the replica self joins
+func NewSharding(client kubernetes.Interface, self Member, config *v1alpha1.CloudHubSharding) *Sharding {
+	return &Sharding{
+		client:        client,
+		self:          self,
+		leaseDuration: time.Duration(config.LeaseDuration) * time.Second,
+		renewInterval: time.Duration(config.RenewInterval) * time.Second,
+		virtualNodes:  int(config.VirtualNodes),
+		now:           time.Now,
+		members:       map[string]Member{self.Name: self},
+		ring:          NewRing([]string{self.Name}, int(config.VirtualNodes)),
+		observed:      make(map[string]observation),
+	}
+}
+
+// current is the sharding of this replica, nil if the edge nodes are not sharded
+var current *Sharding
+
+// Init joins the membership if sharding is enabled, it must be called before the
+// controllers start, so they act only on the nodes owned since the beginning
+func Init(hub *v1alpha1.CloudHub, client kubernetes.Interface) error {
+	if hub.Sharding == nil || !hub.Sharding.Enable {
+		return nil
+	}
+	self, err := selfMember(hub)
+	if err != nil {
+		return err
+	}
+	s := NewSharding(client, self, hub.Sharding)
+	if err := s.refresh(context.TODO()); err != nil {
+		return fmt.Errorf("failed to join the sharding membership: %v", err)
+	}
+	klog.Infof("cloudcore %s joined the sharding membership with members %v", self.Name, s.Members())
+	current = s
+	return nil
+}
+
+func selfMember(hub *v1alpha1.CloudHub) (Member, error) {
+	hostname := util.GetHostname()
+	name := os.Getenv(podNameEnv)
+	if name == "" {
+		name = hostname
+	}
+	address, err := util.GetLocalIP(hostname)
+	if err != nil {
+		return Member{}, fmt.Errorf("failed to get the address of cloudcore: %v", err)
+	}
+	self := Member{Name: name, Address: address}
+	if hub.WebSocket != nil && hub.WebSocket.Enable {
+		self.WebSocketPort = int32(hub.WebSocket.Port)
+	}
+	if hub.Quic != nil && hub.Quic.Enable {
+		self.QuicPort = int32(hub.Quic.Port)
+	}
+	return self, nil
+}
+
+// Enabled returns whether the edge nodes are sharded among the replicas
+func Enabled() bool {
+	return current != nil
+}
+
+// Run keeps the membership until ctx is done
+func Run(ctx context.Context) {
+	current.Run(ctx)
+}
+
+// Owns returns whether this replica owns the edge node, all nodes are owned if not sharding
+func Owns(nodeName string) bool {
+	return current.Owns(nodeName)
+}
+
+// OwnerOf returns the replica owning the edge node if it is not this one
+func OwnerOf(nodeName string) (Member, bool) {
+	return current.OwnerOf(nodeName)
+}
+
+// Gained returns whether the edge node is moved to this replica by the last change of the membership
+func Gained(nodeName string) bool {
+	return current.Gained(nodeName)
+}
+
+// OnRebalance registers the handler called after the membership changed
+func OnRebalance(handler func()) {
+	current.OnRebalance(handler)
+}
+
+// Expired returns whether this replica failed to renew its Lease for the lease duration
+func Expired() bool {
+	return current.Expired()
+}
+
+// Run renews the Lease and refreshes the membership every renew interval until ctx is done,
+// the Lease is deleted then so the other replicas take over the nodes at once
+func (s *Sharding) Run(ctx context.Context) {
+	if s == nil {
+		return
+	}
+	wait.Until(func() {
+		if err := s.refresh(ctx); err != nil {
+			klog.Errorf("failed to refresh the sharding membership: %v", err)
+			s.checkExpired()
+		}
+	}, s.renewInterval, ctx.Done())
+
+	err := s.client.CoordinationV1().Leases(constants.SystemNamespace).Delete(context.TODO(), leaseName(s.self.Name), metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("failed to leave the sharding membership: %v", err)
+	}
+}
+
+// Owns returns whether the replica owns the edge node
+func (s *Sharding) Owns(nodeName string) bool {
+	if s == nil {
+		return true
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return !s.expiredLocked() && s.ring.Owner(nodeName) == s.self.Name
+}
+
+// OwnerOf returns the replica owning the edge node if it is not this one
+func (s *Sharding) OwnerOf(nodeName string) (Member, bool) {
+	if s == nil {
+		return Member{}, false
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	owner := s.ring.Owner(nodeName)
+	if owner == s.self.Name {
+		return Member{}, false
+	}
+	member, ok := s.members[owner]
+	return member, ok
+}
+
+// Gained returns whether the edge node is moved to the replica by the last change of the membership,
+// the objects of the node must be sent again since the former owner may have skipped them
+func (s *Sharding) Gained(nodeName string) bool {
+	if s == nil {
+		return false
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return !s.expiredLocked() && s.previous != nil && s.previous.Owner(nodeName) != s.self.Name &&
+		s.ring.Owner(nodeName) == s.self.Name
+}
+
+// Expired returns whether the replica failed to renew its Lease for the lease duration,
+// it owns no nodes until it renews again
+func (s *Sharding) Expired() bool {
+	if s == nil {
+		return false
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.expiredLocked()
+}
+
+// expiredLocked returns whether the Lease expired, s.lock must be held
+func (s *Sharding) expiredLocked() bool {
+	return s.now().Sub(s.renewed) >= s.leaseDuration
+}
+
+// checkExpired notifies the handlers once the replica failed to renew its Lease for the lease
+// duration, so the sessions of the nodes are terminated and the nodes connect to the other replicas
+func (s *Sharding) checkExpired() {
+	s.lock.Lock()
+	if s.lost || !s.expiredLocked() {
+		s.lock.Unlock()
+		return
+	}
+	s.lost = true
+	handlers := s.handlers
+	s.lock.Unlock()
+
+	klog.Warningf("cloudcore %s failed to renew its lease for %v, it owns no edge nodes until renewed", s.self.Name, s.leaseDuration)
+	for _, handler := range handlers {
+		handler()
+	}
+}
+
+// Members returns the names of the alive replicas
+func (s *Sharding) Members() []string {
+	if s == nil {
+		return nil
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return memberNames(s.members)
+}
+
+// OnRebalance registers the handler called after the membership changed
+func (s *Sharding) OnRebalance(handler func()) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.handlers = append(s.handlers, handler)
+}
+
+// refresh renews the Lease of the replica and rebuilds the ring if the alive replicas changed
+func (s *Sharding) refresh(ctx context.Context) error {
+	renewed := s.now()
+	if err := s.renew(ctx); err != nil {
+		return err
+	}
+	leases, err := s.client.CoordinationV1().Leases(constants.SystemNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: LeaseLabelKey,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list leases: %v", err)
+	}
+
+	now := s.now()
+	members := map[string]Member{s.self.Name: s.self}
+	observed := make(map[string]observation)
+	for i := range leases.Items {
+		lease := &leases.Items[i]
+		if lease.Spec.RenewTime == nil {
+			continue
+		}
+		var member Member
+		if err := json.Unmarshal([]byte(lease.Annotations[MemberAnnotationKey]), &member); err != nil || member.Name == "" {
+			klog.Warningf("invalid member of lease %s: %v", lease.Name, err)
+			continue
+		}
+		if member.Name == s.self.Name {
+			continue
+		}
+
+		renewTime := lease.Spec.RenewTime.Time
+		o, ok := s.observed[lease.Name]
+		if !ok || !o.renewTime.Equal(renewTime) {
+			o = observation{renewTime: renewTime, observedAt: now}
+			// the Lease seen first is trusted by its renew time, so the replicas left long
+			// ago are not counted alive for another lease duration
+			if !ok && renewTime.Before(now) {
+				o.observedAt = renewTime
+			}
+		}
+		if now.Sub(o.observedAt) >= s.leaseDuration {
+			klog.Infof("cloudcore %s expired, delete its lease %s", member.Name, lease.Name)
+			s.deleteLease(ctx, lease)
+			continue
+		}
+		observed[lease.Name] = o
+		members[member.Name] = member
+	}
+
+	s.lock.Lock()
+	changed := !equalNames(memberNames(s.members), memberNames(members))
+	s.members, s.observed, s.renewed = members, observed, renewed
+	if s.lost {
+		// all the nodes owned are gained, since the other replicas may have controlled them meanwhile
+		s.previous, s.ring = NewRing(nil, s.virtualNodes), NewRing(memberNames(members), s.virtualNodes)
+		changed, s.lost = true, false
+	} else if changed {
+		s.previous, s.ring = s.ring, NewRing(memberNames(members), s.virtualNodes)
+	}
+	handlers := s.handlers
+	s.lock.Unlock()
+
+	if changed {
+		klog.Infof("sharding membership changed, members %v", memberNames(members))
+		for _, handler := range handlers {
+			handler()
+		}
+	}
+	return nil
+}
+
+// renew creates or renews the Lease of the replica
+func (s *Sharding) renew(ctx context.Context) error {
+	member, err := json.Marshal(s.self)
+	if err != nil {
+		return fmt.Errorf("failed to marshal member: %v", err)
+	}
+	leases := s.client.CoordinationV1().Leases(constants.SystemNamespace)
+	renewTime := metav1.NewMicroTime(s.now())
+	duration := int32(s.leaseDuration / time.Second)
+
+	lease, err := leases.Get(ctx, leaseName(s.self.Name), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        leaseName(s.self.Name),
+				Namespace:   constants.SystemNamespace,
+				Labels:      map[string]string{LeaseLabelKey: "true"},
+				Annotations: map[string]string{MemberAnnotationKey: string(member)},
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &s.self.Name,
+				LeaseDurationSeconds: &duration,
+				AcquireTime:          &renewTime,
+				RenewTime:            &renewTime,
+			},
+		}
+		if _, err := leases.Create(ctx, lease, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create lease: %v", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get lease: %v", err)
+	}
+
+	if lease.Annotations == nil {
+		lease.Annotations = make(map[string]string)
+	}
+	lease.Annotations[MemberAnnotationKey] = string(member)
+	lease.Spec.HolderIdentity = &s.self.Name
+	lease.Spec.LeaseDurationSeconds = &duration
+	lease.Spec.RenewTime = &renewTime
+	if _, err := leases.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to renew lease: %v", err)
+	}
+	return nil
+}
+
+// deleteLease deletes the expired Lease, it fails if the Lease is renewed meanwhile
+func (s *Sharding) deleteLease(ctx context.Context, lease *coordinationv1.Lease) {
+	err := s.client.CoordinationV1().Leases(lease.Namespace).Delete(ctx, lease.Name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{ResourceVersion: &lease.ResourceVersion},
+	})
+	if err != nil && !apierrors.IsNotFound(err) && !apierrors.IsConflict(err) {
+		klog.Warningf("failed to delete expired lease %s: %v", lease.Name, err)
+	}
+}
+
+func leaseName(member string) string {
+	return leaseNamePrefix + member
+}
+
+func memberNames(members map[string]Member) []string {
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func equalNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/kubeedge/kubeedge/common/constants"
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/cloudcore/v1alpha1"
+)
+
+func TestShardingMembership(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	config := &v1alpha1.CloudHubSharding{Enable: true, LeaseDuration: 30, RenewInterval: 10, VirtualNodes: 100}
+	now := time.Now()
+	clock := func() time.Time { return now }
+
+	a := NewSharding(client, Member{Name: "cloudcore-a", Address: "10.0.0.1", WebSocketPort: 10000}, config)
+	b := NewSharding(client, Member{Name: "cloudcore-b", Address: "10.0.0.2", WebSocketPort: 10000}, config)
+	a.now, b.now = clock, clock
+	rebalanced := 0
+	a.OnRebalance(func() { rebalanced++ })
+
+	refresh := func(s *Sharding) {
+		t.Helper()
+		if err := s.refresh(context.TODO()); err != nil {
+			t.Fatalf("refresh() error = %v", err)
+		}
+	}
+
+	refresh(a)
+	if members := a.Members(); !reflect.DeepEqual(members, []string{"cloudcore-a"}) {
+		t.Fatalf("unexpected members %v", members)
+	}
+	if rebalanced != 0 {
+		t.Errorf("expected no rebalance, but got %d", rebalanced)
+	}
+
+	// the replicas joined own the nodes exclusively
+	refresh(b)
+	refresh(a)
+	if members := a.Members(); !reflect.DeepEqual(members, []string{"cloudcore-a", "cloudcore-b"}) {
+		t.Fatalf("unexpected members %v", members)
+	}
+	if rebalanced != 1 {
+		t.Errorf("expected rebalanced once, but got %d", rebalanced)
+	}
+	for i := 0; i < 100; i++ {
+		node := fmt.Sprintf("edge-%d", i)
+		if a.Owns(node) == b.Owns(node) {
+			t.Fatalf("expected %s owned by exactly one replica", node)
+		}
+		owner, ok := a.OwnerOf(node)
+		if ok == a.Owns(node) || ok && owner.Address != "10.0.0.2" {
+			t.Fatalf("unexpected owner %+v of %s", owner, node)
+		}
+	}
+
+	// the nodes of the replica joined are not gained by the others
+	ownedByB := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		node := fmt.Sprintf("edge-%d", i)
+		ownedByB[node] = b.Owns(node)
+		if a.Gained(node) {
+			t.Fatalf("expected %s not gained by cloudcore-a", node)
+		}
+	}
+
+	// the replica not renewing expires and its lease is deleted
+	now = now.Add(20 * time.Second)
+	refresh(a)
+	if len(a.Members()) != 2 {
+		t.Fatalf("expected cloudcore-b alive before the lease duration")
+	}
+	now = now.Add(20 * time.Second)
+	refresh(a)
+	if members := a.Members(); !reflect.DeepEqual(members, []string{"cloudcore-a"}) {
+		t.Fatalf("unexpected members %v", members)
+	}
+	if rebalanced != 2 {
+		t.Errorf("expected rebalanced twice, but got %d", rebalanced)
+	}
+	// the nodes of the replica expired are gained by the one left
+	for node, owned := range ownedByB {
+		if a.Gained(node) != owned {
+			t.Errorf("expected %s gained %v, but got %v", node, owned, a.Gained(node))
+		}
+	}
+	leases, err := client.CoordinationV1().Leases(constants.SystemNamespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(leases.Items) != 1 || leases.Items[0].Name != leaseName("cloudcore-a") {
+		t.Errorf("expected the lease of cloudcore-b deleted, but got %d leases", len(leases.Items))
+	}
+}
+
+func TestShardingLeaseExpired(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	failing := false
+	client.PrependReactor("*", "leases", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if failing {
+			return true, nil, fmt.Errorf("apiserver unavailable")
+		}
+		return false, nil, nil
+	})
+	config := &v1alpha1.CloudHubSharding{Enable: true, LeaseDuration: 30, RenewInterval: 10, VirtualNodes: 100}
+	now := time.Now()
+	s := NewSharding(client, Member{Name: "cloudcore-a", Address: "10.0.0.1", WebSocketPort: 10000}, config)
+	s.now = func() time.Time { return now }
+	rebalanced := 0
+	s.OnRebalance(func() { rebalanced++ })
+
+	if err := s.refresh(context.TODO()); err != nil {
+		t.Fatalf("refresh() error = %v", err)
+	}
+	if !s.Owns("edge-1") || s.Expired() {
+		t.Fatalf("expected edge-1 owned by the only replica")
+	}
+
+	// the nodes are still owned until the lease expires
+	failing = true
+	now = now.Add(20 * time.Second)
+	if err := s.refresh(context.TODO()); err == nil {
+		t.Fatalf("expected refresh failed")
+	}
+	s.checkExpired()
+	if !s.Owns("edge-1") || rebalanced != 0 {
+		t.Errorf("expected edge-1 owned before the lease expires")
+	}
+
+	// no nodes are owned once the lease expires, as the other replicas may take them over
+	now = now.Add(10 * time.Second)
+	if err := s.refresh(context.TODO()); err == nil {
+		t.Fatalf("expected refresh failed")
+	}
+	s.checkExpired()
+	s.checkExpired()
+	if s.Owns("edge-1") || !s.Expired() || s.Gained("edge-1") {
+		t.Errorf("expected edge-1 not owned after the lease expired")
+	}
+	if rebalanced != 1 {
+		t.Errorf("expected rebalanced once after the lease expired, but got %d", rebalanced)
+	}
+
+	// the nodes owned are gained again after the lease is renewed
+	failing = false
+	now = now.Add(10 * time.Second)
+	if err := s.refresh(context.TODO()); err != nil {
+		t.Fatalf("refresh() error = %v", err)
+	}
+	if !s.Owns("edge-1") || !s.Gained("edge-1") || s.Expired() {
+		t.Errorf("expected edge-1 owned and gained after the lease is renewed")
+	}
+	if rebalanced != 2 {
+		t.Errorf("expected rebalanced after the lease is renewed, but got %d", rebalanced)
+	}
+}
+
+func TestNilSharding(t *testing.T) {
+	var s *Sharding
+	if !s.Owns("edge-1") {
+		t.Errorf("expected all nodes owned if not sharding")
+	}
+	if _, ok := s.OwnerOf("edge-1"); ok {
+		t.Errorf("expected no other owner if not sharding")
+	}
+	if s.Gained("edge-1") {
+		t.Errorf("expected no node gained if not sharding")
+	}
+	if s.Expired() {
+		t.Errorf("expected no lease expired if not sharding")
+	}
+	s.OnRebalance(func() {})
+}
@@ -8,6 +8,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/serializer/json"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	"k8s.io/klog/v2"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -26,14 +27,25 @@ func init() {
 	utilruntime.Must(appsv1alpha1.AddToScheme(appsScheme))
 }
 
-func NewAppsControllerManager(ctx context.Context) (manager.Manager, error) {
+// LeaderElection is the leader election of the controller manager replicas on a Lease
+type LeaderElection struct {
+	Enable    bool
+	Namespace string
+	Name      string
+}
+
+func NewAppsControllerManager(ctx context.Context, leaderElection LeaderElection) (manager.Manager, error) {
 	kubeCfg, err := controllerruntime.GetConfig()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get kubeconfig, %v", err)
 	}
 	controllerManager, err := controllerruntime.NewManager(kubeCfg, controllerruntime.Options{
-		Scheme: appsScheme,
-		// TODO: leader election
+		Scheme:                        appsScheme,
+		LeaderElection:                leaderElection.Enable,
+		LeaderElectionNamespace:       leaderElection.Namespace,
+		LeaderElectionID:              leaderElection.Name,
+		LeaderElectionResourceLock:    resourcelock.LeasesResourceLock,
+		LeaderElectionReleaseOnCancel: true,
 		// TODO: /healthz
 
 	})
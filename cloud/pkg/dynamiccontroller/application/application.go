@@ -77,6 +77,8 @@ const (
 	Update       applicationVerb = "update"
 	UpdateStatus applicationVerb = "updatestatus"
 	Patch        applicationVerb = "patch"
+	// GetNonResource gets non-resource urls such as discovery, openapi and version, key of the application is the url path
+	GetNonResource applicationVerb = "getnonresource"
 )
 
 type PatchInfo struct {
@@ -319,6 +321,27 @@ func (a *Agent) Generate(ctx context.Context, verb applicationVerb, option inter
 	return app, nil
 }
 
+// GenerateNonResource generates application for the non-resource request in ctx,
+// accept is the Accept header of the request which decides the format of response
+func (a *Agent) GenerateNonResource(ctx context.Context, accept string) (*Application, error) {
+	info, ok := apirequest.RequestInfoFrom(ctx)
+	if !ok || info.IsResourceRequest {
+		klog.Errorf("no non-resource request info in context")
+		return nil, fmt.Errorf("no non-resource request info in context")
+	}
+	app, err := newApplication(ctx, info.Path, GetNonResource, a.nodeName, "", NonResourceOption{Accept: accept}, nil)
+	if err != nil {
+		return nil, err
+	}
+	store, ok := a.Applications.LoadOrStore(app.Identifier(), app)
+	if ok {
+		app = store.(*Application)
+		app.add()
+		return app, nil
+	}
+	return app, nil
+}
+
 func (a *Agent) Apply(app *Application) error {
 	store, ok := a.Applications.Load(app.Identifier())
 	if !ok {
@@ -392,6 +415,9 @@ type Center struct {
 	HandlerCenter
 	messageLayer messagelayer.MessageLayer
 	authConfig   *rest.Config
+	// rbacSyncer sends the rbac resources applying to the nodes, it is started when the first node is synced
+	rbacSyncer *rbacSyncer
+	rbacOnce   sync.Once
 }
 
 func NewApplicationCenter(dynamicSharedInformerFactory dynamicinformer.DynamicSharedInformerFactory) *Center {
@@ -528,6 +554,10 @@ func (c *Center) authorizeApplication(app *Application, gvr schema.GroupVersionR
 // push them to edge node.
 func (c *Center) ProcessApplication(app *Application) (interface{}, error) {
 	app.Status = InProcessing
+	c.syncRBAC(app.Nodename)
+	if app.Verb == GetNonResource {
+		return c.getNonResource(app)
+	}
 	gvr, ns, name := metaserver.ParseKey(app.Key)
 	var kubeClient dynamic.Interface
 	var err error
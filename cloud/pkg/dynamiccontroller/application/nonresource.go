@@ -0,0 +1,121 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+
+	"github.com/kubeedge/kubeedge/cloud/pkg/common/client"
+	kefeatures "github.com/kubeedge/kubeedge/pkg/features"
+)
+
+// nonResourcePrefixes are the non-resource urls the edge can get, together with their subpaths
+var nonResourcePrefixes = []string{"/version", "/healthz", "/readyz", "/livez", "/openapi"}
+
+// NonResourceOption is the option of GetNonResource application
+type NonResourceOption struct {
+	// Accept is the Accept header sent to kube-apiserver
+	Accept string
+}
+
+// NonResourceResponse is the response of GetNonResource application
+type NonResourceResponse struct {
+	ContentType string
+	Body        []byte
+}
+
+// createRESTClient creates a rest client for non-resource requests, the token of application is used
+// if requireAuthorization is enabled
+func (c *Center) createRESTClient(app *Application) (*rest.RESTClient, error) {
+	var config *rest.Config
+	if kefeatures.DefaultFeatureGate.Enabled(kefeatures.RequireAuthorization) {
+		authConfig, err := c.generateNewConfig(app.Token)
+		if err != nil {
+			return nil, err
+		}
+		config = authConfig
+	} else {
+		config = client.GetKubeConfig()
+	}
+	config.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
+	return rest.UnversionedRESTClientFor(config)
+}
+
+// getNonResource gets the non-resource url from kube-apiserver, the response is returned as it is
+// so that the content negotiated by client is kept
+func (c *Center) getNonResource(app *Application) (interface{}, error) {
+	if !isAllowedNonResource(app.Key) {
+		return nil, apierrors.NewForbidden(schema.GroupResource{}, app.Key,
+			fmt.Errorf("only version, health, openapi and discovery urls are allowed"))
+	}
+	var option = new(NonResourceOption)
+	if err := app.OptionTo(option); err != nil {
+		return nil, err
+	}
+	restClient, err := c.createRESTClient(app)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(context.TODO(), http.MethodGet, restClient.Get().AbsPath(app.Key).URL().String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if option.Accept != "" {
+		req.Header.Set("Accept", option.Accept)
+	}
+	resp, err := restClient.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response of %v: %v", app.Key, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nonResourceError(resp.StatusCode, body)
+	}
+	return &NonResourceResponse{ContentType: resp.Header.Get("Content-Type"), Body: body}, nil
+}
+
+// nonResourceError converts the failed response to an api error, so that it can be returned to client by edge
+func nonResourceError(code int, body []byte) error {
+	status := new(metav1.Status)
+	if err := json.Unmarshal(body, status); err == nil && status.Kind == "Status" {
+		return &apierrors.StatusError{ErrStatus: *status}
+	}
+	return apierrors.NewGenericServerResponse(code, "get", schema.GroupResource{}, "", string(body), 0, true)
+}
+
+// isAllowedNonResource reports whether the url can be got by edge. Besides the prefixes allowed, only the
+// discovery of /api and /apis is allowed, the urls of resources under them are rejected.
+func isAllowedNonResource(url string) bool {
+	if url == "" || path.Clean(url) != url {
+		return false
+	}
+	for _, prefix := range nonResourcePrefixes {
+		if url == prefix || strings.HasPrefix(url, prefix+"/") {
+			return true
+		}
+	}
+	segments := strings.Split(strings.TrimPrefix(url, "/"), "/")
+	switch segments[0] {
+	case "api":
+		// /api, /api/<version>
+		return len(segments) <= 2
+	case "apis":
+		// /apis, /apis/<group>, /apis/<group>/<version>
+		return len(segments) <= 3
+	}
+	return false
+}
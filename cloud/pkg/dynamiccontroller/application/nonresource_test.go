@@ -0,0 +1,31 @@
+package application
+
+import "testing"
+
+func TestIsAllowedNonResource(t *testing.T) {
+	cases := map[string]bool{
+		"/version":                    true,
+		"/healthz":                    true,
+		"/readyz/etcd":                true,
+		"/livez":                      true,
+		"/openapi/v2":                 true,
+		"/openapi/v3/apis/apps/v1":    true,
+		"/api":                        true,
+		"/api/v1":                     true,
+		"/apis":                       true,
+		"/apis/apps":                  true,
+		"/apis/apps/v1":               true,
+		"/api/v1/secrets":             false,
+		"/api/v1/namespaces/ns1/pods": false,
+		"/apis/apps/v1/deployments":   false,
+		"/versions":                   false,
+		"/metrics":                    false,
+		"/healthz/../api/v1/secrets":  false,
+		"":                            false,
+	}
+	for url, want := range cases {
+		if got := isAllowedNonResource(url); got != want {
+			t.Errorf("isAllowedNonResource(%q) = %v, want %v", url, got, want)
+		}
+	}
+}
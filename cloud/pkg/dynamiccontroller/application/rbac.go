@@ -0,0 +1,326 @@
+package application
+
+import (
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
+	"github.com/kubeedge/kubeedge/cloud/pkg/common/messagelayer"
+	kefeatures "github.com/kubeedge/kubeedge/pkg/features"
+)
+
+var (
+	podGVR                = corev1.SchemeGroupVersion.WithResource("pods")
+	nodeGVR               = corev1.SchemeGroupVersion.WithResource("nodes")
+	clusterRoleBindingGVR = rbacv1.SchemeGroupVersion.WithResource("clusterrolebindings")
+	roleBindingGVR        = rbacv1.SchemeGroupVersion.WithResource("rolebindings")
+	clusterRoleGVR        = rbacv1.SchemeGroupVersion.WithResource("clusterroles")
+	roleGVR               = rbacv1.SchemeGroupVersion.WithResource("roles")
+)
+
+// rbacResources are the resources that metaserver needs to authorize requests locally
+var rbacResources = []schema.GroupVersionResource{clusterRoleBindingGVR, roleBindingGVR, clusterRoleGVR, roleGVR}
+
+// syncRBAC starts to send the rbac resources to the edge node, so that metaserver can
+// authorize requests with the rbac rules when node is offline.
+func (c *Center) syncRBAC(nodeName string) {
+	if !kefeatures.DefaultFeatureGate.Enabled(kefeatures.RequireAuthorization) || nodeName == "" {
+		return
+	}
+	c.rbacOnce.Do(func() {
+		c.rbacSyncer = newRBACSyncer(c.HandlerCenter, c.messageLayer)
+		go c.rbacSyncer.run()
+	})
+	c.rbacSyncer.track(nodeName)
+}
+
+// rbacKey is the key of a rbac object sent to the edge node
+type rbacKey struct {
+	gvr       schema.GroupVersionResource
+	namespace string
+	name      string
+}
+
+// rbacSyncer sends each edge node only the rbac bindings whose subjects match the service accounts
+// of the pods on the node, and the roles referenced by them. The objects are synced again when the
+// pods on the node or the rbac resources change, and the ones not applying anymore are deleted.
+type rbacSyncer struct {
+	messageLayer messagelayer.MessageLayer
+	listers      map[schema.GroupVersionResource]cache.GenericLister
+	queue        workqueue.Interface
+
+	lock sync.Mutex
+	// sent is the rbac objects sent to the nodes tracked, by node name and object key
+	sent map[string]map[rbacKey]*unstructured.Unstructured
+}
+
+// newRBACSyncer prepares the informers of the pods, nodes and rbac resources, it must be
+// called in the goroutine processing the applications, since HandlerCenter isn't thread-safe
+func newRBACSyncer(handlerCenter HandlerCenter, messageLayer messagelayer.MessageLayer) *rbacSyncer {
+	s := &rbacSyncer{
+		messageLayer: messageLayer,
+		listers:      make(map[schema.GroupVersionResource]cache.GenericLister),
+		queue:        workqueue.New(),
+		sent:         make(map[string]map[rbacKey]*unstructured.Unstructured),
+	}
+	for _, gvr := range rbacResources {
+		informer := handlerCenter.ForResource(gvr).informer
+		s.listers[gvr] = informer.Lister()
+		informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(interface{}) { s.enqueueAll() },
+			UpdateFunc: func(interface{}, interface{}) { s.enqueueAll() },
+			DeleteFunc: func(interface{}) { s.enqueueAll() },
+		})
+	}
+
+	podInformer := handlerCenter.ForResource(podGVR).informer
+	s.listers[podGVR] = podInformer.Lister()
+	podInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: s.enqueuePodNode,
+		UpdateFunc: func(oldObj, obj interface{}) {
+			s.enqueuePodNode(oldObj)
+			s.enqueuePodNode(obj)
+		},
+		DeleteFunc: s.enqueuePodNode,
+	})
+
+	handlerCenter.ForResource(nodeGVR).informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			if accessor, ok := deletedObject(obj); ok {
+				s.forget(accessor.GetName())
+			}
+		},
+	})
+	return s
+}
+
+// track starts to sync the rbac objects to the node if it isn't synced yet
+func (s *rbacSyncer) track(nodeName string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.sent[nodeName]; ok {
+		return
+	}
+	s.sent[nodeName] = make(map[rbacKey]*unstructured.Unstructured)
+	s.queue.Add(nodeName)
+	klog.Infof("[metaserver/applicationCenter]start to sync rbac resources to node %v", nodeName)
+}
+
+// forget stops syncing the rbac objects to the node deleted
+func (s *rbacSyncer) forget(nodeName string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.sent[nodeName]; ok {
+		delete(s.sent, nodeName)
+		klog.Infof("[metaserver/applicationCenter]node %v is deleted, stop syncing rbac resources to it", nodeName)
+	}
+}
+
+func (s *rbacSyncer) enqueueAll() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for nodeName := range s.sent {
+		s.queue.Add(nodeName)
+	}
+}
+
+func (s *rbacSyncer) enqueuePodNode(obj interface{}) {
+	pod, ok := deletedObject(obj)
+	if !ok {
+		return
+	}
+	nodeName, _, _ := unstructured.NestedString(pod.UnstructuredContent(), "spec", "nodeName")
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.sent[nodeName]; ok {
+		s.queue.Add(nodeName)
+	}
+}
+
+func (s *rbacSyncer) run() {
+	go func() {
+		<-beehiveContext.Done()
+		s.queue.ShutDown()
+	}()
+	for {
+		item, quit := s.queue.Get()
+		if quit {
+			return
+		}
+		s.sync(item.(string))
+		s.queue.Done(item)
+	}
+}
+
+// sync sends the rbac objects applying to the node which are new or changed, and deletes the ones sent before
+// but not applying anymore
+func (s *rbacSyncer) sync(nodeName string) {
+	s.lock.Lock()
+	sent, ok := s.sent[nodeName]
+	s.lock.Unlock()
+	if !ok {
+		return
+	}
+
+	pods, err := s.listers[podGVR].List(labels.Everything())
+	if err != nil {
+		klog.Errorf("[metaserver/applicationCenter]failed to list pods: %v", err)
+		return
+	}
+	objects := make(map[schema.GroupVersionResource][]runtime.Object)
+	for _, gvr := range rbacResources {
+		if objects[gvr], err = s.listers[gvr].List(labels.Everything()); err != nil {
+			klog.Errorf("[metaserver/applicationCenter]failed to list %v: %v", gvr, err)
+			return
+		}
+	}
+	desired := rbacObjectsFor(serviceAccountsOnNode(nodeName, pods), objects)
+
+	synced := make(map[rbacKey]*unstructured.Unstructured, len(desired))
+	for key, obj := range desired {
+		synced[key] = obj
+		old, ok := sent[key]
+		switch {
+		case !ok:
+			s.send(nodeName, key, watch.Added, obj)
+		case old.GetResourceVersion() != obj.GetResourceVersion():
+			s.send(nodeName, key, watch.Modified, obj)
+		}
+	}
+	for key, old := range sent {
+		if _, ok := desired[key]; !ok {
+			s.send(nodeName, key, watch.Deleted, old)
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	// the node may be deleted while syncing
+	if _, ok := s.sent[nodeName]; ok {
+		s.sent[nodeName] = synced
+	}
+}
+
+func (s *rbacSyncer) send(nodeName string, key rbacKey, eventType watch.EventType, obj *unstructured.Unstructured) {
+	NewSelectorListener(nodeName, key.gvr, NewSelector("", "")).sendObj(watch.Event{Type: eventType, Object: obj}, s.messageLayer)
+}
+
+// serviceAccountsOnNode returns the service accounts of the pods on the node, in format namespace/name
+func serviceAccountsOnNode(nodeName string, pods []runtime.Object) sets.String {
+	serviceAccounts := sets.NewString()
+	for _, obj := range pods {
+		pod, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		if podNode, _, _ := unstructured.NestedString(pod.Object, "spec", "nodeName"); podNode != nodeName {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(pod.Object, "spec", "serviceAccountName")
+		if name == "" {
+			name = "default"
+		}
+		serviceAccounts.Insert(pod.GetNamespace() + "/" + name)
+	}
+	return serviceAccounts
+}
+
+// rbacObjectsFor returns the rbac bindings applying to the service accounts and the roles referenced by them
+func rbacObjectsFor(serviceAccounts sets.String, objects map[schema.GroupVersionResource][]runtime.Object) map[rbacKey]*unstructured.Unstructured {
+	index := make(map[rbacKey]*unstructured.Unstructured)
+	for gvr, list := range objects {
+		for _, obj := range list {
+			if u, ok := obj.(*unstructured.Unstructured); ok {
+				index[rbacKey{gvr: gvr, namespace: u.GetNamespace(), name: u.GetName()}] = u
+			}
+		}
+	}
+
+	desired := make(map[rbacKey]*unstructured.Unstructured)
+	addRole := func(roleRef rbacv1.RoleRef, bindingNamespace string) {
+		key := rbacKey{gvr: clusterRoleGVR, name: roleRef.Name}
+		if roleRef.Kind == "Role" {
+			key = rbacKey{gvr: roleGVR, namespace: bindingNamespace, name: roleRef.Name}
+		}
+		if role, ok := index[key]; ok {
+			desired[key] = role
+		}
+	}
+	for _, gvr := range []schema.GroupVersionResource{clusterRoleBindingGVR, roleBindingGVR} {
+		for _, obj := range objects[gvr] {
+			u, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+			var binding rbacv1.RoleBinding
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &binding); err != nil {
+				klog.Errorf("[metaserver/applicationCenter]failed to convert %v %v: %v", gvr, u.GetName(), err)
+				continue
+			}
+			if !appliesToServiceAccounts(binding.Subjects, binding.Namespace, serviceAccounts) {
+				continue
+			}
+			desired[rbacKey{gvr: gvr, namespace: u.GetNamespace(), name: u.GetName()}] = u
+			addRole(binding.RoleRef, binding.Namespace)
+		}
+	}
+	return desired
+}
+
+// appliesToServiceAccounts returns whether any of the subjects applies to one of the service accounts,
+// the same way as the rbac authorizer matches the users of the service accounts
+func appliesToServiceAccounts(subjects []rbacv1.Subject, bindingNamespace string, serviceAccounts sets.String) bool {
+	for _, subject := range subjects {
+		switch subject.Kind {
+		case rbacv1.ServiceAccountKind:
+			namespace := subject.Namespace
+			if namespace == "" {
+				namespace = bindingNamespace
+			}
+			if serviceAccounts.Has(namespace + "/" + subject.Name) {
+				return true
+			}
+		case rbacv1.UserKind:
+			namespace, name, err := serviceaccount.SplitUsername(subject.Name)
+			if err == nil && serviceAccounts.Has(namespace+"/"+name) {
+				return true
+			}
+		case rbacv1.GroupKind:
+			if len(serviceAccounts) == 0 {
+				continue
+			}
+			if subject.Name == serviceaccount.AllServiceAccountsGroup || subject.Name == user.AllAuthenticated {
+				return true
+			}
+			for _, sa := range serviceAccounts.UnsortedList() {
+				namespace, _, _ := cache.SplitMetaNamespaceKey(sa)
+				if subject.Name == serviceaccount.MakeNamespaceGroupName(namespace) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// deletedObject returns the object of the event, which may be a tombstone if it is deleted
+func deletedObject(obj interface{}) (*unstructured.Unstructured, bool) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	return u, ok
+}
@@ -0,0 +1,108 @@
+package application
+
+import (
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func toUnstructured(t *testing.T, obj interface{}) runtime.Object {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		t.Fatalf("failed to convert %v: %v", obj, err)
+	}
+	return &unstructured.Unstructured{Object: content}
+}
+
+func TestServiceAccountsOnNode(t *testing.T) {
+	pod := func(namespace, nodeName, serviceAccount string) runtime.Object {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"namespace": namespace, "name": "pod"},
+			"spec":     map[string]interface{}{"nodeName": nodeName, "serviceAccountName": serviceAccount},
+		}}
+	}
+	pods := []runtime.Object{
+		pod("ns1", "edge-1", "sa1"),
+		pod("ns2", "edge-1", ""),
+		pod("ns3", "edge-2", "sa3"),
+	}
+	got := serviceAccountsOnNode("edge-1", pods)
+	if want := sets.NewString("ns1/sa1", "ns2/default"); !got.Equal(want) {
+		t.Errorf("expected %v, but got %v", want.List(), got.List())
+	}
+}
+
+func TestRBACObjectsFor(t *testing.T) {
+	roleBinding := func(name string, roleRef rbacv1.RoleRef, subjects ...rbacv1.Subject) *rbacv1.RoleBinding {
+		return &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: name}, RoleRef: roleRef, Subjects: subjects}
+	}
+	clusterRoleBinding := func(name string, roleRef rbacv1.RoleRef, subjects ...rbacv1.Subject) *rbacv1.ClusterRoleBinding {
+		return &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: name}, RoleRef: roleRef, Subjects: subjects}
+	}
+	roleRef := func(kind, name string) rbacv1.RoleRef {
+		return rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: kind, Name: name}
+	}
+
+	objects := map[schema.GroupVersionResource][]runtime.Object{
+		roleBindingGVR: {
+			// service account in the namespace of the binding
+			toUnstructured(t, roleBinding("rb-sa", roleRef("Role", "role-1"),
+				rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "sa1"})),
+			// user of the service account
+			toUnstructured(t, roleBinding("rb-user", roleRef("ClusterRole", "cr-1"),
+				rbacv1.Subject{Kind: rbacv1.UserKind, Name: "system:serviceaccount:ns1:sa1"})),
+			// group of the service accounts in the namespace
+			toUnstructured(t, roleBinding("rb-group", roleRef("Role", "role-2"),
+				rbacv1.Subject{Kind: rbacv1.GroupKind, Name: "system:serviceaccounts:ns1"})),
+			// service account of another node
+			toUnstructured(t, roleBinding("rb-other", roleRef("Role", "role-3"),
+				rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "sa2"})),
+		},
+		clusterRoleBindingGVR: {
+			toUnstructured(t, clusterRoleBinding("crb-authenticated", roleRef("ClusterRole", "cr-2"),
+				rbacv1.Subject{Kind: rbacv1.GroupKind, Name: "system:authenticated"})),
+			toUnstructured(t, clusterRoleBinding("crb-user", roleRef("ClusterRole", "cr-3"),
+				rbacv1.Subject{Kind: rbacv1.UserKind, Name: "admin"})),
+		},
+		roleGVR: {
+			toUnstructured(t, &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "role-1"}}),
+			toUnstructured(t, &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "role-2"}}),
+			toUnstructured(t, &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "role-3"}}),
+			toUnstructured(t, &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Namespace: "ns2", Name: "role-1"}}),
+		},
+		clusterRoleGVR: {
+			toUnstructured(t, &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "cr-1"}}),
+			toUnstructured(t, &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "cr-2"}}),
+			toUnstructured(t, &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "cr-3"}}),
+		},
+	}
+
+	got := rbacObjectsFor(sets.NewString("ns1/sa1"), objects)
+	want := map[rbacKey]bool{
+		{gvr: roleBindingGVR, namespace: "ns1", name: "rb-sa"}:    true,
+		{gvr: roleBindingGVR, namespace: "ns1", name: "rb-user"}:  true,
+		{gvr: roleBindingGVR, namespace: "ns1", name: "rb-group"}: true,
+		{gvr: clusterRoleBindingGVR, name: "crb-authenticated"}:   true,
+		{gvr: roleGVR, namespace: "ns1", name: "role-1"}:          true,
+		{gvr: roleGVR, namespace: "ns1", name: "role-2"}:          true,
+		{gvr: clusterRoleGVR, name: "cr-1"}:                       true,
+		{gvr: clusterRoleGVR, name: "cr-2"}:                       true,
+	}
+	if len(got) != len(want) {
+		t.Errorf("expected %d objects, but got %d: %v", len(want), len(got), got)
+	}
+	for key := range want {
+		if _, ok := got[key]; !ok {
+			t.Errorf("expected %v to be sent", key)
+		}
+	}
+
+	if got := rbacObjectsFor(sets.NewString(), objects); len(got) != 0 {
+		t.Errorf("expected no objects sent to the node without pods, but got %v", got)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/kubeedge/kubeedge/cloud/pkg/common/informers"
 	"github.com/kubeedge/kubeedge/cloud/pkg/common/messagelayer"
 	"github.com/kubeedge/kubeedge/cloud/pkg/common/modules"
+	"github.com/kubeedge/kubeedge/cloud/pkg/common/sharding"
 	"github.com/kubeedge/kubeedge/cloud/pkg/edgecontroller/constants"
 	"github.com/kubeedge/kubeedge/cloud/pkg/edgecontroller/manager"
 	commonconstants "github.com/kubeedge/kubeedge/common/constants"
@@ -47,6 +48,10 @@ type DownstreamController struct {
 	lc *manager.LocationCache
 
 	podLister clientgov1.PodLister
+
+	configMapLister clientgov1.ConfigMapLister
+
+	secretLister clientgov1.SecretLister
 }
 
 func (dc *DownstreamController) syncPod() {
@@ -350,9 +355,66 @@ func (dc *DownstreamController) Start() error {
 	// ruleendpoint
 	go dc.syncRuleEndpoint()
 
+	// the nodes moved to this cloudcore get the objects again, which the former owner may have skipped
+	sharding.OnRebalance(func() {
+		dc.resyncNodes(sharding.Gained)
+	})
+
 	return nil
 }
 
+// resyncNodes sends the pods on the edge nodes selected and the configmaps and secrets used by them,
+// the objects the nodes have already are deduplicated by their resource versions
+func (dc *DownstreamController) resyncNodes(selected func(nodeName string) bool) {
+	pods, err := dc.podLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("failed to list pods to resync nodes: %v", err)
+		return
+	}
+	for _, pod := range pods {
+		if !dc.lc.IsEdgeNode(pod.Spec.NodeName) || !selected(pod.Spec.NodeName) {
+			continue
+		}
+		dc.resend(pod.Spec.NodeName, model.ResourceTypePod, pod.Namespace, pod.Name, pod.ResourceVersion, pod)
+
+		configMaps, secrets := dc.lc.PodConfigMapsAndSecrets(*pod)
+		for _, name := range configMaps {
+			configMap, err := dc.configMapLister.ConfigMaps(pod.Namespace).Get(name)
+			if err != nil {
+				klog.Warningf("failed to get configmap %s/%s to resync node %s: %v", pod.Namespace, name, pod.Spec.NodeName, err)
+				continue
+			}
+			dc.resend(pod.Spec.NodeName, model.ResourceTypeConfigmap, configMap.Namespace, configMap.Name, configMap.ResourceVersion, configMap)
+		}
+		for _, name := range secrets {
+			secret, err := dc.secretLister.Secrets(pod.Namespace).Get(name)
+			if err != nil {
+				klog.Warningf("failed to get secret %s/%s to resync node %s: %v", pod.Namespace, name, pod.Spec.NodeName, err)
+				continue
+			}
+			dc.resend(pod.Spec.NodeName, model.ResourceTypeSecret, secret.Namespace, secret.Name, secret.ResourceVersion, secret)
+		}
+	}
+}
+
+// resend sends the object to the edge node as an update
+func (dc *DownstreamController) resend(nodeName, resourceType, namespace, name, resourceVersion string, obj interface{}) {
+	resource, err := messagelayer.BuildResource(nodeName, namespace, resourceType, name)
+	if err != nil {
+		klog.Warningf("build message resource failed with error: %s", err)
+		return
+	}
+	msg := model.NewMessage("").
+		SetResourceVersion(resourceVersion).
+		BuildRouter(modules.EdgeControllerModuleName, constants.GroupResource, resource, model.UpdateOperation).
+		FillBody(obj)
+	if err := dc.messageLayer.Send(*msg); err != nil {
+		klog.Warningf("send message failed with error: %s, operation: %s, resource: %s", err, msg.GetOperation(), msg.GetResource())
+	} else {
+		klog.V(4).Infof("send message successfully, operation: %s, resource: %s", msg.GetOperation(), msg.GetResource())
+	}
+}
+
 // initLocating to know configmap and secret should send to which nodes
 func (dc *DownstreamController) initLocating() error {
 	set := labels.Set{commonconstants.EdgeNodeRoleKey: commonconstants.EdgeNodeRoleValue}
@@ -433,6 +495,8 @@ func NewDownstreamController(config *v1alpha1.EdgeController, k8sInformerFactory
 		messageLayer:         messagelayer.EdgeControllerMessageLayer(),
 		lc:                   lc,
 		podLister:            podInformer.Lister(),
+		configMapLister:      configMapInformer.Lister(),
+		secretLister:         secretInformer.Lister(),
 		rulesManager:         rulesManager,
 		ruleEndpointsManager: ruleEndpointsManager,
 	}
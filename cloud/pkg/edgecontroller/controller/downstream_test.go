@@ -0,0 +1,105 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgov1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/kubeedge/cloud/pkg/edgecontroller/manager"
+)
+
+// fakeMessageLayer records the messages sent
+type fakeMessageLayer struct {
+	sent []model.Message
+}
+
+func (f *fakeMessageLayer) Send(message model.Message) error {
+	f.sent = append(f.sent, message)
+	return nil
+}
+
+func (f *fakeMessageLayer) Receive() (model.Message, error) {
+	return model.Message{}, nil
+}
+
+func (f *fakeMessageLayer) Response(message model.Message) error {
+	return nil
+}
+
+func TestResyncNodes(t *testing.T) {
+	newIndexer := func(objs ...interface{}) cache.Indexer {
+		indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+		for _, obj := range objs {
+			if err := indexer.Add(obj); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+		}
+		return indexer
+	}
+	newPod := func(name, nodeName string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, ResourceVersion: "1"},
+			Spec: v1.PodSpec{
+				NodeName: nodeName,
+				Volumes: []v1.Volume{
+					{VolumeSource: v1.VolumeSource{ConfigMap: &v1.ConfigMapVolumeSource{LocalObjectReference: v1.LocalObjectReference{Name: "cm"}}}},
+					{VolumeSource: v1.VolumeSource{Secret: &v1.SecretVolumeSource{SecretName: "secret"}}},
+				},
+			},
+		}
+	}
+
+	lc := &manager.LocationCache{}
+	lc.UpdateEdgeNode("edge-1")
+	lc.UpdateEdgeNode("edge-2")
+	messageLayer := &fakeMessageLayer{}
+	dc := &DownstreamController{
+		messageLayer: messageLayer,
+		lc:           lc,
+		podLister: clientgov1.NewPodLister(newIndexer(
+			newPod("gained", "edge-1"), newPod("kept", "edge-2"), newPod("cloud", "cloud-1"))),
+		configMapLister: clientgov1.NewConfigMapLister(newIndexer(
+			&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cm", ResourceVersion: "2"}})),
+		secretLister: clientgov1.NewSecretLister(newIndexer()),
+	}
+
+	dc.resyncNodes(func(nodeName string) bool {
+		return nodeName == "edge-1" || nodeName == "cloud-1"
+	})
+
+	var resources []string
+	for _, msg := range messageLayer.sent {
+		if msg.GetOperation() != model.UpdateOperation {
+			t.Errorf("expected update message, but got %s", msg.GetOperation())
+		}
+		resources = append(resources, msg.GetResource())
+	}
+	sort.Strings(resources)
+	// the secret missing is skipped, and the objects of the nodes not gained are not sent
+	expected := []string{"node/edge-1/default/configmap/cm", "node/edge-1/default/pod/gained"}
+	if !reflect.DeepEqual(resources, expected) {
+		t.Errorf("expected %v sent, but got %v", expected, resources)
+	}
+}
@@ -62,6 +62,7 @@ import (
 	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/cloudcore/v1alpha1"
 	rulesv1 "github.com/kubeedge/kubeedge/pkg/apis/rules/v1"
 	crdClientset "github.com/kubeedge/kubeedge/pkg/client/clientset/versioned"
+	kefeatures "github.com/kubeedge/kubeedge/pkg/features"
 	"github.com/kubeedge/kubeedge/pkg/metaserver/util"
 )
 
@@ -582,6 +583,8 @@ func (uc *UpstreamController) updateNodeStatus() {
 					nodeStatusRequest.Status.DaemonEndpoints.KubeletEndpoint.Port = getNode.Status.DaemonEndpoints.KubeletEndpoint.Port
 				}
 
+				// the conditions edged doesn't report, like the endpoint reported by edgehub, are kept
+				nodeStatusRequest.Status.Conditions = mergeNodeConditions(nodeStatusRequest.Status.Conditions, getNode.Status.Conditions)
 				getNode.Status = nodeStatusRequest.Status
 
 				node, err := uc.kubeClient.CoreV1().Nodes().UpdateStatus(context.Background(), getNode, metaV1.UpdateOptions{})
@@ -669,24 +672,34 @@ func queryInner(uc *UpstreamController, msg model.Message, queryType string) {
 
 	switch msg.GetOperation() {
 	case model.QueryOperation:
-		object, err := kubeClientGet(uc, namespace, name, queryType, msg)
-		if errors.IsNotFound(err) {
-			klog.Warningf("message: %s process failure, resource not found, namespace: %s, name: %s", msg.GetID(), namespace, name)
+		nodeID, err := messagelayer.GetNodeID(msg)
+		if err != nil {
+			klog.Warningf("message: %s process failure, get node id failed with error: %s", msg.GetID(), err)
 			return
 		}
+		resource, err := messagelayer.BuildResource(nodeID, namespace, queryType, name)
 		if err != nil {
-			klog.Warningf("message: %s process failure with error: %s, namespace: %s, name: %s", msg.GetID(), err, namespace, name)
+			klog.Warningf("message: %s process failure, build message resource failed with error: %s", msg.GetID(), err)
 			return
 		}
 
-		nodeID, err := messagelayer.GetNodeID(msg)
-		if err != nil {
-			klog.Warningf("message: %s process failure, get node id failed with error: %s", msg.GetID(), err)
+		object, err := kubeClientGet(uc, namespace, name, queryType, msg)
+		if errors.IsNotFound(err) {
+			klog.Warningf("message: %s process failure, resource not found, namespace: %s, name: %s", msg.GetID(), namespace, name)
+			if !kefeatures.DefaultFeatureGate.Enabled(kefeatures.RemoteQueryNotFound) {
+				return
+			}
+			// answer not found so that edge does not wait for the query to time out, and can cache the answer
+			errMsg := model.NewMessage(msg.GetID()).
+				FillBody(fmt.Sprintf("%s: %v", metaV1.StatusReasonNotFound, err)).
+				BuildRouter(modules.EdgeControllerModuleName, constants.GroupResource, resource, model.ResponseErrorOperation)
+			if err := uc.messageLayer.Response(*errMsg); err != nil {
+				klog.Warningf("message: %s process failure, response failed with error: %s", msg.GetID(), err)
+			}
 			return
 		}
-		resource, err := messagelayer.BuildResource(nodeID, namespace, queryType, name)
 		if err != nil {
-			klog.Warningf("message: %s process failure, build message resource failed with error: %s", msg.GetID(), err)
+			klog.Warningf("message: %s process failure with error: %s, namespace: %s, name: %s", msg.GetID(), err, namespace, name)
 			return
 		}
 
@@ -1271,6 +1284,20 @@ func (uc *UpstreamController) unmarshalPodStatusMessage(msg model.Message) (ns s
 
 // GetPodCondition extracts the provided condition from the given status and returns that.
 // Returns nil if the condition is not present, or return the located condition.
+// mergeNodeConditions returns the conditions reported with the existing conditions of the other types
+func mergeNodeConditions(reported, existing []v1.NodeCondition) []v1.NodeCondition {
+	reportedTypes := make(map[v1.NodeConditionType]bool, len(reported))
+	for _, condition := range reported {
+		reportedTypes[condition.Type] = true
+	}
+	for _, condition := range existing {
+		if !reportedTypes[condition.Type] {
+			reported = append(reported, condition)
+		}
+	}
+	return reported
+}
+
 func (uc *UpstreamController) getPodCondition(status *v1.PodStatus, conditionType v1.PodConditionType) *v1.PodCondition {
 	if status == nil {
 		return nil
@@ -0,0 +1,41 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestMergeNodeConditions(t *testing.T) {
+	reported := []v1.NodeCondition{
+		{Type: v1.NodeReady, Status: v1.ConditionTrue},
+	}
+	existing := []v1.NodeCondition{
+		{Type: v1.NodeReady, Status: v1.ConditionFalse},
+		{Type: "EdgeHubEndpoint", Status: v1.ConditionTrue, Message: "connected to cloudcore endpoint wss://10.0.0.1:10000"},
+	}
+	expected := []v1.NodeCondition{
+		{Type: v1.NodeReady, Status: v1.ConditionTrue},
+		{Type: "EdgeHubEndpoint", Status: v1.ConditionTrue, Message: "connected to cloudcore endpoint wss://10.0.0.1:10000"},
+	}
+	if merged := mergeNodeConditions(reported, existing); !reflect.DeepEqual(merged, expected) {
+		t.Errorf("expected conditions %+v, but got %+v", expected, merged)
+	}
+}
@@ -145,6 +145,9 @@ func (dc *DownstreamController) nodeUpgradeJobAdded(upgrade *v1alpha1.NodeUpgrad
 	// deduplicate: remove duplicate nodes to avoid repeating upgrade to the same node
 	nodesToUpgrade = RemoveDuplicateElement(nodesToUpgrade)
 
+	// the nodes owned by the other cloudcore replicas are upgraded by them
+	nodesToUpgrade = FilterOwnedNodes(nodesToUpgrade)
+
 	klog.Infof("Filtered finished, the below nodes are to upgrade\n%v\n", nodesToUpgrade)
 
 	// if users specify Image, we'll use upgrade Version as its image tag, even though Image contains tag.
@@ -23,6 +23,7 @@ import (
 	"github.com/distribution/distribution/v3/reference"
 	metav1 "k8s.io/api/core/v1"
 
+	"github.com/kubeedge/kubeedge/cloud/pkg/common/sharding"
 	"github.com/kubeedge/kubeedge/common/constants"
 	"github.com/kubeedge/kubeedge/pkg/apis/operations/v1alpha1"
 )
@@ -101,6 +102,17 @@ func RemoveDuplicateElement(s []string) []string {
 	return result
 }
 
+// FilterOwnedNodes returns the nodes owned by this cloudcore
+func FilterOwnedNodes(nodes []string) []string {
+	result := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		if sharding.Owns(node) {
+			result = append(result, node)
+		}
+	}
+	return result
+}
+
 // UpdateNodeUpgradeJobStatus updates the status
 // return the updated result
 func UpdateNodeUpgradeJobStatus(old *v1alpha1.NodeUpgradeJob, status *v1alpha1.UpgradeStatus) *v1alpha1.NodeUpgradeJob {
@@ -52,7 +52,10 @@ func (mh *MessageHandler) getHandler(source string, resource string) (Handle, er
 	return handle, nil
 }
 
-func Process(module string) {
+// Process dispatches the messages sent to module until beehive is done. If leading is not nil, the
+// messages are only dispatched while leading returns true, except the ones updating the rules, so the
+// replica not leading keeps the rules up to date and drops the others without blocking the senders.
+func Process(module string, leading func() bool) {
 	for {
 		select {
 		case <-beehiveContext.Done():
@@ -65,6 +68,10 @@ func Process(module string) {
 			klog.Errorf("get a message, header:%+v router:%+v, err: %v", msg.Header, msg.Router, err)
 			continue
 		}
+		if leading != nil && !leading() && !isRuleMessage(&msg) {
+			klog.Warningf("drop message %s of resource %s, not the leader of module %s", msg.GetID(), msg.GetResource(), module)
+			continue
+		}
 		klog.Infof("get a message, header:%+v router:%+v", msg.Header, msg.Router)
 		err = MessageHandlerInstance.HandleMessage(&msg)
 		if err != nil {
@@ -73,6 +80,12 @@ func Process(module string) {
 	}
 }
 
+// isRuleMessage reports whether the message adds or deletes a rule or rule endpoint
+func isRuleMessage(message *model.Message) bool {
+	rs := strings.Split(message.GetResource(), "/")
+	return len(rs) >= 2 && (rs[0] == model.ResourceTypeRuleEndpoint || rs[0] == model.ResourceTypeRule)
+}
+
 func (mh *MessageHandler) HandleMessage(message *model.Message) error {
 	if message == nil {
 		return fmt.Errorf("nil message error")
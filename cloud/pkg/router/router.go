@@ -1,9 +1,13 @@
 package router
 
 import (
+	"context"
+	"sync"
+
 	"k8s.io/klog/v2"
 
 	"github.com/kubeedge/beehive/pkg/core"
+	"github.com/kubeedge/kubeedge/cloud/pkg/common/leaderelection"
 	"github.com/kubeedge/kubeedge/cloud/pkg/common/modules"
 	routerconfig "github.com/kubeedge/kubeedge/cloud/pkg/router/config"
 	"github.com/kubeedge/kubeedge/cloud/pkg/router/listener"
@@ -24,9 +28,18 @@ import (
 
 type router struct {
 	enable bool
+	// processOnce starts dispatching the messages once for the elected router
+	processOnce sync.Once
+	lock        sync.Mutex
+	// leadingCtx is the context of the leadership of the elected router, it is done once the leadership is lost
+	leadingCtx context.Context
 }
 
-var _ core.Module = (*router)(nil)
+var (
+	_ core.Module             = (*router)(nil)
+	_ leaderelection.Module   = (*router)(nil)
+	_ leaderelection.Follower = (*router)(nil)
+)
 
 func newRouter(enable bool) *router {
 	return &router{
@@ -53,5 +66,33 @@ func (r *router) Enable() bool {
 
 func (r *router) Start() {
 	klog.Info("In router module, start...")
-	listener.Process(r.Name())
+	listener.Process(r.Name(), nil)
+}
+
+// StartWithContext forwards the messages by the rules until the context is done, then the replica
+// only keeps the rules up to date until it becomes the leader of router again
+func (r *router) StartWithContext(ctx context.Context) {
+	klog.Info("In router module, start leading...")
+	r.lock.Lock()
+	r.leadingCtx = ctx
+	r.lock.Unlock()
+	r.process()
+}
+
+// Follow keeps the rules up to date while the replica is not the leader of router,
+// the messages to forward are dropped, so that the senders are not blocked
+func (r *router) Follow(context.Context) {
+	r.process()
+}
+
+func (r *router) process() {
+	r.processOnce.Do(func() {
+		go listener.Process(r.Name(), r.isLeading)
+	})
+}
+
+func (r *router) isLeading() bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.leadingCtx != nil && r.leadingCtx.Err() == nil
 }
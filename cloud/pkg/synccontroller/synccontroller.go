@@ -19,6 +19,7 @@ import (
 	keclient "github.com/kubeedge/kubeedge/cloud/pkg/common/client"
 	"github.com/kubeedge/kubeedge/cloud/pkg/common/informers"
 	"github.com/kubeedge/kubeedge/cloud/pkg/common/modules"
+	"github.com/kubeedge/kubeedge/cloud/pkg/common/sharding"
 	"github.com/kubeedge/kubeedge/cloud/pkg/synccontroller/config"
 	configv1alpha1 "github.com/kubeedge/kubeedge/pkg/apis/componentconfig/cloudcore/v1alpha1"
 	"github.com/kubeedge/kubeedge/pkg/apis/reliablesyncs/v1alpha1"
@@ -96,13 +97,18 @@ func (sctl *SyncController) Enable() bool {
 
 // Start controller
 func (sctl *SyncController) Start() {
-	if !cache.WaitForCacheSync(beehiveContext.Done(), sctl.informersSyncedFuncs...) {
+	sctl.StartWithContext(beehiveContext.GetContext())
+}
+
+// StartWithContext starts the controller, which runs until the context is done
+func (sctl *SyncController) StartWithContext(ctx context.Context) {
+	if !cache.WaitForCacheSync(ctx.Done(), sctl.informersSyncedFuncs...) {
 		klog.Errorf("unable to sync caches for sync controller")
 		return
 	}
 
 	sctl.deleteObjectSyncs() //check outdate sync before start to reconcile
-	go wait.Until(sctl.reconcile, 5*time.Second, beehiveContext.Done())
+	go wait.Until(sctl.reconcile, 5*time.Second, ctx.Done())
 }
 
 func (sctl *SyncController) reconcile() {
@@ -129,6 +135,10 @@ func (sctl *SyncController) manageClusterObjectSync(syncs []*v1alpha1.ClusterObj
 // and generate update and delete events to the edge
 func (sctl *SyncController) manageObjectSync(syncs []*v1alpha1.ObjectSync) {
 	for _, sync := range syncs {
+		// the objects of the nodes owned by the other cloudcore replicas are compared by them
+		if !sharding.Owns(getNodeName(sync.Name)) {
+			continue
+		}
 		sctl.manageObject(sync)
 	}
 }
@@ -140,6 +150,9 @@ func (sctl *SyncController) deleteObjectSyncs() {
 	}
 	for _, sync := range syncs {
 		nodeName := getNodeName(sync.Name)
+		if !sharding.Owns(nodeName) {
+			continue
+		}
 		isGarbage, err := sctl.checkObjectSync(sync)
 		if err != nil {
 			klog.Errorf("failed to check ObjectSync outdated, %s", err)
@@ -33,6 +33,13 @@ const (
 	DefaultCertURL        = "/edge.crt"
 	DefaultNodeUpgradeURL = "/nodeupgrade"
 
+	// RevokedCertsSecretName is the secret of the revoked edge certificates, its data keys are
+	// the revoked node names prefixed by RevokedNodeKeyPrefix and the revoked certificate serial
+	// numbers in hex prefixed by RevokedSerialKeyPrefix, the values are the revocation times in RFC3339
+	RevokedCertsSecretName = "revokedcerts"
+	RevokedNodeKeyPrefix   = "node."
+	RevokedSerialKeyPrefix = "serial."
+
 	DefaultStreamCAFile   = "/etc/kubeedge/ca/streamCA.crt"
 	DefaultStreamCertFile = "/etc/kubeedge/certs/stream.crt"
 	DefaultStreamKeyFile  = "/etc/kubeedge/certs/stream.key"
@@ -70,6 +77,45 @@ const (
 	DefaultRemoteQueryTimeout = 60
 	DefaultMetaServerAddr     = "127.0.0.1:10550"
 
+	DefaultRemoteQueryCacheTTL         = 5 * time.Minute
+	DefaultRemoteQueryCacheNegativeTTL = 30 * time.Second
+
+	// the watch event history is disabled by default, it costs a write to edgecore.db for each event
+	DefaultWatchEventHistorySize = 0
+
+	DefaultEncryptionKeyProvider = "file"
+	DefaultEncryptionKeyFile     = "/etc/kubeedge/encryption/kek"
+	DefaultEncryptionDataKeyFile = "/var/lib/kubeedge/datakeys.json"
+	DefaultDataKeyRotationPeriod = 30 * 24 * time.Hour
+
+	// the min size of the message payloads compressed by EdgeHub and CloudHub
+	DefaultCompressionThreshold = 1024
+
+	// the admission control of CloudHub, the durations are in seconds
+	DefaultAdmissionMaxConcurrentSyncs = 100
+	DefaultAdmissionInitialSyncTimeout = 60
+	DefaultAdmissionRetryAfter         = 10
+
+	// the sharding of the edge nodes among the cloudcore replicas, the durations are in seconds
+	DefaultShardingLeaseDuration = 30
+	DefaultShardingRenewInterval = 10
+	DefaultShardingVirtualNodes  = 100
+
+	// the disk storage of the message pools of CloudHub, the limits are for each node
+	DefaultSpillDBPath            = "/var/lib/kubeedge/cloudhub-spill.db"
+	DefaultSpillAckMessageLimit   = 10000
+	DefaultSpillNoAckMessageLimit = 1000
+
+	// the leader election of the singleton modules of CloudCore, the durations are in seconds
+	DefaultLeaderElectionLeaseDuration = 15
+	DefaultLeaderElectionRenewDeadline = 10
+	DefaultLeaderElectionRetryPeriod   = 2
+
+	// EdgeHub
+	DefaultOutboundQueueDBPath     = "/var/lib/kubeedge/edgehub-queue.db"
+	DefaultOutboundQueueGroupLimit = 10000
+	DefaultMeteredLinkStatePath    = "/var/lib/kubeedge/edgehub-metered-link.json"
+
 	// Config
 	DefaultKubeContentType         = "application/vnd.kubernetes.protobuf"
 	DefaultKubeNamespace           = v1.NamespaceAll
@@ -0,0 +1,230 @@
+package dbm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltStore is the KVStore on bbolt, which writes much less than sqlite since there is no journal file
+// and the pages are copied on write, that is friendly to the flash storage of edge devices.
+type boltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens the bbolt database file at path, the file is created if not exist
+func NewBoltStore(path string) (KVStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 10 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bbolt database %s: %v", path, err)
+	}
+	return &boltStore{db: db}, nil
+}
+
+func (s *boltStore) Insert(table string, obj interface{}) error {
+	return s.Update(func(tx KVStore) error {
+		return tx.Insert(table, obj)
+	})
+}
+
+func (s *boltStore) Save(table string, obj interface{}) error {
+	return s.Update(func(tx KVStore) error {
+		return tx.Save(table, obj)
+	})
+}
+
+func (s *boltStore) Get(table string, obj interface{}) (bool, error) {
+	var found bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		found, err = (&boltTx{tx: tx}).Get(table, obj)
+		return err
+	})
+	return found, err
+}
+
+func (s *boltStore) Delete(table string, obj interface{}) error {
+	return s.Update(func(tx KVStore) error {
+		return tx.Delete(table, obj)
+	})
+}
+
+func (s *boltStore) ForEach(table string, model interface{}, fn func(obj interface{}) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return (&boltTx{tx: tx}).ForEach(table, model, fn)
+	})
+}
+
+func (s *boltStore) ForEachPrefix(table, prefix string, model interface{}, fn func(obj interface{}) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return (&boltTx{tx: tx}).ForEachPrefix(table, prefix, model, fn)
+	})
+}
+
+func (s *boltStore) Update(fn func(tx KVStore) error) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return fn(&boltTx{tx: tx})
+	})
+}
+
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
+
+// boltTx is the KVStore within a bbolt transaction
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+func (t *boltTx) Insert(table string, obj interface{}) error {
+	return t.put(table, obj, false)
+}
+
+func (t *boltTx) Save(table string, obj interface{}) error {
+	return t.put(table, obj, true)
+}
+
+func (t *boltTx) put(table string, obj interface{}, replace bool) error {
+	b, err := t.tx.CreateBucketIfNotExists([]byte(table))
+	if err != nil {
+		return err
+	}
+	pk, auto, err := primaryKey(obj)
+	if err != nil {
+		return err
+	}
+	if auto {
+		if pk.IsZero() {
+			seq, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+			pk.Set(reflect.ValueOf(seq).Convert(pk.Type()))
+		} else if id, ok := sequenceOf(pk); ok && id > b.Sequence() {
+			// the primary key is given, e.g. when migrated from another backend
+			if err := b.SetSequence(id); err != nil {
+				return err
+			}
+		}
+	}
+	key, err := encodeKey(pk)
+	if err != nil {
+		return err
+	}
+	if !replace && b.Get(key) != nil {
+		return ErrKeyExists
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return b.Put(key, data)
+}
+
+func (t *boltTx) Get(table string, obj interface{}) (bool, error) {
+	b := t.tx.Bucket([]byte(table))
+	if b == nil {
+		return false, nil
+	}
+	pk, _, err := primaryKey(obj)
+	if err != nil {
+		return false, err
+	}
+	key, err := encodeKey(pk)
+	if err != nil {
+		return false, err
+	}
+	data := b.Get(key)
+	if data == nil {
+		return false, nil
+	}
+	return true, json.Unmarshal(data, obj)
+}
+
+func (t *boltTx) Delete(table string, obj interface{}) error {
+	b := t.tx.Bucket([]byte(table))
+	if b == nil {
+		return nil
+	}
+	pk, _, err := primaryKey(obj)
+	if err != nil {
+		return err
+	}
+	key, err := encodeKey(pk)
+	if err != nil {
+		return err
+	}
+	return b.Delete(key)
+}
+
+func (t *boltTx) ForEach(table string, model interface{}, fn func(obj interface{}) error) error {
+	return t.ForEachPrefix(table, "", model, fn)
+}
+
+func (t *boltTx) ForEachPrefix(table, prefix string, model interface{}, fn func(obj interface{}) error) error {
+	b := t.tx.Bucket([]byte(table))
+	if b == nil {
+		return nil
+	}
+	typ := reflect.TypeOf(model)
+	if typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("need ptr to struct, got %T", model)
+	}
+	// the keys are in byte order, so the records of the prefix are next to each other
+	c := b.Cursor()
+	for k, v := c.Seek([]byte(prefix)); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, v = c.Next() {
+		obj := reflect.New(typ.Elem()).Interface()
+		if err := json.Unmarshal(v, obj); err != nil {
+			return fmt.Errorf("failed to decode record %q of %s: %v", k, table, err)
+		}
+		if err := fn(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *boltTx) Update(fn func(tx KVStore) error) error {
+	// already in a read-write transaction
+	return fn(t)
+}
+
+func (t *boltTx) Close() error {
+	return fmt.Errorf("can not close a transaction")
+}
+
+// sequenceOf returns the primary key as the sequence of bucket, false is returned if it is not an integer
+func sequenceOf(pk reflect.Value) (uint64, bool) {
+	switch pk.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if pk.Int() < 0 {
+			return 0, false
+		}
+		return uint64(pk.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return pk.Uint(), true
+	}
+	return 0, false
+}
+
+// encodeKey encodes the primary key, integers are encoded in big endian to keep them in order
+func encodeKey(pk reflect.Value) ([]byte, error) {
+	switch pk.Kind() {
+	case reflect.String:
+		return []byte(pk.String()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, uint64(pk.Int()))
+		return key, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, pk.Uint())
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported primary key type %v", pk.Type())
+}
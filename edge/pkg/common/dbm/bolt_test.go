@@ -0,0 +1,191 @@
+package dbm
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type testRecord struct {
+	ID       int64  `orm:"column(id);size(64);auto;pk"`
+	DeviceID string `orm:"column(deviceid); null; type(text)"`
+	Name     string `orm:"column(name);null;type(text)"`
+	Value    string `orm:"column(value);null;type(text)"`
+}
+
+type testUnsignedRecord struct {
+	ID   uint64 `orm:"column(id);auto;pk"`
+	Name string `orm:"column(name);null;type(text)"`
+}
+
+type testMeta struct {
+	Key   string `orm:"column(key); size(256); pk"`
+	Value string `orm:"column(value); null; type(text)"`
+}
+
+const (
+	testTable     = "test_record"
+	testMetaTable = "test_meta"
+)
+
+func newTestStore(t *testing.T) KVStore {
+	s, err := NewBoltStore(filepath.Join(t.TempDir(), "edgecore.bolt"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	t.Cleanup(func() {
+		s.Close()
+	})
+	return s
+}
+
+func TestBoltStoreAutoIncrement(t *testing.T) {
+	s := newTestStore(t)
+	for _, name := range []string{"a", "b", "c"} {
+		if err := s.Insert(testTable, &testRecord{DeviceID: "device", Name: name}); err != nil {
+			t.Fatalf("failed to insert: %v", err)
+		}
+	}
+	// the sequence follows the largest given primary key
+	if err := s.Insert(testTable, &testRecord{ID: 10, DeviceID: "other", Name: "d"}); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+	r := &testRecord{Name: "e"}
+	if err := s.Insert(testTable, r); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+	if r.ID != 11 {
+		t.Errorf("expected id 11, but got %d", r.ID)
+	}
+
+	records := new([]testRecord)
+	if _, err := KVQuery(s, testTable, records); err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+	var ids []int64
+	for _, r := range *records {
+		ids = append(ids, r.ID)
+	}
+	if !reflect.DeepEqual(ids, []int64{1, 2, 3, 10, 11}) {
+		t.Errorf("expected records ordered by id, but got %v", ids)
+	}
+}
+
+func TestBoltStoreUnsignedAutoIncrement(t *testing.T) {
+	s := newTestStore(t)
+	if err := s.Insert(testTable, &testUnsignedRecord{ID: 5, Name: "a"}); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+	r := &testUnsignedRecord{Name: "b"}
+	if err := s.Insert(testTable, r); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+	if r.ID != 6 {
+		t.Errorf("expected id 6, but got %d", r.ID)
+	}
+}
+
+func TestBoltStoreKey(t *testing.T) {
+	s := newTestStore(t)
+	if err := s.Insert(testMetaTable, &testMeta{Key: "a", Value: "1"}); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+	if err := s.Insert(testMetaTable, &testMeta{Key: "a", Value: "2"}); err != ErrKeyExists {
+		t.Errorf("expected ErrKeyExists, but got %v", err)
+	}
+	if err := s.Save(testMetaTable, &testMeta{Key: "a", Value: "3"}); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+	m := &testMeta{Key: "a"}
+	if found, err := s.Get(testMetaTable, m); err != nil || !found || m.Value != "3" {
+		t.Errorf("expected value 3, but got %v, %v, %v", m.Value, found, err)
+	}
+	if err := s.Delete(testMetaTable, &testMeta{Key: "a"}); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if found, err := s.Get(testMetaTable, &testMeta{Key: "a"}); err != nil || found {
+		t.Errorf("expected not found, but got %v, %v", found, err)
+	}
+	// tables never written are empty
+	if found, err := s.Get("unknown", &testMeta{Key: "a"}); err != nil || found {
+		t.Errorf("expected not found, but got %v, %v", found, err)
+	}
+}
+
+func TestKVFilters(t *testing.T) {
+	s := newTestStore(t)
+	for _, r := range []testRecord{
+		{DeviceID: "d1", Name: "temperature"},
+		{DeviceID: "d1", Name: "humidity"},
+		{DeviceID: "d2", Name: "temperature"},
+	} {
+		r := r
+		if err := s.Insert(testTable, &r); err != nil {
+			t.Fatalf("failed to insert: %v", err)
+		}
+	}
+
+	num, err := KVUpdate(s, testTable, new(testRecord), map[string]interface{}{"value": "20"},
+		Eq("deviceid", "d1"), Eq("Name", "temperature"))
+	if err != nil || num != 1 {
+		t.Fatalf("expected 1 record updated, but got %v, %v", num, err)
+	}
+	records := new([]testRecord)
+	if _, err := KVQuery(s, testTable, records, Eq("value", "20")); err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+	if len(*records) != 1 || (*records)[0].DeviceID != "d1" || (*records)[0].Name != "temperature" {
+		t.Errorf("unexpected records %v", *records)
+	}
+
+	if num, err := KVDelete(s, testTable, new(testRecord), Eq("deviceid", "d1")); err != nil || num != 2 {
+		t.Fatalf("expected 2 records deleted, but got %v, %v", num, err)
+	}
+	if num, err := KVCount(s, testTable, new(testRecord)); err != nil || num != 1 {
+		t.Errorf("expected 1 record left, but got %v, %v", num, err)
+	}
+
+	if _, err := KVUpdate(s, testTable, new(testRecord), map[string]interface{}{"unknown": "x"}); err == nil {
+		t.Errorf("expected error for unknown column")
+	}
+}
+
+func TestKVUpdateRollback(t *testing.T) {
+	s := newTestStore(t)
+	if err := s.Insert(testMetaTable, &testMeta{Key: "a", Value: "1"}); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+	err := s.Update(func(tx KVStore) error {
+		if err := tx.Save(testMetaTable, &testMeta{Key: "a", Value: "2"}); err != nil {
+			return err
+		}
+		return tx.Insert(testMetaTable, &testMeta{Key: "a"})
+	})
+	if err != ErrKeyExists {
+		t.Fatalf("expected ErrKeyExists, but got %v", err)
+	}
+	m := &testMeta{Key: "a"}
+	if _, err := s.Get(testMetaTable, m); err != nil || m.Value != "1" {
+		t.Errorf("expected the change rolled back, but got %v, %v", m.Value, err)
+	}
+}
+
+func TestKVQueryPrefix(t *testing.T) {
+	s := newTestStore(t)
+	for _, key := range []string{"/a/1", "/a/2", "/ab/1", "/b/1"} {
+		if err := s.Insert(testMetaTable, &testMeta{Key: key, Value: "v"}); err != nil {
+			t.Fatalf("failed to insert: %v", err)
+		}
+	}
+	records := new([]testMeta)
+	if _, err := KVQueryPrefix(s, testMetaTable, "/a/", records); err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+	var keys []string
+	for _, r := range *records {
+		keys = append(keys, r.Key)
+	}
+	if !reflect.DeepEqual(keys, []string{"/a/1", "/a/2"}) {
+		t.Errorf("expected [/a/1 /a/2], but got %v", keys)
+	}
+}
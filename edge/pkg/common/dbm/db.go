@@ -17,6 +17,14 @@ var once sync.Once
 // InitDBConfig Init DB info
 func InitDBConfig(driverName, dbName, dataSource string) {
 	once.Do(func() {
+		if driverName == DriverBolt {
+			kv, err := NewBoltStore(dataSource)
+			if err != nil {
+				klog.Exitf("Failed to open db: %v", err)
+			}
+			KV = kv
+			return
+		}
 		if err := orm.RegisterDriver(driverName, orm.DRSqlite); err != nil {
 			klog.Exitf("Failed to register driver: %v", err)
 		}
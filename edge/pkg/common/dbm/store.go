@@ -0,0 +1,240 @@
+package dbm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	// DriverSqlite is the driver name of the default sqlite3 backend accessed by beego orm
+	DriverSqlite = "sqlite3"
+	// DriverBolt is the driver name of the embedded bbolt key-value backend
+	DriverBolt = "bbolt"
+)
+
+// ErrKeyExists is returned by Insert if a record with the same primary key already exists
+var ErrKeyExists = errors.New("record with the same primary key already exists")
+
+// KV is the key-value store used by all DAOs instead of DBAccess when a key-value backend is selected,
+// it is nil for the sqlite3 backend
+var KV KVStore
+
+// KVStore is the storage interface of a key-value backend. Every table is a bucket, the records are
+// the models registered to orm, they are keyed by the field tagged with pk and saved in json format.
+type KVStore interface {
+	// Insert saves obj to table, ErrKeyExists is returned if the primary key is used.
+	// An auto increment primary key with zero value is allocated and set back to obj.
+	Insert(table string, obj interface{}) error
+	// Save saves obj to table, the existing record with the same primary key is replaced
+	Save(table string, obj interface{}) error
+	// Get reads the record with the primary key of obj into obj, false is returned if not found
+	Get(table string, obj interface{}) (bool, error)
+	// Delete deletes the record with the primary key of obj
+	Delete(table string, obj interface{}) error
+	// ForEach decodes every record of table into a new object of the type of model in the order
+	// of primary key, and calls fn with it. The iteration stops at the first error returned by fn.
+	ForEach(table string, model interface{}, fn func(obj interface{}) error) error
+	// ForEachPrefix is ForEach on the records whose string primary keys start with prefix only
+	ForEachPrefix(table, prefix string, model interface{}, fn func(obj interface{}) error) error
+	// Update runs fn in a read-write transaction, all changes made by fn are rolled back if it returns error
+	Update(fn func(tx KVStore) error) error
+	// Close closes the store
+	Close() error
+}
+
+// KVFilter reports whether the record obj is selected
+type KVFilter func(obj interface{}) bool
+
+// Eq selects the records whose column equals value, the column is the orm column name or the field name
+func Eq(column string, value interface{}) KVFilter {
+	return func(obj interface{}) bool {
+		v, ok := columnValue(reflect.Indirect(reflect.ValueOf(obj)), column)
+		return ok && fmt.Sprint(v.Interface()) == fmt.Sprint(value)
+	}
+}
+
+func selected(obj interface{}, filters []KVFilter) bool {
+	for _, f := range filters {
+		if !f(obj) {
+			return false
+		}
+	}
+	return true
+}
+
+// KVQuery appends the records of table selected by filters to list, list is a pointer to a slice of models
+func KVQuery(s KVStore, table string, list interface{}, filters ...KVFilter) (int64, error) {
+	return KVQueryPrefix(s, table, "", list, filters...)
+}
+
+// KVQueryPrefix is KVQuery on the records whose string primary keys start with prefix only,
+// so the other records are not read
+func KVQueryPrefix(s KVStore, table, prefix string, list interface{}, filters ...KVFilter) (int64, error) {
+	slice := reflect.ValueOf(list)
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice {
+		return 0, fmt.Errorf("need ptr to slice, got %T", list)
+	}
+	slice = slice.Elem()
+	model := reflect.New(slice.Type().Elem()).Interface()
+	var num int64
+	err := s.ForEachPrefix(table, prefix, model, func(obj interface{}) error {
+		if selected(obj, filters) {
+			slice.Set(reflect.Append(slice, reflect.ValueOf(obj).Elem()))
+			num++
+		}
+		return nil
+	})
+	return num, err
+}
+
+// KVCount counts the records of table selected by filters
+func KVCount(s KVStore, table string, model interface{}, filters ...KVFilter) (int64, error) {
+	var num int64
+	err := s.ForEach(table, model, func(obj interface{}) error {
+		if selected(obj, filters) {
+			num++
+		}
+		return nil
+	})
+	return num, err
+}
+
+// KVDelete deletes the records of table selected by filters
+func KVDelete(s KVStore, table string, model interface{}, filters ...KVFilter) (int64, error) {
+	var num int64
+	err := s.Update(func(tx KVStore) error {
+		var objs []interface{}
+		if err := tx.ForEach(table, model, func(obj interface{}) error {
+			if selected(obj, filters) {
+				objs = append(objs, obj)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, obj := range objs {
+			if err := tx.Delete(table, obj); err != nil {
+				return err
+			}
+		}
+		num = int64(len(objs))
+		return nil
+	})
+	return num, err
+}
+
+// KVUpdate sets the columns of the records of table selected by filters
+func KVUpdate(s KVStore, table string, model interface{}, cols map[string]interface{}, filters ...KVFilter) (int64, error) {
+	var num int64
+	err := s.Update(func(tx KVStore) error {
+		var objs []interface{}
+		if err := tx.ForEach(table, model, func(obj interface{}) error {
+			if selected(obj, filters) {
+				objs = append(objs, obj)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, obj := range objs {
+			if err := setColumns(obj, cols); err != nil {
+				return err
+			}
+			if err := tx.Save(table, obj); err != nil {
+				return err
+			}
+		}
+		num = int64(len(objs))
+		return nil
+	})
+	return num, err
+}
+
+// KVUpdateByKey sets the columns of the record with the primary key of obj, the updated record is read into obj
+func KVUpdateByKey(s KVStore, table string, obj interface{}, cols map[string]interface{}) (int64, error) {
+	var num int64
+	err := s.Update(func(tx KVStore) error {
+		found, err := tx.Get(table, obj)
+		if err != nil || !found {
+			return err
+		}
+		if err := setColumns(obj, cols); err != nil {
+			return err
+		}
+		num = 1
+		return tx.Save(table, obj)
+	})
+	return num, err
+}
+
+// columnValue returns the field of struct v by orm column name or field name
+func columnValue(v reflect.Value, column string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if strings.EqualFold(f.Name, column) || ormTagValue(f.Tag.Get("orm"), "column") == strings.ToLower(column) {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func setColumns(obj interface{}, cols map[string]interface{}) error {
+	v := reflect.Indirect(reflect.ValueOf(obj))
+	for col, value := range cols {
+		field, ok := columnValue(v, col)
+		if !ok {
+			return fmt.Errorf("unknown column %s of %s", col, v.Type().Name())
+		}
+		val := reflect.ValueOf(value)
+		if !val.IsValid() {
+			field.Set(reflect.Zero(field.Type()))
+			continue
+		}
+		if !val.Type().ConvertibleTo(field.Type()) {
+			return fmt.Errorf("can not set column %s of %s with %T", col, v.Type().Name(), value)
+		}
+		field.Set(val.Convert(field.Type()))
+	}
+	return nil
+}
+
+// primaryKey returns the field tagged with pk, and whether it is auto increment
+func primaryKey(obj interface{}) (reflect.Value, bool, error) {
+	v := reflect.Indirect(reflect.ValueOf(obj))
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, false, fmt.Errorf("need ptr to struct, got %T", obj)
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tags := ormTags(t.Field(i).Tag.Get("orm"))
+		if _, ok := tags["pk"]; ok {
+			_, auto := tags["auto"]
+			return v.Field(i), auto, nil
+		}
+	}
+	return reflect.Value{}, false, fmt.Errorf("no primary key in %s", t.Name())
+}
+
+// ormTags parses tag like `orm:"column(id);size(64);auto;pk"` to map{column:id, size:64, auto:"", pk:""}
+func ormTags(tag string) map[string]string {
+	tags := make(map[string]string)
+	for _, item := range strings.Split(tag, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value := item, ""
+		if i := strings.Index(item, "("); i > 0 && strings.HasSuffix(item, ")") {
+			name, value = item[:i], item[i+1:len(item)-1]
+		}
+		tags[name] = value
+	}
+	return tags
+}
+
+func ormTagValue(tag, name string) string {
+	return ormTags(tag)[name]
+}
@@ -17,6 +17,12 @@ const (
 	OperationResponse          = "response"
 	OperationKeepalive         = "keepalive"
 
+	// the state of the outbound queue sent to the producers of a group by edgehub
+	ResourceTypeOutboundQueue = "outbound/queue"
+	OperationOutboundQueue    = "backpressure"
+	OutboundQueueFull         = "outbound_queue_full"
+	OutboundQueueAvailable    = "outbound_queue_available"
+
 	ResourceGroupName = "resource"
 	TwinGroupName     = "twin"
 	FuncGroupName     = "func"
@@ -3,8 +3,6 @@ package dtclient
 import (
 	"github.com/astaxie/beego/orm"
 	"k8s.io/klog/v2"
-
-	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
 )
 
 //DeviceAttr the struct of device attributes
@@ -50,9 +48,7 @@ func DeleteDeviceAttr(obm orm.Ormer, deviceID string, name string) error {
 
 // UpdateDeviceAttrField update special field
 func UpdateDeviceAttrField(deviceID string, name string, col string, value interface{}) error {
-	num, err := dbm.DBAccess.QueryTable(DeviceAttrTableName).Filter("deviceid", deviceID).Filter("name", name).Update(map[string]interface{}{col: value})
-	klog.V(4).Infof("Update affected Num: %d, %s", num, err)
-	return err
+	return getDeviceStore().updateDeviceAttrFields(deviceID, name, map[string]interface{}{col: value})
 }
 
 // UpdateDeviceAttrFields update special fields
@@ -64,12 +60,7 @@ func UpdateDeviceAttrFields(obm orm.Ormer, deviceID string, name string, cols ma
 
 // QueryDeviceAttr query Device
 func QueryDeviceAttr(key string, condition string) (*[]DeviceAttr, error) {
-	attrs := new([]DeviceAttr)
-	_, err := dbm.DBAccess.QueryTable(DeviceAttrTableName).Filter(key, condition).All(attrs)
-	if err != nil {
-		return nil, err
-	}
-	return attrs, nil
+	return getDeviceStore().queryDeviceAttr(key, condition)
 }
 
 //DeviceDelete the struct for deleting device
@@ -87,56 +78,10 @@ type DeviceAttrUpdate struct {
 
 //UpdateDeviceAttrMulti update device attr multi
 func UpdateDeviceAttrMulti(updates []DeviceAttrUpdate) error {
-	var err error
-	for _, update := range updates {
-		err = UpdateDeviceAttrFields(dbm.DBAccess, update.DeviceID, update.Name, update.Cols)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	return getDeviceStore().updateDeviceAttrMulti(updates)
 }
 
 //DeviceAttrTrans transaction of device attr
 func DeviceAttrTrans(adds []DeviceAttr, deletes []DeviceDelete, updates []DeviceAttrUpdate) error {
-	obm := dbm.DefaultOrmFunc()
-	err := obm.Begin()
-	if err != nil {
-		klog.Errorf("failed to begin transaction: %v", err)
-		return err
-	}
-
-	defer func() {
-		if err != nil {
-			dbm.RollbackTransaction(obm)
-		} else {
-			err = obm.Commit()
-			if err != nil {
-				klog.Errorf("failed to commit transaction: %v", err)
-			}
-		}
-	}()
-
-	for _, add := range adds {
-		err = SaveDeviceAttr(obm, &add)
-		if err != nil {
-			return err
-		}
-	}
-
-	for _, delete := range deletes {
-		err = DeleteDeviceAttr(obm, delete.DeviceID, delete.Name)
-		if err != nil {
-			return err
-		}
-	}
-
-	for _, update := range updates {
-		err = UpdateDeviceAttrFields(obm, update.DeviceID, update.Name, update.Cols)
-		if err != nil {
-			return err
-		}
-	}
-
-	return err
+	return getDeviceStore().deviceAttrTrans(adds, deletes, updates)
 }
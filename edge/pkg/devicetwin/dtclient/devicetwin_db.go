@@ -3,8 +3,6 @@ package dtclient
 import (
 	"github.com/astaxie/beego/orm"
 	"k8s.io/klog/v2"
-
-	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
 )
 
 //DeviceTwin the struct of device twin
@@ -55,9 +53,7 @@ func DeleteDeviceTwin(obm orm.Ormer, deviceID string, name string) error {
 
 // UpdateDeviceTwinField update special field
 func UpdateDeviceTwinField(deviceID string, name string, col string, value interface{}) error {
-	num, err := dbm.DBAccess.QueryTable(DeviceTwinTableName).Filter("deviceid", deviceID).Filter("name", name).Update(map[string]interface{}{col: value})
-	klog.V(4).Infof("Update affected Num: %d, %s", num, err)
-	return err
+	return getDeviceStore().updateDeviceTwinFields(deviceID, name, map[string]interface{}{col: value})
 }
 
 // UpdateDeviceTwinFields update special fields
@@ -69,12 +65,7 @@ func UpdateDeviceTwinFields(obm orm.Ormer, deviceID string, name string, cols ma
 
 // QueryDeviceTwin query Device
 func QueryDeviceTwin(key string, condition string) (*[]DeviceTwin, error) {
-	twin := new([]DeviceTwin)
-	_, err := dbm.DBAccess.QueryTable(DeviceTwinTableName).Filter(key, condition).All(twin)
-	if err != nil {
-		return nil, err
-	}
-	return twin, nil
+	return getDeviceStore().queryDeviceTwin(key, condition)
 }
 
 //DeviceTwinUpdate the struct for updating device twin
@@ -86,56 +77,10 @@ type DeviceTwinUpdate struct {
 
 //UpdateDeviceTwinMulti update device twin multi
 func UpdateDeviceTwinMulti(updates []DeviceTwinUpdate) error {
-	var err error
-	for _, update := range updates {
-		err = UpdateDeviceTwinFields(dbm.DBAccess, update.DeviceID, update.Name, update.Cols)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	return getDeviceStore().updateDeviceTwinMulti(updates)
 }
 
 //DeviceTwinTrans transaction of device twin
 func DeviceTwinTrans(adds []DeviceTwin, deletes []DeviceDelete, updates []DeviceTwinUpdate) error {
-	obm := dbm.DefaultOrmFunc()
-	err := obm.Begin()
-	if err != nil {
-		klog.Errorf("failed to begin transaction: %v", err)
-		return err
-	}
-
-	defer func() {
-		if err != nil {
-			dbm.RollbackTransaction(obm)
-		} else {
-			err = obm.Commit()
-			if err != nil {
-				klog.Errorf("failed to commit transaction: %v", err)
-			}
-		}
-	}()
-
-	for _, add := range adds {
-		err = SaveDeviceTwin(obm, &add)
-		if err != nil {
-			return err
-		}
-	}
-
-	for _, delete := range deletes {
-		err = DeleteDeviceTwin(obm, delete.DeviceID, delete.Name)
-		if err != nil {
-			return err
-		}
-	}
-
-	for _, update := range updates {
-		err = UpdateDeviceTwinFields(obm, update.DeviceID, update.Name, update.Cols)
-		if err != nil {
-			return err
-		}
-	}
-
-	return err
+	return getDeviceStore().deviceTwinTrans(adds, deletes, updates)
 }
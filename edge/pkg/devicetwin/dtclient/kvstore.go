@@ -0,0 +1,164 @@
+package dtclient
+
+import (
+	"k8s.io/klog/v2"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+)
+
+// kvDeviceStore is the deviceStore on the key-value store, the devices are keyed by their ids,
+// the attributes and twins by their auto increment ids
+type kvDeviceStore struct {
+	kv dbm.KVStore
+}
+
+func kvDeviceFilters(deviceID string, name string) []dbm.KVFilter {
+	return []dbm.KVFilter{dbm.Eq("deviceid", deviceID), dbm.Eq("name", name)}
+}
+
+func (s kvDeviceStore) updateDeviceFields(deviceID string, cols map[string]interface{}) error {
+	num, err := dbm.KVUpdateByKey(s.kv, DeviceTableName, &Device{ID: deviceID}, cols)
+	klog.V(4).Infof("Update affected Num: %d, %v", num, err)
+	return err
+}
+
+func (s kvDeviceStore) queryDevice(key string, condition string) (*[]Device, error) {
+	devices := new([]Device)
+	if _, err := dbm.KVQuery(s.kv, DeviceTableName, devices, dbm.Eq(key, condition)); err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+func (s kvDeviceStore) listDevices() (*[]Device, error) {
+	devices := new([]Device)
+	if _, err := dbm.KVQuery(s.kv, DeviceTableName, devices); err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+func (s kvDeviceStore) addDeviceTrans(adds []Device, addAttrs []DeviceAttr, addTwins []DeviceTwin) error {
+	return s.kv.Update(func(tx dbm.KVStore) error {
+		for i := range adds {
+			if err := tx.Insert(DeviceTableName, &adds[i]); err != nil {
+				klog.Errorf("save device failed: %v", err)
+				return err
+			}
+		}
+		for i := range addAttrs {
+			if err := tx.Insert(DeviceAttrTableName, &addAttrs[i]); err != nil {
+				return err
+			}
+		}
+		for i := range addTwins {
+			if err := tx.Insert(DeviceTwinTableName, &addTwins[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s kvDeviceStore) deleteDeviceTrans(deletes []string) error {
+	return s.kv.Update(func(tx dbm.KVStore) error {
+		for _, id := range deletes {
+			if err := tx.Delete(DeviceTableName, &Device{ID: id}); err != nil {
+				return err
+			}
+			if _, err := dbm.KVDelete(tx, DeviceAttrTableName, new(DeviceAttr), dbm.Eq("deviceid", id)); err != nil {
+				return err
+			}
+			if _, err := dbm.KVDelete(tx, DeviceTwinTableName, new(DeviceTwin), dbm.Eq("deviceid", id)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func kvUpdateDeviceAttrFields(tx dbm.KVStore, deviceID string, name string, cols map[string]interface{}) error {
+	num, err := dbm.KVUpdate(tx, DeviceAttrTableName, new(DeviceAttr), cols, kvDeviceFilters(deviceID, name)...)
+	klog.V(4).Infof("Update affected Num: %d, %v", num, err)
+	return err
+}
+
+func (s kvDeviceStore) updateDeviceAttrFields(deviceID string, name string, cols map[string]interface{}) error {
+	return kvUpdateDeviceAttrFields(s.kv, deviceID, name, cols)
+}
+
+func (s kvDeviceStore) queryDeviceAttr(key string, condition string) (*[]DeviceAttr, error) {
+	attrs := new([]DeviceAttr)
+	if _, err := dbm.KVQuery(s.kv, DeviceAttrTableName, attrs, dbm.Eq(key, condition)); err != nil {
+		return nil, err
+	}
+	return attrs, nil
+}
+
+func (s kvDeviceStore) updateDeviceAttrMulti(updates []DeviceAttrUpdate) error {
+	return s.deviceAttrTrans(nil, nil, updates)
+}
+
+func (s kvDeviceStore) deviceAttrTrans(adds []DeviceAttr, deletes []DeviceDelete, updates []DeviceAttrUpdate) error {
+	return s.kv.Update(func(tx dbm.KVStore) error {
+		for i := range adds {
+			if err := tx.Insert(DeviceAttrTableName, &adds[i]); err != nil {
+				return err
+			}
+		}
+		for _, d := range deletes {
+			if _, err := dbm.KVDelete(tx, DeviceAttrTableName, new(DeviceAttr), kvDeviceFilters(d.DeviceID, d.Name)...); err != nil {
+				return err
+			}
+		}
+		for _, update := range updates {
+			if err := kvUpdateDeviceAttrFields(tx, update.DeviceID, update.Name, update.Cols); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func kvUpdateDeviceTwinFields(tx dbm.KVStore, deviceID string, name string, cols map[string]interface{}) error {
+	num, err := dbm.KVUpdate(tx, DeviceTwinTableName, new(DeviceTwin), cols, kvDeviceFilters(deviceID, name)...)
+	klog.V(4).Infof("Update affected Num: %d, %v", num, err)
+	return err
+}
+
+func (s kvDeviceStore) updateDeviceTwinFields(deviceID string, name string, cols map[string]interface{}) error {
+	return kvUpdateDeviceTwinFields(s.kv, deviceID, name, cols)
+}
+
+func (s kvDeviceStore) queryDeviceTwin(key string, condition string) (*[]DeviceTwin, error) {
+	twins := new([]DeviceTwin)
+	if _, err := dbm.KVQuery(s.kv, DeviceTwinTableName, twins, dbm.Eq(key, condition)); err != nil {
+		return nil, err
+	}
+	return twins, nil
+}
+
+func (s kvDeviceStore) updateDeviceTwinMulti(updates []DeviceTwinUpdate) error {
+	return s.deviceTwinTrans(nil, nil, updates)
+}
+
+func (s kvDeviceStore) deviceTwinTrans(adds []DeviceTwin, deletes []DeviceDelete, updates []DeviceTwinUpdate) error {
+	return s.kv.Update(func(tx dbm.KVStore) error {
+		for i := range adds {
+			if err := tx.Insert(DeviceTwinTableName, &adds[i]); err != nil {
+				return err
+			}
+		}
+		for _, d := range deletes {
+			if _, err := dbm.KVDelete(tx, DeviceTwinTableName, new(DeviceTwin), kvDeviceFilters(d.DeviceID, d.Name)...); err != nil {
+				return err
+			}
+		}
+		for _, update := range updates {
+			if err := kvUpdateDeviceTwinFields(tx, update.DeviceID, update.Name, update.Cols); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
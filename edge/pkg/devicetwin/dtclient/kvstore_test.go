@@ -0,0 +1,55 @@
+package dtclient
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+)
+
+func TestKVDeviceStore(t *testing.T) {
+	kv, err := dbm.NewBoltStore(filepath.Join(t.TempDir(), "edgecore.bolt"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer kv.Close()
+	s := kvDeviceStore{kv: kv}
+
+	err = s.addDeviceTrans([]Device{{ID: "device", Name: "device"}},
+		[]DeviceAttr{{DeviceID: "device", Name: "attr", Value: "1"}},
+		[]DeviceTwin{{DeviceID: "device", Name: "twin", Expected: "1"}})
+	if err != nil {
+		t.Fatalf("failed to add device: %v", err)
+	}
+	if err := s.updateDeviceFields("device", map[string]interface{}{"state": "online"}); err != nil {
+		t.Fatalf("failed to update device: %v", err)
+	}
+	if devices, err := s.queryDevice("id", "device"); err != nil || len(*devices) != 1 || (*devices)[0].State != "online" {
+		t.Errorf("expected device online, but got %+v, %v", devices, err)
+	}
+	err = s.deviceAttrTrans(nil, nil, []DeviceAttrUpdate{{DeviceID: "device", Name: "attr", Cols: map[string]interface{}{"value": "2"}}})
+	if err != nil {
+		t.Fatalf("failed to update attr: %v", err)
+	}
+	if attrs, err := s.queryDeviceAttr("deviceid", "device"); err != nil || len(*attrs) != 1 || (*attrs)[0].Value != "2" {
+		t.Errorf("expected attr updated, but got %+v, %v", attrs, err)
+	}
+	err = s.updateDeviceTwinMulti([]DeviceTwinUpdate{{DeviceID: "device", Name: "twin", Cols: map[string]interface{}{"expected": "2"}}})
+	if err != nil {
+		t.Fatalf("failed to update twin: %v", err)
+	}
+	if twins, err := s.queryDeviceTwin("deviceid", "device"); err != nil || len(*twins) != 1 || (*twins)[0].Expected != "2" {
+		t.Errorf("expected twin updated, but got %+v, %v", twins, err)
+	}
+
+	// the attributes and twins are deleted with the device
+	if err := s.deleteDeviceTrans([]string{"device"}); err != nil {
+		t.Fatalf("failed to delete device: %v", err)
+	}
+	devices, _ := s.listDevices()
+	attrs, _ := s.queryDeviceAttr("deviceid", "device")
+	twins, _ := s.queryDeviceTwin("deviceid", "device")
+	if len(*devices) != 0 || len(*attrs) != 0 || len(*twins) != 0 {
+		t.Errorf("expected all deleted, but got %v, %v, %v", *devices, *attrs, *twins)
+	}
+}
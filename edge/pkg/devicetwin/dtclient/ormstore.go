@@ -0,0 +1,242 @@
+package dtclient
+
+import (
+	"k8s.io/klog/v2"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+)
+
+// ormDeviceStore is the deviceStore on the sqlite3 database accessed by orm
+type ormDeviceStore struct{}
+
+func (ormDeviceStore) updateDeviceFields(deviceID string, cols map[string]interface{}) error {
+	num, err := dbm.DBAccess.QueryTable(DeviceTableName).Filter("id", deviceID).Update(cols)
+	klog.V(4).Infof("Update affected Num: %d, %s", num, err)
+	return err
+}
+
+func (ormDeviceStore) queryDevice(key string, condition string) (*[]Device, error) {
+	devices := new([]Device)
+	if _, err := dbm.DBAccess.QueryTable(DeviceTableName).Filter(key, condition).All(devices); err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+func (ormDeviceStore) listDevices() (*[]Device, error) {
+	devices := new([]Device)
+	if _, err := dbm.DBAccess.QueryTable(DeviceTableName).All(devices); err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+func (ormDeviceStore) addDeviceTrans(adds []Device, addAttrs []DeviceAttr, addTwins []DeviceTwin) error {
+	obm := dbm.DefaultOrmFunc()
+	err := obm.Begin()
+	if err != nil {
+		klog.Errorf("failed to begin transaction: %v", err)
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			dbm.RollbackTransaction(obm)
+		} else {
+			err = obm.Commit()
+			if err != nil {
+				klog.Errorf("failed to commit transaction: %v", err)
+			}
+		}
+	}()
+
+	for _, add := range adds {
+		err = SaveDevice(obm, &add)
+
+		if err != nil {
+			klog.Errorf("save device failed: %v", err)
+			return err
+		}
+	}
+
+	for _, attr := range addAttrs {
+		err = SaveDeviceAttr(obm, &attr)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, twin := range addTwins {
+		err = SaveDeviceTwin(obm, &twin)
+		if err != nil {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (ormDeviceStore) deleteDeviceTrans(deletes []string) error {
+	obm := dbm.DefaultOrmFunc()
+	err := obm.Begin()
+	if err != nil {
+		klog.Errorf("failed to begin transaction: %v", err)
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			dbm.RollbackTransaction(obm)
+		} else {
+			err = obm.Commit()
+			if err != nil {
+				klog.Errorf("failed to commit transaction: %v", err)
+			}
+		}
+	}()
+
+	for _, delete := range deletes {
+		err = DeleteDeviceByID(obm, delete)
+		if err != nil {
+			return err
+		}
+		err = DeleteDeviceAttrByDeviceID(obm, delete)
+		if err != nil {
+			return err
+		}
+		err = DeleteDeviceTwinByDeviceID(obm, delete)
+		if err != nil {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (ormDeviceStore) updateDeviceAttrFields(deviceID string, name string, cols map[string]interface{}) error {
+	return UpdateDeviceAttrFields(dbm.DBAccess, deviceID, name, cols)
+}
+
+func (ormDeviceStore) queryDeviceAttr(key string, condition string) (*[]DeviceAttr, error) {
+	attrs := new([]DeviceAttr)
+	if _, err := dbm.DBAccess.QueryTable(DeviceAttrTableName).Filter(key, condition).All(attrs); err != nil {
+		return nil, err
+	}
+	return attrs, nil
+}
+
+func (ormDeviceStore) updateDeviceAttrMulti(updates []DeviceAttrUpdate) error {
+	for _, update := range updates {
+		if err := UpdateDeviceAttrFields(dbm.DBAccess, update.DeviceID, update.Name, update.Cols); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ormDeviceStore) deviceAttrTrans(adds []DeviceAttr, deletes []DeviceDelete, updates []DeviceAttrUpdate) error {
+	obm := dbm.DefaultOrmFunc()
+	err := obm.Begin()
+	if err != nil {
+		klog.Errorf("failed to begin transaction: %v", err)
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			dbm.RollbackTransaction(obm)
+		} else {
+			err = obm.Commit()
+			if err != nil {
+				klog.Errorf("failed to commit transaction: %v", err)
+			}
+		}
+	}()
+
+	for _, add := range adds {
+		err = SaveDeviceAttr(obm, &add)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, delete := range deletes {
+		err = DeleteDeviceAttr(obm, delete.DeviceID, delete.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, update := range updates {
+		err = UpdateDeviceAttrFields(obm, update.DeviceID, update.Name, update.Cols)
+		if err != nil {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (ormDeviceStore) updateDeviceTwinFields(deviceID string, name string, cols map[string]interface{}) error {
+	return UpdateDeviceTwinFields(dbm.DBAccess, deviceID, name, cols)
+}
+
+func (ormDeviceStore) queryDeviceTwin(key string, condition string) (*[]DeviceTwin, error) {
+	twins := new([]DeviceTwin)
+	if _, err := dbm.DBAccess.QueryTable(DeviceTwinTableName).Filter(key, condition).All(twins); err != nil {
+		return nil, err
+	}
+	return twins, nil
+}
+
+func (ormDeviceStore) updateDeviceTwinMulti(updates []DeviceTwinUpdate) error {
+	for _, update := range updates {
+		if err := UpdateDeviceTwinFields(dbm.DBAccess, update.DeviceID, update.Name, update.Cols); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ormDeviceStore) deviceTwinTrans(adds []DeviceTwin, deletes []DeviceDelete, updates []DeviceTwinUpdate) error {
+	obm := dbm.DefaultOrmFunc()
+	err := obm.Begin()
+	if err != nil {
+		klog.Errorf("failed to begin transaction: %v", err)
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			dbm.RollbackTransaction(obm)
+		} else {
+			err = obm.Commit()
+			if err != nil {
+				klog.Errorf("failed to commit transaction: %v", err)
+			}
+		}
+	}()
+
+	for _, add := range adds {
+		err = SaveDeviceTwin(obm, &add)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, delete := range deletes {
+		err = DeleteDeviceTwin(obm, delete.DeviceID, delete.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, update := range updates {
+		err = UpdateDeviceTwinFields(obm, update.DeviceID, update.Name, update.Cols)
+		if err != nil {
+			return err
+		}
+	}
+
+	return err
+}
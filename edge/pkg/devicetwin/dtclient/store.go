@@ -0,0 +1,46 @@
+package dtclient
+
+import (
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+)
+
+// deviceStore is the storage of devices, device attributes and device twins on the backend selected
+type deviceStore interface {
+	// updateDeviceFields sets the columns of the device
+	updateDeviceFields(deviceID string, cols map[string]interface{}) error
+	// queryDevice returns the devices whose column key equals condition
+	queryDevice(key string, condition string) (*[]Device, error)
+	// listDevices returns all devices
+	listDevices() (*[]Device, error)
+	// addDeviceTrans saves the devices, attributes and twins in one transaction
+	addDeviceTrans(adds []Device, addAttrs []DeviceAttr, addTwins []DeviceTwin) error
+	// deleteDeviceTrans deletes the devices with their attributes and twins in one transaction
+	deleteDeviceTrans(deletes []string) error
+
+	// updateDeviceAttrFields sets the columns of the attribute name of the device
+	updateDeviceAttrFields(deviceID string, name string, cols map[string]interface{}) error
+	// queryDeviceAttr returns the attributes whose column key equals condition
+	queryDeviceAttr(key string, condition string) (*[]DeviceAttr, error)
+	// updateDeviceAttrMulti applies the updates of attributes in order, it stops at the first error
+	updateDeviceAttrMulti(updates []DeviceAttrUpdate) error
+	// deviceAttrTrans adds, deletes and updates the attributes in one transaction
+	deviceAttrTrans(adds []DeviceAttr, deletes []DeviceDelete, updates []DeviceAttrUpdate) error
+
+	// updateDeviceTwinFields sets the columns of the twin name of the device
+	updateDeviceTwinFields(deviceID string, name string, cols map[string]interface{}) error
+	// queryDeviceTwin returns the twins whose column key equals condition
+	queryDeviceTwin(key string, condition string) (*[]DeviceTwin, error)
+	// updateDeviceTwinMulti applies the updates of twins in order, it stops at the first error
+	updateDeviceTwinMulti(updates []DeviceTwinUpdate) error
+	// deviceTwinTrans adds, deletes and updates the twins in one transaction
+	deviceTwinTrans(adds []DeviceTwin, deletes []DeviceDelete, updates []DeviceTwinUpdate) error
+}
+
+// getDeviceStore returns the storage of the backend selected, the key-value store if it is opened
+// or the sqlite3 database accessed by orm otherwise
+func getDeviceStore() deviceStore {
+	if dbm.KV != nil {
+		return kvDeviceStore{kv: dbm.KV}
+	}
+	return ormDeviceStore{}
+}
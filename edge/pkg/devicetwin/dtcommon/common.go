@@ -85,6 +85,8 @@ const (
 	SendToCloud = "SendToCloud"
 	// LifeCycle life cycle
 	LifeCycle = "LifeCycle"
+	// Backpressure the state of the outbound queue of edgehub
+	Backpressure = "Backpressure"
 	// Connected event
 	Connected = "connected"
 	// Confirm event
@@ -30,6 +30,8 @@ type DTContext struct {
 	Mutex          *sync.RWMutex
 	// DBConn *dtclient.Conn
 	State string
+	// OutboundQueueFull means the messages to cloud are held since the outbound queue of edgehub is full
+	OutboundQueueFull bool
 }
 
 //InitDTContext init dtcontext
@@ -13,6 +13,7 @@ import (
 	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
 	"github.com/kubeedge/beehive/pkg/core/model"
 	connect "github.com/kubeedge/kubeedge/edge/pkg/common/cloudconnection"
+	messagepkg "github.com/kubeedge/kubeedge/edge/pkg/common/message"
 	"github.com/kubeedge/kubeedge/edge/pkg/devicetwin/dtcommon"
 	"github.com/kubeedge/kubeedge/edge/pkg/devicetwin/dtcontext"
 	"github.com/kubeedge/kubeedge/edge/pkg/devicetwin/dttype"
@@ -71,6 +72,7 @@ func initActionCallBack() {
 	ActionCallBack[dtcommon.SendToCloud] = dealSendToCloud
 	ActionCallBack[dtcommon.SendToEdge] = dealSendToEdge
 	ActionCallBack[dtcommon.LifeCycle] = dealLifeCycle
+	ActionCallBack[dtcommon.Backpressure] = dealBackpressure
 	ActionCallBack[dtcommon.Confirm] = dealConfirm
 }
 
@@ -92,8 +94,13 @@ func dealSendToCloud(context *dtcontext.DTContext, resource string, msg interfac
 	if !ok {
 		return errors.New("msg not Message type")
 	}
-	beehiveContext.Send(dtcommon.HubModule, *message)
 	msgID := message.GetID()
+	// the message is sent again by checkConfirm once the outbound queue is available
+	if !context.OutboundQueueFull {
+		beehiveContext.Send(dtcommon.HubModule, *message)
+	} else {
+		klog.V(2).Infof("Outbound queue is full, hold msg %s", msgID)
+	}
 	context.ConfirmMap.Store(msgID, &dttype.DTMessage{Msg: message, Action: dtcommon.SendToCloud, Type: dtcommon.CommModule})
 	return nil
 }
@@ -118,6 +125,16 @@ func dealLifeCycle(context *dtcontext.DTContext, resource string, msg interface{
 	}
 	return nil
 }
+func dealBackpressure(context *dtcontext.DTContext, resource string, msg interface{}) error {
+	message, ok := msg.(*model.Message)
+	if !ok {
+		return errors.New("msg not Message type")
+	}
+	state, _ := message.Content.(string)
+	klog.Infof("Outbound queue event: %s", state)
+	context.OutboundQueueFull = state == messagepkg.OutboundQueueFull
+	return nil
+}
 func dealConfirm(context *dtcontext.DTContext, resource string, msg interface{}) error {
 	klog.V(2).Infof("CONFIRM EVENT")
 	value, ok := msg.(*model.Message)
@@ -26,6 +26,7 @@ import (
 	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
 	"github.com/kubeedge/beehive/pkg/core/model"
 	cloudconn "github.com/kubeedge/kubeedge/edge/pkg/common/cloudconnection"
+	messagepkg "github.com/kubeedge/kubeedge/edge/pkg/common/message"
 	"github.com/kubeedge/kubeedge/edge/pkg/devicetwin/dtcommon"
 	"github.com/kubeedge/kubeedge/edge/pkg/devicetwin/dtcontext"
 	"github.com/kubeedge/kubeedge/edge/pkg/devicetwin/dttype"
@@ -282,6 +283,34 @@ func TestDealLifeCycle(t *testing.T) {
 	}
 }
 
+// TestDealBackpressure is function to test the messages held by dealSendToCloud while the outbound queue is full.
+func TestDealBackpressure(t *testing.T) {
+	beehiveContext.InitContext([]string{common.MsgCtxTypeChannel})
+	dtContext, _ := dtcontext.InitDTContext()
+	dtContext.State = dtcommon.Connected
+
+	err := dealBackpressure(dtContext, "", &model.Message{Content: messagepkg.OutboundQueueFull})
+	if err != nil || !dtContext.OutboundQueueFull {
+		t.Fatalf("dealBackpressure() error = %v, expected outbound queue full", err)
+	}
+	// the message is kept to send again once the queue is available
+	msg := &model.Message{Header: model.MessageHeader{ID: "message"}}
+	if err := dealSendToCloud(dtContext, "", msg); err != nil {
+		t.Fatalf("dealSendToCloud() error = %v", err)
+	}
+	if _, exist := dtContext.ConfirmMap.Load("message"); !exist {
+		t.Errorf("dealSendToCloud() failed to hold message in ConfirmMap")
+	}
+
+	err = dealBackpressure(dtContext, "", &model.Message{Content: messagepkg.OutboundQueueAvailable})
+	if err != nil || dtContext.OutboundQueueFull {
+		t.Errorf("dealBackpressure() error = %v, expected outbound queue available", err)
+	}
+	if err := dealBackpressure(dtContext, "", ""); !reflect.DeepEqual(err, errors.New("msg not Message type")) {
+		t.Errorf("dealBackpressure() error = %v, expected wrong message format", err)
+	}
+}
+
 // TestDealConfirm is function to test dealConfirm().
 func TestDealConfirm(t *testing.T) {
 	beehiveContext.InitContext([]string{common.MsgCtxTypeChannel})
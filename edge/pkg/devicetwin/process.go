@@ -13,6 +13,7 @@ import (
 
 	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
 	"github.com/kubeedge/beehive/pkg/core/model"
+	messagepkg "github.com/kubeedge/kubeedge/edge/pkg/common/message"
 	"github.com/kubeedge/kubeedge/edge/pkg/devicetwin/dtclient"
 	"github.com/kubeedge/kubeedge/edge/pkg/devicetwin/dtcommon"
 	"github.com/kubeedge/kubeedge/edge/pkg/devicetwin/dtcontext"
@@ -108,6 +109,7 @@ func initActionModuleMap() {
 	ActionModuleMap[dtcommon.Connected] = dtcommon.CommModule
 	ActionModuleMap[dtcommon.Disconnected] = dtcommon.CommModule
 	ActionModuleMap[dtcommon.LifeCycle] = dtcommon.CommModule
+	ActionModuleMap[dtcommon.Backpressure] = dtcommon.CommModule
 	ActionModuleMap[dtcommon.Confirm] = dtcommon.CommModule
 	ActionModuleMap[dtcommon.MetaDeviceOperation] = dtcommon.DMIModule
 }
@@ -263,6 +265,10 @@ func classifyMsg(message *dttype.DTMessage) bool {
 			message.Action = dtcommon.LifeCycle
 			return true
 		}
+		if strings.HasPrefix(message.Msg.Router.Resource, messagepkg.ResourceTypeOutboundQueue) {
+			message.Action = dtcommon.Backpressure
+			return true
+		}
 		return false
 	} else if strings.Compare(msgSource, "meta") == 0 {
 		switch message.Msg.Content.(type) {
@@ -0,0 +1,370 @@
+package bandwidth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+
+	"github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha2"
+)
+
+// the names of the budget windows
+const (
+	WindowHourly = "hourly"
+	WindowDaily  = "daily"
+)
+
+var (
+	budgetBytes = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      "edgehub",
+			Name:           "metered_link_budget_bytes",
+			Help:           "Bytes allowed to send to cloud in the budget window, partitioned by window.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"window"},
+	)
+	usedBytes = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      "edgehub",
+			Name:           "metered_link_used_bytes",
+			Help:           "Bytes sent to cloud in the current budget window, partitioned by window and message group.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"window", "group"},
+	)
+	deferredMessages = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      "edgehub",
+			Name:           "metered_link_deferred_messages_total",
+			Help:           "Number of the messages deferred since the budgets are used up, partitioned by message group.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"group"},
+	)
+	registerMetricsOnce sync.Once
+)
+
+// Usage is the usage of a budget window
+type Usage struct {
+	Window     string           `json:"window"`
+	LimitBytes int64            `json:"limitBytes"`
+	UsedBytes  int64            `json:"usedBytes"`
+	GroupBytes map[string]int64 `json:"groupBytes,omitempty"`
+	ResetTime  time.Time        `json:"resetTime"`
+}
+
+// windowState is the usage of a budget window kept in the state file
+type windowState struct {
+	Name  string           `json:"name"`
+	From  time.Time        `json:"from"`
+	Used  int64            `json:"used"`
+	Group map[string]int64 `json:"group,omitempty"`
+}
+
+// window is a budget of bytes in a period of time
+type window struct {
+	name  string
+	limit int64
+	// start returns the start time of the window containing t
+	start func(t time.Time) time.Time
+	// end returns the end time of the window starting at t
+	end   func(t time.Time) time.Time
+	from  time.Time
+	to    time.Time
+	used  int64
+	group map[string]int64
+}
+
+func (w *window) roll(now time.Time) {
+	if !w.to.IsZero() && now.Before(w.to) {
+		return
+	}
+	for group := range w.group {
+		usedBytes.DeleteLabelValues(w.name, group)
+	}
+	w.from = w.start(now)
+	w.to = w.end(w.from)
+	w.used = 0
+	w.group = make(map[string]int64)
+}
+
+// bucket is a token bucket of bytes
+type bucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate, burst float64) *bucket {
+	return &bucket{rate: rate, burst: burst, tokens: burst}
+}
+
+func (b *bucket) refill(now time.Time) {
+	if !b.last.IsZero() {
+		b.tokens += b.rate * now.Sub(b.last).Seconds()
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+}
+
+// wait returns the time to wait for the tokens of size,
+// the size larger than the burst waits for a full bucket
+func (b *bucket) wait(size float64) time.Duration {
+	if size > b.burst {
+		size = b.burst
+	}
+	if b.tokens >= size {
+		return 0
+	}
+	return time.Duration((size - b.tokens) / b.rate * float64(time.Second))
+}
+
+// Meter accounts the bytes sent to cloud within the budgets of the metered link
+type Meter struct {
+	lock     sync.Mutex
+	windows  []*window
+	bucket   *bucket
+	buckets  map[string]*bucket
+	shares   map[string]float64
+	deferred map[string]bool
+	now      func() time.Time
+	// statePath is the file keeping the usage of the windows, empty if the usage is kept in memory only
+	statePath string
+}
+
+// NewMeter returns the Meter of the config, nil is returned if the metered link is disabled
+func NewMeter(c *v1alpha2.EdgeHubMeteredLink) *Meter {
+	if c == nil || !c.Enable {
+		return nil
+	}
+	registerMetricsOnce.Do(func() {
+		legacyregistry.MustRegister(budgetBytes, usedBytes, deferredMessages)
+	})
+
+	m := &Meter{
+		buckets:   make(map[string]*bucket),
+		shares:    make(map[string]float64),
+		deferred:  make(map[string]bool),
+		now:       time.Now,
+		statePath: c.StatePath,
+	}
+	for group, share := range c.GroupShares {
+		m.shares[group] = float64(share) / 100
+	}
+	for _, group := range c.DeferredGroups {
+		m.deferred[group] = true
+	}
+	if c.HourlyBytes > 0 {
+		m.windows = append(m.windows, &window{
+			name:  WindowHourly,
+			limit: c.HourlyBytes,
+			start: func(t time.Time) time.Time { return t.Truncate(time.Hour) },
+			end:   func(t time.Time) time.Time { return t.Add(time.Hour) },
+		})
+	}
+	if c.DailyBytes > 0 {
+		m.windows = append(m.windows, &window{
+			name:  WindowDaily,
+			limit: c.DailyBytes,
+			start: func(t time.Time) time.Time {
+				return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+			},
+			end: func(t time.Time) time.Time { return t.AddDate(0, 0, 1) },
+		})
+	}
+	for _, w := range m.windows {
+		budgetBytes.WithLabelValues(w.name).Set(float64(w.limit))
+	}
+	if err := m.load(); err != nil {
+		klog.Warningf("[edgehub/bandwidth] failed to load usage of metered link, budgets are reset: %v", err)
+	}
+	if c.BytesPerSecond > 0 {
+		burst := c.BurstBytes
+		if burst == 0 {
+			burst = c.BytesPerSecond
+		}
+		m.bucket = newBucket(float64(c.BytesPerSecond), float64(burst))
+		for group, share := range m.shares {
+			m.buckets[group] = newBucket(float64(c.BytesPerSecond)*share, float64(burst)*share)
+		}
+	}
+	return m
+}
+
+// Deferrable reports whether the messages of the group are deferred once the budgets are used up
+func (m *Meter) Deferrable(group string) bool {
+	return m.deferred[group]
+}
+
+// Reserve accounts the message of the group with size bytes. It returns the time to wait if
+// the message is deferrable and the budgets are used up, the message is not accounted then.
+func (m *Meter) Reserve(group string, size int) time.Duration {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	now := m.now()
+	var wait time.Duration
+	later := func(d time.Duration) {
+		if d > wait {
+			wait = d
+		}
+	}
+	// the message larger than a budget is allowed at the start of a window
+	for _, w := range m.windows {
+		w.roll(now)
+		if w.used > 0 && w.used+int64(size) > w.limit {
+			later(w.to.Sub(now))
+		}
+		used := w.group[group]
+		if share, ok := m.shares[group]; ok && used > 0 && float64(used+int64(size)) > float64(w.limit)*share {
+			later(w.to.Sub(now))
+		}
+	}
+	buckets := m.groupBuckets(group)
+	for _, b := range buckets {
+		b.refill(now)
+		later(b.wait(float64(size)))
+	}
+
+	if wait > 0 && m.deferred[group] {
+		return wait
+	}
+	for _, w := range m.windows {
+		w.used += int64(size)
+		w.group[group] += int64(size)
+		usedBytes.WithLabelValues(w.name, group).Set(float64(w.group[group]))
+	}
+	for _, b := range buckets {
+		b.tokens -= float64(size)
+	}
+	return 0
+}
+
+func (m *Meter) groupBuckets(group string) []*bucket {
+	if m.bucket == nil {
+		return nil
+	}
+	if b, ok := m.buckets[group]; ok {
+		return []*bucket{m.bucket, b}
+	}
+	return []*bucket{m.bucket}
+}
+
+// Deferred records a message of the group deferred
+func (m *Meter) Deferred(group string) {
+	deferredMessages.WithLabelValues(group).Inc()
+}
+
+// Usage returns the usage of the budget windows
+func (m *Meter) Usage() []Usage {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	now := m.now()
+	usage := make([]Usage, 0, len(m.windows))
+	for _, w := range m.windows {
+		w.roll(now)
+		groups := make(map[string]int64, len(w.group))
+		for group, used := range w.group {
+			groups[group] = used
+		}
+		usage = append(usage, Usage{
+			Window:     w.name,
+			LimitBytes: w.limit,
+			UsedBytes:  w.used,
+			GroupBytes: groups,
+			ResetTime:  w.to.UTC(),
+		})
+	}
+	return usage
+}
+
+// Save writes the usage of the budget windows to the state file, so it is loaded after restart
+func (m *Meter) Save() error {
+	if m.statePath == "" {
+		return nil
+	}
+	m.lock.Lock()
+	states := make([]windowState, 0, len(m.windows))
+	for _, w := range m.windows {
+		groups := make(map[string]int64, len(w.group))
+		for group, used := range w.group {
+			groups[group] = used
+		}
+		states = append(states, windowState{Name: w.name, From: w.from, Used: w.used, Group: groups})
+	}
+	m.lock.Unlock()
+
+	data, err := json.Marshal(states)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.statePath), 0700); err != nil {
+		return err
+	}
+	tmp := m.statePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.statePath)
+}
+
+// load restores the usage of the budget windows from the state file,
+// the usage of a window passed is reset when the window is used
+func (m *Meter) load() error {
+	if m.statePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(m.statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var states []windowState
+	if err := json.Unmarshal(data, &states); err != nil {
+		return fmt.Errorf("failed to unmarshal %s: %v", m.statePath, err)
+	}
+	for _, state := range states {
+		for _, w := range m.windows {
+			// the state not aligned to the window is corrupted or of another config, it is ignored
+			if w.name != state.Name || state.From.IsZero() || !w.start(state.From).Equal(state.From) {
+				continue
+			}
+			w.from = state.From
+			w.to = w.end(state.From)
+			w.used = state.Used
+			w.group = state.Group
+			if w.group == nil {
+				w.group = make(map[string]int64)
+			}
+			for group, used := range w.group {
+				usedBytes.WithLabelValues(w.name, group).Set(float64(used))
+			}
+		}
+	}
+	return nil
+}
+
+// MessageSize returns the size of the message sent to cloud before compression
+func MessageSize(msg model.Message) int {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
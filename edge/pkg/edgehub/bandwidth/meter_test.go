@@ -0,0 +1,140 @@
+package bandwidth
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha2"
+)
+
+// newTestMeter returns the meter of the config with the clock returned by the pointer
+func newTestMeter(t *testing.T, c v1alpha2.EdgeHubMeteredLink) (*Meter, *time.Time) {
+	t.Helper()
+	c.Enable = true
+	now := time.Date(2022, 6, 1, 10, 30, 0, 0, time.UTC)
+	m := NewMeter(&c)
+	m.now = func() time.Time { return now }
+	return m, &now
+}
+
+func TestNewMeterDisabled(t *testing.T) {
+	if m := NewMeter(&v1alpha2.EdgeHubMeteredLink{Enable: false, HourlyBytes: 100}); m != nil {
+		t.Errorf("expected nil meter if disabled, but got %v", m)
+	}
+}
+
+func TestMeterWindow(t *testing.T) {
+	m, now := newTestMeter(t, v1alpha2.EdgeHubMeteredLink{
+		HourlyBytes:    1000,
+		DeferredGroups: []string{"user"},
+	})
+
+	if wait := m.Reserve("user", 600); wait != 0 {
+		t.Errorf("expected user message sent, but waits %v", wait)
+	}
+	// the deferrable message is deferred until the next hour
+	if wait := m.Reserve("user", 600); wait != 30*time.Minute {
+		t.Errorf("expected user message deferred for 30m, but waits %v", wait)
+	}
+	// the other messages are sent over the budget
+	if wait := m.Reserve("resource", 600); wait != 0 {
+		t.Errorf("expected resource message sent, but waits %v", wait)
+	}
+	usage := m.Usage()
+	if len(usage) != 1 || usage[0].UsedBytes != 1200 || usage[0].GroupBytes["user"] != 600 {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+
+	*now = now.Add(30 * time.Minute)
+	if wait := m.Reserve("user", 600); wait != 0 {
+		t.Errorf("expected user message sent in the next window, but waits %v", wait)
+	}
+	usage = m.Usage()
+	if usage[0].UsedBytes != 600 || !usage[0].ResetTime.Equal(now.Add(time.Hour)) {
+		t.Errorf("unexpected usage in the next window %+v", usage)
+	}
+
+	// the message larger than the budget is sent at the start of a window
+	*now = now.Add(time.Hour)
+	if wait := m.Reserve("user", 2000); wait != 0 {
+		t.Errorf("expected large message sent at the start of window, but waits %v", wait)
+	}
+}
+
+func TestMeterGroupShares(t *testing.T) {
+	m, _ := newTestMeter(t, v1alpha2.EdgeHubMeteredLink{
+		DailyBytes:     1000,
+		GroupShares:    map[string]int32{"twin": 20},
+		DeferredGroups: []string{"twin", "user"},
+	})
+
+	if wait := m.Reserve("twin", 150); wait != 0 {
+		t.Errorf("expected twin message sent, but waits %v", wait)
+	}
+	// the share of twin is used up, but the budget is not
+	if wait := m.Reserve("twin", 100); wait != 13*time.Hour+30*time.Minute {
+		t.Errorf("expected twin message deferred until the next day, but waits %v", wait)
+	}
+	if wait := m.Reserve("user", 100); wait != 0 {
+		t.Errorf("expected user message sent, but waits %v", wait)
+	}
+}
+
+func TestMeterRate(t *testing.T) {
+	m, now := newTestMeter(t, v1alpha2.EdgeHubMeteredLink{
+		BytesPerSecond: 100,
+		BurstBytes:     200,
+		DeferredGroups: []string{"user"},
+	})
+
+	if wait := m.Reserve("user", 200); wait != 0 {
+		t.Errorf("expected burst sent, but waits %v", wait)
+	}
+	if wait := m.Reserve("user", 50); wait != 500*time.Millisecond {
+		t.Errorf("expected user message deferred for 500ms, but waits %v", wait)
+	}
+	// the other messages are sent and take the tokens of the bucket
+	if wait := m.Reserve("resource", 100); wait != 0 {
+		t.Errorf("expected resource message sent, but waits %v", wait)
+	}
+	*now = now.Add(time.Second)
+	if wait := m.Reserve("user", 50); wait != 500*time.Millisecond {
+		t.Errorf("expected user message deferred for 500ms, but waits %v", wait)
+	}
+	*now = now.Add(time.Second)
+	if wait := m.Reserve("user", 50); wait != 0 {
+		t.Errorf("expected user message sent, but waits %v", wait)
+	}
+}
+
+func TestMeterSave(t *testing.T) {
+	c := v1alpha2.EdgeHubMeteredLink{
+		HourlyBytes:    1000,
+		DailyBytes:     5000,
+		DeferredGroups: []string{"user"},
+		StatePath:      filepath.Join(t.TempDir(), "metered-link.json"),
+	}
+	m, now := newTestMeter(t, c)
+	m.Reserve("user", 600)
+	if err := m.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// the budgets used before restart are kept
+	restored, _ := newTestMeter(t, c)
+	if wait := restored.Reserve("user", 600); wait != 30*time.Minute {
+		t.Errorf("expected user message deferred for 30m after restart, but waits %v", wait)
+	}
+	usage := restored.Usage()
+	if len(usage) != 2 || usage[0].GroupBytes["user"] != 600 || usage[1].UsedBytes != 600 {
+		t.Errorf("unexpected usage after restart %+v", usage)
+	}
+
+	// the usage of the window passed is reset
+	restored, later := newTestMeter(t, c)
+	*later = now.Add(time.Hour)
+	if wait := restored.Reserve("user", 600); wait != 0 {
+		t.Errorf("expected user message sent in the next window, but waits %v", wait)
+	}
+}
@@ -56,7 +56,9 @@ type CertManager struct {
 
 	caURL   string
 	certURL string
-	Done    chan struct{}
+	// proxy is the proxy to request the certificates, nil means no proxy
+	proxy http.ProxyFunc
+	Done  chan struct{}
 }
 
 // NewCertManager creates a CertManager for edge certificate management according to EdgeHub config
@@ -81,6 +83,7 @@ func NewCertManager(edgehub v1alpha2.EdgeHub, nodename string) CertManager {
 		now:                time.Now,
 		caURL:              edgehub.HTTPServer + constants.DefaultCAURL,
 		certURL:            edgehub.HTTPServer + constants.DefaultCertURL,
+		proxy:              http.NewProxyFunc(edgehub.Proxy),
 		Done:               make(chan struct{}),
 	}
 }
@@ -115,7 +118,7 @@ func (cm *CertManager) getCurrent() (*tls.Certificate, error) {
 
 // applyCerts realizes the certificate application by token
 func (cm *CertManager) applyCerts() error {
-	cacert, err := GetCACert(cm.caURL)
+	cacert, err := GetCACert(cm.caURL, cm.proxy)
 	if err != nil {
 		return fmt.Errorf("failed to get CA certificate, err: %v", err)
 	}
@@ -242,9 +245,9 @@ func (cm *CertManager) getCA() ([]byte, error) {
 	return os.ReadFile(cm.caFile)
 }
 
-// GetCACert gets the cloudcore CA certificate
-func GetCACert(url string) ([]byte, error) {
-	client := http.NewHTTPClient()
+// GetCACert gets the cloudcore CA certificate, through the proxy if it is not nil
+func GetCACert(url string, proxy http.ProxyFunc) ([]byte, error) {
+	client := http.WithProxy(http.NewHTTPClient(), proxy)
 	req, err := http.BuildRequest(nethttp.MethodGet, url, nil, "", "")
 	if err != nil {
 		return nil, err
@@ -273,6 +276,7 @@ func (cm *CertManager) GetEdgeCert(url string, capem []byte, cert tls.Certificat
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create http client:%v", err)
 	}
+	client = http.WithProxy(client, cm.proxy)
 
 	req, err := http.BuildRequest(nethttp.MethodGet, url, bytes.NewReader(csr), token, cm.NodeName)
 	if err != nil {
@@ -6,39 +6,44 @@ import (
 
 	"github.com/kubeedge/kubeedge/edge/pkg/edgehub/clients/quicclient"
 	"github.com/kubeedge/kubeedge/edge/pkg/edgehub/clients/wsclient"
+	"github.com/kubeedge/kubeedge/edge/pkg/edgehub/common/http"
 	"github.com/kubeedge/kubeedge/edge/pkg/edgehub/config"
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha2"
 )
 
-//GetClient returns an Adapter object with new web socket
-func GetClient() (Adapter, error) {
+//GetClient returns an Adapter object connecting to the endpoint
+func GetClient(endpoint v1alpha2.EdgeHubEndpoint) (Adapter, error) {
 	config := config.Config
-	switch {
-	case config.WebSocket.Enable:
+	switch endpoint.Protocol {
+	case v1alpha2.ProtocolWebSocket:
 		websocketConf := wsclient.WebSocketConfig{
-			URL:              config.WebSocketURL,
-			CertFilePath:     config.TLSCertFile,
-			KeyFilePath:      config.TLSPrivateKeyFile,
-			HandshakeTimeout: time.Duration(config.WebSocket.HandshakeTimeout) * time.Second,
-			ReadDeadline:     time.Duration(config.WebSocket.ReadDeadline) * time.Second,
-			WriteDeadline:    time.Duration(config.WebSocket.WriteDeadline) * time.Second,
-			ProjectID:        config.ProjectID,
-			NodeID:           config.NodeName,
+			URL:               config.WebSocketURLOf(endpoint.Server),
+			CertFilePath:      config.TLSCertFile,
+			KeyFilePath:       config.TLSPrivateKeyFile,
+			HandshakeTimeout:  time.Duration(config.WebSocket.HandshakeTimeout) * time.Second,
+			ReadDeadline:      time.Duration(config.WebSocket.ReadDeadline) * time.Second,
+			WriteDeadline:     time.Duration(config.WebSocket.WriteDeadline) * time.Second,
+			ProjectID:         config.ProjectID,
+			NodeID:            config.NodeName,
+			CompressThreshold: config.CompressThreshold(),
+			Proxy:             http.NewProxyFunc(config.Proxy),
 		}
 		return wsclient.NewWebSocketClient(&websocketConf), nil
-	case config.Quic.Enable:
+	case v1alpha2.ProtocolQuic:
 		quicConfig := quicclient.QuicConfig{
-			Addr:             config.Quic.Server,
-			CaFilePath:       config.TLSCAFile,
-			CertFilePath:     config.TLSCertFile,
-			KeyFilePath:      config.TLSPrivateKeyFile,
-			HandshakeTimeout: time.Duration(config.Quic.HandshakeTimeout) * time.Second,
-			ReadDeadline:     time.Duration(config.Quic.ReadDeadline) * time.Second,
-			WriteDeadline:    time.Duration(config.Quic.WriteDeadline) * time.Second,
-			ProjectID:        config.ProjectID,
-			NodeID:           config.NodeName,
+			Addr:              endpoint.Server,
+			CaFilePath:        config.TLSCAFile,
+			CertFilePath:      config.TLSCertFile,
+			KeyFilePath:       config.TLSPrivateKeyFile,
+			HandshakeTimeout:  time.Duration(config.Quic.HandshakeTimeout) * time.Second,
+			ReadDeadline:      time.Duration(config.Quic.ReadDeadline) * time.Second,
+			WriteDeadline:     time.Duration(config.Quic.WriteDeadline) * time.Second,
+			ProjectID:         config.ProjectID,
+			NodeID:            config.NodeName,
+			CompressThreshold: config.CompressThreshold(),
 		}
 		return quicclient.NewQuicClient(&quicConfig), nil
 	}
 
-	return nil, fmt.Errorf("Websocket and Quic are both disabled")
+	return nil, fmt.Errorf("unsupported protocol %q of endpoint %s", endpoint.Protocol, endpoint.Server)
 }
@@ -11,6 +11,7 @@ import (
 	"k8s.io/klog/v2"
 
 	"github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/kubeedge/pkg/util"
 	"github.com/kubeedge/viaduct/pkg/api"
 	qclient "github.com/kubeedge/viaduct/pkg/client"
 	"github.com/kubeedge/viaduct/pkg/conn"
@@ -33,6 +34,8 @@ type QuicConfig struct {
 	WriteDeadline    time.Duration
 	NodeID           string
 	ProjectID        string
+	// the payloads not smaller than CompressThreshold are compressed, 0 disables compression
+	CompressThreshold int
 }
 
 // NewQuicClient initializes a new quic client instance
@@ -63,10 +66,12 @@ func (qcc *QuicClient) Init() error {
 	}
 
 	option := qclient.Options{
-		HandshakeTimeout: qcc.config.HandshakeTimeout,
-		TLSConfig:        tlsConfig,
-		Type:             api.ProtocolTypeQuic,
-		Addr:             qcc.config.Addr,
+		HandshakeTimeout:  qcc.config.HandshakeTimeout,
+		TLSConfig:         tlsConfig,
+		Type:              api.ProtocolTypeQuic,
+		Addr:              qcc.config.Addr,
+		CompressThreshold: qcc.config.CompressThreshold,
+		Classify:          util.ClassifyMessage,
 	}
 	exOpts := api.QuicClientOption{Header: make(http.Header)}
 	exOpts.Header.Set("node_id", qcc.config.NodeID)
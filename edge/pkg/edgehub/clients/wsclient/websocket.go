@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"time"
 
@@ -13,8 +14,10 @@ import (
 
 	"github.com/kubeedge/beehive/pkg/core/model"
 	"github.com/kubeedge/kubeedge/edge/pkg/edgehub/config"
+	"github.com/kubeedge/kubeedge/pkg/util"
 	"github.com/kubeedge/viaduct/pkg/api"
 	wsclient "github.com/kubeedge/viaduct/pkg/client"
+	"github.com/kubeedge/viaduct/pkg/comm"
 	"github.com/kubeedge/viaduct/pkg/conn"
 )
 
@@ -39,6 +42,10 @@ type WebSocketConfig struct {
 	WriteDeadline    time.Duration
 	NodeID           string
 	ProjectID        string
+	// the payloads not smaller than CompressThreshold are compressed, 0 disables compression
+	CompressThreshold int
+	// Proxy returns the proxy to connect cloudcore, connect directly if it is nil
+	Proxy func(*http.Request) (*url.URL, error)
 }
 
 // NewWebSocketClient initializes a new websocket client instance
@@ -72,20 +79,32 @@ func (wsc *WebSocketClient) Init() error {
 	}
 
 	option := wsclient.Options{
-		HandshakeTimeout: wsc.config.HandshakeTimeout,
-		TLSConfig:        tlsConfig,
-		Type:             api.ProtocolTypeWS,
-		Addr:             wsc.config.URL,
-		AutoRoute:        false,
-		ConnUse:          api.UseTypeMessage,
+		HandshakeTimeout:  wsc.config.HandshakeTimeout,
+		TLSConfig:         tlsConfig,
+		Type:              api.ProtocolTypeWS,
+		Addr:              wsc.config.URL,
+		AutoRoute:         false,
+		ConnUse:           api.UseTypeMessage,
+		CompressThreshold: wsc.config.CompressThreshold,
+		Classify:          util.ClassifyMessage,
 	}
-	exOpts := api.WSClientOption{Header: make(http.Header)}
+	exOpts := api.WSClientOption{Header: make(http.Header), Proxy: wsc.config.Proxy}
 	exOpts.Header.Set("node_id", wsc.config.NodeID)
 	exOpts.Header.Set("project_id", wsc.config.ProjectID)
 	client := &wsclient.Client{Options: option, ExOpts: exOpts}
 
 	for i := 0; i < retryCount; i++ {
 		connection, err := client.Connect()
+		var retryErr *comm.RetryAfterError
+		if errors.As(err, &retryErr) {
+			// the cloud is busy, retrying at once makes it worse
+			return err
+		}
+		var redirectErr *comm.RedirectError
+		if errors.As(err, &redirectErr) {
+			// the node is owned by another cloudcore
+			return err
+		}
 		if err != nil {
 			klog.Errorf("Init websocket connection failed %s", err.Error())
 		} else {
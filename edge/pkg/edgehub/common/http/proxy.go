@@ -0,0 +1,82 @@
+package http
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha2"
+)
+
+// ProxyFunc returns the url of the proxy to send the request, nil means no proxy
+type ProxyFunc func(*http.Request) (*url.URL, error)
+
+// NewProxyFunc returns the ProxyFunc of the proxy config, nil is returned if the proxy is disabled.
+// The proxy is taken from the environment variables if the url of the proxy is not configured.
+func NewProxyFunc(p *v1alpha2.EdgeHubProxy) ProxyFunc {
+	if p == nil || !p.Enable {
+		return nil
+	}
+	proxy := http.ProxyFromEnvironment
+	if p.URL != "" {
+		proxyURL, err := url.Parse(p.URL)
+		if err != nil {
+			return func(*http.Request) (*url.URL, error) {
+				return nil, err
+			}
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+	return func(req *http.Request) (*url.URL, error) {
+		if matchNoProxy(req.URL.Hostname(), p.NoProxy) {
+			return nil, nil
+		}
+		proxyURL, err := proxy(req)
+		if err != nil || proxyURL == nil {
+			return proxyURL, err
+		}
+		if p.Username != "" {
+			withUser := *proxyURL
+			withUser.User = url.UserPassword(p.Username, p.Password)
+			return &withUser, nil
+		}
+		return proxyURL, nil
+	}
+}
+
+// WithProxy sets the proxy of the client
+func WithProxy(client *http.Client, proxy ProxyFunc) *http.Client {
+	if tr, ok := client.Transport.(*http.Transport); ok && proxy != nil {
+		tr.Proxy = proxy
+	}
+	return client
+}
+
+// matchNoProxy reports whether the host is in the list of hosts to connect directly
+func matchNoProxy(host string, noProxy []string) bool {
+	host = strings.ToLower(host)
+	ip := net.ParseIP(host)
+	for _, entry := range noProxy {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case entry == "*":
+			return true
+		case ip != nil:
+			if _, cidr, err := net.ParseCIDR(entry); err == nil && cidr.Contains(ip) {
+				return true
+			}
+			if entryIP := net.ParseIP(entry); entryIP != nil && entryIP.Equal(ip) {
+				return true
+			}
+		default:
+			domain := strings.TrimPrefix(entry, ".")
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return true
+			}
+		}
+	}
+	return false
+}
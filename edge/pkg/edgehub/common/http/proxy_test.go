@@ -0,0 +1,291 @@
+package http
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha2"
+)
+
+const (
+	proxyUser     = "edge"
+	proxyPassword = "secret"
+)
+
+// pipe copies the data between the connections until one of them is closed
+func pipe(a, b net.Conn) {
+	go func() {
+		_, _ = io.Copy(a, b)
+		a.Close()
+	}()
+	_, _ = io.Copy(b, a)
+	b.Close()
+}
+
+// newConnectProxy starts a HTTP CONNECT proxy with basic authentication
+func newConnectProxy(tunnels *int32) *httptest.Server {
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(proxyUser+":"+proxyPassword))
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Proxy-Authorization") != auth {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			target.Close()
+			return
+		}
+		if _, err := conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+			conn.Close()
+			target.Close()
+			return
+		}
+		atomic.AddInt32(tunnels, 1)
+		pipe(conn, target)
+	}))
+}
+
+// newSocks5Proxy starts a SOCKS5 proxy with username/password authentication
+func newSocks5Proxy(t *testing.T, tunnels *int32) net.Listener {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSocks5(conn, tunnels)
+		}
+	}()
+	return ln
+}
+
+func serveSocks5(conn net.Conn, tunnels *int32) {
+	read := func(n int) []byte {
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return nil
+		}
+		return buf
+	}
+	// greeting, only username/password authentication is accepted
+	greeting := read(2)
+	if greeting == nil || greeting[0] != 5 {
+		conn.Close()
+		return
+	}
+	if _, err := conn.Write([]byte{5, 2}); err != nil || read(int(greeting[1])) == nil {
+		conn.Close()
+		return
+	}
+	// username/password authentication
+	version := read(2)
+	if version == nil {
+		conn.Close()
+		return
+	}
+	user := string(read(int(version[1])))
+	password := string(read(int(read(1)[0])))
+	if user != proxyUser || password != proxyPassword {
+		_, _ = conn.Write([]byte{1, 1})
+		conn.Close()
+		return
+	}
+	if _, err := conn.Write([]byte{1, 0}); err != nil {
+		conn.Close()
+		return
+	}
+	// connect request
+	req := read(4)
+	if req == nil || req[1] != 1 {
+		conn.Close()
+		return
+	}
+	var host string
+	switch req[3] {
+	case 1:
+		host = net.IP(read(4)).String()
+	case 3:
+		host = string(read(int(read(1)[0])))
+	case 4:
+		host = net.IP(read(16)).String()
+	}
+	port := binary.BigEndian.Uint16(read(2))
+	target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+	if err != nil {
+		_, _ = conn.Write([]byte{5, 4, 0, 1, 0, 0, 0, 0, 0, 0})
+		conn.Close()
+		return
+	}
+	if _, err := conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0}); err != nil {
+		conn.Close()
+		target.Close()
+		return
+	}
+	atomic.AddInt32(tunnels, 1)
+	pipe(conn, target)
+}
+
+// newCloudServer starts a https server which echoes the websocket messages
+func newCloudServer() *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !websocket.IsWebSocketUpgrade(r) {
+			_, _ = w.Write([]byte("ca"))
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			mt, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(mt, data); err != nil {
+				return
+			}
+		}
+	}))
+}
+
+// TestProxy tests the https requests and the websocket connections through the proxies
+func TestProxy(t *testing.T) {
+	cloud := newCloudServer()
+	defer cloud.Close()
+
+	var connectTunnels, socks5Tunnels int32
+	connectProxy := newConnectProxy(&connectTunnels)
+	defer connectProxy.Close()
+	socks5Proxy := newSocks5Proxy(t, &socks5Tunnels)
+	defer socks5Proxy.Close()
+
+	tests := []struct {
+		name     string
+		proxy    v1alpha2.EdgeHubProxy
+		tunnels  *int32
+		wantFail bool
+	}{
+		{
+			name:    "http connect",
+			proxy:   v1alpha2.EdgeHubProxy{Enable: true, URL: connectProxy.URL, Username: proxyUser, Password: proxyPassword},
+			tunnels: &connectTunnels,
+		},
+		{
+			name:     "http connect with wrong password",
+			proxy:    v1alpha2.EdgeHubProxy{Enable: true, URL: connectProxy.URL, Username: proxyUser, Password: "wrong"},
+			tunnels:  &connectTunnels,
+			wantFail: true,
+		},
+		{
+			name:    "socks5",
+			proxy:   v1alpha2.EdgeHubProxy{Enable: true, URL: "socks5://" + socks5Proxy.Addr().String(), Username: proxyUser, Password: proxyPassword},
+			tunnels: &socks5Tunnels,
+		},
+		{
+			name:    "no proxy",
+			proxy:   v1alpha2.EdgeHubProxy{Enable: true, URL: connectProxy.URL, NoProxy: []string{"127.0.0.0/8"}},
+			tunnels: &connectTunnels,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := NewProxyFunc(&tt.proxy)
+			before := atomic.LoadInt32(tt.tunnels)
+
+			// the https request of the certificate
+			client := WithProxy(NewHTTPClient(), proxy)
+			defer client.CloseIdleConnections()
+			resp, err := client.Get(cloud.URL)
+			if err == nil {
+				body, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				if string(body) != "ca" {
+					t.Errorf("expected body ca, but got %s", body)
+				}
+			}
+			if (err != nil) != tt.wantFail {
+				t.Fatalf("expected failure %v, but got error %v", tt.wantFail, err)
+			}
+
+			// the websocket connection to cloudcore
+			dialer := websocket.Dialer{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+				Proxy:           proxy,
+			}
+			conn, _, err := dialer.Dial("wss"+cloud.URL[len("https"):], nil)
+			if (err != nil) != tt.wantFail {
+				t.Fatalf("expected failure %v, but got error %v", tt.wantFail, err)
+			}
+			if err == nil {
+				defer conn.Close()
+				if err := conn.WriteMessage(websocket.TextMessage, []byte("ping")); err != nil {
+					t.Fatalf("failed to write message: %v", err)
+				}
+				if _, data, err := conn.ReadMessage(); err != nil || string(data) != "ping" {
+					t.Errorf("expected echo ping, but got %s, error %v", data, err)
+				}
+			}
+
+			wantTunnels := int32(2)
+			if tt.wantFail || len(tt.proxy.NoProxy) > 0 {
+				wantTunnels = 0
+			}
+			if got := atomic.LoadInt32(tt.tunnels) - before; got != wantTunnels {
+				t.Errorf("expected %d tunnels through the proxy, but got %d", wantTunnels, got)
+			}
+		})
+	}
+}
+
+func TestNewProxyFuncDisabled(t *testing.T) {
+	if NewProxyFunc(nil) != nil || NewProxyFunc(&v1alpha2.EdgeHubProxy{URL: "http://proxy:3128"}) != nil {
+		t.Errorf("expected nil ProxyFunc if the proxy is disabled")
+	}
+}
+
+func TestMatchNoProxy(t *testing.T) {
+	noProxy := []string{"10.0.0.0/8", "192.168.1.1", "example.com", ".internal"}
+	tests := map[string]bool{
+		"10.1.2.3":          true,
+		"192.168.1.1":       true,
+		"192.168.1.2":       false,
+		"example.com":       true,
+		"cloud.example.com": true,
+		"badexample.com":    false,
+		"cloud.internal":    true,
+		"kubeedge.io":       false,
+	}
+	for host, want := range tests {
+		if got := matchNoProxy(host, noProxy); got != want {
+			t.Errorf("matchNoProxy(%q) = %v, want %v", host, got, want)
+		}
+	}
+	if !matchNoProxy("kubeedge.io", []string{"*"}) {
+		t.Errorf("expected all hosts matched by *")
+	}
+}
@@ -14,14 +14,54 @@ type Configure struct {
 	v1alpha2.EdgeHub
 	WebSocketURL string
 	NodeName     string
+	// Endpoints are the cloudcore endpoints to connect, it is built from the servers
+	// of websocket or quic if endpoints are not configured
+	Endpoints []v1alpha2.EdgeHubEndpoint
 }
 
 func InitConfigure(eh *v1alpha2.EdgeHub, nodeName string) {
 	once.Do(func() {
 		Config = Configure{
-			EdgeHub:      *eh,
-			WebSocketURL: strings.Join([]string{"wss:/", eh.WebSocket.Server, eh.ProjectID, nodeName, "events"}, "/"),
-			NodeName:     nodeName,
+			EdgeHub:   *eh,
+			NodeName:  nodeName,
+			Endpoints: endpoints(eh),
 		}
+		Config.WebSocketURL = Config.WebSocketURLOf(eh.WebSocket.Server)
 	})
 }
+
+// WebSocketURLOf returns the url of the websocket server
+func (c *Configure) WebSocketURLOf(server string) string {
+	return strings.Join([]string{"wss:/", server, c.ProjectID, c.NodeName, "events"}, "/")
+}
+
+// CompressThreshold returns the min size of the payloads to compress, 0 disables compression
+func (c *Configure) CompressThreshold() int {
+	if c.Compression != nil && c.Compression.Enable {
+		return int(c.Compression.Threshold)
+	}
+	return 0
+}
+
+func endpoints(eh *v1alpha2.EdgeHub) []v1alpha2.EdgeHubEndpoint {
+	if len(eh.Endpoints) == 0 {
+		switch {
+		case eh.WebSocket != nil && eh.WebSocket.Enable:
+			return []v1alpha2.EdgeHubEndpoint{{Protocol: v1alpha2.ProtocolWebSocket, Server: eh.WebSocket.Server, Weight: 1}}
+		case eh.Quic != nil && eh.Quic.Enable:
+			return []v1alpha2.EdgeHubEndpoint{{Protocol: v1alpha2.ProtocolQuic, Server: eh.Quic.Server, Weight: 1}}
+		}
+		return nil
+	}
+	eps := make([]v1alpha2.EdgeHubEndpoint, 0, len(eh.Endpoints))
+	for _, ep := range eh.Endpoints {
+		if ep.Protocol == "" {
+			ep.Protocol = v1alpha2.ProtocolWebSocket
+		}
+		if ep.Weight == 0 {
+			ep.Weight = 1
+		}
+		eps = append(eps, ep)
+	}
+	return eps
+}
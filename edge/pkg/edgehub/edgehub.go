@@ -1,7 +1,9 @@
 package edgehub
 
 import (
+	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"k8s.io/client-go/util/flowcontrol"
@@ -10,13 +12,16 @@ import (
 	"github.com/kubeedge/beehive/pkg/core"
 	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
 	"github.com/kubeedge/kubeedge/edge/pkg/common/modules"
+	"github.com/kubeedge/kubeedge/edge/pkg/edgehub/bandwidth"
 	"github.com/kubeedge/kubeedge/edge/pkg/edgehub/certificate"
 	"github.com/kubeedge/kubeedge/edge/pkg/edgehub/clients"
 	"github.com/kubeedge/kubeedge/edge/pkg/edgehub/config"
+	"github.com/kubeedge/kubeedge/edge/pkg/edgehub/queue"
 
 	// register Upgrade handler
 	_ "github.com/kubeedge/kubeedge/edge/pkg/edgehub/upgrade"
 	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha2"
+	"github.com/kubeedge/viaduct/pkg/comm"
 )
 
 //EdgeHub defines edgehub object structure
@@ -27,6 +32,12 @@ type EdgeHub struct {
 	rateLimiter   flowcontrol.RateLimiter
 	keeperLock    sync.RWMutex
 	enable        bool
+	// outbound stores the messages to cloud while disconnected, it is nil if disabled
+	outbound *queue.Queue
+	// connected is 1 if connected to cloud
+	connected int32
+	// meter accounts the bytes sent to cloud on the metered link, it is nil if disabled
+	meter *bandwidth.Meter
 }
 
 var _ core.Module = (*EdgeHub)(nil)
@@ -58,6 +69,7 @@ func newEdgeHub(enable bool) *EdgeHub {
 // Register register edgehub
 func Register(eh *v1alpha2.EdgeHub, nodeName string) {
 	config.InitConfigure(eh, nodeName)
+	registerMetrics()
 	core.Register(newEdgeHub(eh.Enable))
 }
 
@@ -87,6 +99,31 @@ func (eh *EdgeHub) Start() {
 
 	go eh.ifRotationDone()
 
+	if len(config.Config.Endpoints) == 0 {
+		klog.Exitf("failed to init controller: Websocket and Quic are both disabled")
+		return
+	}
+	if oq := config.Config.OutboundQueue; oq != nil && oq.Enable {
+		outbound, err := queue.Open(oq.DBPath, oq.GroupLimits, oq.DefaultGroupLimit)
+		if err != nil {
+			klog.Exitf("failed to init controller: %v", err)
+			return
+		}
+		defer outbound.Close()
+		eh.outbound = outbound
+		outbound.OnBackpressure(eh.pubBackpressure)
+		go eh.routeToQueue()
+	}
+
+	eh.meter = bandwidth.NewMeter(config.Config.MeteredLink)
+	if eh.meter != nil {
+		go eh.saveMeteredLink()
+	}
+
+	waitTime := time.Duration(config.Config.Heartbeat) * time.Second * 2
+	selector := newEndpointSelector(config.Config.Endpoints, waitTime)
+	go selector.runProbe()
+
 	for {
 		select {
 		case <-beehiveContext.Done():
@@ -94,37 +131,66 @@ func (eh *EdgeHub) Start() {
 			return
 		default:
 		}
-		err := eh.initial()
+		endpoint, wait := selector.next()
+		if wait > 0 {
+			klog.Warningf("all endpoints are unavailable, will connect %s after %s", endpoint, wait.String())
+			time.Sleep(wait)
+		}
+		err := eh.initial(endpoint.EdgeHubEndpoint)
 		if err != nil {
 			klog.Exitf("failed to init controller: %v", err)
 			return
 		}
 
-		waitTime := time.Duration(config.Config.Heartbeat) * time.Second * 2
-
 		err = eh.chClient.Init()
+		var retryErr *comm.RetryAfterError
+		if errors.As(err, &retryErr) {
+			klog.Warningf("%s is busy, retry after %s", endpoint, retryErr.RetryAfter)
+			selector.busy(endpoint, retryErr.RetryAfter)
+			continue
+		}
+		var redirectErr *comm.RedirectError
+		if errors.As(err, &redirectErr) {
+			klog.Infof("%s redirects to %s", endpoint, redirectErr.Location)
+			selector.redirected(endpoint, redirectErr.Location)
+			continue
+		}
 		if err != nil {
-			klog.Errorf("connection failed: %v, will reconnect after %s", err, waitTime.String())
-			time.Sleep(waitTime)
+			klog.Errorf("connection to %s failed: %v", endpoint, err)
+			selector.failed(endpoint)
 			continue
 		}
+		klog.Infof("connected to cloudcore endpoint %s", endpoint)
+		selector.connected(endpoint)
 		// execute hook func after connect
 		eh.pubConnectInfo(true)
+		atomic.StoreInt32(&eh.connected, 1)
+		done := make(chan struct{})
 		go eh.routeToEdge()
-		go eh.routeToCloud()
+		if eh.outbound != nil {
+			go eh.drainQueue(done)
+		} else {
+			go eh.routeToCloud(done)
+		}
 		go eh.keepalive()
+		go reportEndpoint(endpoint)
+		if eh.meter != nil {
+			go eh.reportMeteredLink(done)
+		}
 
 		// wait the stop signal
 		// stop authinfo manager/websocket connection
 		<-eh.reconnectChan
+		atomic.StoreInt32(&eh.connected, 0)
+		close(done)
 		eh.chClient.UnInit()
 
 		// execute hook fun after disconnect
 		eh.pubConnectInfo(false)
 
-		// sleep one period of heartbeat, then try to connect cloud hub again
-		klog.Warningf("connection is broken, will reconnect after %s", waitTime.String())
-		time.Sleep(waitTime)
+		// back off the broken endpoint, then try to connect cloud hub again
+		klog.Warningf("connection to %s is broken", endpoint)
+		selector.failed(endpoint)
 
 		// clean channel
 	clean:
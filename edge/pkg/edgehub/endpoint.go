@@ -0,0 +1,346 @@
+package edgehub
+
+import (
+	"bytes"
+	crand "crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
+	"github.com/kubeedge/beehive/pkg/core/model"
+	messagepkg "github.com/kubeedge/kubeedge/edge/pkg/common/message"
+	"github.com/kubeedge/kubeedge/edge/pkg/common/modules"
+	"github.com/kubeedge/kubeedge/edge/pkg/edgehub/config"
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha2"
+)
+
+const (
+	// endpointProbePeriod is the period to probe the endpoints not in use
+	endpointProbePeriod  = 30 * time.Second
+	endpointProbeTimeout = 5 * time.Second
+	// maxBackoffSteps limits the backoff of an endpoint to base*2^maxBackoffSteps
+	maxBackoffSteps = 3
+	// backoffJitter is the max fraction the backoff is shortened by randomly, so the edge nodes
+	// disconnected at the same time don't reconnect at the same time
+	backoffJitter = 0.5
+
+	// the public header flags of gQUIC, the probe packet carries an unsupported version
+	// and the 8-byte connection ID which the version negotiation packet echoes
+	quicFlagVersion = 0x01
+	quicFlagConnID  = 0x08
+	quicConnIDLen   = 8
+
+	// NodeConditionEdgeHubEndpoint is the type of node condition reporting the endpoint EdgeHub connects to
+	NodeConditionEdgeHubEndpoint = "EdgeHubEndpoint"
+	reportEndpointTimeout        = 30 * time.Second
+)
+
+type endpointState struct {
+	v1alpha2.EdgeHubEndpoint
+	// healthy is the result of the last probe or connection, it is true before probed
+	healthy  bool
+	failures int
+	// retryAt is the time before which the endpoint is not connected after failures
+	retryAt time.Time
+	// origin is the configured endpoint which redirected to this one, nil if it is configured
+	origin *endpointState
+}
+
+func (ep *endpointState) String() string {
+	return fmt.Sprintf("%s://%s", ep.Protocol, ep.Server)
+}
+
+// endpointSelector chooses the cloudcore endpoint to connect from the configured ones
+type endpointSelector struct {
+	lock      sync.Mutex
+	endpoints []*endpointState
+	// active is the endpoint connected currently, it is not probed
+	active *endpointState
+	// redirect is the endpoint to connect next since a configured endpoint redirected to it
+	redirect *endpointState
+	// backoff is the base duration an endpoint is not connected after a failure
+	backoff time.Duration
+	// jitter is the max fraction the backoff is shortened by randomly
+	jitter float64
+	probe  func(ep v1alpha2.EdgeHubEndpoint) error
+	now    func() time.Time
+	rand   *rand.Rand
+}
+
+func newEndpointSelector(endpoints []v1alpha2.EdgeHubEndpoint, backoff time.Duration) *endpointSelector {
+	s := &endpointSelector{
+		backoff: backoff,
+		jitter:  backoffJitter,
+		probe:   probeEndpoint,
+		now:     time.Now,
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for _, ep := range endpoints {
+		s.endpoints = append(s.endpoints, &endpointState{EdgeHubEndpoint: ep, healthy: true})
+	}
+	return s
+}
+
+// next returns the endpoint to connect and how long to wait before connecting it.
+// The healthy endpoints with the highest priority are chosen randomly in proportion to the weights,
+// the unhealthy ones are tried only if none is healthy since the probes may be blocked by firewalls.
+func (s *endpointSelector) next() (*endpointState, time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if ep := s.redirect; ep != nil {
+		s.redirect = nil
+		return ep, 0
+	}
+
+	now := s.now()
+	var ready, healthy []*endpointState
+	for _, ep := range s.endpoints {
+		if ep.retryAt.After(now) {
+			continue
+		}
+		ready = append(ready, ep)
+		if ep.healthy {
+			healthy = append(healthy, ep)
+		}
+	}
+	if len(ready) == 0 {
+		// all endpoints are backing off, wait for the earliest one
+		earliest := s.endpoints[0]
+		for _, ep := range s.endpoints[1:] {
+			if ep.retryAt.Before(earliest.retryAt) {
+				earliest = ep
+			}
+		}
+		return earliest, earliest.retryAt.Sub(now)
+	}
+	candidates := healthy
+	if len(candidates) == 0 {
+		candidates = ready
+	}
+
+	var group []*endpointState
+	var total int64
+	for _, ep := range candidates {
+		switch {
+		case len(group) == 0 || ep.Priority < group[0].Priority:
+			group, total = []*endpointState{ep}, int64(ep.Weight)
+		case ep.Priority == group[0].Priority:
+			group, total = append(group, ep), total+int64(ep.Weight)
+		}
+	}
+	if total <= 0 {
+		return group[s.rand.Intn(len(group))], 0
+	}
+	n := s.rand.Int63n(total)
+	for _, ep := range group {
+		if n < int64(ep.Weight) {
+			return ep, 0
+		}
+		n -= int64(ep.Weight)
+	}
+	return group[len(group)-1], 0
+}
+
+// connected records the endpoint is connected successfully
+func (s *endpointSelector) connected(ep *endpointState) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	ep.healthy, ep.failures, ep.retryAt = true, 0, time.Time{}
+	s.active = ep
+}
+
+// failed records the endpoint failed to connect or is disconnected, it is not connected
+// again until the backoff, which is doubled on every consecutive failure, expires
+func (s *endpointSelector) failed(ep *endpointState) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.fail(ep)
+}
+
+// redirected records the endpoint redirected to the server at location, the server is connected
+// next. A redirected endpoint redirecting again fails its origin, so the node doesn't bounce among
+// the cloudcore replicas while their membership is changing.
+func (s *endpointSelector) redirected(ep *endpointState, location string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if ep.origin != nil {
+		s.fail(ep)
+		return
+	}
+	target := &endpointState{EdgeHubEndpoint: ep.EdgeHubEndpoint, healthy: true, origin: ep}
+	target.Server = location
+	s.redirect = target
+}
+
+// busy records the endpoint rejected to connect since it is busy, it is not connected
+// again until both the backoff and the retry-after hint of the endpoint expire
+func (s *endpointSelector) busy(ep *endpointState, retryAfter time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if ep.origin != nil {
+		ep = ep.origin
+	}
+	s.fail(ep)
+	// the hint is the earliest time to retry, the jitter spreads the retries after it
+	if retryAt := s.now().Add(retryAfter + s.randomize(retryAfter/2)); retryAt.After(ep.retryAt) {
+		ep.retryAt = retryAt
+	}
+}
+
+func (s *endpointSelector) fail(ep *endpointState) {
+	if s.active == ep {
+		s.active = nil
+	}
+	// the failures of the redirected endpoint back off the configured one redirecting to it
+	if ep.origin != nil {
+		ep = ep.origin
+	}
+	ep.healthy = false
+	ep.failures++
+	steps := ep.failures - 1
+	if steps > maxBackoffSteps {
+		steps = maxBackoffSteps
+	}
+	backoff := s.backoff << uint(steps)
+	ep.retryAt = s.now().Add(backoff - s.randomize(time.Duration(float64(backoff)*s.jitter)))
+}
+
+// randomize returns a random duration in [0, d)
+func (s *endpointSelector) randomize(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(s.rand.Int63n(int64(d)))
+}
+
+// probeOnce probes the endpoints except the active one and records whether they are healthy
+func (s *endpointSelector) probeOnce() {
+	s.lock.Lock()
+	var targets []*endpointState
+	for _, ep := range s.endpoints {
+		if ep != s.active {
+			targets = append(targets, ep)
+		}
+	}
+	s.lock.Unlock()
+
+	for _, ep := range targets {
+		err := s.probe(ep.EdgeHubEndpoint)
+		if err != nil {
+			klog.V(4).Infof("[edgehub/endpoint] endpoint %s is unhealthy: %v", ep, err)
+		}
+		s.lock.Lock()
+		ep.healthy = err == nil
+		s.lock.Unlock()
+	}
+}
+
+func (s *endpointSelector) runProbe() {
+	if len(s.endpoints) < 2 {
+		return
+	}
+	ticker := time.NewTicker(endpointProbePeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-beehiveContext.Done():
+			return
+		case <-ticker.C:
+			s.probeOnce()
+		}
+	}
+}
+
+// quicProbeVersion is the version offered by the probe, which no QUIC server supports
+var quicProbeVersion = []byte("Q000")
+
+// probeEndpoint checks whether the endpoint is reachable without registering the node to it
+func probeEndpoint(ep v1alpha2.EdgeHubEndpoint) error {
+	switch ep.Protocol {
+	case v1alpha2.ProtocolQuic:
+		return probeQUIC(ep.Server)
+	default:
+		conn, err := net.DialTimeout("tcp", ep.Server, endpointProbeTimeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// probeQUIC sends a packet of an unsupported version to the QUIC server and waits for the version
+// negotiation packet, which the server answers without creating a session or handshaking
+func probeQUIC(server string) error {
+	conn, err := net.DialTimeout("udp", server, endpointProbeTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(endpointProbeTimeout)); err != nil {
+		return err
+	}
+
+	connID := make([]byte, quicConnIDLen)
+	if _, err := crand.Read(connID); err != nil {
+		return err
+	}
+	packet := []byte{quicFlagVersion | quicFlagConnID}
+	packet = append(packet, connID...)
+	packet = append(packet, quicProbeVersion...)
+	// the packet number
+	packet = append(packet, 1)
+	if _, err := conn.Write(packet); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 1500)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return err
+	}
+	if n <= 1+quicConnIDLen || buf[0]&quicFlagVersion == 0 || !bytes.Equal(buf[1:1+quicConnIDLen], connID) {
+		return fmt.Errorf("unexpected response from quic server %s", server)
+	}
+	return nil
+}
+
+// reportEndpoint reports the endpoint connected through the node condition
+func reportEndpoint(ep *endpointState) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	patch := map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []map[string]interface{}{{
+				"type":               NodeConditionEdgeHubEndpoint,
+				"status":             "True",
+				"reason":             "Connected",
+				"message":            fmt.Sprintf("connected to cloudcore endpoint %s", ep),
+				"lastHeartbeatTime":  now,
+				"lastTransitionTime": now,
+			}},
+		},
+	}
+	if err := patchNode(patch); err != nil {
+		klog.Warningf("[edgehub/endpoint] failed to report endpoint %s: %v", ep, err)
+	}
+}
+
+// patchNode sends the strategic merge patch of the node to cloud through metamanager
+func patchNode(patch interface{}) error {
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal node patch: %v", err)
+	}
+	resource := fmt.Sprintf("%s/%s/%s", "default", model.ResourceTypeNodePatch, config.Config.NodeName)
+	msg := messagepkg.BuildMsg(modules.MetaGroup, "", modules.EdgeHubModuleName, resource,
+		model.PatchOperation, base64.URLEncoding.EncodeToString(data))
+	_, err = beehiveContext.SendSync(modules.MetaManagerModuleName, *msg, reportEndpointTimeout)
+	return err
+}
@@ -0,0 +1,197 @@
+package edgehub
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/lucas-clemente/quic-go"
+
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha2"
+)
+
+func TestEndpointSelector(t *testing.T) {
+	s := newEndpointSelector([]v1alpha2.EdgeHubEndpoint{
+		{Protocol: v1alpha2.ProtocolWebSocket, Server: "a:10000", Priority: 0, Weight: 1},
+		{Protocol: v1alpha2.ProtocolQuic, Server: "b:10001", Priority: 0, Weight: 0},
+		{Protocol: v1alpha2.ProtocolWebSocket, Server: "c:10000", Priority: 1, Weight: 1},
+	}, 10*time.Second)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	// the jitter is tested by TestEndpointSelectorJitter
+	s.jitter = 0
+	a, b, c := s.endpoints[0], s.endpoints[1], s.endpoints[2]
+
+	expect := func(name string, want *endpointState, wantWait time.Duration) {
+		t.Helper()
+		for i := 0; i < 10; i++ {
+			if got, wait := s.next(); got != want || wait != wantWait {
+				t.Fatalf("%s: expected %s after %s, but got %s after %s", name, want, wantWait, got, wait)
+			}
+		}
+	}
+
+	// b has no weight among the endpoints with the highest priority
+	expect("weighted", a, 0)
+	s.connected(a)
+
+	// fail over to c once b is found unhealthy
+	s.probe = func(ep v1alpha2.EdgeHubEndpoint) error {
+		if ep.Server == a.Server {
+			t.Errorf("the active endpoint should not be probed")
+		}
+		if ep.Server == b.Server {
+			return errors.New("unreachable")
+		}
+		return nil
+	}
+	s.probeOnce()
+	s.failed(a)
+	expect("failover", c, 0)
+
+	// the unhealthy ones are tried if none is healthy
+	s.failed(c)
+	expect("unhealthy", b, 0)
+
+	// all endpoints are backing off, the backoff of c is doubled after another failure
+	s.failed(b)
+	now = now.Add(5 * time.Second)
+	s.failed(c)
+	expect("backoff", a, 5*time.Second)
+	s.failed(a)
+	if got, wait := s.next(); got != b || wait != 5*time.Second {
+		t.Errorf("expected %s after 5s, but got %s after %s", b, got, wait)
+	}
+	if c.retryAt.Sub(now) != 20*time.Second {
+		t.Errorf("expected %s backing off 20s, but got %s", c, c.retryAt.Sub(now))
+	}
+}
+
+func TestEndpointSelectorJitter(t *testing.T) {
+	s := newEndpointSelector([]v1alpha2.EdgeHubEndpoint{
+		{Protocol: v1alpha2.ProtocolWebSocket, Server: "a:10000", Weight: 1},
+	}, 10*time.Second)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	a := s.endpoints[0]
+
+	// the backoff is shortened by half at most
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 20; i++ {
+		a.failures = 0
+		s.failed(a)
+		backoff := a.retryAt.Sub(now)
+		if backoff <= 5*time.Second || backoff > 10*time.Second {
+			t.Fatalf("expected backoff in (5s, 10s], but got %s", backoff)
+		}
+		seen[backoff] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("expected backoff randomized, but got %v", seen)
+	}
+
+	// the retry-after hint longer than the backoff delays the retry
+	a.failures = 0
+	s.busy(a, time.Minute)
+	if backoff := a.retryAt.Sub(now); backoff < time.Minute || backoff >= 90*time.Second {
+		t.Errorf("expected backoff in [1m, 1m30s), but got %s", backoff)
+	}
+	// the backoff longer than the retry-after hint is kept
+	a.failures = 0
+	s.busy(a, time.Second)
+	if backoff := a.retryAt.Sub(now); backoff <= 5*time.Second {
+		t.Errorf("expected backoff longer than 5s, but got %s", backoff)
+	}
+}
+
+func TestEndpointSelectorRedirect(t *testing.T) {
+	s := newEndpointSelector([]v1alpha2.EdgeHubEndpoint{
+		{Protocol: v1alpha2.ProtocolWebSocket, Server: "vip:10000", Weight: 1},
+	}, 10*time.Second)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	s.jitter = 0
+	a := s.endpoints[0]
+
+	// the server redirected to is connected next, then the configured one again
+	s.redirected(a, "10.0.0.2:10000")
+	target, wait := s.next()
+	if target.Server != "10.0.0.2:10000" || target.Protocol != v1alpha2.ProtocolWebSocket || wait != 0 {
+		t.Fatalf("expected redirected to 10.0.0.2:10000 at once, but got %s after %s", target, wait)
+	}
+	if got, _ := s.next(); got != a {
+		t.Fatalf("expected %s after the redirect, but got %s", a, got)
+	}
+
+	// the failures of the server redirected to back off the configured one
+	s.failed(target)
+	if a.retryAt.Sub(now) != 10*time.Second {
+		t.Errorf("expected %s backing off 10s, but got %s", a, a.retryAt.Sub(now))
+	}
+
+	// the server redirected to redirecting again is not followed
+	s.redirected(a, "10.0.0.2:10000")
+	target, _ = s.next()
+	s.redirected(target, "10.0.0.3:10000")
+	if got, _ := s.next(); got != a || a.failures != 2 {
+		t.Errorf("expected %s failed twice, but got %s failed %d times", a, got, a.failures)
+	}
+}
+
+func TestProbeQUIC(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "cloudcore"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	listener, err := quic.ListenAddr("127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to listen quic: %v", err)
+	}
+	defer listener.Close()
+	accepted := make(chan struct{})
+	go func() {
+		if _, err := listener.Accept(); err == nil {
+			close(accepted)
+		}
+	}()
+
+	if err := probeQUIC(listener.Addr().String()); err != nil {
+		t.Errorf("expected the quic server healthy, but got %v", err)
+	}
+	select {
+	case <-accepted:
+		t.Errorf("expected no session created by the probe")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// the port no server listens on
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen udp: %v", err)
+	}
+	addr := conn.LocalAddr().String()
+	conn.Close()
+	if err := probeQUIC(addr); err == nil {
+		t.Errorf("expected the endpoint without quic server unhealthy")
+	}
+}
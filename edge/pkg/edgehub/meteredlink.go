@@ -0,0 +1,93 @@
+package edgehub
+
+import (
+	"encoding/json"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
+	"github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/kubeedge/edge/pkg/edgehub/bandwidth"
+)
+
+const (
+	// AnnotationMeteredLink is the node annotation reporting the usage of the metered link budgets
+	AnnotationMeteredLink = "edgehub.kubeedge.io/metered-link"
+
+	reportMeteredLinkPeriod = 5 * time.Minute
+	saveMeteredLinkPeriod   = 30 * time.Second
+)
+
+// maxDeferInterval is the longest interval to check again whether a deferred message can be sent
+var maxDeferInterval = time.Second
+
+// reserveBandwidth accounts the message in the budgets of the metered link, it returns
+// the time to wait if the message is deferred since the budgets are used up
+func (eh *EdgeHub) reserveBandwidth(message model.Message) time.Duration {
+	if eh.meter == nil {
+		return 0
+	}
+	wait := eh.meter.Reserve(message.GetGroup(), bandwidth.MessageSize(message))
+	if wait > maxDeferInterval {
+		wait = maxDeferInterval
+	}
+	return wait
+}
+
+// isDeferrable reports whether the message waits for the next budget window once the budgets are used up
+func (eh *EdgeHub) isDeferrable(message model.Message) bool {
+	return eh.meter != nil && eh.meter.Deferrable(message.GetGroup())
+}
+
+// reportMeteredLink reports the usage of the metered link budgets through the node annotation
+// periodically until the connection is done
+func (eh *EdgeHub) reportMeteredLink(done <-chan struct{}) {
+	ticker := time.NewTicker(reportMeteredLinkPeriod)
+	defer ticker.Stop()
+	for {
+		data, err := json.Marshal(eh.meter.Usage())
+		if err != nil {
+			klog.Errorf("[edgehub/meteredlink] failed to marshal usage: %v", err)
+			return
+		}
+		patch := map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]string{
+					AnnotationMeteredLink: string(data),
+				},
+			},
+		}
+		if err := patchNode(patch); err != nil {
+			klog.Warningf("[edgehub/meteredlink] failed to report usage of metered link: %v", err)
+		}
+
+		select {
+		case <-beehiveContext.Done():
+			return
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// saveMeteredLink saves the usage of the metered link budgets periodically and on stop,
+// so restarting edgecore does not reset the budgets
+func (eh *EdgeHub) saveMeteredLink() {
+	ticker := time.NewTicker(saveMeteredLinkPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-beehiveContext.Done():
+			if err := eh.meter.Save(); err != nil {
+				klog.Errorf("[edgehub/meteredlink] failed to save usage of metered link: %v", err)
+			}
+			return
+		case <-ticker.C:
+		}
+		if err := eh.meter.Save(); err != nil {
+			klog.Errorf("[edgehub/meteredlink] failed to save usage of metered link: %v", err)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package edgehub
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+
+	"github.com/kubeedge/kubeedge/pkg/util"
+)
+
+// the reasons the bulk messages are dropped
+const (
+	dropReasonBufferFull   = "buffer_full"
+	dropReasonDisconnected = "disconnected"
+)
+
+var (
+	droppedBulkMessages = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      "edgehub",
+			Name:           "bulk_dropped_messages_total",
+			Help:           "Number of the bulk messages dropped before sent to cloud, partitioned by reason.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"reason"},
+	)
+	registerMetricsOnce sync.Once
+)
+
+// registerMetrics registers the metrics of payload compression and bulk messages
+func registerMetrics() {
+	registerMetricsOnce.Do(func() {
+		legacyregistry.RawMustRegister(util.CompressionCollectors("edgehub")...)
+		legacyregistry.MustRegister(droppedBulkMessages)
+	})
+}
@@ -3,6 +3,8 @@ package edgehub
 import (
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"k8s.io/klog/v2"
@@ -15,6 +17,10 @@ import (
 	"github.com/kubeedge/kubeedge/edge/pkg/edgehub/clients"
 	"github.com/kubeedge/kubeedge/edge/pkg/edgehub/common/msghandler"
 	"github.com/kubeedge/kubeedge/edge/pkg/edgehub/config"
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha2"
+	"github.com/kubeedge/kubeedge/pkg/stream"
+	"github.com/kubeedge/kubeedge/pkg/util"
+	"github.com/kubeedge/viaduct/pkg/api"
 )
 
 var groupMap = map[string]string{
@@ -31,8 +37,8 @@ var (
 	longThrottleLatency = 1 * time.Second
 )
 
-func (eh *EdgeHub) initial() (err error) {
-	cloudHubClient, err := clients.GetClient()
+func (eh *EdgeHub) initial(endpoint v1alpha2.EdgeHubEndpoint) (err error) {
+	cloudHubClient, err := clients.GetClient(endpoint)
 	if err != nil {
 		return err
 	}
@@ -123,10 +129,11 @@ func (eh *EdgeHub) routeToEdge() {
 }
 
 func (eh *EdgeHub) sendToCloud(message model.Message) error {
-	eh.keeperLock.Lock()
+	// the connection orders the writes of each traffic class by itself
+	eh.keeperLock.RLock()
 	klog.V(4).Infof("[edgehub/sendToCloud] send msg to cloud, msg: %+v", message)
 	err := eh.chClient.Send(message)
-	eh.keeperLock.Unlock()
+	eh.keeperLock.RUnlock()
 	if err != nil {
 		return fmt.Errorf("failed to send message, error: %v", err)
 	}
@@ -134,12 +141,21 @@ func (eh *EdgeHub) sendToCloud(message model.Message) error {
 	return nil
 }
 
-func (eh *EdgeHub) routeToCloud() {
+// routeToCloud sends the messages to cloud until the connection is done
+func (eh *EdgeHub) routeToCloud(done <-chan struct{}) {
+	// the bulk messages are sent by another goroutine,
+	// so the control plane messages never wait behind them
+	bulk := newBulkBuffer(maxBulkMessages)
+	go eh.sendBulkToCloud(bulk, done)
+
 	for {
 		select {
 		case <-beehiveContext.Done():
 			klog.Warning("EdgeHub RouteToCloud stop")
 			return
+		case <-done:
+			klog.Warning("EdgeHub RouteToCloud stop")
+			return
 		default:
 		}
 		message, err := beehiveContext.Receive(modules.EdgeHubModuleName)
@@ -148,6 +164,15 @@ func (eh *EdgeHub) routeToCloud() {
 			time.Sleep(time.Second)
 			continue
 		}
+		if util.ClassifyMessage(&message) == api.TrafficClassBulk || eh.isDeferrable(message) {
+			if !bulk.push(message) {
+				klog.Warningf("msgID: %s, group: %s, bulk buffer is full, drop message", message.GetID(), message.GetGroup())
+				droppedBulkMessages.WithLabelValues(dropReasonBufferFull).Inc()
+				eh.rejectMessage(message, "bulk buffer is full")
+			}
+			continue
+		}
+		eh.reserveBandwidth(message)
 
 		err = eh.tryThrottle(message.GetID())
 		if err != nil {
@@ -159,12 +184,308 @@ func (eh *EdgeHub) routeToCloud() {
 		err = eh.sendToCloud(message)
 		if err != nil {
 			klog.Errorf("failed to send message to cloud: %v", err)
-			eh.reconnectChan <- struct{}{}
+			select {
+			case eh.reconnectChan <- struct{}{}:
+			case <-done:
+			}
+			return
+		}
+	}
+}
+
+// sendBulkToCloud sends the bulk messages until the connection is done,
+// the messages left in the buffer are dropped then
+func (eh *EdgeHub) sendBulkToCloud(bulk *bulkBuffer, done <-chan struct{}) {
+	defer func() {
+		for _, message := range bulk.clear() {
+			droppedBulkMessages.WithLabelValues(dropReasonDisconnected).Inc()
+			eh.rejectMessage(message, "cloud is disconnected")
+		}
+	}()
+	for {
+		message, ok := bulk.pop(done)
+		if !ok {
+			return
+		}
+
+		for wait, deferred := eh.reserveBandwidth(message), false; wait > 0; wait = eh.reserveBandwidth(message) {
+			if !deferred {
+				klog.V(2).Infof("msgID: %s, group: %s, budgets of metered link are used up, defer message",
+					message.GetID(), message.GetGroup())
+				eh.meter.Deferred(message.GetGroup())
+				deferred = true
+			}
+			select {
+			case <-done:
+				return
+			case <-time.After(wait):
+			}
+		}
+
+		err := eh.tryThrottle(message.GetID())
+		if err != nil {
+			klog.Errorf("msgID: %s, client rate limiter returned an error: %v ", message.GetID(), err)
+			continue
+		}
+
+		err = eh.sendToCloud(message)
+		if err != nil {
+			klog.Errorf("failed to send bulk message to cloud: %v", err)
+			select {
+			case eh.reconnectChan <- struct{}{}:
+			case <-done:
+			}
 			return
 		}
 	}
 }
 
+// maxBulkMessages is the max number of the bulk messages waiting to be sent while the outbound
+// queue is disabled, the messages beyond it are dropped instead of growing the memory unbounded
+const maxBulkMessages = 1000
+
+// bulkBuffer holds the bulk messages waiting to be sent, pushing never blocks,
+// so routeToCloud keeps sending the control plane messages while the bulk messages wait
+type bulkBuffer struct {
+	lock     sync.Mutex
+	messages []model.Message
+	limit    int
+	// ready is signaled after a message is pushed
+	ready chan struct{}
+}
+
+func newBulkBuffer(limit int) *bulkBuffer {
+	return &bulkBuffer{limit: limit, ready: make(chan struct{}, 1)}
+}
+
+// push appends the message to the buffer, false is returned if the buffer is full
+func (b *bulkBuffer) push(message model.Message) bool {
+	b.lock.Lock()
+	if len(b.messages) >= b.limit {
+		b.lock.Unlock()
+		return false
+	}
+	b.messages = append(b.messages, message)
+	b.lock.Unlock()
+	select {
+	case b.ready <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// clear removes the messages in the buffer and returns them
+func (b *bulkBuffer) clear() []model.Message {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	messages := b.messages
+	b.messages = nil
+	return messages
+}
+
+// pop returns the first message in the buffer, it waits for one if the buffer is empty,
+// false is returned if done is closed meanwhile
+func (b *bulkBuffer) pop(done <-chan struct{}) (model.Message, bool) {
+	for {
+		b.lock.Lock()
+		if len(b.messages) > 0 {
+			message := b.messages[0]
+			b.messages[0] = model.Message{}
+			b.messages = b.messages[1:]
+			b.lock.Unlock()
+			return message, true
+		}
+		b.lock.Unlock()
+
+		select {
+		case <-b.ready:
+		case <-done:
+			return model.Message{}, false
+		}
+	}
+}
+
+// routeToQueue sends the messages to cloud directly while connected and nothing is queued, otherwise
+// stores them in the outbound queue. The producers waiting for responses get error responses if the
+// messages are rejected, and the others are told by pubBackpressure.
+func (eh *EdgeHub) routeToQueue() {
+	for {
+		select {
+		case <-beehiveContext.Done():
+			klog.Warning("EdgeHub RouteToQueue stop")
+			return
+		default:
+		}
+		message, err := beehiveContext.Receive(modules.EdgeHubModuleName)
+		if err != nil {
+			klog.Errorf("failed to receive message from edge: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		// the query is useless once it timed out, so it is not stored
+		if message.GetOperation() == model.QueryOperation && atomic.LoadInt32(&eh.connected) == 0 {
+			eh.rejectMessage(message, "cloud is disconnected")
+			continue
+		}
+		// the stream tunnel is flow controlled and reconnected by EdgeStream, so it is not stored
+		if message.GetGroup() == stream.HubGroup {
+			eh.sendStream(message)
+			continue
+		}
+		if eh.trySendDirectly(message) {
+			continue
+		}
+		err = eh.outbound.Push(message)
+		if err != nil {
+			klog.Errorf("[edgehub/routeToQueue] msgID: %s, group: %s, failed to queue message: %v",
+				message.GetID(), message.GetGroup(), err)
+			eh.rejectMessage(message, err.Error())
+		}
+	}
+}
+
+// trySendDirectly sends the message without storing it if the cloud is connected and no message
+// is queued, so the order of messages is kept. It returns false if the message should be queued,
+// since it is deferred by the metered link or failed to send, drainQueue reconnects in the latter case.
+func (eh *EdgeHub) trySendDirectly(message model.Message) bool {
+	if atomic.LoadInt32(&eh.connected) == 0 || !eh.outbound.Empty() {
+		return false
+	}
+	if eh.reserveBandwidth(message) > 0 {
+		return false
+	}
+	if err := eh.tryThrottle(message.GetID()); err != nil {
+		klog.Errorf("msgID: %s, client rate limiter returned an error: %v ", message.GetID(), err)
+		return false
+	}
+	if err := eh.sendToCloud(message); err != nil {
+		klog.Errorf("msgID: %s, failed to send message to cloud, queue it: %v", message.GetID(), err)
+		return false
+	}
+	return true
+}
+
+// sendStream sends the stream tunnel message if the cloud is connected, or discards it
+func (eh *EdgeHub) sendStream(message model.Message) {
+	if atomic.LoadInt32(&eh.connected) == 0 {
+		klog.V(4).Infof("msgID: %s, cloud is disconnected, discard stream message", message.GetID())
+		return
+	}
+	if err := eh.sendToCloud(message); err != nil {
+		klog.Errorf("failed to send stream message to cloud: %v", err)
+	}
+}
+
+// rejectMessage sends the error response to the producer if it is waiting for one
+func (eh *EdgeHub) rejectMessage(message model.Message, reason string) {
+	if !message.IsSync() {
+		return
+	}
+	resp := model.NewMessage(message.GetID()).
+		SetRoute(modules.EdgeHubModuleName, message.GetGroup()).
+		SetResourceOperation(message.GetResource(), model.ResponseErrorOperation).
+		FillBody(reason)
+	beehiveContext.SendResp(*resp)
+}
+
+// drainQueue sends the queued messages to cloud until the connection is done, the bulk messages are
+// sent by another goroutine, so the control plane messages never wait behind them
+func (eh *EdgeHub) drainQueue(done <-chan struct{}) {
+	go eh.drainLane(api.TrafficClassBulk, done)
+	eh.drainLane(api.TrafficClassControl, done)
+}
+
+// drainLane sends the queued messages of the traffic class to cloud until the connection is done
+func (eh *EdgeHub) drainLane(class api.TrafficClass, done <-chan struct{}) {
+	// deferred is the groups waiting for the budgets of the metered link until the time, their messages
+	// are skipped meanwhile, so they don't block the messages of the other groups in the same class
+	deferred := make(map[string]time.Time)
+	skip := func(group string) bool {
+		_, ok := deferred[group]
+		return ok
+	}
+	// deferredIDs is the ID of the message deferred last by group, so each deferred message is counted once
+	deferredIDs := make(map[string]string)
+	for {
+		var wake <-chan time.Time
+		var timer *time.Timer
+		if until := earliestDeferred(deferred, time.Now()); !until.IsZero() {
+			timer = time.NewTimer(time.Until(until))
+			wake = timer.C
+		}
+		entry, err := eh.outbound.Next(class, done, wake, skip)
+		if timer != nil {
+			timer.Stop()
+		}
+		if err != nil {
+			klog.Errorf("failed to read outbound queue: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		if entry == nil {
+			select {
+			case <-done:
+				klog.Warning("EdgeHub DrainQueue stop")
+				return
+			default:
+				// the deferred groups are due
+				continue
+			}
+		}
+
+		// the deferred message is read again after waiting, so the messages of higher priority queued
+		// meanwhile are sent first
+		if wait := eh.reserveBandwidth(entry.Message); wait > 0 {
+			group := entry.Message.GetGroup()
+			if deferredIDs[group] != entry.Message.GetID() {
+				klog.V(2).Infof("msgID: %s, group: %s, budgets of metered link are used up, defer message",
+					entry.Message.GetID(), group)
+				eh.meter.Deferred(group)
+				deferredIDs[group] = entry.Message.GetID()
+			}
+			deferred[group] = time.Now().Add(wait)
+			continue
+		}
+
+		err = eh.tryThrottle(entry.Message.GetID())
+		if err != nil {
+			klog.Errorf("msgID: %s, client rate limiter returned an error: %v ", entry.Message.GetID(), err)
+			continue
+		}
+
+		// the message is kept in queue and sent again after reconnecting if failed
+		err = eh.sendToCloud(entry.Message)
+		if err != nil {
+			klog.Errorf("failed to send message to cloud: %v", err)
+			select {
+			case eh.reconnectChan <- struct{}{}:
+			case <-done:
+			}
+			return
+		}
+		if err := eh.outbound.Remove(entry); err != nil {
+			klog.Errorf("msgID: %s, %v", entry.Message.GetID(), err)
+		}
+	}
+}
+
+// earliestDeferred removes the groups due from deferred, and returns the earliest time
+// of the groups left, the zero time is returned if there is none
+func earliestDeferred(deferred map[string]time.Time, now time.Time) time.Time {
+	var earliest time.Time
+	for group, until := range deferred {
+		if !now.Before(until) {
+			delete(deferred, group)
+			continue
+		}
+		if earliest.IsZero() || until.Before(earliest) {
+			earliest = until
+		}
+	}
+	return earliest
+}
+
 func (eh *EdgeHub) keepalive() {
 	for {
 		select {
@@ -203,6 +524,23 @@ func (eh *EdgeHub) pubConnectInfo(isConnected bool) {
 	}
 }
 
+// pubBackpressure tells the producers of the group whether the outbound queue of the group is full,
+// so they can hold their messages instead of having them rejected
+func (eh *EdgeHub) pubBackpressure(group string, full bool) {
+	moduleGroup, ok := groupMap[group]
+	if !ok {
+		return
+	}
+	content := messagepkg.OutboundQueueAvailable
+	if full {
+		content = messagepkg.OutboundQueueFull
+		klog.Warningf("outbound queue of group %s is full", group)
+	}
+	message := model.NewMessage("").BuildRouter(messagepkg.SourceNodeConnection, moduleGroup,
+		messagepkg.ResourceTypeOutboundQueue+"/"+group, messagepkg.OperationOutboundQueue).FillBody(content)
+	beehiveContext.SendToGroup(moduleGroup, *message)
+}
+
 func (eh *EdgeHub) ifRotationDone() {
 	if eh.certManager.RotateCertificates {
 		for {
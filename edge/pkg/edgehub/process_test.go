@@ -239,7 +239,7 @@ func TestRouteToCloud(t *testing.T) {
 
 			core.Register(&EdgeHub{enable: true})
 
-			go tt.hub.routeToCloud()
+			go tt.hub.routeToCloud(make(chan struct{}))
 			time.Sleep(2 * time.Second)
 
 			msg := model.NewMessage("").BuildHeader("test_id", "", 1)
@@ -288,3 +288,38 @@ func TestKeepalive(t *testing.T) {
 		})
 	}
 }
+
+func TestBulkBuffer(t *testing.T) {
+	bulk := newBulkBuffer(1000)
+	// pushing never blocks even if nobody pops
+	for i := 0; i < 1000; i++ {
+		if !bulk.push(*model.NewMessage("").BuildHeader(fmt.Sprintf("msg-%d", i), "", 0)) {
+			t.Fatalf("expected msg-%d pushed", i)
+		}
+	}
+	// the messages beyond the limit are rejected
+	if bulk.push(*model.NewMessage("").BuildHeader("msg-1000", "", 0)) {
+		t.Errorf("expected msg-1000 rejected by the limit")
+	}
+	done := make(chan struct{})
+	for i := 0; i < 1000; i++ {
+		message, ok := bulk.pop(done)
+		if !ok || message.GetID() != fmt.Sprintf("msg-%d", i) {
+			t.Fatalf("expected msg-%d popped, but got %q, %v", i, message.GetID(), ok)
+		}
+	}
+
+	close(done)
+	if _, ok := bulk.pop(done); ok {
+		t.Errorf("expected pop stopped by done")
+	}
+
+	// the messages left are cleared when the connection is done
+	bulk.push(*model.NewMessage("").BuildHeader("msg-left", "", 0))
+	if left := bulk.clear(); len(left) != 1 || left[0].GetID() != "msg-left" {
+		t.Errorf("expected msg-left cleared, but got %v", left)
+	}
+	if _, ok := bulk.pop(done); ok {
+		t.Errorf("expected no message left after cleared")
+	}
+}
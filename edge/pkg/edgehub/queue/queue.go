@@ -0,0 +1,516 @@
+package queue
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+
+	"github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/kubeedge/pkg/util"
+	"github.com/kubeedge/viaduct/pkg/api"
+)
+
+// Priority classes of the messages, the messages of a higher class are sent first
+const (
+	// PriorityHigh is the class of node status and lease, which keep the node alive in cloud
+	PriorityHigh = iota
+	// PriorityNormal is the class of the other resources and responses
+	PriorityNormal
+	// PriorityLow is the class of the bulk traffic classified by util.ClassifyMessage,
+	// such as device twin and user messages
+	PriorityLow
+	numPriorities
+)
+
+// ErrFull means the message is rejected since its group is full
+var ErrFull = errors.New("outbound queue is full")
+
+var (
+	bucketIndex = []byte("index")
+	bucketMeta  = []byte("meta")
+)
+
+var (
+	queuedMessages = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      "edgehub",
+			Name:           "outbound_queue_messages",
+			Help:           "Number of the messages in the outbound queue, partitioned by message group.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"group"},
+	)
+	droppedMessages = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      "edgehub",
+			Name:           "outbound_queue_dropped_total",
+			Help:           "Number of the messages dropped by the outbound queue, partitioned by message group and reason.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"group", "reason"},
+	)
+	registerMetricsOnce sync.Once
+)
+
+// Entry is a message in the queue
+type Entry struct {
+	Message  model.Message
+	priority int
+	group    string
+	seq      uint64
+}
+
+// record is the stored form of a queued message
+type record struct {
+	Group string `json:"group"`
+	// Key is the coalescing key of the message, the message is replaced by the newer one of the same key
+	Key     string        `json:"key,omitempty"`
+	Message model.Message `json:"message"`
+	// Raw is the content of the message if it is bytes, which is not kept by json
+	Raw []byte `json:"raw,omitempty"`
+}
+
+// Queue is the persistent queue of the messages sent to cloud, the messages are stored in the buckets
+// of their groups in the buckets of their priority classes, keyed by the sequence of arrival
+type Queue struct {
+	lock         sync.Mutex
+	db           *bolt.DB
+	limits       map[string]int
+	defaultLimit int
+	// counts is the number of queued messages of each group
+	counts map[string]int
+	// notify is signaled when a message of the traffic class is pushed
+	notify [api.NumTrafficClasses]chan struct{}
+	// full is the groups whose messages were rejected, they are available again once half drained
+	full map[string]bool
+	// onBackpressure is called when a group becomes full or available again
+	onBackpressure func(group string, full bool)
+}
+
+// Open opens the queue stored in the file of path, the messages queued before are kept
+func Open(path string, limits map[string]int32, defaultLimit int32) (*Queue, error) {
+	registerMetricsOnce.Do(func() {
+		legacyregistry.MustRegister(queuedMessages, droppedMessages)
+	})
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 10 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open outbound queue %s: %v", path, err)
+	}
+	q := &Queue{
+		db:           db,
+		limits:       make(map[string]int),
+		defaultLimit: int(defaultLimit),
+		counts:       make(map[string]int),
+		full:         make(map[string]bool),
+	}
+	for i := range q.notify {
+		q.notify[i] = make(chan struct{}, 1)
+	}
+	for group, limit := range limits {
+		q.limits[group] = int(limit)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketIndex, bucketMeta} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		for p := 0; p < numPriorities; p++ {
+			b, err := tx.CreateBucketIfNotExists(priorityBucket(p))
+			if err != nil {
+				return err
+			}
+			err = b.ForEach(func(group, _ []byte) error {
+				q.counts[string(group)] += b.Bucket(group).Stats().KeyN
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to load outbound queue %s: %v", path, err)
+	}
+	for group, count := range q.counts {
+		queuedMessages.WithLabelValues(group).Set(float64(count))
+	}
+	klog.Infof("[edgehub/queue] outbound queue opened with %v messages", q.counts)
+	return q, nil
+}
+
+// OnBackpressure sets the handler called when a group becomes full or available again,
+// so the asynchronous producers can hold their messages meanwhile
+func (q *Queue) OnBackpressure(handler func(group string, full bool)) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.onBackpressure = handler
+}
+
+// Close closes the queue
+func (q *Queue) Close() error {
+	return q.db.Close()
+}
+
+// PriorityOf returns the priority class of the message
+func PriorityOf(msg model.Message) int {
+	if util.ClassifyMessage(&msg) == api.TrafficClassBulk {
+		return PriorityLow
+	}
+	switch resourceType(msg.GetResource()) {
+	case model.ResourceTypeNodeStatus, model.ResourceTypeNodePatch, model.ResourceTypeNode, model.ResourceTypeLease:
+		return PriorityHigh
+	}
+	return PriorityNormal
+}
+
+// CoalesceKey returns the key of the message to coalesce, the queued message is replaced by the
+// newer one of the same key. It is empty if the message can not be superseded, only the updates
+// carrying the whole status of a resource are coalesced.
+func CoalesceKey(msg model.Message) string {
+	if msg.GetOperation() != model.UpdateOperation {
+		return ""
+	}
+	switch resourceType(msg.GetResource()) {
+	case model.ResourceTypeNodeStatus, model.ResourceTypePodStatus, model.ResourceTypeLease:
+		return msg.GetGroup() + "/" + msg.GetResource()
+	}
+	return ""
+}
+
+// classOf returns the traffic class of the messages of the priority class
+func classOf(priority int) api.TrafficClass {
+	if priority == PriorityLow {
+		return api.TrafficClassBulk
+	}
+	return api.TrafficClassControl
+}
+
+// resourceType returns the type of the resource in format <namespace>/<type>[/<name>]
+func resourceType(resource string) string {
+	tokens := strings.Split(resource, "/")
+	if len(tokens) < 2 {
+		return ""
+	}
+	return tokens[1]
+}
+
+// Push stores the message in the queue, ErrFull is returned if the group of the message is full
+// and there is no message of a lower priority in the group to drop
+func (q *Queue) Push(msg model.Message) error {
+	r := record{Group: msg.GetGroup(), Key: CoalesceKey(msg), Message: msg}
+	if raw, ok := msg.GetContent().([]byte); ok {
+		r.Raw = raw
+		r.Message.Content = nil
+	}
+	value, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %v", err)
+	}
+	priority := PriorityOf(msg)
+
+	q.lock.Lock()
+	var removed []record
+	err = q.db.Update(func(tx *bolt.Tx) error {
+		removed = nil
+		if r.Key != "" {
+			old, err := q.removeByKey(tx, r.Key)
+			if err != nil {
+				return err
+			}
+			if old != nil {
+				removed = append(removed, *old)
+			}
+		}
+		if len(removed) == 0 && q.counts[r.Group] >= q.limit(r.Group) {
+			old, err := q.evict(tx, r.Group, priority)
+			if err != nil {
+				return err
+			}
+			if old == nil {
+				return ErrFull
+			}
+			removed = append(removed, *old)
+		}
+
+		seq, err := tx.Bucket(bucketMeta).NextSequence()
+		if err != nil {
+			return err
+		}
+		b, err := tx.Bucket(priorityBucket(priority)).CreateBucketIfNotExists([]byte(r.Group))
+		if err != nil {
+			return err
+		}
+		if err := b.Put(seqKey(seq), value); err != nil {
+			return err
+		}
+		if r.Key != "" {
+			return tx.Bucket(bucketIndex).Put([]byte(r.Key), indexValue(priority, seq, r.Group))
+		}
+		return nil
+	})
+	if err == ErrFull {
+		droppedMessages.WithLabelValues(r.Group, "full").Inc()
+		full := q.full[r.Group]
+		q.full[r.Group] = true
+		handler := q.onBackpressure
+		q.lock.Unlock()
+		if !full && handler != nil {
+			handler(r.Group, true)
+		}
+		return err
+	}
+	if err != nil {
+		q.lock.Unlock()
+		return fmt.Errorf("failed to store message: %v", err)
+	}
+
+	for _, old := range removed {
+		q.counts[old.Group]--
+		if old.Key == "" {
+			droppedMessages.WithLabelValues(old.Group, "evicted").Inc()
+		} else {
+			droppedMessages.WithLabelValues(old.Group, "coalesced").Inc()
+		}
+	}
+	q.counts[r.Group]++
+	queuedMessages.WithLabelValues(r.Group).Set(float64(q.counts[r.Group]))
+	q.lock.Unlock()
+
+	select {
+	case q.notify[classOf(priority)] <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (q *Queue) limit(group string) int {
+	if limit, ok := q.limits[group]; ok {
+		return limit
+	}
+	return q.defaultLimit
+}
+
+// removeByKey removes the message of the coalescing key
+func (q *Queue) removeByKey(tx *bolt.Tx, key string) (*record, error) {
+	v := tx.Bucket(bucketIndex).Get([]byte(key))
+	if v == nil {
+		return nil, nil
+	}
+	priority, seq, group := parseIndexValue(v)
+	if err := tx.Bucket(bucketIndex).Delete([]byte(key)); err != nil {
+		return nil, err
+	}
+	b := tx.Bucket(priorityBucket(priority)).Bucket([]byte(group))
+	if b == nil {
+		return nil, nil
+	}
+	old := b.Get(seqKey(seq))
+	if old == nil {
+		return nil, nil
+	}
+	var r record
+	if err := json.Unmarshal(old, &r); err != nil {
+		return nil, err
+	}
+	return &r, b.Delete(seqKey(seq))
+}
+
+// evict removes the oldest message of the group in the lowest class lower than priority
+func (q *Queue) evict(tx *bolt.Tx, group string, priority int) (*record, error) {
+	for p := numPriorities - 1; p > priority; p-- {
+		b := tx.Bucket(priorityBucket(p)).Bucket([]byte(group))
+		if b == nil {
+			continue
+		}
+		c := b.Cursor()
+		if k, v := c.First(); k != nil {
+			var r record
+			if err := json.Unmarshal(v, &r); err != nil {
+				return nil, err
+			}
+			if r.Key != "" {
+				if err := tx.Bucket(bucketIndex).Delete([]byte(r.Key)); err != nil {
+					return nil, err
+				}
+				// the evicted one is not counted as coalesced
+				r.Key = ""
+			}
+			return &r, c.Delete()
+		}
+	}
+	return nil, nil
+}
+
+// Next returns the first message of the highest priority class in the traffic class whose group is not
+// skipped, it blocks until a message of the traffic class is pushed if there is none. The messages of
+// each traffic class are drained apart, so the bulk messages never hold the control plane messages.
+// The nil entry is returned if stop is closed or wake fires, wake may be nil. The message is kept in
+// the queue until it is removed by Remove.
+func (q *Queue) Next(class api.TrafficClass, stop <-chan struct{}, wake <-chan time.Time, skip func(group string) bool) (*Entry, error) {
+	for {
+		entry, err := q.peek(class, skip)
+		if err != nil || entry != nil {
+			return entry, err
+		}
+		select {
+		case <-q.notify[class]:
+		case <-wake:
+			return nil, nil
+		case <-stop:
+			return nil, nil
+		}
+	}
+}
+
+// peek returns the message arrived first among the first messages of the groups not skipped,
+// so it costs the number of groups rather than the number of messages skipped
+func (q *Queue) peek(class api.TrafficClass, skip func(group string) bool) (*Entry, error) {
+	var entry *Entry
+	err := q.db.View(func(tx *bolt.Tx) error {
+		for p := 0; p < numPriorities; p++ {
+			if classOf(p) != class {
+				continue
+			}
+			var first []byte
+			var group []byte
+			pb := tx.Bucket(priorityBucket(p))
+			err := pb.ForEach(func(name, _ []byte) error {
+				if skip != nil && skip(string(name)) {
+					return nil
+				}
+				k, _ := pb.Bucket(name).Cursor().First()
+				if k != nil && (first == nil || bytes.Compare(k, first) < 0) {
+					first, group = k, name
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if first == nil {
+				continue
+			}
+			var r record
+			if err := json.Unmarshal(pb.Bucket(group).Get(first), &r); err != nil {
+				return err
+			}
+			if r.Raw != nil {
+				r.Message.Content = r.Raw
+			}
+			entry = &Entry{Message: r.Message, priority: p, group: string(group), seq: binary.BigEndian.Uint64(first)}
+			return nil
+		}
+		return nil
+	})
+	return entry, err
+}
+
+// Remove removes the message of the entry after it is sent
+func (q *Queue) Remove(entry *Entry) error {
+	q.lock.Lock()
+	var removed *record
+	err := q.db.Update(func(tx *bolt.Tx) error {
+		removed = nil
+		b := tx.Bucket(priorityBucket(entry.priority)).Bucket([]byte(entry.group))
+		if b == nil {
+			return nil
+		}
+		v := b.Get(seqKey(entry.seq))
+		if v == nil {
+			// coalesced or evicted after peeked
+			return nil
+		}
+		var r record
+		if err := json.Unmarshal(v, &r); err != nil {
+			return err
+		}
+		if r.Key != "" {
+			// the index may point to the newer message of the key
+			if iv := tx.Bucket(bucketIndex).Get([]byte(r.Key)); iv != nil {
+				if p, seq, _ := parseIndexValue(iv); p == entry.priority && seq == entry.seq {
+					if err := tx.Bucket(bucketIndex).Delete([]byte(r.Key)); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		removed = &r
+		return b.Delete(seqKey(entry.seq))
+	})
+	if err != nil {
+		q.lock.Unlock()
+		return fmt.Errorf("failed to remove message: %v", err)
+	}
+	if removed == nil {
+		q.lock.Unlock()
+		return nil
+	}
+	group := removed.Group
+	q.counts[group]--
+	queuedMessages.WithLabelValues(group).Set(float64(q.counts[group]))
+	available := q.full[group] && q.counts[group] <= q.limit(group)/2
+	if available {
+		delete(q.full, group)
+	}
+	handler := q.onBackpressure
+	q.lock.Unlock()
+	if available && handler != nil {
+		handler(group, false)
+	}
+	return nil
+}
+
+// Len returns the number of queued messages of the group
+func (q *Queue) Len(group string) int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.counts[group]
+}
+
+// Empty reports whether there is no queued message
+func (q *Queue) Empty() bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for _, count := range q.counts {
+		if count > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func priorityBucket(priority int) []byte {
+	return []byte(fmt.Sprintf("priority-%d", priority))
+}
+
+func seqKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
+
+// indexValue is the location of the message of a coalescing key, which is the priority, the sequence and the group
+func indexValue(priority int, seq uint64, group string) []byte {
+	value := make([]byte, 9+len(group))
+	value[0] = byte(priority)
+	binary.BigEndian.PutUint64(value[1:9], seq)
+	copy(value[9:], group)
+	return value
+}
+
+func parseIndexValue(value []byte) (int, uint64, string) {
+	return int(value[0]), binary.BigEndian.Uint64(value[1:9]), string(value[9:])
+}
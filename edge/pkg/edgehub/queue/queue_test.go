@@ -0,0 +1,279 @@
+package queue
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubeedge/beehive/pkg/core/model"
+	messagepkg "github.com/kubeedge/kubeedge/edge/pkg/common/message"
+	"github.com/kubeedge/viaduct/pkg/api"
+)
+
+func newMessage(group, resource, operation string, content interface{}) model.Message {
+	return *model.NewMessage("").BuildRouter("edged", group, resource, operation).FillBody(content)
+}
+
+// drain removes all messages in the queue and returns their contents in order
+func drain(t *testing.T, q *Queue) []interface{} {
+	t.Helper()
+	stop := make(chan struct{})
+	close(stop)
+	var contents []interface{}
+	for {
+		entry, err := q.Next(api.TrafficClassControl, stop, nil, nil)
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		if entry == nil {
+			entry, err = q.Next(api.TrafficClassBulk, stop, nil, nil)
+			if err != nil {
+				t.Fatalf("Next() error = %v", err)
+			}
+		}
+		if entry == nil {
+			return contents
+		}
+		contents = append(contents, entry.Message.GetContent())
+		if err := q.Remove(entry); err != nil {
+			t.Fatalf("Remove() error = %v", err)
+		}
+	}
+}
+
+func TestQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.db")
+	q, err := Open(path, map[string]int32{messagepkg.TwinGroupName: 2}, 10)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	msgs := []model.Message{
+		newMessage(messagepkg.TwinGroupName, "default/device/d1", model.UpdateOperation, "twin-1"),
+		newMessage(messagepkg.ResourceGroupName, "default/podstatus/p1", model.UpdateOperation, "pod-1"),
+		newMessage(messagepkg.ResourceGroupName, "default/nodestatus/n1", model.UpdateOperation, "node-1"),
+		newMessage(messagepkg.ResourceGroupName, "default/podstatus/p1", model.UpdateOperation, "pod-2"),
+		newMessage(messagepkg.TwinGroupName, "default/device/d2", model.UpdateOperation, []byte("twin-2")),
+		newMessage(messagepkg.ResourceGroupName, "default/nodestatus/n1", model.UpdateOperation, "node-2"),
+	}
+	for _, msg := range msgs {
+		if err := q.Push(msg); err != nil {
+			t.Fatalf("Push() error = %v", err)
+		}
+	}
+	// the twin group is full and there is no message of lower priority to drop
+	if err := q.Push(newMessage(messagepkg.TwinGroupName, "default/device/d3", model.UpdateOperation, "twin-3")); err != ErrFull {
+		t.Errorf("expected ErrFull, but got %v", err)
+	}
+	if got := q.Len(messagepkg.ResourceGroupName); got != 2 {
+		t.Errorf("expected 2 coalesced resource messages, but got %d", got)
+	}
+
+	// the queued messages are kept after reopening
+	if err := q.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	q, err = Open(path, map[string]int32{messagepkg.TwinGroupName: 2}, 10)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer q.Close()
+
+	want := []string{"node-2", "pod-2", "twin-1", "twin-2"}
+	got := drain(t, q)
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, but got %v", want, got)
+	}
+	for i := range want {
+		var content string
+		switch c := got[i].(type) {
+		case string:
+			content = c
+		case []byte:
+			content = string(c)
+		}
+		if content != want[i] {
+			t.Errorf("expected %v, but got %v", want, got)
+			break
+		}
+	}
+	if got := q.Len(messagepkg.TwinGroupName); got != 0 {
+		t.Errorf("expected empty twin group, but got %d", got)
+	}
+}
+
+func TestQueueEvict(t *testing.T) {
+	q, err := Open(filepath.Join(t.TempDir(), "queue.db"), nil, 2)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer q.Close()
+
+	msgs := []model.Message{
+		newMessage(messagepkg.ResourceGroupName, "default/configmap/c1", model.InsertOperation, "configmap-1"),
+		newMessage(messagepkg.ResourceGroupName, "default/configmap/c2", model.InsertOperation, "configmap-2"),
+		// the oldest message of lower priority is dropped for node status
+		newMessage(messagepkg.ResourceGroupName, "default/nodestatus/n1", model.UpdateOperation, "node-1"),
+	}
+	for _, msg := range msgs {
+		if err := q.Push(msg); err != nil {
+			t.Fatalf("Push() error = %v", err)
+		}
+	}
+	got := drain(t, q)
+	if len(got) != 2 || got[0] != "node-1" || got[1] != "configmap-2" {
+		t.Errorf("expected [node-1 configmap-2], but got %v", got)
+	}
+}
+
+func TestQueueSkip(t *testing.T) {
+	q, err := Open(filepath.Join(t.TempDir(), "queue.db"), nil, 10)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer q.Close()
+
+	msgs := []model.Message{
+		newMessage(messagepkg.UserGroupName, "default/user/u1", model.UpdateOperation, "user-1"),
+		newMessage(messagepkg.TwinGroupName, "default/device/d1", model.UpdateOperation, "twin-1"),
+	}
+	for _, msg := range msgs {
+		if err := q.Push(msg); err != nil {
+			t.Fatalf("Push() error = %v", err)
+		}
+	}
+	stop := make(chan struct{})
+	close(stop)
+	skipUser := func(group string) bool { return group == messagepkg.UserGroupName }
+	// the message of the same priority behind the skipped group is returned
+	entry, err := q.Next(api.TrafficClassBulk, stop, nil, skipUser)
+	if err != nil || entry == nil || entry.Message.GetContent() != "twin-1" {
+		t.Fatalf("expected twin-1, but got %+v, err: %v", entry, err)
+	}
+	if err := q.Remove(entry); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	// the skipped messages are kept in the queue
+	wake := make(chan time.Time, 1)
+	wake <- time.Now()
+	if entry, err := q.Next(api.TrafficClassBulk, make(chan struct{}), wake, skipUser); err != nil || entry != nil {
+		t.Errorf("expected nil entry on wake, but got %+v, err: %v", entry, err)
+	}
+	if got := drain(t, q); len(got) != 1 || got[0] != "user-1" {
+		t.Errorf("expected [user-1], but got %v", got)
+	}
+}
+
+func TestQueueBackpressure(t *testing.T) {
+	q, err := Open(filepath.Join(t.TempDir(), "queue.db"), nil, 4)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer q.Close()
+	var states []bool
+	q.OnBackpressure(func(group string, full bool) {
+		if group != messagepkg.TwinGroupName {
+			t.Errorf("unexpected group %s", group)
+		}
+		states = append(states, full)
+	})
+
+	if !q.Empty() {
+		t.Errorf("expected empty queue")
+	}
+	for i := 0; i < 6; i++ {
+		msg := newMessage(messagepkg.TwinGroupName, fmt.Sprintf("default/device/d%d", i), model.UpdateOperation, i)
+		if err := q.Push(msg); err != nil && err != ErrFull {
+			t.Fatalf("Push() error = %v", err)
+		}
+	}
+	// the producers are told once when the group becomes full
+	if len(states) != 1 || !states[0] {
+		t.Fatalf("expected [true], but got %v", states)
+	}
+	stop := make(chan struct{})
+	close(stop)
+	for i := 0; i < 2; i++ {
+		entry, err := q.Next(api.TrafficClassBulk, stop, nil, nil)
+		if err != nil || entry == nil {
+			t.Fatalf("expected entry, but got %+v, err: %v", entry, err)
+		}
+		if err := q.Remove(entry); err != nil {
+			t.Fatalf("Remove() error = %v", err)
+		}
+	}
+	// and once when half of the group is drained
+	if len(states) != 2 || states[1] {
+		t.Errorf("expected [true false], but got %v", states)
+	}
+	if q.Empty() {
+		t.Errorf("expected queued messages")
+	}
+}
+
+func TestPriorityOf(t *testing.T) {
+	tests := map[string]struct {
+		msg  model.Message
+		want int
+	}{
+		"node status": {newMessage(messagepkg.ResourceGroupName, "default/nodestatus/n1", model.UpdateOperation, nil), PriorityHigh},
+		"lease":       {newMessage(messagepkg.ResourceGroupName, "kube-node-lease/lease/n1", model.UpdateOperation, nil), PriorityHigh},
+		"pod status":  {newMessage(messagepkg.ResourceGroupName, "default/podstatus/p1", model.UpdateOperation, nil), PriorityNormal},
+		"twin":        {newMessage(messagepkg.TwinGroupName, "default/device/d1", model.UpdateOperation, nil), PriorityLow},
+		"user":        {newMessage(messagepkg.UserGroupName, "topic", model.UploadOperation, nil), PriorityLow},
+	}
+	for name, tt := range tests {
+		if got := PriorityOf(tt.msg); got != tt.want {
+			t.Errorf("%s: PriorityOf() = %d, want %d", name, got, tt.want)
+		}
+	}
+}
+
+func TestQueueTrafficClass(t *testing.T) {
+	q, err := Open(filepath.Join(t.TempDir(), "queue.db"), nil, 10)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer q.Close()
+
+	msgs := []model.Message{
+		newMessage(messagepkg.TwinGroupName, "default/device/d1", model.UpdateOperation, "twin-1"),
+		newMessage(messagepkg.ResourceGroupName, "default/configmap/c1", model.InsertOperation, "configmap-1"),
+		newMessage(messagepkg.UserGroupName, "default/user/u1", model.UpdateOperation, "user-1"),
+	}
+	for _, msg := range msgs {
+		if err := q.Push(msg); err != nil {
+			t.Fatalf("Push() error = %v", err)
+		}
+	}
+	stop := make(chan struct{})
+	close(stop)
+	next := func(class api.TrafficClass) interface{} {
+		entry, err := q.Next(class, stop, nil, nil)
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		if entry == nil {
+			return nil
+		}
+		if err := q.Remove(entry); err != nil {
+			t.Fatalf("Remove() error = %v", err)
+		}
+		return entry.Message.GetContent()
+	}
+	// the control plane messages are drained apart from the bulk messages queued before them
+	if got := next(api.TrafficClassControl); got != "configmap-1" {
+		t.Errorf("expected configmap-1, but got %v", got)
+	}
+	if got := next(api.TrafficClassControl); got != nil {
+		t.Errorf("expected no control plane message, but got %v", got)
+	}
+	// the bulk messages of the groups are drained in order of arrival
+	if got := next(api.TrafficClassBulk); got != "twin-1" {
+		t.Errorf("expected twin-1, but got %v", got)
+	}
+	if got := next(api.TrafficClassBulk); got != "user-1" {
+		t.Errorf("expected user-1, but got %v", got)
+	}
+}
@@ -29,6 +29,9 @@ import (
 	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
 	"github.com/kubeedge/kubeedge/edge/pkg/common/modules"
 	"github.com/kubeedge/kubeedge/edge/pkg/edgehub"
+	edgehubhttp "github.com/kubeedge/kubeedge/edge/pkg/edgehub/common/http"
+	"github.com/kubeedge/kubeedge/edge/pkg/edgehub/common/msghandler"
+	edgehubconfig "github.com/kubeedge/kubeedge/edge/pkg/edgehub/config"
 	"github.com/kubeedge/kubeedge/edge/pkg/edgestream/config"
 	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha2"
 	"github.com/kubeedge/kubeedge/pkg/stream"
@@ -38,6 +41,10 @@ type edgestream struct {
 	enable           bool
 	hostnameOverride string
 	nodeIP           string
+	// proxy is the proxy of EdgeHub to connect the tunnel server, nil means no proxy
+	proxy edgehubhttp.ProxyFunc
+	// hubTunnels holds the tunnel over the CloudHub connection if TunnelOverCloudHub is set
+	hubTunnels hubTunnels
 }
 
 var _ core.Module = (*edgestream)(nil)
@@ -53,6 +60,9 @@ func newEdgeStream(enable bool, hostnameOverride, nodeIP string) *edgestream {
 // Register register edgestream
 func Register(s *v1alpha2.EdgeStream, hostnameOverride, nodeIP string) {
 	config.InitConfigure(s)
+	if s.Enable && s.TunnelOverCloudHub {
+		msghandler.RegisterHandler(&hubHandler{})
+	}
 	core.Register(newEdgeStream(s.Enable, hostnameOverride, nodeIP))
 }
 
@@ -78,6 +88,10 @@ func (e *edgestream) Start() {
 	if ok := <-edgehub.GetCertSyncChannel()[e.Name()]; !ok {
 		klog.Exitf("Failed to find cert key pair")
 	}
+	if config.Config.TunnelOverCloudHub {
+		e.startOverHub()
+		return
+	}
 
 	cert, err := tls.LoadX509KeyPair(config.Config.TLSTunnelCertFile, config.Config.TLSTunnelPrivateKeyFile)
 	if err != nil {
@@ -87,6 +101,7 @@ func (e *edgestream) Start() {
 		InsecureSkipVerify: true,
 		Certificates:       []tls.Certificate{cert},
 	}
+	e.proxy = edgehubhttp.NewProxyFunc(edgehubconfig.Config.Proxy)
 
 	ticker := time.NewTicker(time.Second * 2)
 	defer ticker.Stop()
@@ -110,6 +125,7 @@ func (e *edgestream) TLSClientConnect(url url.URL, tlsConfig *tls.Config) error
 	dial := websocket.Dialer{
 		TLSClientConfig:  tlsConfig,
 		HandshakeTimeout: time.Duration(config.Config.HandshakeTimeout) * time.Second,
+		Proxy:            e.proxy,
 	}
 	header := http.Header{}
 	header.Add(stream.SessionKeyHostNameOverride, e.hostnameOverride)
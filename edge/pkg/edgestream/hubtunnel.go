@@ -0,0 +1,145 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package edgestream
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
+	"github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/kubeedge/edge/pkg/common/modules"
+	"github.com/kubeedge/kubeedge/edge/pkg/edgehub/clients"
+	"github.com/kubeedge/kubeedge/pkg/stream"
+)
+
+// hubHandler routes the stream tunnel messages received by EdgeHub to EdgeStream
+type hubHandler struct {
+}
+
+func (*hubHandler) Filter(message *model.Message) bool {
+	return message.GetGroup() == stream.HubGroup
+}
+
+func (*hubHandler) Process(message *model.Message, clientHub clients.Adapter) error {
+	beehiveContext.Send(modules.EdgeStreamModuleName, *message)
+	return nil
+}
+
+// hubTunnels holds the tunnel over the CloudHub connection being served
+type hubTunnels struct {
+	sync.Mutex
+	current *stream.HubTunnel
+}
+
+func (h *hubTunnels) set(tunnel *stream.HubTunnel) {
+	h.Lock()
+	defer h.Unlock()
+	h.current = tunnel
+}
+
+func (h *hubTunnels) get() *stream.HubTunnel {
+	h.Lock()
+	defer h.Unlock()
+	return h.current
+}
+
+// sendToHub sends the stream tunnel message to the cloud by EdgeHub
+func sendToHub(operation string, content []byte) {
+	message := model.NewMessage("").
+		BuildRouter(modules.EdgeStreamModuleName, stream.HubGroup, stream.HubResourceTunnel, operation).
+		FillBody(content)
+	beehiveContext.Send(modules.EdgeHubModuleName, *message)
+}
+
+// startOverHub serves the tunnel over the CloudHub connection of EdgeHub,
+// the tunnel is opened again every 2 seconds after it is closed
+func (e *edgestream) startOverHub() {
+	go e.receiveFromHub()
+
+	ticker := time.NewTicker(time.Second * 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-beehiveContext.Done():
+			return
+		case <-ticker.C:
+			err := e.hubConnect()
+			if err != nil {
+				klog.Errorf("tunnel over cloudhub error %v", err)
+			}
+		}
+	}
+}
+
+func (e *edgestream) hubConnect() error {
+	klog.Info("Start a new tunnel stream over cloudhub connection ...")
+
+	info, err := json.Marshal(stream.ConnectInfo{
+		HostNameOverride: e.hostnameOverride,
+		InternalIP:       e.nodeIP,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal connect info: %v", err)
+	}
+
+	tunnel := stream.NewHubTunnel(func(m *stream.Message) error {
+		sendToHub(stream.HubOperationTunnel, m.Bytes())
+		return nil
+	})
+	e.hubTunnels.set(tunnel)
+	sendToHub(stream.HubOperationConnect, info)
+
+	session := newTunnelSession(tunnel)
+	return session.Serve()
+}
+
+// receiveFromHub delivers the stream tunnel messages received by EdgeHub to the tunnel being served
+func (e *edgestream) receiveFromHub() {
+	for {
+		select {
+		case <-beehiveContext.Done():
+			return
+		default:
+		}
+		message, err := beehiveContext.Receive(modules.EdgeStreamModuleName)
+		if err != nil {
+			klog.Errorf("failed to receive stream message: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		content, err := message.GetContentData()
+		if err != nil {
+			klog.Errorf("failed to get content of stream message %s: %v", message.GetID(), err)
+			continue
+		}
+		m, err := stream.ReadMessageFromTunnel(bytes.NewReader(content))
+		if err != nil {
+			klog.Errorf("failed to read stream message %s: %v", message.GetID(), err)
+			continue
+		}
+		if tunnel := e.hubTunnels.get(); tunnel != nil {
+			tunnel.Deliver(m)
+		}
+	}
+}
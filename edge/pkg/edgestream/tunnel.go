@@ -39,10 +39,14 @@ type TunnelSession struct {
 }
 
 func NewTunnelSession(c *websocket.Conn) *TunnelSession {
+	return newTunnelSession(stream.NewDefaultTunnel(c))
+}
+
+func newTunnelSession(tunnel stream.SafeWriteTunneler) *TunnelSession {
 	return &TunnelSession{
 		closeLock:     sync.Mutex{},
 		localConsLock: sync.RWMutex{},
-		Tunnel:        stream.NewDefaultTunnel(c),
+		Tunnel:        tunnel,
 		localCons:     make(map[uint64]stream.EdgedConnection, 128),
 	}
 }
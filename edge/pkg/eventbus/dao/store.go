@@ -0,0 +1,86 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dao
+
+import (
+	"k8s.io/klog/v2"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+)
+
+// topicStore is the storage of sub_topics on the backend selected
+type topicStore interface {
+	// insert saves the topic, the existing one is replaced
+	insert(topic string) error
+	// deleteByKey deletes the topic
+	deleteByKey(topic string) error
+	// list returns all topics
+	list() (*[]SubTopics, error)
+}
+
+// getTopicStore returns the storage of the backend selected, the key-value store if it is opened
+// or the sqlite3 database accessed by orm otherwise
+func getTopicStore() topicStore {
+	if dbm.KV != nil {
+		return kvTopicStore{kv: dbm.KV}
+	}
+	return ormTopicStore{}
+}
+
+// ormTopicStore is the topicStore on the sqlite3 database accessed by orm
+type ormTopicStore struct{}
+
+func (ormTopicStore) insert(topic string) error {
+	_, err := dbm.DBAccess.Raw("INSERT OR REPLACE INTO sub_topics (topic) VALUES (?)", topic).Exec()
+	klog.V(4).Infof("INSERT result %v", err)
+	return err
+}
+
+func (ormTopicStore) deleteByKey(topic string) error {
+	num, err := dbm.DBAccess.QueryTable(SubTopicsName).Filter("topic", topic).Delete()
+	klog.V(4).Infof("Delete affected Num: %d, %v", num, err)
+	return err
+}
+
+func (ormTopicStore) list() (*[]SubTopics, error) {
+	topics := new([]SubTopics)
+	if _, err := dbm.DBAccess.QueryTable(SubTopicsName).All(topics); err != nil {
+		return nil, err
+	}
+	return topics, nil
+}
+
+// kvTopicStore is the topicStore on the key-value store, the topics are keyed by themselves
+type kvTopicStore struct {
+	kv dbm.KVStore
+}
+
+func (s kvTopicStore) insert(topic string) error {
+	return s.kv.Save(SubTopicsName, &SubTopics{Topic: topic})
+}
+
+func (s kvTopicStore) deleteByKey(topic string) error {
+	return s.kv.Delete(SubTopicsName, &SubTopics{Topic: topic})
+}
+
+func (s kvTopicStore) list() (*[]SubTopics, error) {
+	topics := new([]SubTopics)
+	if _, err := dbm.KVQuery(s.kv, SubTopicsName, topics); err != nil {
+		return nil, err
+	}
+	return topics, nil
+}
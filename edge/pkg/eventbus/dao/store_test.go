@@ -0,0 +1,50 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dao
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+)
+
+func TestKVTopicStore(t *testing.T) {
+	kv, err := dbm.NewBoltStore(filepath.Join(t.TempDir(), "edgecore.bolt"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer kv.Close()
+	s := kvTopicStore{kv: kv}
+
+	for _, topic := range []string{"b", "a", "b"} {
+		if err := s.insert(topic); err != nil {
+			t.Fatalf("failed to insert: %v", err)
+		}
+	}
+	if err := s.deleteByKey("a"); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	topics, err := s.list()
+	if err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	if expected := []SubTopics{{Topic: "b"}}; !reflect.DeepEqual(*topics, expected) {
+		t.Errorf("expected topics %v, but got %v", expected, *topics)
+	}
+}
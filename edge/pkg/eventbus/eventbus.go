@@ -164,6 +164,9 @@ func (eb *eventbus) pubCloudMsgToEdge() {
 			topic := fmt.Sprintf("$hw/events/node/%s/authInfo/get/result", eventconfig.Config.NodeName)
 			payload, _ := json.Marshal(accessInfo.GetContent())
 			eb.publish(topic, payload)
+		case messagepkg.OperationOutboundQueue:
+			// the messages from mqtt can not be held, they are rejected until the queue is available
+			klog.Warningf("Outbound queue event occur: %v", accessInfo.GetContent())
 		default:
 			klog.Warningf("Action not found")
 		}
@@ -57,6 +57,10 @@ func (c *configMaps) Get(name string) (*api.ConfigMap, error) {
 		return nil, fmt.Errorf("get configmap from metaManager failed, err: %v", err)
 	}
 
+	if err := responseError(msg); err != nil {
+		return nil, err
+	}
+
 	content, err := msg.GetContentData()
 	if err != nil {
 		return nil, fmt.Errorf("parse message to configmap failed, err: %v", err)
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/kubeedge/edge/pkg/common/modules"
+)
+
+// fakeSend answers the sync messages with resp
+type fakeSend struct {
+	resp *model.Message
+}
+
+func (s *fakeSend) SendSync(message *model.Message) (*model.Message, error) {
+	resp := s.resp
+	resp.Header.ParentID = message.GetID()
+	return resp, nil
+}
+
+func (s *fakeSend) Send(message *model.Message) {}
+
+func newResponse(operation string, content interface{}) *model.Message {
+	return model.NewMessage("").
+		BuildRouter(modules.MetaManagerModuleName, modules.MetaGroup, "default/configmap/cm1", operation).
+		FillBody(content)
+}
+
+func TestGetConfigMap(t *testing.T) {
+	notFound := apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, "cm1").Status()
+	configMap := `{"metadata":{"name":"cm1","namespace":"default"}}`
+
+	cases := []struct {
+		name     string
+		resp     *model.Message
+		check    func(error) bool
+		wantName string
+	}{
+		{
+			name:     "found in meta db",
+			resp:     newResponse(model.ResponseOperation, []string{configMap}),
+			check:    func(err error) bool { return err == nil },
+			wantName: "cm1",
+		},
+		{
+			name:  "not found in cloud or by negative cache",
+			resp:  newResponse(model.ResponseErrorOperation, notFound),
+			check: apierrors.IsNotFound,
+		},
+		{
+			name: "other error",
+			resp: newResponse(model.ResponseErrorOperation, "Error to query meta in DB: timeout"),
+			check: func(err error) bool {
+				return err != nil && !apierrors.IsNotFound(err) && err.Error() == "Error to query meta in DB: timeout"
+			},
+		},
+	}
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			cm, err := newConfigMaps(metav1.NamespaceDefault, &fakeSend{resp: test.resp}).Get("cm1")
+			if !test.check(err) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if test.wantName != "" && (cm == nil || cm.Name != test.wantName) {
+				t.Errorf("expected configmap %s, but got %v", test.wantName, cm)
+			}
+			if test.wantName == "" && cm != (*api.ConfigMap)(nil) {
+				t.Errorf("expected no configmap, but got %v", cm)
+			}
+		})
+	}
+}
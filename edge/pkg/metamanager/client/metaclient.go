@@ -1,8 +1,12 @@
 package client
 
 import (
+	"encoding/json"
+	"errors"
 	"time"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog/v2"
 
@@ -133,3 +137,24 @@ func SetSyncPeriod(time time.Duration) {
 func SetSyncMsgRespTimeout(time time.Duration) {
 	syncMsgRespTimeout = time
 }
+
+// responseError returns the error answered by metaManager, the status answered is returned
+// as the api error, so that it can be checked such as by apierrors.IsNotFound
+func responseError(msg *model.Message) error {
+	if msg.GetOperation() != model.ResponseErrorOperation {
+		return nil
+	}
+	content, err := msg.GetContentData()
+	if err != nil {
+		return err
+	}
+	var status metav1.Status
+	if err := json.Unmarshal(content, &status); err == nil && status.Reason != "" {
+		return &apierrors.StatusError{ErrStatus: status}
+	}
+	var reason string
+	if err := json.Unmarshal(content, &reason); err == nil {
+		return errors.New(reason)
+	}
+	return errors.New(string(content))
+}
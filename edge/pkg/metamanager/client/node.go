@@ -99,6 +99,10 @@ func (c *nodes) Get(name string) (*api.Node, error) {
 		return nil, fmt.Errorf("get node failed, err: %v", err)
 	}
 
+	if err := responseError(msg); err != nil {
+		return nil, err
+	}
+
 	content, err := msg.GetContentData()
 	if err != nil {
 		return nil, fmt.Errorf("parse message to node failed, err: %v", err)
@@ -57,6 +57,10 @@ func (c *persistentvolumes) Get(name string, options metav1.GetOptions) (*api.Pe
 		return nil, fmt.Errorf("get persistentvolume from metaManager failed, err: %v", err)
 	}
 
+	if err := responseError(msg); err != nil {
+		return nil, err
+	}
+
 	content, err := msg.GetContentData()
 	if err != nil {
 		return nil, fmt.Errorf("parse message to persistentvolume failed, err: %v", err)
@@ -57,6 +57,10 @@ func (c *persistentvolumeclaims) Get(name string, options metav1.GetOptions) (*a
 		return nil, fmt.Errorf("get persistentvolumeclaim from metaManager failed, err: %v", err)
 	}
 
+	if err := responseError(msg); err != nil {
+		return nil, err
+	}
+
 	content, err := msg.GetContentData()
 	if err != nil {
 		return nil, fmt.Errorf("parse message to persistentvolumeclaim failed, err: %v", err)
@@ -56,6 +56,10 @@ func (c *secrets) Get(name string) (*api.Secret, error) {
 		return nil, fmt.Errorf("get secret from metaManager failed, err: %v", err)
 	}
 
+	if err := responseError(msg); err != nil {
+		return nil, err
+	}
+
 	content, err := msg.GetContentData()
 	if err != nil {
 		return nil, fmt.Errorf("parse message to secret failed, err: %v", err)
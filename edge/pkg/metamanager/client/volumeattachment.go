@@ -69,6 +69,10 @@ func (c *volumeattachments) Get(name string, options metav1.GetOptions) (*api.Vo
 		return nil, fmt.Errorf("get volumeattachment from metaManager failed, err: %v", err)
 	}
 
+	if err := responseError(msg); err != nil {
+		return nil, err
+	}
+
 	content, err := msg.GetContentData()
 	if err != nil {
 		return nil, fmt.Errorf("parse message to volumeattachment failed, err: %v", err)
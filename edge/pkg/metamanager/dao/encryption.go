@@ -0,0 +1,81 @@
+package dao
+
+import (
+	"k8s.io/klog/v2"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/encryption"
+)
+
+// encryptMeta returns a copy of meta with the value encrypted if its type should be encrypted
+func encryptMeta(meta *Meta) (*Meta, error) {
+	if !encryption.ResourceEncrypted(meta.Type) {
+		return meta, nil
+	}
+	value, err := encryption.Encrypt(meta.Key, meta.Value)
+	if err != nil {
+		return nil, err
+	}
+	encrypted := *meta
+	encrypted.Value = value
+	return &encrypted, nil
+}
+
+// encryptMetaCols returns a copy of cols with the value encrypted if the type of meta should be encrypted
+func encryptMetaCols(key string, cols map[string]interface{}) (map[string]interface{}, error) {
+	value, ok := cols["value"].(string)
+	if !ok || !encryption.Loaded() {
+		return cols, nil
+	}
+	resType, ok := cols["type"].(string)
+	if !ok {
+		metas, err := queryRawMeta("key", key)
+		if err != nil || len(*metas) == 0 {
+			return cols, err
+		}
+		resType = (*metas)[0].Type
+	}
+	if !encryption.ResourceEncrypted(resType) {
+		return cols, nil
+	}
+	encrypted, err := encryption.Encrypt(key, value)
+	if err != nil {
+		return nil, err
+	}
+	newCols := make(map[string]interface{}, len(cols))
+	for k, v := range cols {
+		newCols[k] = v
+	}
+	newCols["value"] = encrypted
+	return newCols, nil
+}
+
+// ReencryptMeta transforms the values in table meta to the form they should be stored with now,
+// values are encrypted by the current data key if their types should be encrypted, or decrypted otherwise
+func ReencryptMeta() error {
+	metas, err := getMetaStore().list()
+	if err != nil {
+		return err
+	}
+	var num int
+	for _, m := range *metas {
+		value, changed, err := encryption.Transform(m.Key, m.Value, encryption.ResourceEncrypted(m.Type))
+		if err != nil {
+			return err
+		}
+		if !changed {
+			continue
+		}
+		if err := swapMetaValue(m.Key, m.Value, value); err != nil {
+			return err
+		}
+		num++
+	}
+	klog.Infof("[metamanager/encryption] %d values in table %s re-encrypted", num, MetaTableName)
+	return nil
+}
+
+// swapMetaValue sets the value of meta to newValue only if it is still oldValue,
+// so that the value written by others during re-encryption is not overwritten
+func swapMetaValue(key, oldValue, newValue string) error {
+	return getMetaStore().swapValue(key, oldValue, newValue)
+}
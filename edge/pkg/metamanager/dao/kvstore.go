@@ -0,0 +1,71 @@
+package dao
+
+import (
+	"k8s.io/klog/v2"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+)
+
+// kvMetaStore is the metaStore on the key-value store, the metas are keyed by their keys
+type kvMetaStore struct {
+	kv dbm.KVStore
+}
+
+func (s kvMetaStore) insert(meta *Meta) error {
+	err := s.kv.Insert(MetaTableName, meta)
+	if err == dbm.ErrKeyExists {
+		return nil
+	}
+	return err
+}
+
+func (s kvMetaStore) save(meta *Meta) error {
+	return s.kv.Save(MetaTableName, meta)
+}
+
+func (s kvMetaStore) update(meta *Meta) error {
+	return s.updateFields(meta.Key, map[string]interface{}{"type": meta.Type, "value": meta.Value})
+}
+
+func (s kvMetaStore) updateFields(key string, cols map[string]interface{}) error {
+	num, err := dbm.KVUpdateByKey(s.kv, MetaTableName, &Meta{Key: key}, cols)
+	klog.V(4).Infof("Update affected Num: %d, %v", num, err)
+	return err
+}
+
+func (s kvMetaStore) deleteByKey(key string) error {
+	return s.kv.Delete(MetaTableName, &Meta{Key: key})
+}
+
+func (s kvMetaStore) query(column, value string) (*[]Meta, error) {
+	meta := new([]Meta)
+	if column == "key" {
+		// query by primary key
+		m := &Meta{Key: value}
+		found, err := s.kv.Get(MetaTableName, m)
+		if found {
+			*meta = append(*meta, *m)
+		}
+		return meta, err
+	}
+	_, err := dbm.KVQuery(s.kv, MetaTableName, meta, dbm.Eq(column, value))
+	return meta, err
+}
+
+func (s kvMetaStore) list() (*[]Meta, error) {
+	meta := new([]Meta)
+	_, err := dbm.KVQuery(s.kv, MetaTableName, meta)
+	return meta, err
+}
+
+func (s kvMetaStore) swapValue(key, oldValue, newValue string) error {
+	return s.kv.Update(func(tx dbm.KVStore) error {
+		m := &Meta{Key: key}
+		found, err := tx.Get(MetaTableName, m)
+		if err != nil || !found || m.Value != oldValue {
+			return err
+		}
+		m.Value = newValue
+		return tx.Save(MetaTableName, m)
+	})
+}
@@ -3,9 +3,7 @@ package dao
 import (
 	"strings"
 
-	"k8s.io/klog/v2"
-
-	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/encryption"
 )
 
 //constant metatable name reference
@@ -22,12 +20,13 @@ type Meta struct {
 
 // SaveMeta save meta to db
 func SaveMeta(meta *Meta) error {
-	num, err := dbm.DBAccess.Insert(meta)
-	klog.V(4).Infof("Insert affected Num: %d, %v", num, err)
-	if err == nil || IsNonUniqueNameError(err) {
-		return nil
+	encryption.BeginWrite()
+	defer encryption.EndWrite()
+	meta, err := encryptMeta(meta)
+	if err != nil {
+		return err
 	}
-	return err
+	return getMetaStore().insert(meta)
 }
 
 // IsNonUniqueNameError tests if the error returned by sqlite is unique.
@@ -42,43 +41,50 @@ func IsNonUniqueNameError(err error) bool {
 
 // DeleteMetaByKey delete meta by key
 func DeleteMetaByKey(key string) error {
-	num, err := dbm.DBAccess.QueryTable(MetaTableName).Filter("key", key).Delete()
-	klog.V(4).Infof("Delete affected Num: %d, %v", num, err)
-	return err
+	return getMetaStore().deleteByKey(key)
 }
 
 // UpdateMeta update meta
 func UpdateMeta(meta *Meta) error {
-	num, err := dbm.DBAccess.Update(meta) // will update all field
-	klog.V(4).Infof("Update affected Num: %d, %v", num, err)
-	return err
+	encryption.BeginWrite()
+	defer encryption.EndWrite()
+	meta, err := encryptMeta(meta)
+	if err != nil {
+		return err
+	}
+	return getMetaStore().update(meta)
 }
 
 // InsertOrUpdate insert or update meta
 func InsertOrUpdate(meta *Meta) error {
-	_, err := dbm.DBAccess.Raw("INSERT OR REPLACE INTO meta (key, type, value) VALUES (?,?,?)", meta.Key, meta.Type, meta.Value).Exec() // will update all field
-	klog.V(4).Infof("Update result %v", err)
-	return err
+	encryption.BeginWrite()
+	defer encryption.EndWrite()
+	meta, err := encryptMeta(meta)
+	if err != nil {
+		return err
+	}
+	return getMetaStore().save(meta)
 }
 
 // UpdateMetaField update special field
 func UpdateMetaField(key string, col string, value interface{}) error {
-	num, err := dbm.DBAccess.QueryTable(MetaTableName).Filter("key", key).Update(map[string]interface{}{col: value})
-	klog.V(4).Infof("Update affected Num: %d, %v", num, err)
-	return err
+	return UpdateMetaFields(key, map[string]interface{}{col: value})
 }
 
 // UpdateMetaFields update special fields
 func UpdateMetaFields(key string, cols map[string]interface{}) error {
-	num, err := dbm.DBAccess.QueryTable(MetaTableName).Filter("key", key).Update(cols)
-	klog.V(4).Infof("Update affected Num: %d, %v", num, err)
-	return err
+	encryption.BeginWrite()
+	defer encryption.EndWrite()
+	cols, err := encryptMetaCols(key, cols)
+	if err != nil {
+		return err
+	}
+	return getMetaStore().updateFields(key, cols)
 }
 
 // QueryMeta return only meta's value, if no error, Meta not null
 func QueryMeta(key string, condition string) (*[]string, error) {
-	meta := new([]Meta)
-	_, err := dbm.DBAccess.QueryTable(MetaTableName).Filter(key, condition).All(meta)
+	meta, err := queryMeta(key, condition)
 	if err != nil {
 		return nil, err
 	}
@@ -92,11 +98,29 @@ func QueryMeta(key string, condition string) (*[]string, error) {
 
 // QueryAllMeta return all meta, if no error, Meta not null
 func QueryAllMeta(key string, condition string) (*[]Meta, error) {
-	meta := new([]Meta)
-	_, err := dbm.DBAccess.QueryTable(MetaTableName).Filter(key, condition).All(meta)
+	meta, err := queryMeta(key, condition)
 	if err != nil {
 		return nil, err
 	}
 
 	return meta, nil
 }
+
+func queryMeta(key string, condition string) (*[]Meta, error) {
+	meta, err := queryRawMeta(key, condition)
+	if err != nil {
+		return nil, err
+	}
+	for i := range *meta {
+		m := &(*meta)[i]
+		if m.Value, err = encryption.Decrypt(m.Key, m.Value); err != nil {
+			return nil, err
+		}
+	}
+	return meta, nil
+}
+
+// queryRawMeta return the meta as they are stored, the values may be encrypted
+func queryRawMeta(key string, condition string) (*[]Meta, error) {
+	return getMetaStore().query(key, condition)
+}
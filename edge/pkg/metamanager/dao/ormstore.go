@@ -0,0 +1,61 @@
+package dao
+
+import (
+	"k8s.io/klog/v2"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+)
+
+// ormMetaStore is the metaStore on the sqlite3 database accessed by orm
+type ormMetaStore struct{}
+
+func (ormMetaStore) insert(meta *Meta) error {
+	num, err := dbm.DBAccess.Insert(meta)
+	klog.V(4).Infof("Insert affected Num: %d, %v", num, err)
+	if err == nil || IsNonUniqueNameError(err) {
+		return nil
+	}
+	return err
+}
+
+func (ormMetaStore) save(meta *Meta) error {
+	_, err := dbm.DBAccess.Raw("INSERT OR REPLACE INTO meta (key, type, value) VALUES (?,?,?)", meta.Key, meta.Type, meta.Value).Exec() // will update all field
+	klog.V(4).Infof("Update result %v", err)
+	return err
+}
+
+func (ormMetaStore) update(meta *Meta) error {
+	num, err := dbm.DBAccess.Update(meta) // will update all field
+	klog.V(4).Infof("Update affected Num: %d, %v", num, err)
+	return err
+}
+
+func (ormMetaStore) updateFields(key string, cols map[string]interface{}) error {
+	num, err := dbm.DBAccess.QueryTable(MetaTableName).Filter("key", key).Update(cols)
+	klog.V(4).Infof("Update affected Num: %d, %v", num, err)
+	return err
+}
+
+func (ormMetaStore) deleteByKey(key string) error {
+	num, err := dbm.DBAccess.QueryTable(MetaTableName).Filter("key", key).Delete()
+	klog.V(4).Infof("Delete affected Num: %d, %v", num, err)
+	return err
+}
+
+func (ormMetaStore) query(column, value string) (*[]Meta, error) {
+	meta := new([]Meta)
+	_, err := dbm.DBAccess.QueryTable(MetaTableName).Filter(column, value).All(meta)
+	return meta, err
+}
+
+func (ormMetaStore) list() (*[]Meta, error) {
+	meta := new([]Meta)
+	_, err := dbm.DBAccess.QueryTable(MetaTableName).Limit(-1).All(meta)
+	return meta, err
+}
+
+func (ormMetaStore) swapValue(key, oldValue, newValue string) error {
+	_, err := dbm.DBAccess.QueryTable(MetaTableName).Filter("key", key).Filter("value", oldValue).
+		Update(map[string]interface{}{"value": newValue})
+	return err
+}
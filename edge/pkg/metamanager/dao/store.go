@@ -0,0 +1,34 @@
+package dao
+
+import (
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+)
+
+// metaStore is the storage of table meta behind the DAOs, the values are stored as they are given
+type metaStore interface {
+	// insert saves meta, nothing is changed if the key exists
+	insert(meta *Meta) error
+	// save saves meta, the existing one of the key is replaced
+	save(meta *Meta) error
+	// update updates all fields of the existing meta of the key
+	update(meta *Meta) error
+	// updateFields sets the columns of the meta of the key
+	updateFields(key string, cols map[string]interface{}) error
+	// deleteByKey deletes the meta of the key
+	deleteByKey(key string) error
+	// query returns the metas whose column equals value
+	query(column, value string) (*[]Meta, error)
+	// list returns all metas
+	list() (*[]Meta, error)
+	// swapValue sets the value of the meta of the key to newValue only if it is oldValue
+	swapValue(key, oldValue, newValue string) error
+}
+
+// getMetaStore returns the storage of the backend selected, the key-value store if it is opened
+// or the sqlite3 database accessed by orm otherwise
+func getMetaStore() metaStore {
+	if dbm.KV != nil {
+		return kvMetaStore{kv: dbm.KV}
+	}
+	return ormMetaStore{}
+}
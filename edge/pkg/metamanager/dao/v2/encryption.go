@@ -0,0 +1,197 @@
+package v2
+
+import (
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/encryption"
+	"github.com/kubeedge/kubeedge/pkg/metaserver/util"
+)
+
+// resourceEncrypted reports whether the objects of gvr should be encrypted, gvr is in the form
+// of gvr.String() like "/v1, Resource=secrets", the resource is converted to the meta type like secret
+func resourceEncrypted(gvr string) bool {
+	i := strings.LastIndex(gvr, "Resource=")
+	if i < 0 {
+		return false
+	}
+	return encryption.ResourceEncrypted(util.UnsafeResourceToKind(gvr[i+len("Resource="):]))
+}
+
+func encryptMetaV2(m *MetaV2) (*MetaV2, error) {
+	if !resourceEncrypted(m.GroupVersionResource) {
+		return m, nil
+	}
+	value, err := encryption.Encrypt(m.Key, m.Value)
+	if err != nil {
+		return nil, err
+	}
+	encrypted := *m
+	encrypted.Value = value
+	return &encrypted, nil
+}
+
+func decryptMetaV2(objs []MetaV2) error {
+	var err error
+	for i := range objs {
+		if objs[i].Value, err = encryption.Decrypt(objs[i].Key, objs[i].Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encryptEvent(event *MetaV2Event) (*MetaV2Event, error) {
+	if !resourceEncrypted(event.GroupVersionResource) {
+		return event, nil
+	}
+	value, err := encryption.Encrypt(event.Key, event.Value)
+	if err != nil {
+		return nil, err
+	}
+	encrypted := *event
+	encrypted.Value = value
+	return &encrypted, nil
+}
+
+func decryptEvents(events []MetaV2Event) error {
+	var err error
+	for i := range events {
+		if events[i].Value, err = encryption.Decrypt(events[i].Key, events[i].Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReencryptMetaV2 transforms the values in table meta_v2 and meta_v2_event to the form they should be stored
+// with now, values are encrypted by the current data key if their resources should be encrypted, or decrypted otherwise.
+// The journals in table meta_v2_journal are always encrypted by the current data key.
+func ReencryptMetaV2() error {
+	s := getStore()
+	objs, err := s.allMeta()
+	if err != nil {
+		return err
+	}
+	events, err := s.allEvents()
+	if err != nil {
+		return err
+	}
+
+	var num int
+	for _, m := range *objs {
+		value, changed, err := encryption.Transform(m.Key, m.Value, resourceEncrypted(m.GroupVersionResource))
+		if err != nil {
+			return err
+		}
+		if !changed {
+			continue
+		}
+		if err := s.swapMetaValue(m.Key, m.Value, value); err != nil {
+			return err
+		}
+		num++
+	}
+	for _, e := range *events {
+		value, changed, err := encryption.Transform(e.Key, e.Value, resourceEncrypted(e.GroupVersionResource))
+		if err != nil {
+			return err
+		}
+		if !changed {
+			continue
+		}
+		if err := s.swapEventValue(e.ID, e.Value, value); err != nil {
+			return err
+		}
+		num++
+	}
+	klog.Infof("[metamanager/encryption] %d values in table %s and %s re-encrypted", num, NewMetaTableName, EventTableName)
+
+	num, err = reencryptJournals(s)
+	if err != nil {
+		return err
+	}
+	klog.Infof("[metamanager/encryption] %d journals in table %s re-encrypted", num, JournalTableName)
+	return nil
+}
+
+// journalColumns returns the columns of the journal carrying the credential and the request,
+// which are encrypted whenever the data keys are loaded regardless of the resources configured
+func journalColumns(j *MetaV2Journal) map[string]*string {
+	return map[string]*string{
+		"token":   &j.Token,
+		"reqbody": &j.ReqBody,
+		"option":  &j.Option,
+	}
+}
+
+// journalKey is the record key authenticated with the encrypted column of the journal
+func journalKey(j *MetaV2Journal, column string) string {
+	return JournalTableName + "/" + column + "/" + j.Key
+}
+
+func encryptJournal(j *MetaV2Journal) (*MetaV2Journal, error) {
+	encrypted := *j
+	if !encryption.Loaded() {
+		// the token is a credential, it is never saved in plaintext
+		encrypted.Token = ""
+		return &encrypted, nil
+	}
+	for column, value := range journalColumns(&encrypted) {
+		if *value == "" {
+			continue
+		}
+		v, err := encryption.Encrypt(journalKey(j, column), *value)
+		if err != nil {
+			return nil, err
+		}
+		*value = v
+	}
+	return &encrypted, nil
+}
+
+func decryptJournals(journals []MetaV2Journal) error {
+	for i := range journals {
+		for column, value := range journalColumns(&journals[i]) {
+			v, err := encryption.Decrypt(journalKey(&journals[i], column), *value)
+			if err != nil {
+				return err
+			}
+			*value = v
+		}
+	}
+	return nil
+}
+
+// reencryptJournals encrypts the columns of the journals by the current data key
+func reencryptJournals(s store) (int, error) {
+	journals, err := s.allJournals()
+	if err != nil {
+		return 0, err
+	}
+
+	var num int
+	for i := range *journals {
+		j := &(*journals)[i]
+		cols := make(map[string]interface{})
+		for column, value := range journalColumns(j) {
+			v, changed, err := encryption.Transform(journalKey(j, column), *value, *value != "")
+			if err != nil {
+				return num, err
+			}
+			if changed {
+				cols[column] = v
+			}
+		}
+		if len(cols) == 0 {
+			continue
+		}
+		// the columns are never changed after the journal is inserted, so they are updated by ID only
+		if err := s.updateJournal(j.ID, cols); err != nil {
+			return num, err
+		}
+		num++
+	}
+	return num, nil
+}
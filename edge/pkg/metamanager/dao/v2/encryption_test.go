@@ -0,0 +1,56 @@
+package v2
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/encryption"
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha2"
+)
+
+func TestEncryptJournal(t *testing.T) {
+	journal := &MetaV2Journal{
+		Key:     "/core/v1/secrets/default/a",
+		Token:   "Bearer token",
+		ReqBody: `{"data":"foo"}`,
+	}
+
+	// the token is not kept without the data keys
+	plain, err := encryptJournal(journal)
+	if err != nil {
+		t.Fatalf("encryptJournal() error = %v", err)
+	}
+	if plain.Token != "" || plain.ReqBody != journal.ReqBody {
+		t.Errorf("expected only the token dropped, but got %+v", plain)
+	}
+
+	dir := t.TempDir()
+	err = encryption.Init(&v1alpha2.MetaEncryption{
+		Enable:      true,
+		KeyProvider: encryption.FileProviderName,
+		KeyFile:     filepath.Join(dir, "kek"),
+		DataKeyFile: filepath.Join(dir, "datakeys.json"),
+	})
+	if err != nil {
+		t.Fatalf("failed to init encryption: %v", err)
+	}
+	encrypted, err := encryptJournal(journal)
+	if err != nil {
+		t.Fatalf("encryptJournal() error = %v", err)
+	}
+	if strings.Contains(encrypted.Token, "token") || strings.Contains(encrypted.ReqBody, "foo") || encrypted.Option != "" {
+		t.Errorf("expected the columns encrypted, but got %+v", encrypted)
+	}
+	if journal.Token != "Bearer token" {
+		t.Errorf("expected the journal unchanged, but got %+v", journal)
+	}
+
+	journals := []MetaV2Journal{*encrypted}
+	if err := decryptJournals(journals); err != nil {
+		t.Fatalf("decryptJournals() error = %v", err)
+	}
+	if journals[0] != *journal {
+		t.Errorf("expected %+v, but got %+v", *journal, journals[0])
+	}
+}
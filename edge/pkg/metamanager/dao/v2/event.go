@@ -0,0 +1,100 @@
+package v2
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/encryption"
+)
+
+//constant event table name reference
+const (
+	EventTableName = "meta_v2_event"
+
+	// column name
+	ID = "ID"
+)
+
+// MetaV2Event record a watch event of k8s api object, it is used to
+// replay the events happened after a given resource version for watchers
+type MetaV2Event struct {
+	// ID is the auto increment primary key, it keeps the order of events
+	// which have the same resource version
+	ID int64 `orm:"column(id); size(64); auto; pk"`
+	// Key is the key of the object, same as MetaV2.Key
+	Key string `orm:"column(key); size(256)"`
+	// GroupVersionResource are set buy gvr.String() like "/v1, Resource=endpoints"
+	GroupVersionResource string `orm:"column(groupversionresource); size(256)"`
+	// Namespace is the namespace of an api object, and set as metadata.namespace
+	Namespace string `orm:"column(namespace); size(256)"`
+	// Name is the name of api object, and set as metadata.name
+	Name string `orm:"column(name); size(256)"`
+	// ResourceVersion is the resource version of the obj when the event happened
+	ResourceVersion uint64 `orm:"column(resourceversion); size(256); index"`
+	// Type is the watch event type, ADDED, MODIFIED or DELETED
+	Type string `orm:"column(type); size(32)"`
+	// Value is the api object in json format
+	Value string `orm:"column(value); null; type(text)"`
+}
+
+// InsertEvent save a watch event to table meta_v2_event
+func InsertEvent(event *MetaV2Event) error {
+	encryption.BeginWrite()
+	defer encryption.EndWrite()
+	encrypted, err := encryptEvent(event)
+	if err != nil {
+		return err
+	}
+	err = getStore().insertEvent(encrypted)
+	event.ID = encrypted.ID
+	return err
+}
+
+// RawEventsAfterRV list the events of Group Version Resource Namespace Name whose resource version
+// is larger than rv, the result is ordered by the sequence the events happened
+func RawEventsAfterRV(gvr schema.GroupVersionResource, namespace string, name string, rv uint64) (*[]MetaV2Event, error) {
+	events, err := getStore().eventsAfterRV(gvr, namespace, name, rv)
+	if err != nil {
+		return nil, err
+	}
+	if err := decryptEvents(*events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// RawLastEventAtRV return the newest event of the key whose resource version is not larger than rv,
+// which records the object of the key at rv, the second return value is false if it is not recorded
+func RawLastEventAtRV(key string, rv uint64) (*MetaV2Event, bool, error) {
+	event, exist, err := getStore().lastEventAtRV(key, rv)
+	if err != nil || !exist {
+		return nil, exist, err
+	}
+	events := []MetaV2Event{*event}
+	if err := decryptEvents(events); err != nil {
+		return nil, false, err
+	}
+	return &events[0], true, nil
+}
+
+// MinEventRV return the smallest resource version in table meta_v2_event,
+// the second return value is false if there is no event at all
+func MinEventRV() (uint64, bool, error) {
+	return getStore().minEventRV()
+}
+
+// MaxEventRV return the largest resource version in table meta_v2_event
+func MaxEventRV() (uint64, error) {
+	return getStore().maxEventRV()
+}
+
+// CountEvents return the number of events in table meta_v2_event
+func CountEvents() (int64, error) {
+	return getStore().countEvents()
+}
+
+// CompactEvents keeps at most size newest events in table meta_v2_event,
+// it returns the resource version that events at or before it were all removed,
+// 0 is returned if nothing was removed
+func CompactEvents(size int64) (uint64, error) {
+	return getStore().compactEvents(size)
+}
@@ -0,0 +1,83 @@
+package v2
+
+import (
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/encryption"
+)
+
+//constant journal table name reference
+const (
+	JournalTableName = "meta_v2_journal"
+
+	// column name
+	BaseRV = "BaseResourceVersion"
+)
+
+// MetaV2Journal record a write request that has been applied to table meta_v2 while
+// the node was offline, journals are replayed to the cloud in the order of ID
+type MetaV2Journal struct {
+	// ID is the auto increment primary key, it keeps the order of write requests
+	ID int64 `orm:"column(id); size(64); auto; pk"`
+	// Key is the key of the written object, same as MetaV2.Key
+	Key string `orm:"column(key); size(256); index"`
+	// Verb is the application verb of the request, like create, update, patch and delete
+	Verb string `orm:"column(verb); size(32)"`
+	// RequestInfo is the request info in json format, it is used to rebuild the request context
+	RequestInfo string `orm:"column(requestinfo); type(text)"`
+	// Option is the request option in json format, for patch request it is the patch info
+	Option string `orm:"column(option); null; type(text)"`
+	// ReqBody is the request object in json format
+	ReqBody string `orm:"column(reqbody); null; type(text)"`
+	// Token is the authorization header of the request
+	Token string `orm:"column(token); null; type(text)"`
+	// BaseResourceVersion is the resource version of the local object that the write was based on
+	BaseResourceVersion uint64 `orm:"column(baseresourceversion); size(256)"`
+	// ResourceVersion is the resource version assigned to the object by the local write
+	ResourceVersion uint64 `orm:"column(resourceversion); size(256)"`
+}
+
+// InsertJournal save a journal to table meta_v2_journal, the columns carrying the credential and
+// the request are encrypted if the data keys are loaded, otherwise the token is not saved
+func InsertJournal(journal *MetaV2Journal) error {
+	encryption.BeginWrite()
+	defer encryption.EndWrite()
+	encrypted, err := encryptJournal(journal)
+	if err != nil {
+		return err
+	}
+	err = getStore().insertJournal(encrypted)
+	journal.ID = encrypted.ID
+	return err
+}
+
+// DeleteJournal delete a journal by id
+func DeleteJournal(id int64) error {
+	return getStore().deleteJournal(id)
+}
+
+// ListJournals list all journals in the order they were recorded
+func ListJournals() (*[]MetaV2Journal, error) {
+	journals, err := getStore().listJournals()
+	if err != nil {
+		return nil, err
+	}
+	if err := decryptJournals(*journals); err != nil {
+		return nil, err
+	}
+	return journals, nil
+}
+
+// CountJournalsByKey return the number of journals that are not replayed for the key
+func CountJournalsByKey(key string) (int64, error) {
+	return getStore().countJournalsByKey(key)
+}
+
+// DeleteJournalsByKey delete all journals of the key
+func DeleteJournalsByKey(key string) error {
+	return getStore().deleteJournalsByKey(key)
+}
+
+// RebaseJournals update the base resource version of journals of the key from oldRV to newRV,
+// it is called after the journal which wrote oldRV has been replayed and cloud assigned newRV
+func RebaseJournals(key string, oldRV, newRV uint64) error {
+	return getStore().rebaseJournals(key, oldRV, newRV)
+}
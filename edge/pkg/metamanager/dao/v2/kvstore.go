@@ -0,0 +1,201 @@
+package v2
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+)
+
+// kvStore is the store on the key-value store, the objects are keyed by their keys,
+// and the events and journals by their IDs
+type kvStore struct {
+	kv dbm.KVStore
+}
+
+func (s kvStore) saveMeta(m *MetaV2) error {
+	return s.kv.Save(NewMetaTableName, m)
+}
+
+func (s kvStore) deleteMeta(key string) error {
+	return s.kv.Delete(NewMetaTableName, &MetaV2{Key: key})
+}
+
+func (s kvStore) maxMetaRV() (uint64, error) {
+	var max uint64
+	err := s.kv.ForEach(NewMetaTableName, new(MetaV2), func(obj interface{}) error {
+		if rv := obj.(*MetaV2).ResourceVersion; rv > max {
+			max = rv
+		}
+		return nil
+	})
+	return max, err
+}
+
+func (s kvStore) listMeta(gvr schema.GroupVersionResource, namespace string, name string) (*[]MetaV2, error) {
+	objs := new([]MetaV2)
+	_, err := dbm.KVQueryPrefix(s.kv, NewMetaTableName, gvrnnPrefix(gvr, namespace, name), objs,
+		gvrnnFilters(gvr, namespace, name)...)
+	return objs, err
+}
+
+func (s kvStore) allMeta() (*[]MetaV2, error) {
+	objs := new([]MetaV2)
+	_, err := dbm.KVQuery(s.kv, NewMetaTableName, objs)
+	return objs, err
+}
+
+func (s kvStore) swapMetaValue(key, oldValue, newValue string) error {
+	return s.swapValue(NewMetaTableName, &MetaV2{Key: key}, oldValue, newValue)
+}
+
+func (s kvStore) insertEvent(event *MetaV2Event) error {
+	return s.kv.Insert(EventTableName, event)
+}
+
+func (s kvStore) eventsAfterRV(gvr schema.GroupVersionResource, namespace string, name string, rv uint64) (*[]MetaV2Event, error) {
+	events := new([]MetaV2Event)
+	filters := append(gvrnnFilters(gvr, namespace, name), func(obj interface{}) bool {
+		return obj.(*MetaV2Event).ResourceVersion > rv
+	})
+	if _, err := dbm.KVQuery(s.kv, EventTableName, events, filters...); err != nil {
+		return nil, err
+	}
+	sortEvents(*events)
+	return events, nil
+}
+
+func (s kvStore) lastEventAtRV(key string, rv uint64) (*MetaV2Event, bool, error) {
+	events := new([]MetaV2Event)
+	_, err := dbm.KVQuery(s.kv, EventTableName, events, dbm.Eq(KEY, key), func(obj interface{}) bool {
+		return obj.(*MetaV2Event).ResourceVersion <= rv
+	})
+	if err != nil || len(*events) == 0 {
+		return nil, false, err
+	}
+	sortEvents(*events)
+	return &(*events)[len(*events)-1], true, nil
+}
+
+func (s kvStore) allEvents() (*[]MetaV2Event, error) {
+	events := new([]MetaV2Event)
+	_, err := dbm.KVQuery(s.kv, EventTableName, events)
+	return events, err
+}
+
+func (s kvStore) minEventRV() (uint64, bool, error) {
+	var min uint64
+	var exist bool
+	err := s.kv.ForEach(EventTableName, new(MetaV2Event), func(obj interface{}) error {
+		if rv := obj.(*MetaV2Event).ResourceVersion; !exist || rv < min {
+			min, exist = rv, true
+		}
+		return nil
+	})
+	return min, exist, err
+}
+
+func (s kvStore) maxEventRV() (uint64, error) {
+	var max uint64
+	err := s.kv.ForEach(EventTableName, new(MetaV2Event), func(obj interface{}) error {
+		if rv := obj.(*MetaV2Event).ResourceVersion; rv > max {
+			max = rv
+		}
+		return nil
+	})
+	return max, err
+}
+
+func (s kvStore) countEvents() (int64, error) {
+	return dbm.KVCount(s.kv, EventTableName, new(MetaV2Event))
+}
+
+func (s kvStore) compactEvents(size int64) (uint64, error) {
+	events, err := s.allEvents()
+	if err != nil {
+		return 0, err
+	}
+	if int64(len(*events)) <= size {
+		return 0, nil
+	}
+	sortEvents(*events)
+	// the oldest event we are going to keep
+	keep := (*events)[int64(len(*events))-size].ResourceVersion
+	num, err := dbm.KVDelete(s.kv, EventTableName, new(MetaV2Event), func(obj interface{}) bool {
+		return obj.(*MetaV2Event).ResourceVersion < keep
+	})
+	klog.V(4).Infof("Delete affected Num: %d, %v", num, err)
+	if err != nil || num == 0 {
+		return 0, err
+	}
+	return keep - 1, nil
+}
+
+func (s kvStore) swapEventValue(id int64, oldValue, newValue string) error {
+	return s.swapValue(EventTableName, &MetaV2Event{ID: id}, oldValue, newValue)
+}
+
+func (s kvStore) insertJournal(journal *MetaV2Journal) error {
+	return s.kv.Insert(JournalTableName, journal)
+}
+
+func (s kvStore) deleteJournal(id int64) error {
+	return s.kv.Delete(JournalTableName, &MetaV2Journal{ID: id})
+}
+
+func (s kvStore) listJournals() (*[]MetaV2Journal, error) {
+	// records of key-value store are ordered by primary key already
+	return s.allJournals()
+}
+
+func (s kvStore) allJournals() (*[]MetaV2Journal, error) {
+	journals := new([]MetaV2Journal)
+	_, err := dbm.KVQuery(s.kv, JournalTableName, journals)
+	return journals, err
+}
+
+func (s kvStore) countJournalsByKey(key string) (int64, error) {
+	return dbm.KVCount(s.kv, JournalTableName, new(MetaV2Journal), dbm.Eq(KEY, key))
+}
+
+func (s kvStore) deleteJournalsByKey(key string) error {
+	num, err := dbm.KVDelete(s.kv, JournalTableName, new(MetaV2Journal), dbm.Eq(KEY, key))
+	klog.V(4).Infof("Delete affected Num: %d, %v", num, err)
+	return err
+}
+
+func (s kvStore) rebaseJournals(key string, oldRV, newRV uint64) error {
+	num, err := dbm.KVUpdate(s.kv, JournalTableName, new(MetaV2Journal), map[string]interface{}{BaseRV: newRV},
+		dbm.Eq(KEY, key), dbm.Eq(BaseRV, oldRV))
+	klog.V(4).Infof("Update affected Num: %d, %v", num, err)
+	return err
+}
+
+func (s kvStore) updateJournal(id int64, cols map[string]interface{}) error {
+	_, err := dbm.KVUpdateByKey(s.kv, JournalTableName, &MetaV2Journal{ID: id}, cols)
+	return err
+}
+
+// swapValue sets the value of the record obj to newValue only if it is still oldValue
+func (s kvStore) swapValue(table string, obj interface{}, oldValue, newValue string) error {
+	return s.kv.Update(func(tx dbm.KVStore) error {
+		found, err := tx.Get(table, obj)
+		if err != nil || !found || !dbm.Eq("value", oldValue)(obj) {
+			return err
+		}
+		_, err = dbm.KVUpdateByKey(tx, table, obj, map[string]interface{}{"value": newValue})
+		return err
+	})
+}
+
+// sortEvents sorts the events by the sequence they happened
+func sortEvents(events []MetaV2Event) {
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].ResourceVersion != events[j].ResourceVersion {
+			return events[i].ResourceVersion < events[j].ResourceVersion
+		}
+		return events[i].ID < events[j].ID
+	})
+}
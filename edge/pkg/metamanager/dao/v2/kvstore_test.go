@@ -0,0 +1,116 @@
+package v2
+
+import (
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+)
+
+func TestKVStoreListMeta(t *testing.T) {
+	kv, err := dbm.NewBoltStore(filepath.Join(t.TempDir(), "edgecore.bolt"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer kv.Close()
+	s := kvStore{kv: kv}
+
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	nodes := schema.GroupVersionResource{Version: "v1", Resource: "nodes"}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	for _, m := range []MetaV2{
+		{Key: "/core/v1/pods/default/a", GroupVersionResource: pods.String(), Namespace: "default", Name: "a"},
+		{Key: "/core/v1/pods/default/ab", GroupVersionResource: pods.String(), Namespace: "default", Name: "ab"},
+		{Key: "/core/v1/pods/kube-system/a", GroupVersionResource: pods.String(), Namespace: "kube-system", Name: "a"},
+		{Key: "/core/v1/nodes/null/a", GroupVersionResource: nodes.String(), Namespace: "", Name: "a"},
+		{Key: "/apps/v1/deployments/default/a", GroupVersionResource: deployments.String(), Namespace: "default", Name: "a"},
+	} {
+		m := m
+		if err := s.saveMeta(&m); err != nil {
+			t.Fatalf("saveMeta() error = %v", err)
+		}
+	}
+
+	cases := []struct {
+		gvr       schema.GroupVersionResource
+		namespace string
+		name      string
+		want      []string
+	}{
+		{gvr: pods, namespace: NullNamespace, name: NullName,
+			want: []string{"/core/v1/pods/default/a", "/core/v1/pods/default/ab", "/core/v1/pods/kube-system/a"}},
+		{gvr: pods, namespace: "default", name: NullName,
+			want: []string{"/core/v1/pods/default/a", "/core/v1/pods/default/ab"}},
+		{gvr: pods, namespace: "default", name: "a", want: []string{"/core/v1/pods/default/a"}},
+		{gvr: pods, namespace: "", name: "a", want: []string{"/core/v1/pods/default/a", "/core/v1/pods/kube-system/a"}},
+		{gvr: nodes, namespace: NullNamespace, name: "a", want: []string{"/core/v1/nodes/null/a"}},
+		{gvr: deployments, namespace: "default", name: NullName, want: []string{"/apps/v1/deployments/default/a"}},
+	}
+	for _, c := range cases {
+		objs, err := s.listMeta(c.gvr, c.namespace, c.name)
+		if err != nil {
+			t.Fatalf("listMeta() error = %v", err)
+		}
+		var got []string
+		for _, obj := range *objs {
+			got = append(got, obj.Key)
+		}
+		sort.Strings(got)
+		if len(got) != len(c.want) {
+			t.Errorf("listMeta(%v, %s, %s) expected %v, but got %v", c.gvr, c.namespace, c.name, c.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("listMeta(%v, %s, %s) expected %v, but got %v", c.gvr, c.namespace, c.name, c.want, got)
+				break
+			}
+		}
+	}
+}
+
+func TestKVStoreLastEventAtRV(t *testing.T) {
+	kv, err := dbm.NewBoltStore(filepath.Join(t.TempDir(), "edgecore.bolt"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer kv.Close()
+	s := kvStore{kv: kv}
+
+	for _, e := range []MetaV2Event{
+		{Key: "/core/v1/pods/default/a", ResourceVersion: 3, Type: "ADDED"},
+		{Key: "/core/v1/pods/default/b", ResourceVersion: 4, Type: "ADDED"},
+		{Key: "/core/v1/pods/default/a", ResourceVersion: 5, Type: "MODIFIED"},
+		{Key: "/core/v1/pods/default/a", ResourceVersion: 7, Type: "DELETED"},
+	} {
+		e := e
+		if err := s.insertEvent(&e); err != nil {
+			t.Fatalf("insertEvent() error = %v", err)
+		}
+	}
+
+	cases := []struct {
+		key   string
+		rv    uint64
+		exist bool
+		want  uint64
+	}{
+		{key: "/core/v1/pods/default/a", rv: 2, exist: false},
+		{key: "/core/v1/pods/default/a", rv: 3, exist: true, want: 3},
+		{key: "/core/v1/pods/default/a", rv: 6, exist: true, want: 5},
+		{key: "/core/v1/pods/default/a", rv: 10, exist: true, want: 7},
+		{key: "/core/v1/pods/default/c", rv: 10, exist: false},
+	}
+	for _, c := range cases {
+		event, exist, err := s.lastEventAtRV(c.key, c.rv)
+		if err != nil {
+			t.Fatalf("lastEventAtRV(%v, %v) error = %v", c.key, c.rv, err)
+		}
+		if exist != c.exist || (exist && event.ResourceVersion != c.want) {
+			t.Errorf("lastEventAtRV(%v, %v) = %+v, %v, want resource version %v, %v", c.key, c.rv, event, exist, c.want, c.exist)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/encryption"
 )
 
 //constant metatable name reference
@@ -46,37 +47,36 @@ type MetaV2 struct {
 	Value string `orm:"column(value); null; type(text)"`
 }
 
+// InsertOrUpdateMetaV2 insert or update a record of table meta_v2
+func InsertOrUpdateMetaV2(m *MetaV2) error {
+	encryption.BeginWrite()
+	defer encryption.EndWrite()
+	m, err := encryptMetaV2(m)
+	if err != nil {
+		return err
+	}
+	return getStore().saveMeta(m)
+}
+
+// DeleteMetaV2ByKey delete a record of table meta_v2 by key
+func DeleteMetaV2ByKey(key string) error {
+	return getStore().deleteMeta(key)
+}
+
+// MaxMetaRV return the largest resource version in table meta_v2
+func MaxMetaRV() (uint64, error) {
+	return getStore().maxMetaRV()
+}
+
 // List a slice of raw data by Group Version Resource Namespace Name
 func RawMetaByGVRNN(gvr schema.GroupVersionResource, namespace string, name string) (*[]MetaV2, error) {
-	objs := new([]MetaV2)
-	var err error
-	// TODO: use getCondition
-	//cond := getCondition(gvr,namespace,name)
-	//klog.Infof("cond:%+v",cond)
-	//_,err = dbm.DBAccess.QueryTable(NewMetaTableName).SetCond(cond).All(objs)
-	if gvr.Empty() {
-		_, err = dbm.DBAccess.QueryTable(NewMetaTableName).All(objs)
-	} else {
-		switch namespace {
-		case NullNamespace, "":
-			switch name {
-			case NullName, "":
-				_, err = dbm.DBAccess.QueryTable(NewMetaTableName).Filter(GVR, gvr.String()).All(objs)
-			default:
-				_, err = dbm.DBAccess.QueryTable(NewMetaTableName).Filter(GVR, gvr.String()).Filter(NAME, name).All(objs)
-			}
-		default:
-			switch name {
-			case NullName, "":
-				_, err = dbm.DBAccess.QueryTable(NewMetaTableName).Filter(GVR, gvr.String()).Filter(NS, namespace).All(objs)
-			default:
-				_, err = dbm.DBAccess.QueryTable(NewMetaTableName).Filter(GVR, gvr.String()).Filter(NS, namespace).Filter(NAME, name).All(objs)
-			}
-		}
-	}
+	objs, err := getStore().listMeta(gvr, namespace, name)
 	if err != nil {
 		return nil, err
 	}
+	if err := decryptMetaV2(*objs); err != nil {
+		return nil, err
+	}
 	return objs, nil
 }
 
@@ -91,3 +91,41 @@ func getCondition(gvr schema.GroupVersionResource, namespace string, name string
 	}
 	return cond
 }
+
+// gvrnnPrefix returns the prefix of the keys of Group Version Resource Namespace Name, the keys
+// are in format /Group/Version/Resources/Namespace/Name, see MetaV2.Key
+func gvrnnPrefix(gvr schema.GroupVersionResource, namespace string, name string) string {
+	if gvr.Empty() {
+		return ""
+	}
+	group := gvr.Group
+	if group == "" {
+		group = GroupCore
+	}
+	prefix := "/" + group + "/" + gvr.Version + "/" + gvr.Resource + "/"
+	if namespace == NullNamespace || namespace == "" {
+		// the objects of all namespaces are selected unless they are cluster scoped
+		return prefix
+	}
+	prefix += namespace + "/"
+	if name != NullName && name != "" {
+		prefix += name
+	}
+	return prefix
+}
+
+// gvrnnFilters returns the key-value filters of Group Version Resource Namespace Name
+func gvrnnFilters(gvr schema.GroupVersionResource, namespace string, name string) []dbm.KVFilter {
+	var filters []dbm.KVFilter
+	if gvr.Empty() {
+		return filters
+	}
+	filters = append(filters, dbm.Eq(GVR, gvr.String()))
+	if namespace != NullNamespace && namespace != "" {
+		filters = append(filters, dbm.Eq(NS, namespace))
+	}
+	if name != NullName && name != "" {
+		filters = append(filters, dbm.Eq(NAME, name))
+	}
+	return filters
+}
@@ -0,0 +1,201 @@
+package v2
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+)
+
+// ormStore is the store on the sqlite3 database accessed by orm
+type ormStore struct{}
+
+func (ormStore) saveMeta(m *MetaV2) error {
+	_, err := dbm.DBAccess.Raw("INSERT OR REPLACE INTO meta_v2 (key, groupversionresource, namespace,name,resourceversion,value) VALUES (?,?,?,?,?,?)", m.Key, m.GroupVersionResource, m.Namespace, m.Name, m.ResourceVersion, m.Value).Exec()
+	return err
+}
+
+func (ormStore) deleteMeta(key string) error {
+	_, err := dbm.DBAccess.Delete(&MetaV2{Key: key})
+	return err
+}
+
+func (ormStore) maxMetaRV() (uint64, error) {
+	m := new(MetaV2)
+	_, err := dbm.DBAccess.QueryTable(NewMetaTableName).OrderBy("-" + RV).Limit(1).All(m)
+	return m.ResourceVersion, err
+}
+
+func (ormStore) listMeta(gvr schema.GroupVersionResource, namespace string, name string) (*[]MetaV2, error) {
+	objs := new([]MetaV2)
+	var err error
+	// TODO: use getCondition
+	//cond := getCondition(gvr,namespace,name)
+	//klog.Infof("cond:%+v",cond)
+	//_,err = dbm.DBAccess.QueryTable(NewMetaTableName).SetCond(cond).All(objs)
+	if gvr.Empty() {
+		_, err = dbm.DBAccess.QueryTable(NewMetaTableName).All(objs)
+	} else {
+		switch namespace {
+		case NullNamespace, "":
+			switch name {
+			case NullName, "":
+				_, err = dbm.DBAccess.QueryTable(NewMetaTableName).Filter(GVR, gvr.String()).All(objs)
+			default:
+				_, err = dbm.DBAccess.QueryTable(NewMetaTableName).Filter(GVR, gvr.String()).Filter(NAME, name).All(objs)
+			}
+		default:
+			switch name {
+			case NullName, "":
+				_, err = dbm.DBAccess.QueryTable(NewMetaTableName).Filter(GVR, gvr.String()).Filter(NS, namespace).All(objs)
+			default:
+				_, err = dbm.DBAccess.QueryTable(NewMetaTableName).Filter(GVR, gvr.String()).Filter(NS, namespace).Filter(NAME, name).All(objs)
+			}
+		}
+	}
+	return objs, err
+}
+
+func (ormStore) allMeta() (*[]MetaV2, error) {
+	objs := new([]MetaV2)
+	_, err := dbm.DBAccess.QueryTable(NewMetaTableName).Limit(-1).All(objs)
+	return objs, err
+}
+
+func (ormStore) swapMetaValue(key, oldValue, newValue string) error {
+	_, err := dbm.DBAccess.QueryTable(NewMetaTableName).Filter(KEY, key).Filter("value", oldValue).
+		Update(map[string]interface{}{"value": newValue})
+	return err
+}
+
+func (ormStore) insertEvent(event *MetaV2Event) error {
+	num, err := dbm.DBAccess.Insert(event)
+	klog.V(4).Infof("Insert affected Num: %d, %v", num, err)
+	return err
+}
+
+func (ormStore) eventsAfterRV(gvr schema.GroupVersionResource, namespace string, name string, rv uint64) (*[]MetaV2Event, error) {
+	events := new([]MetaV2Event)
+	qs := dbm.DBAccess.QueryTable(EventTableName).Filter(RV+"__gt", rv)
+	if !gvr.Empty() {
+		qs = qs.Filter(GVR, gvr.String())
+		if namespace != NullNamespace && namespace != "" {
+			qs = qs.Filter(NS, namespace)
+		}
+		if name != NullName && name != "" {
+			qs = qs.Filter(NAME, name)
+		}
+	}
+	_, err := qs.OrderBy(RV, ID).All(events)
+	return events, err
+}
+
+func (ormStore) lastEventAtRV(key string, rv uint64) (*MetaV2Event, bool, error) {
+	events := new([]MetaV2Event)
+	num, err := dbm.DBAccess.QueryTable(EventTableName).Filter(KEY, key).Filter(RV+"__lte", rv).
+		OrderBy("-"+RV, "-"+ID).Limit(1).All(events)
+	if err != nil || num == 0 {
+		return nil, false, err
+	}
+	return &(*events)[0], true, nil
+}
+
+func (ormStore) allEvents() (*[]MetaV2Event, error) {
+	events := new([]MetaV2Event)
+	_, err := dbm.DBAccess.QueryTable(EventTableName).Limit(-1).All(events)
+	return events, err
+}
+
+func (ormStore) minEventRV() (uint64, bool, error) {
+	events := new([]MetaV2Event)
+	num, err := dbm.DBAccess.QueryTable(EventTableName).OrderBy(RV).Limit(1).All(events, RV)
+	if err != nil || num == 0 {
+		return 0, false, err
+	}
+	return (*events)[0].ResourceVersion, true, nil
+}
+
+func (ormStore) maxEventRV() (uint64, error) {
+	events := new([]MetaV2Event)
+	num, err := dbm.DBAccess.QueryTable(EventTableName).OrderBy("-"+RV).Limit(1).All(events, RV)
+	if err != nil || num == 0 {
+		return 0, err
+	}
+	return (*events)[0].ResourceVersion, nil
+}
+
+func (ormStore) countEvents() (int64, error) {
+	return dbm.DBAccess.QueryTable(EventTableName).Count()
+}
+
+func (ormStore) compactEvents(size int64) (uint64, error) {
+	count, err := dbm.DBAccess.QueryTable(EventTableName).Count()
+	if err != nil || count <= size {
+		return 0, err
+	}
+	// the oldest event we are going to keep
+	events := new([]MetaV2Event)
+	num, err := dbm.DBAccess.QueryTable(EventTableName).OrderBy("-"+RV, "-"+ID).Offset(size-1).Limit(1).All(events, RV)
+	if err != nil || num == 0 {
+		return 0, err
+	}
+	keep := (*events)[0].ResourceVersion
+	num, err = dbm.DBAccess.QueryTable(EventTableName).Filter(RV+"__lt", keep).Delete()
+	klog.V(4).Infof("Delete affected Num: %d, %v", num, err)
+	if err != nil || num == 0 {
+		return 0, err
+	}
+	return keep - 1, nil
+}
+
+func (ormStore) swapEventValue(id int64, oldValue, newValue string) error {
+	_, err := dbm.DBAccess.QueryTable(EventTableName).Filter(ID, id).Filter("value", oldValue).
+		Update(map[string]interface{}{"value": newValue})
+	return err
+}
+
+func (ormStore) insertJournal(journal *MetaV2Journal) error {
+	num, err := dbm.DBAccess.Insert(journal)
+	klog.V(4).Infof("Insert affected Num: %d, %v", num, err)
+	return err
+}
+
+func (ormStore) deleteJournal(id int64) error {
+	num, err := dbm.DBAccess.QueryTable(JournalTableName).Filter(ID, id).Delete()
+	klog.V(4).Infof("Delete affected Num: %d, %v", num, err)
+	return err
+}
+
+func (ormStore) listJournals() (*[]MetaV2Journal, error) {
+	journals := new([]MetaV2Journal)
+	_, err := dbm.DBAccess.QueryTable(JournalTableName).OrderBy(ID).All(journals)
+	return journals, err
+}
+
+func (ormStore) allJournals() (*[]MetaV2Journal, error) {
+	journals := new([]MetaV2Journal)
+	_, err := dbm.DBAccess.QueryTable(JournalTableName).Limit(-1).All(journals)
+	return journals, err
+}
+
+func (ormStore) countJournalsByKey(key string) (int64, error) {
+	return dbm.DBAccess.QueryTable(JournalTableName).Filter(KEY, key).Count()
+}
+
+func (ormStore) deleteJournalsByKey(key string) error {
+	num, err := dbm.DBAccess.QueryTable(JournalTableName).Filter(KEY, key).Delete()
+	klog.V(4).Infof("Delete affected Num: %d, %v", num, err)
+	return err
+}
+
+func (ormStore) rebaseJournals(key string, oldRV, newRV uint64) error {
+	num, err := dbm.DBAccess.QueryTable(JournalTableName).Filter(KEY, key).Filter(BaseRV, oldRV).
+		Update(map[string]interface{}{BaseRV: newRV})
+	klog.V(4).Infof("Update affected Num: %d, %v", num, err)
+	return err
+}
+
+func (ormStore) updateJournal(id int64, cols map[string]interface{}) error {
+	_, err := dbm.DBAccess.QueryTable(JournalTableName).Filter(ID, id).Update(cols)
+	return err
+}
@@ -0,0 +1,71 @@
+package v2
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+)
+
+// store is the storage of table meta_v2, meta_v2_event and meta_v2_journal behind the DAOs,
+// the records are stored as they are given, so the values may be encrypted
+type store interface {
+	// saveMeta saves the object, the existing one of the key is replaced
+	saveMeta(m *MetaV2) error
+	// deleteMeta deletes the object of the key
+	deleteMeta(key string) error
+	// maxMetaRV returns the largest resource version of the objects
+	maxMetaRV() (uint64, error)
+	// listMeta returns the objects of Group Version Resource Namespace Name
+	listMeta(gvr schema.GroupVersionResource, namespace string, name string) (*[]MetaV2, error)
+	// allMeta returns all objects
+	allMeta() (*[]MetaV2, error)
+	// swapMetaValue sets the value of the object of the key to newValue only if it is oldValue
+	swapMetaValue(key, oldValue, newValue string) error
+
+	// insertEvent saves the event, the ID allocated is set back to it
+	insertEvent(event *MetaV2Event) error
+	// eventsAfterRV returns the events of Group Version Resource Namespace Name whose resource
+	// version is larger than rv, in the order they happened
+	eventsAfterRV(gvr schema.GroupVersionResource, namespace string, name string, rv uint64) (*[]MetaV2Event, error)
+	// lastEventAtRV returns the newest event of the key whose resource version is not larger than rv,
+	// false is returned if there is none
+	lastEventAtRV(key string, rv uint64) (*MetaV2Event, bool, error)
+	// allEvents returns all events
+	allEvents() (*[]MetaV2Event, error)
+	// minEventRV returns the smallest resource version of the events, false if there is no event
+	minEventRV() (uint64, bool, error)
+	// maxEventRV returns the largest resource version of the events
+	maxEventRV() (uint64, error)
+	// countEvents returns the number of events
+	countEvents() (int64, error)
+	// compactEvents keeps at most size newest events, see CompactEvents
+	compactEvents(size int64) (uint64, error)
+	// swapEventValue sets the value of the event of id to newValue only if it is oldValue
+	swapEventValue(id int64, oldValue, newValue string) error
+
+	// insertJournal saves the journal, the ID allocated is set back to it
+	insertJournal(journal *MetaV2Journal) error
+	// deleteJournal deletes the journal of id
+	deleteJournal(id int64) error
+	// listJournals returns the journals in the order of ID
+	listJournals() (*[]MetaV2Journal, error)
+	// allJournals returns all journals
+	allJournals() (*[]MetaV2Journal, error)
+	// countJournalsByKey returns the number of journals of the key
+	countJournalsByKey(key string) (int64, error)
+	// deleteJournalsByKey deletes the journals of the key
+	deleteJournalsByKey(key string) error
+	// rebaseJournals sets the base resource version of the journals of the key from oldRV to newRV
+	rebaseJournals(key string, oldRV, newRV uint64) error
+	// updateJournal sets the columns of the journal of id
+	updateJournal(id int64, cols map[string]interface{}) error
+}
+
+// getStore returns the storage of the backend selected, the key-value store if it is opened
+// or the sqlite3 database accessed by orm otherwise
+func getStore() store {
+	if dbm.KV != nil {
+		return kvStore{kv: dbm.KV}
+	}
+	return ormStore{}
+}
@@ -0,0 +1,52 @@
+package metamanager
+
+import (
+	"time"
+
+	"k8s.io/klog/v2"
+
+	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
+	metamanagerconfig "github.com/kubeedge/kubeedge/edge/pkg/metamanager/config"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+	v2 "github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao/v2"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/encryption"
+)
+
+// keyRotationCheckPeriod is how often the age of the data key is checked
+const keyRotationCheckPeriod = time.Hour
+
+// runKeyRotation re-encrypts the stored values once at startup, which encrypts the existing plaintext
+// values after encryption is enabled and finishes the rotation interrupted by restart, and then
+// rotates the data key periodically
+func runKeyRotation() {
+	if !encryption.Loaded() {
+		return
+	}
+	if err := encryption.Reconcile(reencrypt); err != nil {
+		klog.Errorf("[metamanager/encryption] failed to re-encrypt values: %v", err)
+	}
+
+	period := metamanagerconfig.Config.Encryption.DataKeyRotationPeriod.Duration
+	if period <= 0 {
+		return
+	}
+	ticker := time.NewTicker(keyRotationCheckPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-beehiveContext.Done():
+			return
+		case <-ticker.C:
+			if err := encryption.Rotate(period, reencrypt); err != nil {
+				klog.Errorf("[metamanager/encryption] failed to rotate data key: %v", err)
+			}
+		}
+	}
+}
+
+func reencrypt() error {
+	if err := dao.ReencryptMeta(); err != nil {
+		return err
+	}
+	return v2.ReencryptMetaV2()
+}
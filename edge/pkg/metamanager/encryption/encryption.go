@@ -0,0 +1,372 @@
+package encryption
+
+import (
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha2"
+)
+
+// valuePrefix is the prefix of encrypted values, the id of data key and the base64 encoded
+// nonce|ciphertext follow it, like kubeedge:enc:aesgcm:1:ciphertext
+const valuePrefix = "kubeedge:enc:aesgcm:"
+
+var (
+	ring      *keyring
+	enabled   bool
+	resources = make(map[string]bool)
+	// rotateLock serializes the rotations
+	rotateLock sync.Mutex
+	// writeLock is held shared by the writers from encrypting a value until it is stored, and exclusively
+	// by the rotation while switching the current data key. So the values encrypted by the old data key
+	// are all stored before the re-encryption scans them, and the old data key can be removed after it.
+	writeLock sync.RWMutex
+)
+
+// Init loads the data keys sealed by the key provider, a data key is generated if there is none.
+// The data keys are loaded even if encryption is disabled as long as the data key file exists,
+// so that the encrypted values can still be read and re-encrypted to plaintext.
+func Init(c *v1alpha2.MetaEncryption) error {
+	if c == nil || (!c.Enable && !fileExists(c.DataKeyFile)) {
+		return nil
+	}
+	provider, err := NewKeyProvider(c.KeyProvider, c.KeyFile)
+	if err != nil {
+		return err
+	}
+	r, err := loadKeyring(provider, c.DataKeyFile)
+	if err != nil {
+		return err
+	}
+	if r.current == "" {
+		if err := r.addKey(); err != nil {
+			return err
+		}
+	}
+	ring = r
+	enabled = c.Enable
+	for _, res := range c.Resources {
+		resources[strings.ToLower(res)] = true
+	}
+	klog.Infof("[metamanager/encryption] data keys loaded, encryption enabled: %v, resources: %v", enabled, c.Resources)
+	return nil
+}
+
+// Loaded reports whether the data keys are loaded
+func Loaded() bool {
+	return ring != nil
+}
+
+// ResourceEncrypted reports whether the resources of resType are encrypted, resType is the
+// resource type of meta like secret
+func ResourceEncrypted(resType string) bool {
+	return enabled && resources[strings.ToLower(resType)]
+}
+
+// IsEncrypted reports whether value is encrypted
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, valuePrefix)
+}
+
+// Encrypt encrypts plaintext of the record key by the current data key,
+// the key is authenticated so that the encrypted value can not be moved to another record
+func Encrypt(key, plaintext string) (string, error) {
+	if ring == nil {
+		return "", fmt.Errorf("data keys are not loaded")
+	}
+	id, aead := ring.currentKey()
+	data, err := seal(aead, []byte(plaintext), []byte(key))
+	if err != nil {
+		return "", err
+	}
+	return valuePrefix + id + ":" + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// Decrypt decrypts the value of the record key, value that is not encrypted is returned as it is
+func Decrypt(key, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	if ring == nil {
+		return "", fmt.Errorf("failed to decrypt %s: data keys are not loaded", key)
+	}
+	id, aead, data, err := ring.parse(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt %s: %v", key, err)
+	}
+	plaintext, err := open(aead, data, []byte(key))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt %s with data key %s: %v", key, id, err)
+	}
+	return string(plaintext), nil
+}
+
+// Transform returns the value that the record key should be stored with now, it is encrypted by the
+// current data key if encrypt is true, or plaintext otherwise. The second return value is false if
+// the stored value is already in that form.
+func Transform(key, value string, encrypt bool) (string, bool, error) {
+	if !IsEncrypted(value) {
+		if !encrypt {
+			return value, false, nil
+		}
+		encrypted, err := Encrypt(key, value)
+		return encrypted, err == nil, err
+	}
+	if encrypt && ring != nil && ring.isCurrent(value) {
+		return value, false, nil
+	}
+	plaintext, err := Decrypt(key, value)
+	if err != nil || !encrypt {
+		return plaintext, err == nil, err
+	}
+	encrypted, err := Encrypt(key, plaintext)
+	return encrypted, err == nil, err
+}
+
+// BeginWrite must be called before encrypting a value to store, and EndWrite after the value is stored
+func BeginWrite() {
+	writeLock.RLock()
+}
+
+// EndWrite ends the write begun by BeginWrite
+func EndWrite() {
+	writeLock.RUnlock()
+}
+
+// Reconcile calls reencrypt to transform all the stored values by Transform, and then
+// removes the data keys other than the current one since they are not used any more
+func Reconcile(reencrypt func() error) error {
+	if ring == nil {
+		return nil
+	}
+	rotateLock.Lock()
+	defer rotateLock.Unlock()
+	return ring.reconcile(reencrypt)
+}
+
+// Rotate generates a new data key as the current one if the current data key has been used
+// for period, and re-encrypts the stored values with it by reencrypt
+func Rotate(period time.Duration, reencrypt func() error) error {
+	if ring == nil || !enabled || period <= 0 {
+		return nil
+	}
+	rotateLock.Lock()
+	defer rotateLock.Unlock()
+	if time.Since(ring.currentCreated()) < period {
+		return nil
+	}
+	// wait for the writes with the old data key in progress
+	writeLock.Lock()
+	err := ring.addKey()
+	writeLock.Unlock()
+	if err != nil {
+		return err
+	}
+	id, _ := ring.currentKey()
+	klog.Infof("[metamanager/encryption] data key rotated to %s", id)
+	// the old data keys are kept until all values are re-encrypted, so that
+	// the rotation can be resumed by Reconcile after edgecore restarts
+	return ring.reconcile(reencrypt)
+}
+
+// sealedKey is a data key sealed by key provider
+type sealedKey struct {
+	ID      string    `json:"id"`
+	Sealed  []byte    `json:"sealed"`
+	Created time.Time `json:"created"`
+}
+
+// keyringFile is the content of data key file
+type keyringFile struct {
+	Provider string      `json:"provider"`
+	Current  string      `json:"current"`
+	Keys     []sealedKey `json:"keys"`
+}
+
+// keyring holds the data keys, the values are always encrypted by the current one,
+// the others are kept to decrypt the values not re-encrypted yet after rotation
+type keyring struct {
+	lock     sync.RWMutex
+	provider KeyProvider
+	path     string
+	current  string
+	sealed   []sealedKey
+	aeads    map[string]cipher.AEAD
+}
+
+func loadKeyring(provider KeyProvider, path string) (*keyring, error) {
+	r := &keyring{
+		provider: provider,
+		path:     path,
+		aeads:    make(map[string]cipher.AEAD),
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f keyringFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to decode data key file %s: %v", path, err)
+	}
+	if f.Provider != provider.Name() {
+		return nil, fmt.Errorf("data keys in %s are sealed by key provider %q, but %q is configured", path, f.Provider, provider.Name())
+	}
+	for _, k := range f.Keys {
+		key, err := provider.Unseal(k.Sealed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unseal data key %s: %v", k.ID, err)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		r.aeads[k.ID] = aead
+	}
+	if _, ok := r.aeads[f.Current]; !ok && len(f.Keys) != 0 {
+		return nil, fmt.Errorf("current data key %s not found in %s", f.Current, path)
+	}
+	r.current = f.Current
+	r.sealed = f.Keys
+	return r, nil
+}
+
+// addKey generates a new data key and makes it current
+func (r *keyring) addKey() error {
+	key, err := newKey()
+	if err != nil {
+		return err
+	}
+	sealed, err := r.provider.Seal(key)
+	if err != nil {
+		return fmt.Errorf("failed to seal data key: %v", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var id int
+	for _, k := range r.sealed {
+		if n, _ := strconv.Atoi(k.ID); n > id {
+			id = n
+		}
+	}
+	k := sealedKey{ID: strconv.Itoa(id + 1), Sealed: sealed, Created: time.Now()}
+	keys := append(append([]sealedKey{}, r.sealed...), k)
+	// persist the data key before any value is encrypted by it
+	if err := r.save(k.ID, keys); err != nil {
+		return err
+	}
+	r.sealed = keys
+	r.current = k.ID
+	r.aeads[k.ID] = aead
+	return nil
+}
+
+func (r *keyring) reconcile(reencrypt func() error) error {
+	if err := reencrypt(); err != nil {
+		return fmt.Errorf("failed to re-encrypt values: %v", err)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if len(r.sealed) <= 1 {
+		return nil
+	}
+	var keys []sealedKey
+	for _, k := range r.sealed {
+		if k.ID == r.current {
+			keys = append(keys, k)
+		}
+	}
+	if err := r.save(r.current, keys); err != nil {
+		return err
+	}
+	for _, k := range r.sealed {
+		if k.ID != r.current {
+			delete(r.aeads, k.ID)
+		}
+	}
+	r.sealed = keys
+	return nil
+}
+
+// save writes the data key file atomically
+func (r *keyring) save(current string, keys []sealedKey) error {
+	data, err := json.Marshal(keyringFile{Provider: r.provider.Name(), Current: current, Keys: keys})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0700); err != nil {
+		return err
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.path)
+}
+
+func (r *keyring) currentKey() (string, cipher.AEAD) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.current, r.aeads[r.current]
+}
+
+func (r *keyring) currentCreated() time.Time {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	for _, k := range r.sealed {
+		if k.ID == r.current {
+			return k.Created
+		}
+	}
+	return time.Time{}
+}
+
+func (r *keyring) isCurrent(value string) bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return strings.HasPrefix(value, valuePrefix+r.current+":")
+}
+
+// parse returns the data key id, the data key and the encrypted data of value
+func (r *keyring) parse(value string) (string, cipher.AEAD, []byte, error) {
+	s := strings.TrimPrefix(value, valuePrefix)
+	i := strings.Index(s, ":")
+	if i < 0 {
+		return "", nil, nil, fmt.Errorf("malformed encrypted value")
+	}
+	id := s[:i]
+	data, err := base64.StdEncoding.DecodeString(s[i+1:])
+	if err != nil {
+		return "", nil, nil, err
+	}
+	r.lock.RLock()
+	aead, ok := r.aeads[id]
+	r.lock.RUnlock()
+	if !ok {
+		return "", nil, nil, fmt.Errorf("data key %s not found", id)
+	}
+	return id, aead, data, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,168 @@
+package encryption
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha2"
+)
+
+func initTestEncryption(t *testing.T, dir string, enable bool) {
+	ring, enabled, resources = nil, false, make(map[string]bool)
+	err := Init(&v1alpha2.MetaEncryption{
+		Enable:                enable,
+		Resources:             []string{"secret"},
+		KeyProvider:           FileProviderName,
+		KeyFile:               filepath.Join(dir, "kek"),
+		DataKeyFile:           filepath.Join(dir, "datakeys.json"),
+		DataKeyRotationPeriod: metav1.Duration{Duration: time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("failed to init encryption: %v", err)
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	initTestEncryption(t, t.TempDir(), true)
+	if !ResourceEncrypted("Secret") || ResourceEncrypted("configmap") {
+		t.Errorf("unexpected encrypted resources %v", resources)
+	}
+
+	encrypted, err := Encrypt("default/secret/a", `{"data":"foo"}`)
+	if err != nil {
+		t.Fatalf("failed to encrypt: %v", err)
+	}
+	if !IsEncrypted(encrypted) || strings.Contains(encrypted, "foo") {
+		t.Errorf("value is not encrypted: %s", encrypted)
+	}
+	plaintext, err := Decrypt("default/secret/a", encrypted)
+	if err != nil || plaintext != `{"data":"foo"}` {
+		t.Errorf("expected the original value, but got %s, %v", plaintext, err)
+	}
+	// the value can not be moved to another record
+	if _, err := Decrypt("default/secret/b", encrypted); err == nil {
+		t.Errorf("expected error decrypting with another key")
+	}
+	// plaintext is returned as it is
+	if plaintext, err := Decrypt("default/configmap/a", "{}"); err != nil || plaintext != "{}" {
+		t.Errorf("expected plaintext returned as it is, but got %s, %v", plaintext, err)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	dir := t.TempDir()
+	initTestEncryption(t, dir, true)
+
+	stored := map[string]string{"default/secret/a": `{"data":"foo"}`, "default/configmap/a": "{}"}
+	reencrypt := func() error {
+		for key, value := range stored {
+			newValue, changed, err := Transform(key, value, strings.Contains(key, "/secret/") && ResourceEncrypted("secret"))
+			if err != nil {
+				return err
+			}
+			if changed {
+				stored[key] = newValue
+			}
+		}
+		return nil
+	}
+	// plaintext secrets are encrypted
+	if err := Reconcile(reencrypt); err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+	old := stored["default/secret/a"]
+	if !IsEncrypted(old) || IsEncrypted(stored["default/configmap/a"]) {
+		t.Fatalf("unexpected stored values %v", stored)
+	}
+
+	// the data key is too young to rotate
+	if err := Rotate(time.Hour, reencrypt); err != nil || stored["default/secret/a"] != old {
+		t.Fatalf("expected no rotation, but got %v", err)
+	}
+	if err := Rotate(time.Nanosecond, reencrypt); err != nil {
+		t.Fatalf("failed to rotate: %v", err)
+	}
+	if stored["default/secret/a"] == old || !ring.isCurrent(stored["default/secret/a"]) {
+		t.Errorf("expected the value re-encrypted by the new data key")
+	}
+	if len(ring.sealed) != 1 || ring.current != "2" {
+		t.Errorf("expected only data key 2 kept, but got %v", ring.sealed)
+	}
+
+	// the data keys are loaded after restart, even if encryption is disabled
+	initTestEncryption(t, dir, false)
+	if plaintext, err := Decrypt("default/secret/a", stored["default/secret/a"]); err != nil || plaintext != `{"data":"foo"}` {
+		t.Errorf("expected the original value, but got %s, %v", plaintext, err)
+	}
+	if err := Reconcile(reencrypt); err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+	if stored["default/secret/a"] != `{"data":"foo"}` {
+		t.Errorf("expected the value decrypted after encryption disabled, but got %s", stored["default/secret/a"])
+	}
+}
+
+func TestRotateWaitsForWrites(t *testing.T) {
+	initTestEncryption(t, t.TempDir(), true)
+
+	stored := make(map[string]string)
+	var lock sync.Mutex
+	reencrypt := func() error {
+		lock.Lock()
+		defer lock.Unlock()
+		for key, value := range stored {
+			newValue, _, err := Transform(key, value, true)
+			if err != nil {
+				return err
+			}
+			stored[key] = newValue
+		}
+		return nil
+	}
+
+	// the value is encrypted by the old data key, and stored after the rotation starts
+	BeginWrite()
+	value, err := Encrypt("default/secret/a", "foo")
+	if err != nil {
+		t.Fatalf("failed to encrypt: %v", err)
+	}
+	rotated := make(chan error)
+	go func() {
+		rotated <- Rotate(time.Nanosecond, reencrypt)
+	}()
+	select {
+	case err := <-rotated:
+		t.Fatalf("expected rotation waiting for the write, but got %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	lock.Lock()
+	stored["default/secret/a"] = value
+	lock.Unlock()
+	EndWrite()
+
+	if err := <-rotated; err != nil {
+		t.Fatalf("failed to rotate: %v", err)
+	}
+	if plaintext, err := Decrypt("default/secret/a", stored["default/secret/a"]); err != nil || plaintext != "foo" {
+		t.Errorf("expected the value re-encrypted by the new data key, but got %s, %v", plaintext, err)
+	}
+}
+
+func TestKeyProviderMismatch(t *testing.T) {
+	dir := t.TempDir()
+	initTestEncryption(t, dir, true)
+
+	// the data keys can not be unsealed by another key
+	provider, err := newFileProvider(filepath.Join(dir, "another-kek"))
+	if err != nil {
+		t.Fatalf("failed to create key provider: %v", err)
+	}
+	if _, err := loadKeyring(provider, filepath.Join(dir, "datakeys.json")); err == nil {
+		t.Errorf("expected error unsealing data keys by another key")
+	}
+}
@@ -0,0 +1,134 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// FileProviderName is the name of the key provider which keeps the key encryption key in a local file
+	FileProviderName = "file"
+
+	keySize = 32
+)
+
+// KeyProvider seals the data keys that encrypt the resources, so that the data keys are never
+// persisted in plaintext. The key encryption key is kept by the provider, e.g. in a file, a TPM or a KMS.
+type KeyProvider interface {
+	// Name returns the name of the provider, it is recorded along with the sealed data keys
+	Name() string
+	// Seal encrypts the data key
+	Seal(key []byte) ([]byte, error)
+	// Unseal decrypts the data key sealed by Seal
+	Unseal(sealed []byte) ([]byte, error)
+}
+
+// NewKeyProvider returns the key provider of name
+func NewKeyProvider(name string, keyFile string) (KeyProvider, error) {
+	switch name {
+	case FileProviderName:
+		return newFileProvider(keyFile)
+	}
+	return nil, fmt.Errorf("unknown key provider %q", name)
+}
+
+// fileProvider seals the data keys with AES-GCM by a key read from file, the key file
+// is generated if not exist. It should be placed on a storage different from edgecore.db,
+// such as a removable or an encrypted partition, to really protect the data keys.
+type fileProvider struct {
+	aead cipher.AEAD
+}
+
+func newFileProvider(keyFile string) (KeyProvider, error) {
+	key, err := loadOrCreateKeyFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &fileProvider{aead: aead}, nil
+}
+
+func (p *fileProvider) Name() string {
+	return FileProviderName
+}
+
+func (p *fileProvider) Seal(key []byte) ([]byte, error) {
+	return seal(p.aead, key, nil)
+}
+
+func (p *fileProvider) Unseal(sealed []byte) ([]byte, error) {
+	return open(p.aead, sealed, nil)
+}
+
+// loadOrCreateKeyFile reads the base64 encoded key from keyFile, a random key is written to it if not exist
+func loadOrCreateKeyFile(keyFile string) ([]byte, error) {
+	data, err := os.ReadFile(keyFile)
+	if err == nil {
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode key file %s: %v", keyFile, err)
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("invalid key size %d in key file %s, %d is expected", len(key), keyFile, keySize)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	key, err := newKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(keyFile), 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(keyFile, []byte(base64.StdEncoding.EncodeToString(key)), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write key file %s: %v", keyFile, err)
+	}
+	return key, nil
+}
+
+func newKey() ([]byte, error) {
+	key := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext and returns nonce|ciphertext
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, data, additionalData []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("the encrypted data is too short")
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
@@ -10,6 +10,7 @@ import (
 	metamanagerconfig "github.com/kubeedge/kubeedge/edge/pkg/metamanager/config"
 	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
 	v2 "github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao/v2"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/encryption"
 	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver"
 	metaserverconfig "github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/config"
 	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/kubernetes/storage/sqlite/imitator"
@@ -45,6 +46,8 @@ func initDBTable(module core.Module) {
 	}
 	orm.RegisterModel(new(dao.Meta))
 	orm.RegisterModel(new(v2.MetaV2))
+	orm.RegisterModel(new(v2.MetaV2Event))
+	orm.RegisterModel(new(v2.MetaV2Journal))
 }
 
 func (*metaManager) Name() string {
@@ -60,6 +63,12 @@ func (m *metaManager) Enable() bool {
 }
 
 func (m *metaManager) Start() {
+	if err := encryption.Init(metamanagerconfig.Config.Encryption); err != nil {
+		klog.Exitf("failed to init encryption of metamanager: %v", err)
+	}
+	go runKeyRotation()
+	remoteCache = newRemoteQueryCache(metamanagerconfig.Config.RemoteQueryCache)
+
 	if metaserverconfig.Config.Enable {
 		imitator.StorageInit()
 		go metaserver.NewMetaServer().Start(beehiveContext.Done())
@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/request/bearertoken"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/apiserver/pkg/authentication/token/cache"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/klog/v2"
+
+	"github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+	v2 "github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao/v2"
+)
+
+const (
+	// tokenCacheTTL is how long the result of token authentication is cached
+	tokenCacheTTL = 10 * time.Second
+
+	// the extra info of bound service account token, same as kube-apiserver
+	podNameKey = "authentication.kubernetes.io/pod-name"
+	podUIDKey  = "authentication.kubernetes.io/pod-uid"
+)
+
+var (
+	secretGVR = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+	defaultAuthenticator = NewRequestAuthenticator()
+)
+
+// NewRequestAuthenticator returns an authenticator which authenticates the bearer token of request
+// with the service account tokens cached at edge
+func NewRequestAuthenticator() authenticator.Request {
+	return bearertoken.New(cache.New(&tokenAuthenticator{}, false, tokenCacheTTL, tokenCacheTTL))
+}
+
+// tokenAuthenticator authenticates service account tokens without kube-apiserver, a token is valid if it is:
+// 1. a bound token requested by edged for the pods on this node, and not expired
+// 2. a legacy token stored in a service account token secret
+// The tokens are all issued by kube-apiserver, so a token that equals to one of them is trusted.
+type tokenAuthenticator struct{}
+
+func (a *tokenAuthenticator) AuthenticateToken(ctx context.Context, token string) (*authenticator.Response, bool, error) {
+	info, err := authenticateBoundToken(token)
+	if err != nil || info != nil {
+		return toResponse(info), info != nil, err
+	}
+	info, err = authenticateSecretToken(token)
+	return toResponse(info), info != nil, err
+}
+
+func toResponse(info user.Info) *authenticator.Response {
+	if info == nil {
+		return nil
+	}
+	return &authenticator.Response{User: info}
+}
+
+// authenticateBoundToken finds the token in the token requests cached by edged
+func authenticateBoundToken(token string) (user.Info, error) {
+	metas, err := dao.QueryAllMeta("type", model.ResourceTypeServiceAccountToken)
+	if err != nil {
+		return nil, err
+	}
+	for _, meta := range *metas {
+		var tr authenticationv1.TokenRequest
+		if err := json.Unmarshal([]byte(meta.Value), &tr); err != nil {
+			klog.Errorf("[metaserver/auth] failed to unmarshal token request %v: %v", meta.Key, err)
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(tr.Status.Token), []byte(token)) != 1 {
+			continue
+		}
+		if tr.Status.ExpirationTimestamp.Time.Before(time.Now()) {
+			return nil, fmt.Errorf("service account token has expired")
+		}
+		return boundTokenUserInfo(token)
+	}
+	return nil, nil
+}
+
+// boundTokenClaims are the private claims of bound service account token
+type boundTokenClaims struct {
+	Kubernetes struct {
+		Namespace string `json:"namespace"`
+		Pod       *struct {
+			Name string `json:"name"`
+			UID  string `json:"uid"`
+		} `json:"pod,omitempty"`
+		ServiceAccount struct {
+			Name string `json:"name"`
+			UID  string `json:"uid"`
+		} `json:"serviceaccount"`
+	} `json:"kubernetes.io"`
+}
+
+// boundTokenUserInfo get the user info from the claims of jwt, the signature is not verified
+// since the token has been matched with the one issued by kube-apiserver
+func boundTokenUserInfo(token string) (user.Info, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed service account token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("malformed service account token: %v", err)
+	}
+	var claims boundTokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("malformed service account token: %v", err)
+	}
+	k8s := claims.Kubernetes
+	if k8s.Namespace == "" || k8s.ServiceAccount.Name == "" {
+		return nil, fmt.Errorf("service account token has no service account claims")
+	}
+
+	info := &user.DefaultInfo{
+		Name:   serviceaccount.MakeUsername(k8s.Namespace, k8s.ServiceAccount.Name),
+		UID:    k8s.ServiceAccount.UID,
+		Groups: append(serviceaccount.MakeGroupNames(k8s.Namespace), user.AllAuthenticated),
+	}
+	if k8s.Pod != nil {
+		info.Extra = map[string][]string{
+			podNameKey: {k8s.Pod.Name},
+			podUIDKey:  {k8s.Pod.UID},
+		}
+	}
+	return info, nil
+}
+
+// authenticateSecretToken finds the token in the service account token secrets cached by metaserver
+func authenticateSecretToken(token string) (user.Info, error) {
+	objs, err := v2.RawMetaByGVRNN(secretGVR, "", "")
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range *objs {
+		var secret corev1.Secret
+		if err := json.Unmarshal([]byte(obj.Value), &secret); err != nil {
+			klog.Errorf("[metaserver/auth] failed to unmarshal secret %v: %v", obj.Key, err)
+			continue
+		}
+		if secret.Type != corev1.SecretTypeServiceAccountToken {
+			continue
+		}
+		if subtle.ConstantTimeCompare(secret.Data[corev1.ServiceAccountTokenKey], []byte(token)) != 1 {
+			continue
+		}
+		name := secret.Annotations[corev1.ServiceAccountNameKey]
+		if name == "" {
+			return nil, fmt.Errorf("service account token secret %v has no service account", obj.Key)
+		}
+		return &user.DefaultInfo{
+			Name:   serviceaccount.MakeUsername(secret.Namespace, name),
+			UID:    secret.Annotations[corev1.ServiceAccountUIDKey],
+			Groups: append(serviceaccount.MakeGroupNames(secret.Namespace), user.AllAuthenticated),
+		}, nil
+	}
+	return nil, nil
+}
+
+// AuthenticateRequest authenticates the request with default authenticator
+func AuthenticateRequest(req *http.Request) (user.Info, bool) {
+	resp, ok, err := defaultAuthenticator.AuthenticateRequest(req)
+	if err != nil {
+		klog.V(4).Infof("[metaserver/auth] failed to authenticate request: %v", err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	return resp.User, true
+}
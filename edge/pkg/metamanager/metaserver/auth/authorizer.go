@@ -0,0 +1,387 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapifilters "k8s.io/apiserver/pkg/endpoints/filters"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	rbacv1helpers "k8s.io/kubernetes/pkg/apis/rbac/v1"
+
+	v2 "github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao/v2"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/kubernetes/storage/sqlite/imitator"
+)
+
+// RuleSource provides the rbac resources to evaluate rules
+type RuleSource interface {
+	ListClusterRoleBindings() ([]*rbacv1.ClusterRoleBinding, error)
+	ListRoleBindings(namespace string) ([]*rbacv1.RoleBinding, error)
+	GetClusterRole(name string) (*rbacv1.ClusterRole, error)
+	GetRole(namespace, name string) (*rbacv1.Role, error)
+}
+
+// RBACAuthorizer authorizes requests with rbac rules, it works the same as the rbac authorizer of kube-apiserver
+type RBACAuthorizer struct {
+	source RuleSource
+}
+
+// NewRBACAuthorizer returns a RBACAuthorizer with the rules from source
+func NewRBACAuthorizer(source RuleSource) *RBACAuthorizer {
+	return &RBACAuthorizer{source: source}
+}
+
+type authorizingVisitor struct {
+	requestAttributes authorizer.Attributes
+
+	allowed bool
+	reason  string
+	errors  []error
+}
+
+func (v *authorizingVisitor) visit(source fmt.Stringer, rule *rbacv1.PolicyRule, err error) bool {
+	if rule != nil && ruleAllows(v.requestAttributes, rule) {
+		v.allowed = true
+		v.reason = fmt.Sprintf("RBAC: allowed by %s", source.String())
+		return false
+	}
+	if err != nil {
+		v.errors = append(v.errors, err)
+	}
+	return true
+}
+
+func (r *RBACAuthorizer) Authorize(ctx context.Context, requestAttributes authorizer.Attributes) (authorizer.Decision, string, error) {
+	ruleCheckingVisitor := &authorizingVisitor{requestAttributes: requestAttributes}
+
+	r.visitRulesFor(requestAttributes.GetUser(), requestAttributes.GetNamespace(), ruleCheckingVisitor.visit)
+	if ruleCheckingVisitor.allowed {
+		return authorizer.DecisionAllow, ruleCheckingVisitor.reason, nil
+	}
+
+	reason := ""
+	if len(ruleCheckingVisitor.errors) > 0 {
+		reason = fmt.Sprintf("RBAC: %v", ruleCheckingVisitor.errors)
+	}
+	return authorizer.DecisionNoOpinion, reason, nil
+}
+
+// visitRulesFor visits the rules that apply to the user in namespace until visitor returns false
+func (r *RBACAuthorizer) visitRulesFor(u user.Info, namespace string, visitor func(source fmt.Stringer, rule *rbacv1.PolicyRule, err error) bool) {
+	clusterRoleBindings, err := r.source.ListClusterRoleBindings()
+	if err != nil {
+		if !visitor(nil, nil, err) {
+			return
+		}
+	}
+	for _, clusterRoleBinding := range clusterRoleBindings {
+		subjectIndex, applies := appliesTo(u, clusterRoleBinding.Subjects, "")
+		if !applies {
+			continue
+		}
+		rules, err := r.getRoleReferenceRules(clusterRoleBinding.RoleRef, "")
+		if err != nil {
+			if !visitor(nil, nil, err) {
+				return
+			}
+			continue
+		}
+		source := &bindingDescriber{
+			kind:    "ClusterRoleBinding",
+			name:    clusterRoleBinding.Name,
+			roleRef: clusterRoleBinding.RoleRef,
+			subject: &clusterRoleBinding.Subjects[subjectIndex],
+		}
+		for i := range rules {
+			if !visitor(source, &rules[i], nil) {
+				return
+			}
+		}
+	}
+
+	if len(namespace) == 0 {
+		return
+	}
+	roleBindings, err := r.source.ListRoleBindings(namespace)
+	if err != nil {
+		visitor(nil, nil, err)
+		return
+	}
+	for _, roleBinding := range roleBindings {
+		subjectIndex, applies := appliesTo(u, roleBinding.Subjects, namespace)
+		if !applies {
+			continue
+		}
+		rules, err := r.getRoleReferenceRules(roleBinding.RoleRef, namespace)
+		if err != nil {
+			if !visitor(nil, nil, err) {
+				return
+			}
+			continue
+		}
+		source := &bindingDescriber{
+			kind:      "RoleBinding",
+			name:      roleBinding.Name,
+			namespace: roleBinding.Namespace,
+			roleRef:   roleBinding.RoleRef,
+			subject:   &roleBinding.Subjects[subjectIndex],
+		}
+		for i := range rules {
+			if !visitor(source, &rules[i], nil) {
+				return
+			}
+		}
+	}
+}
+
+// getRoleReferenceRules attempts to resolve the RoleBinding or ClusterRoleBinding.
+func (r *RBACAuthorizer) getRoleReferenceRules(roleRef rbacv1.RoleRef, bindingNamespace string) ([]rbacv1.PolicyRule, error) {
+	switch roleRef.Kind {
+	case "Role":
+		role, err := r.source.GetRole(bindingNamespace, roleRef.Name)
+		if err != nil {
+			return nil, err
+		}
+		return role.Rules, nil
+	case "ClusterRole":
+		clusterRole, err := r.source.GetClusterRole(roleRef.Name)
+		if err != nil {
+			return nil, err
+		}
+		return clusterRole.Rules, nil
+	default:
+		return nil, fmt.Errorf("unsupported role reference kind: %q", roleRef.Kind)
+	}
+}
+
+// appliesTo returns whether any of the bindingSubjects applies to the specified subject,
+// and if true, the index of the first subject that applies
+func appliesTo(u user.Info, bindingSubjects []rbacv1.Subject, namespace string) (int, bool) {
+	for i, bindingSubject := range bindingSubjects {
+		if appliesToUser(u, bindingSubject, namespace) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func appliesToUser(u user.Info, subject rbacv1.Subject, namespace string) bool {
+	switch subject.Kind {
+	case rbacv1.UserKind:
+		return u.GetName() == subject.Name
+	case rbacv1.GroupKind:
+		for _, group := range u.GetGroups() {
+			if group == subject.Name {
+				return true
+			}
+		}
+		return false
+	case rbacv1.ServiceAccountKind:
+		// default the namespace to namespace we're working in if its available.  This allows rolebindings that reference
+		// SAs in th local namespace to avoid having to qualify them.
+		saNamespace := namespace
+		if len(subject.Namespace) > 0 {
+			saNamespace = subject.Namespace
+		}
+		if len(saNamespace) == 0 {
+			return false
+		}
+		return serviceaccount.MatchesUsername(saNamespace, subject.Name, u.GetName())
+	default:
+		return false
+	}
+}
+
+func ruleAllows(requestAttributes authorizer.Attributes, rule *rbacv1.PolicyRule) bool {
+	if requestAttributes.IsResourceRequest() {
+		combinedResource := requestAttributes.GetResource()
+		if len(requestAttributes.GetSubresource()) > 0 {
+			combinedResource = requestAttributes.GetResource() + "/" + requestAttributes.GetSubresource()
+		}
+
+		return rbacv1helpers.VerbMatches(rule, requestAttributes.GetVerb()) &&
+			rbacv1helpers.APIGroupMatches(rule, requestAttributes.GetAPIGroup()) &&
+			rbacv1helpers.ResourceMatches(rule, combinedResource, requestAttributes.GetSubresource()) &&
+			rbacv1helpers.ResourceNameMatches(rule, requestAttributes.GetName())
+	}
+
+	return rbacv1helpers.VerbMatches(rule, requestAttributes.GetVerb()) &&
+		rbacv1helpers.NonResourceURLMatches(rule, requestAttributes.GetPath())
+}
+
+type bindingDescriber struct {
+	kind      string
+	name      string
+	namespace string
+	roleRef   rbacv1.RoleRef
+	subject   *rbacv1.Subject
+}
+
+func (d *bindingDescriber) String() string {
+	binding := d.name
+	if d.namespace != "" {
+		binding = d.namespace + "/" + d.name
+	}
+	return fmt.Sprintf("%s %q of %s %q to %s",
+		d.kind, binding, d.roleRef.Kind, d.roleRef.Name, describeSubject(d.subject, d.namespace))
+}
+
+func describeSubject(s *rbacv1.Subject, bindingNamespace string) string {
+	switch s.Kind {
+	case rbacv1.ServiceAccountKind:
+		if len(s.Namespace) > 0 {
+			return fmt.Sprintf("%s %q", s.Kind, s.Name+"/"+s.Namespace)
+		}
+		return fmt.Sprintf("%s %q", s.Kind, s.Name+"/"+bindingNamespace)
+	default:
+		return fmt.Sprintf("%s %q", s.Kind, s.Name)
+	}
+}
+
+var (
+	clusterRoleBindingGVR = rbacv1.SchemeGroupVersion.WithResource("clusterrolebindings")
+	roleBindingGVR        = rbacv1.SchemeGroupVersion.WithResource("rolebindings")
+	clusterRoleGVR        = rbacv1.SchemeGroupVersion.WithResource("clusterroles")
+	roleGVR               = rbacv1.SchemeGroupVersion.WithResource("roles")
+)
+
+// localRuleSource reads the rbac resources synced from cloud in table meta_v2,
+// the decoded resources are cached until the storage revision changes
+type localRuleSource struct {
+	lock     sync.Mutex
+	revision uint64
+	cache    map[string]interface{}
+}
+
+func newLocalRuleSource() *localRuleSource {
+	return &localRuleSource{cache: make(map[string]interface{})}
+}
+
+// load decode the rbac resources of gvr with namespace and name, newObj returns the pointer to decode into
+func (s *localRuleSource) load(gvr schema.GroupVersionResource, namespace string, name string, newObj func() interface{}) ([]interface{}, error) {
+	key := fmt.Sprintf("%s/%s/%s", gvr.Resource, namespace, name)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if revision := imitator.DefaultV2Client.GetRevision(); revision != s.revision {
+		s.revision = revision
+		s.cache = make(map[string]interface{})
+	}
+	if cached, ok := s.cache[key]; ok {
+		return cached.([]interface{}), nil
+	}
+
+	objs, err := v2.RawMetaByGVRNN(gvr, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	var ret []interface{}
+	for _, obj := range *objs {
+		out := newObj()
+		if err := json.Unmarshal([]byte(obj.Value), out); err != nil {
+			return nil, fmt.Errorf("failed to decode %v: %v", obj.Key, err)
+		}
+		ret = append(ret, out)
+	}
+	s.cache[key] = ret
+	return ret, nil
+}
+
+func (s *localRuleSource) ListClusterRoleBindings() ([]*rbacv1.ClusterRoleBinding, error) {
+	objs, err := s.load(clusterRoleBindingGVR, "", "", func() interface{} { return new(rbacv1.ClusterRoleBinding) })
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*rbacv1.ClusterRoleBinding, 0, len(objs))
+	for _, obj := range objs {
+		ret = append(ret, obj.(*rbacv1.ClusterRoleBinding))
+	}
+	return ret, nil
+}
+
+func (s *localRuleSource) ListRoleBindings(namespace string) ([]*rbacv1.RoleBinding, error) {
+	objs, err := s.load(roleBindingGVR, namespace, "", func() interface{} { return new(rbacv1.RoleBinding) })
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*rbacv1.RoleBinding, 0, len(objs))
+	for _, obj := range objs {
+		ret = append(ret, obj.(*rbacv1.RoleBinding))
+	}
+	return ret, nil
+}
+
+func (s *localRuleSource) GetClusterRole(name string) (*rbacv1.ClusterRole, error) {
+	objs, err := s.load(clusterRoleGVR, "", name, func() interface{} { return new(rbacv1.ClusterRole) })
+	if err != nil {
+		return nil, err
+	}
+	if len(objs) == 0 {
+		return nil, errors.NewNotFound(clusterRoleGVR.GroupResource(), name)
+	}
+	return objs[0].(*rbacv1.ClusterRole), nil
+}
+
+func (s *localRuleSource) GetRole(namespace, name string) (*rbacv1.Role, error) {
+	objs, err := s.load(roleGVR, namespace, name, func() interface{} { return new(rbacv1.Role) })
+	if err != nil {
+		return nil, err
+	}
+	if len(objs) == 0 {
+		return nil, errors.NewNotFound(roleGVR.GroupResource(), name)
+	}
+	return objs[0].(*rbacv1.Role), nil
+}
+
+var defaultAuthorizer = NewRBACAuthorizer(newLocalRuleSource())
+
+// Authorize authorizes the request in ctx with the rbac resources cached at edge,
+// an Unauthorized error is returned if the user of request is unknown, and a
+// Forbidden error is returned if the request is not allowed
+func Authorize(ctx context.Context) error {
+	if _, ok := apirequest.UserFrom(ctx); !ok {
+		return errors.NewUnauthorized("failed to authenticate the request at edge")
+	}
+	attrs, err := genericapifilters.GetAuthorizerAttributes(ctx)
+	if err != nil {
+		return errors.NewInternalError(err)
+	}
+	decision, reason, err := defaultAuthorizer.Authorize(ctx, attrs)
+	if err != nil {
+		return errors.NewInternalError(err)
+	}
+	if decision == authorizer.DecisionAllow {
+		return nil
+	}
+	return forbidden(attrs, reason)
+}
+
+// forbidden returns the same error as kube-apiserver does when a request is forbidden
+func forbidden(attrs authorizer.Attributes, reason string) error {
+	resource := attrs.GetResource()
+	if subresource := attrs.GetSubresource(); len(subresource) > 0 {
+		resource = resource + "/" + subresource
+	}
+	var msg string
+	switch {
+	case !attrs.IsResourceRequest():
+		msg = fmt.Sprintf("User %q cannot %s path %q", attrs.GetUser().GetName(), attrs.GetVerb(), attrs.GetPath())
+	case len(attrs.GetNamespace()) > 0:
+		msg = fmt.Sprintf("User %q cannot %s resource %q in API group %q in the namespace %q",
+			attrs.GetUser().GetName(), attrs.GetVerb(), resource, attrs.GetAPIGroup(), attrs.GetNamespace())
+	default:
+		msg = fmt.Sprintf("User %q cannot %s resource %q in API group %q at the cluster scope",
+			attrs.GetUser().GetName(), attrs.GetVerb(), resource, attrs.GetAPIGroup())
+	}
+	if len(reason) > 0 {
+		msg = fmt.Sprintf("%s: %s", msg, reason)
+	}
+	gr := schema.GroupResource{Group: attrs.GetAPIGroup(), Resource: attrs.GetResource()}
+	return errors.NewForbidden(gr, attrs.GetName(), fmt.Errorf("%s", msg))
+}
@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+)
+
+type fakeRuleSource struct {
+	clusterRoleBindings []*rbacv1.ClusterRoleBinding
+	roleBindings        []*rbacv1.RoleBinding
+	clusterRoles        []*rbacv1.ClusterRole
+	roles               []*rbacv1.Role
+}
+
+func (s *fakeRuleSource) ListClusterRoleBindings() ([]*rbacv1.ClusterRoleBinding, error) {
+	return s.clusterRoleBindings, nil
+}
+
+func (s *fakeRuleSource) ListRoleBindings(namespace string) ([]*rbacv1.RoleBinding, error) {
+	var ret []*rbacv1.RoleBinding
+	for _, rb := range s.roleBindings {
+		if rb.Namespace == namespace {
+			ret = append(ret, rb)
+		}
+	}
+	return ret, nil
+}
+
+func (s *fakeRuleSource) GetClusterRole(name string) (*rbacv1.ClusterRole, error) {
+	for _, cr := range s.clusterRoles {
+		if cr.Name == name {
+			return cr, nil
+		}
+	}
+	return nil, errors.NewNotFound(clusterRoleGVR.GroupResource(), name)
+}
+
+func (s *fakeRuleSource) GetRole(namespace, name string) (*rbacv1.Role, error) {
+	for _, r := range s.roles {
+		if r.Namespace == namespace && r.Name == name {
+			return r, nil
+		}
+	}
+	return nil, errors.NewNotFound(roleGVR.GroupResource(), name)
+}
+
+func TestRBACAuthorizer(t *testing.T) {
+	source := &fakeRuleSource{
+		clusterRoleBindings: []*rbacv1.ClusterRoleBinding{{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-reader"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "system:serviceaccounts:monitor"}},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "node-reader"},
+		}},
+		roleBindings: []*rbacv1.RoleBinding{{
+			ObjectMeta: metav1.ObjectMeta{Name: "configmap-reader", Namespace: "default"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "app"}},
+			RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "configmap-reader"},
+		}},
+		clusterRoles: []*rbacv1.ClusterRole{{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-reader"},
+			Rules:      []rbacv1.PolicyRule{{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"nodes"}}},
+		}},
+		roles: []*rbacv1.Role{{
+			ObjectMeta: metav1.ObjectMeta{Name: "configmap-reader", Namespace: "default"},
+			Rules: []rbacv1.PolicyRule{{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"configmaps"},
+				ResourceNames: []string{"app-config"}}},
+		}},
+	}
+	app := serviceaccount.UserInfo("default", "app", "")
+	monitor := serviceaccount.UserInfo("monitor", "agent", "")
+
+	cases := []struct {
+		name    string
+		attrs   authorizer.AttributesRecord
+		allowed bool
+	}{
+		{
+			name:    "allowed by role binding",
+			attrs:   authorizer.AttributesRecord{User: app, Verb: "get", Namespace: "default", Resource: "configmaps", Name: "app-config", ResourceRequest: true},
+			allowed: true,
+		},
+		{
+			name:  "resource name not allowed",
+			attrs: authorizer.AttributesRecord{User: app, Verb: "get", Namespace: "default", Resource: "configmaps", Name: "other", ResourceRequest: true},
+		},
+		{
+			name:  "role binding in other namespace",
+			attrs: authorizer.AttributesRecord{User: serviceaccount.UserInfo("kube-system", "app", ""), Verb: "get", Namespace: "kube-system", Resource: "configmaps", Name: "app-config", ResourceRequest: true},
+		},
+		{
+			name:    "allowed by cluster role binding to group",
+			attrs:   authorizer.AttributesRecord{User: monitor, Verb: "list", Resource: "nodes", ResourceRequest: true},
+			allowed: true,
+		},
+		{
+			name:  "verb not allowed",
+			attrs: authorizer.AttributesRecord{User: monitor, Verb: "delete", Resource: "nodes", Name: "edge", ResourceRequest: true},
+		},
+		{
+			name:  "unknown user",
+			attrs: authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "nobody"}, Verb: "list", Resource: "nodes", ResourceRequest: true},
+		},
+	}
+
+	a := NewRBACAuthorizer(source)
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			decision, reason, err := a.Authorize(context.TODO(), test.attrs)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if allowed := decision == authorizer.DecisionAllow; allowed != test.allowed {
+				t.Errorf("expected allowed %v, but got %v, reason: %v", test.allowed, allowed, reason)
+			}
+		})
+	}
+}
+
+func TestBoundTokenUserInfo(t *testing.T) {
+	payload := `{"sub":"system:serviceaccount:default:app","kubernetes.io":{"namespace":"default",` +
+		`"pod":{"name":"app-0","uid":"pod-uid"},"serviceaccount":{"name":"app","uid":"sa-uid"}}}`
+	token := "header." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".signature"
+
+	info, err := boundTokenUserInfo(token)
+	if err != nil {
+		t.Fatalf("failed to get user info: %v", err)
+	}
+	if info.GetName() != "system:serviceaccount:default:app" || info.GetUID() != "sa-uid" {
+		t.Errorf("unexpected user %v", info)
+	}
+	if pods := info.GetExtra()[podNameKey]; len(pods) != 1 || pods[0] != "app-0" {
+		t.Errorf("unexpected extra %v", info.GetExtra())
+	}
+
+	if _, err := boundTokenUserInfo("not-a-jwt"); err == nil {
+		t.Errorf("expected error for malformed token")
+	}
+}
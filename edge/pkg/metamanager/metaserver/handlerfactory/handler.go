@@ -41,7 +41,7 @@ func NewFactory() *Factory {
 	utilruntime.Must(err)
 	f := Factory{
 		storage:           s,
-		scope:             scope.NewRequestScope(),
+		scope:             newRequestScope(s),
 		MinRequestTimeout: 1800 * time.Second,
 		handlers:          make(map[string]http.Handler),
 		lock:              sync.RWMutex{},
@@ -49,6 +49,13 @@ func NewFactory() *Factory {
 	return &f
 }
 
+// newRequestScope returns a request scope which prints tables with the storage's convertor
+func newRequestScope(s *storage.REST) *handlers.RequestScope {
+	requestScope := scope.NewRequestScope()
+	requestScope.TableConvertor = s.TableConvertor
+	return requestScope
+}
+
 func (f *Factory) Get() http.Handler {
 	if h, ok := f.getHandler("get"); ok {
 		return h
@@ -72,7 +79,7 @@ func (f *Factory) List() http.Handler {
 }
 
 func (f *Factory) Create(req *request.RequestInfo) http.Handler {
-	s := scope.NewRequestScope()
+	s := newRequestScope(f.storage)
 	s.Kind = schema.GroupVersionKind{
 		Group:   req.APIGroup,
 		Version: req.APIVersion,
@@ -93,6 +100,30 @@ func (f *Factory) Delete() http.Handler {
 	return h
 }
 
+// NonResource returns the handler for non-resource requests such as discovery, openapi and version,
+// the response from cloud is written as it is
+func (f *Factory) NonResource() http.Handler {
+	if h, ok := f.getHandler("nonresource"); ok {
+		return h
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		resp, err := f.storage.GetNonResource(req.Context(), req.Header.Get("Accept"))
+		if err != nil {
+			responsewriters.ErrorNegotiated(err, f.scope.Serializer, schema.GroupVersion{}, w, req)
+			return
+		}
+		w.Header().Set("Content-Type", resp.ContentType)
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(resp.Body); err != nil {
+			klog.Errorf("failed to write response of %v: %v", req.URL.Path, err)
+		}
+	})
+	f.handlers["nonresource"] = h
+	return h
+}
+
 func (f *Factory) getHandler(key string) (http.Handler, bool) {
 	f.lock.RLock()
 	defer f.lock.RUnlock()
@@ -101,7 +132,7 @@ func (f *Factory) getHandler(key string) (http.Handler, bool) {
 }
 
 func (f *Factory) Update(req *request.RequestInfo) http.Handler {
-	s := scope.NewRequestScope()
+	s := newRequestScope(f.storage)
 	s.Kind = schema.GroupVersionKind{
 		Group:   req.APIGroup,
 		Version: req.APIVersion,
@@ -0,0 +1,177 @@
+package cacher
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/storage"
+	"k8s.io/klog/v2"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/kubernetes/storage/sqlite/imitator"
+	"github.com/kubeedge/kubeedge/pkg/metaserver/util"
+)
+
+const (
+	// We have set a buffer in order to reduce times of context switches.
+	inputBufSize  = 100
+	resultBufSize = 100
+
+	// blockTimeout is how long the dispatching waits for a watcher whose buffer is full,
+	// the watcher is terminated after that to not block other watchers
+	blockTimeout = 100 * time.Millisecond
+)
+
+// cacheWatcher implements watch.Interface, it filters the events dispatched by watchCache
+type cacheWatcher struct {
+	id        int
+	key       watcherKey
+	gvr       schema.GroupVersionResource
+	namespace string
+	name      string
+	// startRV is the resource version the watcher starts from, events not newer than it are skipped
+	startRV uint64
+	pred    storage.SelectionPredicate
+
+	input  chan *watchCacheEvent
+	result chan watch.Event
+	done   chan struct{}
+	once   sync.Once
+}
+
+func newCacheWatcher(id int, gvr schema.GroupVersionResource, namespace, name string, startRV uint64, pred storage.SelectionPredicate) *cacheWatcher {
+	return &cacheWatcher{
+		id:        id,
+		gvr:       gvr,
+		namespace: namespace,
+		name:      name,
+		startRV:   startRV,
+		pred:      normalizePredicate(pred),
+		input:     make(chan *watchCacheEvent, inputBufSize),
+		result:    make(chan watch.Event, resultBufSize),
+		done:      make(chan struct{}),
+	}
+}
+
+func (w *cacheWatcher) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+func (w *cacheWatcher) Stop() {
+	w.stop()
+}
+
+func (w *cacheWatcher) stop() {
+	w.once.Do(func() {
+		close(w.done)
+	})
+}
+
+// tryAdd adds the event to watcher without blocking
+func (w *cacheWatcher) tryAdd(event *watchCacheEvent) bool {
+	select {
+	case w.input <- event:
+		return true
+	default:
+		return false
+	}
+}
+
+// add adds the event to watcher, false is returned if the watcher can not receive it in time
+func (w *cacheWatcher) add(event *watchCacheEvent) bool {
+	if w.tryAdd(event) {
+		return true
+	}
+	t := time.NewTimer(blockTimeout)
+	defer t.Stop()
+	select {
+	case w.input <- event:
+		return true
+	case <-w.done:
+		return true
+	case <-t.C:
+		return false
+	}
+}
+
+// process sends the init events and then the dispatched events to result chan until the watcher is stopped
+func (w *cacheWatcher) process(ctx context.Context, initEvents []*watchCacheEvent) {
+	defer close(w.result)
+	for _, e := range initEvents {
+		if !w.send(ctx, e) {
+			return
+		}
+	}
+	for {
+		select {
+		case e := <-w.input:
+			if !w.send(ctx, e) {
+				return
+			}
+		case <-w.done:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (w *cacheWatcher) send(ctx context.Context, e *watchCacheEvent) bool {
+	event, ok := w.convertToWatchEvent(e)
+	if !ok {
+		return true
+	}
+	select {
+	case w.result <- event:
+		return true
+	case <-w.done:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// convertToWatchEvent converts the cache event to the event seen by this watcher. An object that starts
+// to match the watcher is added, and an object that no longer matches the watcher is deleted.
+func (w *cacheWatcher) convertToWatchEvent(e *watchCacheEvent) (watch.Event, bool) {
+	if e.Type == watch.Bookmark {
+		return w.bookmark(e.ResourceVersion)
+	}
+	if e.ResourceVersion <= w.startRV && w.startRV != 0 {
+		return watch.Event{}, false
+	}
+	curMatched := e.Type != watch.Deleted && matches(e.Object, w.namespace, w.name, w.pred)
+	prevMatched := false
+	switch {
+	case e.PrevObject != nil:
+		prevMatched = matches(e.PrevObject, w.namespace, w.name, w.pred)
+	case e.Type == watch.Deleted:
+		prevMatched = matches(e.Object, w.namespace, w.name, w.pred)
+	}
+	var eventType watch.EventType
+	switch {
+	case curMatched && !prevMatched:
+		eventType = watch.Added
+	case curMatched && prevMatched:
+		eventType = watch.Modified
+	case !curMatched && prevMatched:
+		eventType = watch.Deleted
+	default:
+		return watch.Event{}, false
+	}
+	// the cached object is shared by all watchers and the cache, so every watcher gets its own copy
+	return watch.Event{Type: eventType, Object: e.Object.obj.DeepCopy()}, true
+}
+
+func (w *cacheWatcher) bookmark(rv uint64) (watch.Event, bool) {
+	obj := new(unstructured.Unstructured)
+	obj.SetGroupVersionKind(w.gvr.GroupVersion().WithKind(util.UnsafeResourceToKind(w.gvr.Resource)))
+	if err := imitator.Versioner.UpdateObject(obj, rv); err != nil {
+		klog.Errorf("[metaserver/cacher] failed to set resource version of bookmark: %v", err)
+		return watch.Event{}, false
+	}
+	return watch.Event{Type: watch.Bookmark, Object: obj}, true
+}
@@ -0,0 +1,134 @@
+package cacher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/conversion"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/storage"
+	"k8s.io/klog/v2"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/kubernetes/storage/sqlite/imitator"
+	"github.com/kubeedge/kubeedge/pkg/metaserver"
+	"github.com/kubeedge/kubeedge/pkg/metaserver/util"
+)
+
+// errTooOldResourceVersion means the events after the resource version are no longer in watch cache
+var errTooOldResourceVersion = errors.New("too old resource version for watch cache")
+
+// Cacher is modeled on the cacher of kube-apiserver. Instead of every watcher watching the imitator
+// and filtering all events by itself, a watchCache is created for each resource on demand,
+// which watches the imitator once and dispatches the decoded events to the watchers by indexes.
+// List is served from the watchCache too, other requests are delegated to the underlying storage.
+type Cacher struct {
+	storage.Interface
+	client imitator.Client
+	ctx    context.Context
+
+	lock   sync.Mutex
+	caches map[schema.GroupVersionResource]*watchCache
+}
+
+// NewCacher returns a Cacher over storage s, whose caches are fed by client until ctx is done
+func NewCacher(ctx context.Context, s storage.Interface, client imitator.Client) *Cacher {
+	return &Cacher{
+		Interface: s,
+		client:    client,
+		ctx:       ctx,
+		caches:    make(map[schema.GroupVersionResource]*watchCache),
+	}
+}
+
+// getCache returns the watchCache of gvr, the cache is created if not exist
+func (c *Cacher) getCache(gvr schema.GroupVersionResource) (*watchCache, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if cache, ok := c.caches[gvr]; ok {
+		return cache, nil
+	}
+	cache, err := newWatchCache(c.ctx, c.client, gvr)
+	if err != nil {
+		return nil, err
+	}
+	c.caches[gvr] = cache
+	go func() {
+		<-cache.stopped
+		c.lock.Lock()
+		delete(c.caches, gvr)
+		c.lock.Unlock()
+	}()
+	return cache, nil
+}
+
+func (c *Cacher) Watch(ctx context.Context, key string, opts storage.ListOptions) (watch.Interface, error) {
+	return c.watch(ctx, key, opts, false)
+}
+
+func (c *Cacher) WatchList(ctx context.Context, key string, opts storage.ListOptions) (watch.Interface, error) {
+	return c.watch(ctx, key, opts, true)
+}
+
+func (c *Cacher) watch(ctx context.Context, key string, opts storage.ListOptions, recursive bool) (watch.Interface, error) {
+	rev, err := imitator.Versioner.ParseResourceVersion(opts.ResourceVersion)
+	if err != nil {
+		return nil, err
+	}
+	gvr, namespace, name := metaserver.ParseKey(key)
+	cache, err := c.getCache(gvr)
+	if err != nil {
+		return nil, err
+	}
+	w, err := cache.watch(ctx, namespace, name, rev, opts.Predicate)
+	if err == errTooOldResourceVersion {
+		// the events are not in memory, try to replay them from the underlying storage
+		klog.V(4).Infof("[metaserver/cacher] watch %v from resource version %v by underlying storage", key, rev)
+		if recursive {
+			return c.Interface.WatchList(ctx, key, opts)
+		}
+		return c.Interface.Watch(ctx, key, opts)
+	}
+	return w, err
+}
+
+func (c *Cacher) GetToList(ctx context.Context, key string, opts storage.ListOptions, listObj runtime.Object) error {
+	return c.List(ctx, key, opts, listObj)
+}
+
+// List lists the objects from watch cache, the objects are copied since they are shared with watchers
+func (c *Cacher) List(ctx context.Context, key string, opts storage.ListOptions, listObj runtime.Object) error {
+	listPtr, err := meta.GetItemsPtr(listObj)
+	if err != nil {
+		return err
+	}
+	v, err := conversion.EnforcePtr(listPtr)
+	if err != nil || v.Kind() != reflect.Slice {
+		return fmt.Errorf("need ptr to slice: %v", err)
+	}
+	unstrList, ok := listObj.(*unstructured.UnstructuredList)
+	if !ok {
+		return fmt.Errorf("list obj is not unstructured type")
+	}
+
+	gvr, namespace, name := metaserver.ParseKey(key)
+	cache, err := c.getCache(gvr)
+	if err != nil {
+		return err
+	}
+	objs, rv := cache.list(namespace, name, opts.Predicate)
+	for _, obj := range objs {
+		unstrList.Items = append(unstrList.Items, *obj.DeepCopy())
+	}
+	unstrList.SetResourceVersion(strconv.FormatUint(rv, 10))
+	unstrList.SetSelfLink(key)
+	unstrList.SetGroupVersionKind(gvr.GroupVersion().WithKind(util.UnsafeResourceToKind(gvr.Resource) + "List"))
+	return nil
+}
@@ -0,0 +1,385 @@
+package cacher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/storage"
+
+	"github.com/kubeedge/beehive/pkg/core/model"
+	v2 "github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao/v2"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/kubernetes/storage/sqlite/imitator"
+	"github.com/kubeedge/kubeedge/pkg/metaserver"
+)
+
+var podGVR = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+
+// fakeClient is an imitator.Client whose List returns objs and Watch returns the injected events
+type fakeClient struct {
+	objs   []*unstructured.Unstructured
+	events chan watch.Event
+}
+
+func (f *fakeClient) Inject(msg model.Message) {}
+
+func (f *fakeClient) InjectEvent(e watch.Event) error {
+	f.events <- e
+	return nil
+}
+
+func (f *fakeClient) InsertOrUpdateObj(ctx context.Context, obj runtime.Object) error { return nil }
+
+func (f *fakeClient) DeleteObj(ctx context.Context, obj runtime.Object) error { return nil }
+
+func (f *fakeClient) GetRevision() uint64 { return 0 }
+
+func (f *fakeClient) SetRevision(version interface{}) {}
+
+func (f *fakeClient) List(ctx context.Context, key string) (imitator.Resp, error) {
+	var kvs []v2.MetaV2
+	var rev uint64
+	for _, obj := range f.objs {
+		data, err := json.Marshal(obj)
+		if err != nil {
+			return imitator.Resp{}, err
+		}
+		rv, _ := strconv.ParseUint(obj.GetResourceVersion(), 10, 64)
+		if rv > rev {
+			rev = rv
+		}
+		kvs = append(kvs, v2.MetaV2{Key: metaserver.KeyFunc(obj), Value: string(data)})
+	}
+	return imitator.Resp{Kvs: &kvs, Revision: rev}, nil
+}
+
+func (f *fakeClient) Get(ctx context.Context, key string) (imitator.Resp, error) {
+	return imitator.Resp{}, fmt.Errorf("not implemented")
+}
+
+func (f *fakeClient) Watch(ctx context.Context, key string, rev uint64) (<-chan watch.Event, error) {
+	return f.events, nil
+}
+
+// fakeStorage records the watches delegated by cacher
+type fakeStorage struct {
+	storage.Interface
+	watches int
+}
+
+func (s *fakeStorage) WatchList(ctx context.Context, key string, opts storage.ListOptions) (watch.Interface, error) {
+	s.watches++
+	return watch.NewEmptyWatch(), nil
+}
+
+func newPod(namespace, name, nodeName string, rv int, podLabels map[string]string) *unstructured.Unstructured {
+	obj := new(unstructured.Unstructured)
+	obj.SetAPIVersion("v1")
+	obj.SetKind("Pod")
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetResourceVersion(strconv.Itoa(rv))
+	obj.SetLabels(podLabels)
+	obj.Object["spec"] = map[string]interface{}{"nodeName": nodeName}
+	return obj
+}
+
+func newPredicate(labelSelector, fieldSelector string) storage.SelectionPredicate {
+	l, err := labels.Parse(labelSelector)
+	if err != nil {
+		panic(err)
+	}
+	f, err := fields.ParseSelector(fieldSelector)
+	if err != nil {
+		panic(err)
+	}
+	return storage.SelectionPredicate{Label: l, Field: f}
+}
+
+func newTestCacher(objs ...*unstructured.Unstructured) (*Cacher, *fakeClient, *fakeStorage, context.CancelFunc) {
+	client := &fakeClient{objs: objs, events: make(chan watch.Event)}
+	s := &fakeStorage{}
+	ctx, cancel := context.WithCancel(context.Background())
+	return NewCacher(ctx, s, client), client, s, cancel
+}
+
+func expectEvent(t *testing.T, w watch.Interface, eventType watch.EventType, name string) {
+	t.Helper()
+	select {
+	case e, ok := <-w.ResultChan():
+		if !ok {
+			t.Fatalf("watch is closed, expected %v event of %v", eventType, name)
+		}
+		obj := e.Object.(*unstructured.Unstructured)
+		if e.Type != eventType || obj.GetName() != name {
+			t.Fatalf("expected %v event of %v, but got %v event of %v", eventType, name, e.Type, obj.GetName())
+		}
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatalf("timeout waiting for %v event of %v", eventType, name)
+	}
+}
+
+func expectNoEvent(t *testing.T, w watch.Interface) {
+	t.Helper()
+	select {
+	case e := <-w.ResultChan():
+		t.Fatalf("unexpected event %v", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestList(t *testing.T) {
+	c, _, _, cancel := newTestCacher(
+		newPod("default", "a", "node1", 1, map[string]string{"app": "a"}),
+		newPod("default", "b", "node2", 2, map[string]string{"app": "b"}),
+		newPod("kube-system", "c", "node1", 3, map[string]string{"app": "a"}),
+	)
+	defer cancel()
+
+	cases := []struct {
+		name     string
+		key      string
+		pred     storage.SelectionPredicate
+		expected int
+	}{
+		{name: "all", key: "/core/v1/pods/null/null", pred: storage.Everything, expected: 3},
+		{name: "namespace", key: "/core/v1/pods/default/null", pred: storage.Everything, expected: 2},
+		{name: "label", key: "/core/v1/pods/null/null", pred: newPredicate("app=a", ""), expected: 2},
+		{name: "field", key: "/core/v1/pods/default/null", pred: newPredicate("", "spec.nodeName=node1"), expected: 1},
+	}
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			list := new(unstructured.UnstructuredList)
+			if err := c.List(context.TODO(), test.key, storage.ListOptions{Predicate: test.pred}, list); err != nil {
+				t.Fatalf("failed to list: %v", err)
+			}
+			if len(list.Items) != test.expected {
+				t.Errorf("expected %v items, but got %v", test.expected, len(list.Items))
+			}
+			if list.GetResourceVersion() != "3" || list.GetKind() != "PodList" {
+				t.Errorf("unexpected list meta %v %v", list.GetResourceVersion(), list.GetKind())
+			}
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	c, client, _, cancel := newTestCacher(newPod("default", "a", "node1", 1, nil))
+	defer cancel()
+
+	ctx, stop := context.WithCancel(context.TODO())
+	defer stop()
+	opts := storage.ListOptions{Predicate: newPredicate("", "spec.nodeName=node1")}
+	w, err := c.WatchList(ctx, "/core/v1/pods/default/null", opts)
+	if err != nil {
+		t.Fatalf("failed to watch: %v", err)
+	}
+	expectEvent(t, w, watch.Added, "a")
+
+	client.InjectEvent(watch.Event{Type: watch.Added, Object: newPod("default", "b", "node2", 2, nil)})
+	client.InjectEvent(watch.Event{Type: watch.Added, Object: newPod("default", "c", "node1", 3, nil)})
+	expectEvent(t, w, watch.Added, "c")
+	// duplicated event is dropped
+	client.InjectEvent(watch.Event{Type: watch.Added, Object: newPod("default", "c", "node1", 3, nil)})
+	// moved out of the watched node
+	client.InjectEvent(watch.Event{Type: watch.Modified, Object: newPod("default", "a", "node2", 4, nil)})
+	expectEvent(t, w, watch.Deleted, "a")
+	// moved into the watched node
+	client.InjectEvent(watch.Event{Type: watch.Modified, Object: newPod("default", "b", "node1", 5, nil)})
+	expectEvent(t, w, watch.Added, "b")
+	client.InjectEvent(watch.Event{Type: watch.Modified, Object: newPod("kube-system", "d", "node1", 6, nil)})
+	client.InjectEvent(watch.Event{Type: watch.Deleted, Object: newPod("default", "c", "node1", 7, nil)})
+	expectEvent(t, w, watch.Deleted, "c")
+	expectNoEvent(t, w)
+
+	// watch from resource version replays the events in memory
+	opts.ResourceVersion = "4"
+	w2, err := c.WatchList(ctx, "/core/v1/pods/null/null", opts)
+	if err != nil {
+		t.Fatalf("failed to watch: %v", err)
+	}
+	expectEvent(t, w2, watch.Added, "b")
+	expectEvent(t, w2, watch.Added, "d")
+	expectEvent(t, w2, watch.Deleted, "c")
+	expectNoEvent(t, w2)
+}
+
+func TestWatchTooOldResourceVersion(t *testing.T) {
+	c, _, s, cancel := newTestCacher(newPod("default", "a", "node1", 10, nil))
+	defer cancel()
+
+	opts := storage.ListOptions{ResourceVersion: "5", Predicate: storage.Everything}
+	if _, err := c.WatchList(context.TODO(), "/core/v1/pods/null/null", opts); err != nil {
+		t.Fatalf("failed to watch: %v", err)
+	}
+	if s.watches != 1 {
+		t.Errorf("expected watch from old resource version is delegated to underlying storage")
+	}
+}
+
+func TestWatchEvictedEvents(t *testing.T) {
+	c, client, s, cancel := newTestCacher()
+	defer cancel()
+	cache, err := c.getCache(podGVR)
+	if err != nil {
+		t.Fatalf("failed to get cache: %v", err)
+	}
+	// the events of rv 1 and 2 are evicted, and the oldest event kept is of rv 3
+	for rv := 1; rv <= eventBufferSize+2; rv++ {
+		client.InjectEvent(watch.Event{Type: watch.Added, Object: newPod("default", strconv.Itoa(rv), "node1", rv, nil)})
+	}
+	if err := wait.PollImmediate(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		cache.lock.RLock()
+		defer cache.lock.RUnlock()
+		return cache.resourceVersion == eventBufferSize+2, nil
+	}); err != nil {
+		t.Fatalf("expected all events processed")
+	}
+
+	ctx, stop := context.WithCancel(context.TODO())
+	defer stop()
+	opts := storage.ListOptions{ResourceVersion: "1", Predicate: storage.Everything}
+	if _, err := c.WatchList(ctx, "/core/v1/pods/null/null", opts); err != nil {
+		t.Fatalf("failed to watch: %v", err)
+	}
+	if s.watches != 1 {
+		t.Errorf("expected watch from evicted resource version is delegated to underlying storage")
+	}
+	opts.ResourceVersion = "2"
+	w, err := c.WatchList(ctx, "/core/v1/pods/null/null", opts)
+	if err != nil {
+		t.Fatalf("failed to watch: %v", err)
+	}
+	if s.watches != 1 {
+		t.Errorf("expected watch from resource version right before the oldest event is served by cache")
+	}
+	expectEvent(t, w, watch.Added, "3")
+}
+
+func TestWatchersGetCopies(t *testing.T) {
+	c, client, _, cancel := newTestCacher()
+	defer cancel()
+
+	ctx, stop := context.WithCancel(context.TODO())
+	defer stop()
+	var watchers []watch.Interface
+	for i := 0; i < 2; i++ {
+		w, err := c.WatchList(ctx, "/core/v1/pods/null/null", storage.ListOptions{Predicate: storage.Everything})
+		if err != nil {
+			t.Fatalf("failed to watch: %v", err)
+		}
+		watchers = append(watchers, w)
+	}
+	client.InjectEvent(watch.Event{Type: watch.Added, Object: newPod("default", "a", "node1", 1, nil)})
+
+	first := (<-watchers[0].ResultChan()).Object.(*unstructured.Unstructured)
+	first.SetLabels(map[string]string{"changed": "true"})
+	second := (<-watchers[1].ResultChan()).Object.(*unstructured.Unstructured)
+	if first == second || len(second.GetLabels()) != 0 {
+		t.Errorf("expected every watcher gets its own copy of object")
+	}
+}
+
+func TestForgetWatcher(t *testing.T) {
+	c, _, _, cancel := newTestCacher()
+	defer cancel()
+	cache, err := c.getCache(podGVR)
+	if err != nil {
+		t.Fatalf("failed to get cache: %v", err)
+	}
+
+	var watchers []watch.Interface
+	for _, selector := range []string{"spec.nodeName=node1", "spec.nodeName=node2", ""} {
+		w, err := cache.watch(context.TODO(), "", "", 0, newPredicate("", selector))
+		if err != nil {
+			t.Fatalf("failed to watch: %v", err)
+		}
+		watchers = append(watchers, w)
+	}
+	if cache.indexes[nodeNameIndex] != 2 {
+		t.Errorf("expected 2 watchers indexed by node name, but got %v", cache.indexes[nodeNameIndex])
+	}
+	for _, w := range watchers {
+		w.Stop()
+		if _, ok := <-w.ResultChan(); ok {
+			t.Errorf("expected result chan closed")
+		}
+	}
+	if err := wait.PollImmediate(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		cache.lock.RLock()
+		defer cache.lock.RUnlock()
+		return len(cache.watchers) == 0 && len(cache.indexes) == 0, nil
+	}); err != nil {
+		t.Errorf("expected watchers to be forgotten")
+	}
+}
+
+func TestWatcherKeyFor(t *testing.T) {
+	cases := []struct {
+		name     string
+		pred     storage.SelectionPredicate
+		expected watcherKey
+	}{
+		{name: "everything", pred: newPredicate("", ""), expected: watcherKey{namespace: "default"}},
+		{name: "field", pred: newPredicate("app=a", "spec.nodeName=node1"), expected: watcherKey{"default", nodeNameIndex, "node1"}},
+		{name: "label", pred: newPredicate("env!=test,app in (a)", ""), expected: watcherKey{"default", "label:app", "a"}},
+		{name: "not indexed", pred: newPredicate("app in (a,b)", "spec.nodeName!=node1"), expected: watcherKey{namespace: "default"}},
+	}
+	for _, test := range cases {
+		if key := watcherKeyFor("default", "", test.pred); key != test.expected {
+			t.Errorf("%v: expected %v, but got %v", test.name, test.expected, key)
+		}
+	}
+}
+
+// BenchmarkDispatch shows the cost of dispatching an event to watchers. The indexed watchers
+// watch the pods of different nodes, so the cost is flat with the number of them.
+func BenchmarkDispatch(b *testing.B) {
+	for _, indexed := range []bool{true, false} {
+		for _, count := range []int{1, 10, 100, 1000} {
+			b.Run(fmt.Sprintf("indexed=%v/watchers=%d", indexed, count), func(b *testing.B) {
+				benchmarkDispatch(b, count, indexed)
+			})
+		}
+	}
+}
+
+func benchmarkDispatch(b *testing.B, count int, indexed bool) {
+	client := &fakeClient{events: make(chan watch.Event)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cache, err := newWatchCache(ctx, client, podGVR)
+	if err != nil {
+		b.Fatalf("failed to create cache: %v", err)
+	}
+	for i := 0; i < count; i++ {
+		selector := fmt.Sprintf("spec.nodeName=node%d", i)
+		if !indexed {
+			selector = fmt.Sprintf("spec.nodeName!=node%d", i)
+		}
+		w, err := cache.watch(ctx, "", "", 0, newPredicate("", selector))
+		if err != nil {
+			b.Fatalf("failed to watch: %v", err)
+		}
+		go func() {
+			for range w.ResultChan() {
+			}
+		}()
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cache.processEvent(watch.Event{Type: watch.Modified, Object: newPod("default", "pod", "node0", i+1, nil)})
+	}
+}
@@ -0,0 +1,466 @@
+package cacher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/storage"
+	"k8s.io/klog/v2"
+
+	v2 "github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao/v2"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/kubernetes/storage/sqlite/imitator"
+	"github.com/kubeedge/kubeedge/pkg/metaserver/util"
+)
+
+const (
+	// eventBufferSize is the number of recent events kept in memory for each resource,
+	// a watch from a resource version older than them is served by the underlying storage
+	eventBufferSize = 100
+
+	// bookmarkFrequency defines how frequently watch bookmarks should be send
+	bookmarkFrequency = time.Minute
+
+	// index names of watchers, the label indexes are prefixed with labelIndexPrefix
+	nameIndex        = "metadata.name"
+	nodeNameIndex    = "spec.nodeName"
+	labelIndexPrefix = "label:"
+)
+
+// cachedObject is a decoded object with its attributes, it is shared by all watchers and must not be modified
+type cachedObject struct {
+	obj             *unstructured.Unstructured
+	namespace       string
+	name            string
+	resourceVersion uint64
+	labels          labels.Set
+	fields          fields.Set
+}
+
+func newCachedObject(obj runtime.Object) (*cachedObject, error) {
+	unstr, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("obj is not unstructured type")
+	}
+	rv, err := imitator.Versioner.ObjectResourceVersion(unstr)
+	if err != nil {
+		return nil, err
+	}
+	l, f, err := util.UnstructuredAttr(unstr)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := f["metadata.namespace"]; !ok && unstr.GetNamespace() != "" {
+		f["metadata.namespace"] = unstr.GetNamespace()
+	}
+	return &cachedObject{
+		obj:             unstr,
+		namespace:       unstr.GetNamespace(),
+		name:            unstr.GetName(),
+		resourceVersion: rv,
+		labels:          l,
+		fields:          f,
+	}, nil
+}
+
+func (o *cachedObject) key() string {
+	return o.namespace + "/" + o.name
+}
+
+// indexValue returns the value of object for the index
+func (o *cachedObject) indexValue(index string) (string, bool) {
+	switch {
+	case index == nameIndex:
+		return o.name, true
+	case len(index) > len(labelIndexPrefix) && index[:len(labelIndexPrefix)] == labelIndexPrefix:
+		v, ok := o.labels[index[len(labelIndexPrefix):]]
+		return v, ok
+	default:
+		v, ok := o.fields[index]
+		return v, ok
+	}
+}
+
+// watchCacheEvent is an event of cache, PrevObject is the object before the event if it was cached
+type watchCacheEvent struct {
+	Type            watch.EventType
+	Object          *cachedObject
+	PrevObject      *cachedObject
+	ResourceVersion uint64
+}
+
+// watcherKey is the key that watchers are indexed by, index and value are empty
+// for the watchers which can only be indexed by namespace
+type watcherKey struct {
+	namespace string
+	index     string
+	value     string
+}
+
+// watchCache is the shared cache of a resource. It is fed by a single watch on the imitator,
+// every event is decoded once and dispatched to the watchers selected by indexes.
+type watchCache struct {
+	gvr    schema.GroupVersionResource
+	client imitator.Client
+
+	lock sync.RWMutex
+	// objects are the current objects of resource, key is namespace/name
+	objects map[string]*cachedObject
+	// resourceVersion is the max resource version of events that have been processed
+	resourceVersion uint64
+	// events is a cyclic buffer of recent events, start is the index of the oldest one
+	events []*watchCacheEvent
+	start  int
+	// oldestRV is the resource version since which all events are kept in events, it is the
+	// revision of the initial list until the buffer is full, and then the resource version
+	// right before the oldest event in the buffer
+	oldestRV uint64
+
+	nextWatcherID int
+	watchers      map[watcherKey]map[int]*cacheWatcher
+	// indexes records the number of watchers of each index, only these indexes are computed for events
+	indexes map[string]int
+
+	// stopped is closed when the cache stops watching imitator
+	stopped chan struct{}
+}
+
+// newWatchCache lists and watches the resource from imitator, the cache is synced when it returns
+func newWatchCache(ctx context.Context, client imitator.Client, gvr schema.GroupVersionResource) (*watchCache, error) {
+	group := gvr.Group
+	if group == "" {
+		group = v2.GroupCore
+	}
+	key := fmt.Sprintf("/%s/%s/%s/%s/%s", group, gvr.Version, gvr.Resource, v2.NullNamespace, v2.NullName)
+	// watch before list to not miss any event, events already in the list are skipped by resource version
+	wch, err := client.Watch(ctx, key, 0)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.List(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &watchCache{
+		gvr:             gvr,
+		client:          client,
+		objects:         make(map[string]*cachedObject),
+		resourceVersion: resp.Revision,
+		oldestRV:        resp.Revision,
+		watchers:        make(map[watcherKey]map[int]*cacheWatcher),
+		indexes:         make(map[string]int),
+		stopped:         make(chan struct{}),
+	}
+	for _, kv := range *resp.Kvs {
+		obj := new(unstructured.Unstructured)
+		if err := runtime.DecodeInto(unstructured.UnstructuredJSONScheme, []byte(kv.Value), obj); err != nil {
+			klog.Errorf("[metaserver/cacher] failed to decode %v: %v", kv.Key, err)
+			continue
+		}
+		cached, err := newCachedObject(obj)
+		if err != nil {
+			klog.Errorf("[metaserver/cacher] failed to cache %v: %v", kv.Key, err)
+			continue
+		}
+		c.objects[cached.key()] = cached
+	}
+	go c.run(ctx, wch)
+	klog.Infof("[metaserver/cacher] cache of %v is synced at revision %v with %v objects", gvr, c.resourceVersion, len(c.objects))
+	return c, nil
+}
+
+func (c *watchCache) run(ctx context.Context, wch <-chan watch.Event) {
+	ticker := time.NewTicker(bookmarkFrequency)
+	defer ticker.Stop()
+	defer close(c.stopped)
+	defer c.terminateAllWatchers()
+	for {
+		select {
+		case e, ok := <-wch:
+			if !ok {
+				return
+			}
+			c.processEvent(e)
+		case <-ticker.C:
+			c.sendBookmarks()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// processEvent updates the cache and dispatches the event to the watchers
+func (c *watchCache) processEvent(e watch.Event) {
+	obj, err := newCachedObject(e.Object)
+	if err != nil {
+		klog.Errorf("[metaserver/cacher] failed to process event of %v: %v", c.gvr, err)
+		return
+	}
+	event, watchers := c.update(e.Type, obj)
+	if event == nil {
+		return
+	}
+	for _, w := range watchers {
+		if !w.add(event) {
+			klog.Warningf("[metaserver/cacher] terminate watcher of %v because it is too slow to receive events", c.gvr)
+			c.forgetWatcher(w)
+			w.stop()
+		}
+	}
+}
+
+// update applies the event to cache and returns the watchers interested in it,
+// nil event is returned if the event has been processed before
+func (c *watchCache) update(eventType watch.EventType, obj *cachedObject) (*watchCacheEvent, []*cacheWatcher) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	key := obj.key()
+	prev := c.objects[key]
+	switch eventType {
+	case watch.Added, watch.Modified:
+		if prev != nil && prev.resourceVersion >= obj.resourceVersion {
+			return nil, nil
+		}
+		if prev == nil {
+			eventType = watch.Added
+		} else {
+			eventType = watch.Modified
+		}
+		c.objects[key] = obj
+	case watch.Deleted:
+		if prev == nil {
+			return nil, nil
+		}
+		delete(c.objects, key)
+	default:
+		return nil, nil
+	}
+
+	rv := obj.resourceVersion
+	if rv > c.resourceVersion {
+		c.resourceVersion = rv
+	}
+	event := &watchCacheEvent{Type: eventType, Object: obj, PrevObject: prev, ResourceVersion: rv}
+	if len(c.events) < eventBufferSize {
+		c.events = append(c.events, event)
+	} else {
+		c.events[c.start] = event
+		c.start = (c.start + 1) % eventBufferSize
+		c.oldestRV = c.events[c.start].ResourceVersion - 1
+	}
+	return event, c.watchersFor(obj, prev)
+}
+
+// watchersFor returns the watchers that may be interested in the objects
+func (c *watchCache) watchersFor(objs ...*cachedObject) []*cacheWatcher {
+	var keys []watcherKey
+	for _, obj := range objs {
+		if obj == nil {
+			continue
+		}
+		namespaces := []string{""}
+		if obj.namespace != "" {
+			namespaces = append(namespaces, obj.namespace)
+		}
+		for _, ns := range namespaces {
+			keys = append(keys, watcherKey{namespace: ns})
+			for index := range c.indexes {
+				if v, ok := obj.indexValue(index); ok {
+					keys = append(keys, watcherKey{namespace: ns, index: index, value: v})
+				}
+			}
+		}
+	}
+
+	var ret []*cacheWatcher
+	seen := make(map[int]struct{})
+	for _, key := range keys {
+		for id, w := range c.watchers[key] {
+			if _, ok := seen[id]; ok {
+				continue
+			}
+			seen[id] = struct{}{}
+			ret = append(ret, w)
+		}
+	}
+	return ret
+}
+
+// list returns the objects matching namespace, name and pred, and the resource version of cache
+func (c *watchCache) list(namespace, name string, pred storage.SelectionPredicate) ([]*unstructured.Unstructured, uint64) {
+	pred = normalizePredicate(pred)
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	var ret []*unstructured.Unstructured
+	for _, obj := range c.objects {
+		if matches(obj, namespace, name, pred) {
+			ret = append(ret, obj.obj)
+		}
+	}
+	return ret, c.resourceVersion
+}
+
+// watch starts a watcher from rev, errTooOldResourceVersion is returned if the events
+// after rev are no longer cached
+func (c *watchCache) watch(ctx context.Context, namespace, name string, rev uint64, pred storage.SelectionPredicate) (watch.Interface, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var initEvents []*watchCacheEvent
+	if rev == 0 {
+		// send the current objects as added events
+		for _, obj := range c.objects {
+			initEvents = append(initEvents, &watchCacheEvent{Type: watch.Added, Object: obj, ResourceVersion: obj.resourceVersion})
+		}
+	} else {
+		if rev < c.oldestRV {
+			return nil, errTooOldResourceVersion
+		}
+		for i := 0; i < len(c.events); i++ {
+			e := c.events[(c.start+i)%len(c.events)]
+			if e.ResourceVersion > rev {
+				initEvents = append(initEvents, e)
+			}
+		}
+	}
+
+	w := newCacheWatcher(c.nextWatcherID, c.gvr, namespace, name, rev, pred)
+	c.nextWatcherID++
+	w.key = watcherKeyFor(namespace, name, w.pred)
+	if c.watchers[w.key] == nil {
+		c.watchers[w.key] = make(map[int]*cacheWatcher)
+	}
+	c.watchers[w.key][w.id] = w
+	if w.key.index != "" {
+		c.indexes[w.key.index]++
+	}
+
+	go w.process(ctx, initEvents)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-w.done:
+		}
+		c.forgetWatcher(w)
+		w.stop()
+	}()
+	return w, nil
+}
+
+// watcherKeyFor chooses the index of watcher, a watcher is indexed by the field or label it requires exactly
+func watcherKeyFor(namespace, name string, pred storage.SelectionPredicate) watcherKey {
+	key := watcherKey{namespace: namespace}
+	if name != "" {
+		key.index, key.value = nameIndex, name
+		return key
+	}
+	for _, index := range []string{nameIndex, nodeNameIndex} {
+		if v, found := pred.Field.RequiresExactMatch(index); found {
+			key.index, key.value = index, v
+			return key
+		}
+	}
+	if requirements, selectable := pred.Label.Requirements(); selectable {
+		for _, r := range requirements {
+			values := r.Values()
+			switch r.Operator() {
+			case selection.Equals, selection.DoubleEquals, selection.In:
+				if values.Len() != 1 {
+					continue
+				}
+				key.index, key.value = labelIndexPrefix+r.Key(), values.List()[0]
+				return key
+			}
+		}
+	}
+	return key
+}
+
+func (c *watchCache) forgetWatcher(w *cacheWatcher) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	watchers, ok := c.watchers[w.key]
+	if !ok {
+		return
+	}
+	if _, ok := watchers[w.id]; !ok {
+		return
+	}
+	delete(watchers, w.id)
+	if len(watchers) == 0 {
+		delete(c.watchers, w.key)
+	}
+	if w.key.index != "" {
+		if c.indexes[w.key.index]--; c.indexes[w.key.index] == 0 {
+			delete(c.indexes, w.key.index)
+		}
+	}
+}
+
+func (c *watchCache) sendBookmarks() {
+	c.lock.RLock()
+	rv := c.resourceVersion
+	var watchers []*cacheWatcher
+	for _, ws := range c.watchers {
+		for _, w := range ws {
+			if w.pred.AllowWatchBookmarks {
+				watchers = append(watchers, w)
+			}
+		}
+	}
+	c.lock.RUnlock()
+	if rv == 0 {
+		return
+	}
+	event := &watchCacheEvent{Type: watch.Bookmark, ResourceVersion: rv}
+	for _, w := range watchers {
+		// bookmark is best effort, skip the watchers that are busy
+		w.tryAdd(event)
+	}
+}
+
+func (c *watchCache) terminateAllWatchers() {
+	c.lock.Lock()
+	var watchers []*cacheWatcher
+	for _, ws := range c.watchers {
+		for _, w := range ws {
+			watchers = append(watchers, w)
+		}
+	}
+	c.watchers = make(map[watcherKey]map[int]*cacheWatcher)
+	c.indexes = make(map[string]int)
+	c.lock.Unlock()
+	for _, w := range watchers {
+		w.stop()
+	}
+}
+
+// normalizePredicate makes sure the selectors of pred are not nil
+func normalizePredicate(pred storage.SelectionPredicate) storage.SelectionPredicate {
+	if pred.Label == nil {
+		pred.Label = labels.Everything()
+	}
+	if pred.Field == nil {
+		pred.Field = fields.Everything()
+	}
+	return pred
+}
+
+func matches(obj *cachedObject, namespace, name string, pred storage.SelectionPredicate) bool {
+	if namespace != "" && obj.namespace != namespace {
+		return false
+	}
+	if name != "" && obj.name != name {
+		return false
+	}
+	return pred.MatchesObjectAttributes(obj.labels, obj.fields)
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/klog/v2"
+
+	commontypes "github.com/kubeedge/kubeedge/common/types"
+	v2 "github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao/v2"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/kubernetes/storage/sqlite/imitator"
+)
+
+// crdKeyRoot is the key of all CustomResourceDefinitions
+var crdKeyRoot = fmt.Sprintf("/%s/%s/%s", apiextensionsv1.GroupName, apiextensionsv1.SchemeGroupVersion.Version, "customresourcedefinitions")
+
+const (
+	// crdCacheTTL is how long the CustomResourceDefinition got is reused by table requests
+	crdCacheTTL = time.Minute
+	// crdNegativeCacheTTL is how long the resource without CustomResourceDefinition is remembered
+	crdNegativeCacheTTL = 10 * time.Second
+)
+
+type crdEntry struct {
+	crd    *apiextensionsv1.CustomResourceDefinition
+	err    error
+	expire time.Time
+}
+
+// crdCache caches the CustomResourceDefinitions of resources, and the resources whose CRD is not found
+type crdCache struct {
+	lock    sync.Mutex
+	entries map[schema.GroupResource]crdEntry
+	now     func() time.Time
+}
+
+func newCRDCache() *crdCache {
+	return &crdCache{
+		entries: make(map[schema.GroupResource]crdEntry),
+		now:     time.Now,
+	}
+}
+
+// get returns the entry of gr, false is returned if it is not cached or expired
+func (c *crdCache) get(gr schema.GroupResource) (crdEntry, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	e, ok := c.entries[gr]
+	if !ok {
+		return crdEntry{}, false
+	}
+	if !c.now().Before(e.expire) {
+		delete(c.entries, gr)
+		return crdEntry{}, false
+	}
+	return e, true
+}
+
+// add caches the crd of gr, or the NotFound error if the crd does not exist
+func (c *crdCache) add(gr schema.GroupResource, crd *apiextensionsv1.CustomResourceDefinition, err error) {
+	ttl := crdCacheTTL
+	if err != nil {
+		ttl = crdNegativeCacheTTL
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries[gr] = crdEntry{crd: crd, err: err, expire: c.now().Add(ttl)}
+}
+
+// remove drops the entry of gr, so that the crd is fetched again by the next request
+func (c *crdCache) remove(gr schema.GroupResource) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.entries, gr)
+}
+
+// Receive implements watchhook.Receiver, the cached crd is dropped once the crd is changed.
+// The name of crd is <resource>.<group>, so it is parsed as the group resource it defines.
+func (c *crdCache) Receive(e watch.Event) error {
+	accessor, err := meta.Accessor(e.Object)
+	if err != nil {
+		return err
+	}
+	c.remove(schema.ParseGroupResource(accessor.GetName()))
+	return nil
+}
+
+// getCRD get the CustomResourceDefinition of gr from cache, local store, or from cloud if it is not stored
+func (r *REST) getCRD(ctx context.Context, gr schema.GroupResource) (*apiextensionsv1.CustomResourceDefinition, error) {
+	if e, ok := r.crds.get(gr); ok {
+		return e.crd, e.err
+	}
+	crd, err := r.fetchCRD(ctx, gr)
+	if err == nil || errors.IsNotFound(err) {
+		r.crds.add(gr, crd, err)
+	}
+	return crd, err
+}
+
+func (r *REST) fetchCRD(ctx context.Context, gr schema.GroupResource) (*apiextensionsv1.CustomResourceDefinition, error) {
+	key := fmt.Sprintf("%s/%s/%s", crdKeyRoot, v2.NullNamespace, gr.String())
+	obj, err := getLocal(ctx, key)
+	if err != nil {
+		token, _ := ctx.Value(commontypes.AuthorizationKey).(string)
+		obj, err = r.getFromCloud(key, token)
+		if err != nil {
+			return nil, err
+		}
+		// save to local through the event history, so that the watchers of crd are notified
+		if err := imitator.DefaultV2Client.InjectEvent(watch.Event{Type: watch.Added, Object: obj}); err != nil {
+			klog.Warningf("[metaserver/reststorage] failed to save crd %v to local: %v", key, err)
+		}
+	}
+	crd := new(apiextensionsv1.CustomResourceDefinition)
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), crd); err != nil {
+		return nil, err
+	}
+	return crd, nil
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func TestCRDCache(t *testing.T) {
+	now := time.Now()
+	c := newCRDCache()
+	c.now = func() time.Time { return now }
+
+	devices := schema.GroupResource{Group: "devices.kubeedge.io", Resource: "devices"}
+	others := schema.GroupResource{Group: "example.io", Resource: "others"}
+	c.add(devices, &apiextensionsv1.CustomResourceDefinition{}, nil)
+	c.add(others, nil, errors.NewNotFound(apiextensionsv1.Resource("customresourcedefinitions"), others.String()))
+
+	if e, ok := c.get(devices); !ok || e.crd == nil {
+		t.Errorf("expected crd of %v cached, but got %+v", devices, e)
+	}
+	if e, ok := c.get(others); !ok || !errors.IsNotFound(e.err) {
+		t.Errorf("expected NotFound of %v cached, but got %+v", others, e)
+	}
+
+	// the resource not found is retried earlier than the crd found
+	now = now.Add(crdNegativeCacheTTL)
+	if _, ok := c.get(others); ok {
+		t.Errorf("expected NotFound of %v expired", others)
+	}
+	if _, ok := c.get(devices); !ok {
+		t.Errorf("expected crd of %v cached", devices)
+	}
+	now = now.Add(crdCacheTTL)
+	if _, ok := c.get(devices); ok {
+		t.Errorf("expected crd of %v expired", devices)
+	}
+}
+
+func TestCRDCacheReceive(t *testing.T) {
+	c := newCRDCache()
+	devices := schema.GroupResource{Group: "devices.kubeedge.io", Resource: "devices"}
+	c.add(devices, &apiextensionsv1.CustomResourceDefinition{}, nil)
+
+	crd := new(unstructured.Unstructured)
+	crd.SetAPIVersion("apiextensions.k8s.io/v1")
+	crd.SetKind("CustomResourceDefinition")
+	crd.SetName("devices.devices.kubeedge.io")
+	if err := c.Receive(watch.Event{Type: watch.Modified, Object: crd}); err != nil {
+		t.Fatalf("failed to receive event: %v", err)
+	}
+	if _, ok := c.get(devices); ok {
+		t.Errorf("expected crd of %v dropped once it is modified", devices)
+	}
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/klog/v2"
+
+	"github.com/kubeedge/kubeedge/cloud/pkg/dynamiccontroller/application"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+)
+
+// NonResourceMetaType is the type of meta which stores the responses of non-resource requests
+const NonResourceMetaType = "nonresource"
+
+// GetNonResource gets the response of non-resource request such as discovery, openapi and version from cloud.
+// The response is persisted at edge and served when cloud is unreachable, so that clients can start offline.
+func (r *REST) GetNonResource(ctx context.Context, accept string) (*application.NonResourceResponse, error) {
+	info, _ := apirequest.RequestInfoFrom(ctx)
+	path := info.Path
+	// try remote cloud
+	resp, err := func() (*application.NonResourceResponse, error) {
+		app, err := r.Agent.GenerateNonResource(ctx, accept)
+		if err != nil {
+			klog.Errorf("[metaserver/reststorage] failed to generate application: %v", err)
+			return nil, err
+		}
+		err = r.Agent.Apply(app)
+		defer app.Close()
+		if err != nil {
+			klog.Errorf("[metaserver/reststorage] failed to get %v from cloud: %v", path, err)
+			return nil, err
+		}
+		var resp = new(application.NonResourceResponse)
+		if err := app.RespBodyTo(resp); err != nil {
+			return nil, err
+		}
+		// save to local, ignore error
+		saveNonResource(path, accept, resp)
+		klog.V(4).Infof("[metaserver/reststorage] successfully process non-resource req (%v) through cloud", path)
+		return resp, nil
+	}()
+	// try local
+	if err != nil {
+		if authErr := authorizeLocal(ctx, err); authErr != nil {
+			return nil, authErr
+		}
+		resp, err = loadNonResource(path, accept)
+		if err != nil {
+			klog.Errorf("[metaserver/reststorage] failed to get %v at local: %v", path, err)
+			return nil, errors.NewServiceUnavailable(fmt.Sprintf("%v is not available at edge", path))
+		}
+		klog.Infof("[metaserver/reststorage] successfully process non-resource req (%v) at local", path)
+	}
+	return resp, nil
+}
+
+// nonResourceKey returns the key of response for the accepted media types, responses of different
+// media types are stored separately since clients may negotiate protobuf or aggregated discovery
+func nonResourceKey(path, accept string) string {
+	sum := sha256.Sum256([]byte(accept))
+	return fmt.Sprintf("%s#%s", path, hex.EncodeToString(sum[:8]))
+}
+
+// isJSON checks whether the content type is plain json, which is acceptable for most clients
+func isJSON(contentType string) bool {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "application/json" && params["g"] == "" && params["as"] == ""
+}
+
+// acceptsJSON checks whether plain json is acceptable for the Accept header
+func acceptsJSON(accept string) bool {
+	if accept == "" {
+		return true
+	}
+	for _, clause := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(clause))
+		if err != nil || params["g"] != "" || params["as"] != "" {
+			continue
+		}
+		if mediaType == "application/json" || mediaType == "application/*" || mediaType == "*/*" {
+			return true
+		}
+	}
+	return false
+}
+
+func saveNonResource(path, accept string, resp *application.NonResourceResponse) {
+	value, err := json.Marshal(resp)
+	if err != nil {
+		klog.Errorf("[metaserver/reststorage] failed to marshal response of %v: %v", path, err)
+		return
+	}
+	keys := []string{nonResourceKey(path, accept)}
+	if isJSON(resp.ContentType) {
+		// the json response is used as fallback for the Accept headers never seen before
+		keys = append(keys, path)
+	}
+	for _, key := range keys {
+		// openapi specs are large, skip writing if nothing changed
+		if values, err := dao.QueryMeta("key", key); err == nil && len(*values) == 1 && (*values)[0] == string(value) {
+			continue
+		}
+		meta := &dao.Meta{Key: key, Type: NonResourceMetaType, Value: string(value)}
+		if err := dao.InsertOrUpdate(meta); err != nil {
+			klog.Errorf("[metaserver/reststorage] failed to save response of %v: %v", path, err)
+		}
+	}
+}
+
+func loadNonResource(path, accept string) (*application.NonResourceResponse, error) {
+	keys := []string{nonResourceKey(path, accept)}
+	if acceptsJSON(accept) {
+		keys = append(keys, path)
+	}
+	for _, key := range keys {
+		values, err := dao.QueryMeta("key", key)
+		if err != nil {
+			return nil, err
+		}
+		if len(*values) == 0 {
+			continue
+		}
+		var resp = new(application.NonResourceResponse)
+		if err := json.Unmarshal([]byte((*values)[0]), resp); err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+	return nil, fmt.Errorf("no response cached for %v", path)
+}
@@ -0,0 +1,43 @@
+package storage
+
+import "testing"
+
+func TestAcceptsJSON(t *testing.T) {
+	cases := map[string]bool{
+		"":                 true,
+		"application/json": true,
+		"application/json;g=apidiscovery.k8s.io;v=v2beta1;as=APIGroupDiscoveryList,application/json": true,
+		"application/json;g=apidiscovery.k8s.io;v=v2beta1;as=APIGroupDiscoveryList":                  false,
+		"application/com.github.proto-openapi.spec.v2@v1.0+protobuf":                                 false,
+		"application/com.github.proto-openapi.spec.v2@v1.0+protobuf, */*":                            true,
+	}
+	for accept, expected := range cases {
+		if got := acceptsJSON(accept); got != expected {
+			t.Errorf("acceptsJSON(%q): expected %v, but got %v", accept, expected, got)
+		}
+	}
+}
+
+func TestIsJSON(t *testing.T) {
+	cases := map[string]bool{
+		"application/json":                true,
+		"application/json; charset=utf-8": true,
+		"application/json;g=apidiscovery.k8s.io;v=v2beta1;as=APIGroupDiscoveryList": false,
+		"application/com.github.proto-openapi.spec.v2@v1.0+protobuf":                false,
+		"": false,
+	}
+	for contentType, expected := range cases {
+		if got := isJSON(contentType); got != expected {
+			t.Errorf("isJSON(%q): expected %v, but got %v", contentType, expected, got)
+		}
+	}
+}
+
+func TestNonResourceKey(t *testing.T) {
+	if nonResourceKey("/apis", "application/json") == nonResourceKey("/apis", "application/yaml") {
+		t.Errorf("expected different keys for different accept headers")
+	}
+	if nonResourceKey("/apis", "application/json") != nonResourceKey("/apis", "application/json") {
+		t.Errorf("expected same keys for same accept headers")
+	}
+}
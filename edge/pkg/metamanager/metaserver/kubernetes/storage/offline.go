@@ -0,0 +1,358 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/google/uuid"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/watch"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/storage/names"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/klog/v2"
+
+	"github.com/kubeedge/kubeedge/cloud/pkg/dynamiccontroller/application"
+	commontypes "github.com/kubeedge/kubeedge/common/types"
+	v2 "github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao/v2"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/kubernetes/storage/sqlite/imitator"
+	kefeatures "github.com/kubeedge/kubeedge/pkg/features"
+	"github.com/kubeedge/kubeedge/pkg/metaserver"
+)
+
+// offlineWritable returns true if the write request can be applied to local store,
+// that is the offline write is enabled and the error is caused by failing to access cloud
+// rather than being rejected by cloud
+func offlineWritable(err error) bool {
+	if err == nil || !kefeatures.DefaultFeatureGate.Enabled(kefeatures.MetaServerOfflineWrite) {
+		return false
+	}
+	_, rejected := err.(errors.APIStatus)
+	return !rejected
+}
+
+// hasPendingJournals returns true if there are journals of the object waiting to be replayed,
+// then the write request must be applied to local store too, to keep the order of writes
+func hasPendingJournals(key string) bool {
+	if !kefeatures.DefaultFeatureGate.Enabled(kefeatures.MetaServerOfflineWrite) {
+		return false
+	}
+	count, err := v2.CountJournalsByKey(key)
+	if err != nil {
+		klog.Errorf("[metaserver/reststorage] failed to count journals of %v: %v", key, err)
+		return false
+	}
+	return count > 0
+}
+
+// createKey returns the key of the obj to create, the namespace of the request is set to obj
+// first since the obj may not carry it
+func createKey(ctx context.Context, obj runtime.Object) (string, error) {
+	unstr, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return metaserver.KeyFuncObj(obj)
+	}
+	if ns, ok := apirequest.NamespaceFrom(ctx); ok && ns != "" {
+		unstr = unstr.DeepCopy()
+		unstr.SetNamespace(ns)
+	}
+	return metaserver.KeyFuncObj(unstr)
+}
+
+// getLocal get the obj of key from local store
+func getLocal(ctx context.Context, key string) (*unstructured.Unstructured, error) {
+	gvr, _, name := metaserver.ParseKey(key)
+	resp, err := imitator.DefaultV2Client.Get(ctx, key)
+	if err != nil || len(*resp.Kvs) == 0 {
+		return nil, errors.NewNotFound(gvr.GroupResource(), name)
+	}
+	obj := new(unstructured.Unstructured)
+	if err := runtime.DecodeInto(unstructured.UnstructuredJSONScheme, []byte((*resp.Kvs)[0].Value), obj); err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+	return obj, nil
+}
+
+// localRevision is the last resource version assigned to the objects written locally
+var localRevision uint64
+
+// nextResourceVersion returns a resource version for the obj written locally, which is unique among the
+// concurrent writes. It will be replaced by the one assigned by cloud after the journal is replayed
+func nextResourceVersion() string {
+	for {
+		last := atomic.LoadUint64(&localRevision)
+		next := imitator.DefaultV2Client.GetRevision() + 1
+		if next <= last {
+			next = last + 1
+		}
+		if atomic.CompareAndSwapUint64(&localRevision, last, next) {
+			return strconv.FormatUint(next, 10)
+		}
+	}
+}
+
+// writeLocal save obj to local store, notify watchers and record the request in journal
+func writeLocal(ctx context.Context, eventType watch.EventType, obj *unstructured.Unstructured, journal *v2.MetaV2Journal) error {
+	info, ok := apirequest.RequestInfoFrom(ctx)
+	if !ok {
+		return errors.NewInternalError(fmt.Errorf("no request info in context"))
+	}
+	if err := authorizeLocal(ctx, nil); err != nil {
+		return err
+	}
+	token, _ := ctx.Value(commontypes.AuthorizationKey).(string)
+	rv, err := imitator.Versioner.ObjectResourceVersion(obj)
+	if err != nil {
+		return errors.NewInternalError(err)
+	}
+	journal.RequestInfo = string(toBytes(info))
+	journal.Token = token
+	journal.ResourceVersion = rv
+	if err := v2.InsertJournal(journal); err != nil {
+		return errors.NewInternalError(err)
+	}
+	if err := imitator.DefaultV2Client.InjectEvent(watch.Event{Type: eventType, Object: obj}); err != nil {
+		return errors.NewInternalError(err)
+	}
+	klog.Infof("[metaserver/reststorage] successfully %v (%v) at local, waiting to be replayed to cloud", journal.Verb, journal.Key)
+	return nil
+}
+
+func (r *REST) createLocal(ctx context.Context, obj runtime.Object, options *metav1.CreateOptions) (runtime.Object, error) {
+	unstr, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, errors.NewInternalError(fmt.Errorf("obj is not unstructured type"))
+	}
+	reqObj := unstr.DeepCopy()
+	if reqObj.GetName() == "" && reqObj.GetGenerateName() != "" {
+		// the name must be generated here, otherwise cloud will generate a different one
+		reqObj.SetName(names.SimpleNameGenerator.GenerateName(reqObj.GetGenerateName()))
+	}
+	if ns, ok := apirequest.NamespaceFrom(ctx); ok && ns != "" {
+		reqObj.SetNamespace(ns)
+	}
+	key, err := metaserver.KeyFuncObj(reqObj)
+	if err != nil {
+		return nil, errors.NewBadRequest(err.Error())
+	}
+	if _, err := getLocal(ctx, key); err == nil {
+		gvr, _, _ := metaserver.ParseKey(key)
+		return nil, errors.NewAlreadyExists(gvr.GroupResource(), reqObj.GetName())
+	}
+
+	newObj := reqObj.DeepCopy()
+	newObj.SetUID(types.UID(uuid.New().String()))
+	newObj.SetCreationTimestamp(metav1.Now())
+	newObj.SetResourceVersion(nextResourceVersion())
+	journal := &v2.MetaV2Journal{
+		Key:     key,
+		Verb:    string(application.Create),
+		Option:  string(toBytes(options)),
+		ReqBody: string(toBytes(reqObj)),
+	}
+	if err := writeLocal(ctx, watch.Added, newObj, journal); err != nil {
+		return nil, err
+	}
+	return newObj, nil
+}
+
+func (r *REST) updateLocal(ctx context.Context, obj runtime.Object, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+	unstr, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, false, errors.NewInternalError(fmt.Errorf("obj is not unstructured type"))
+	}
+	key, err := metaserver.KeyFuncReq(ctx, "")
+	if err != nil {
+		return nil, false, errors.NewBadRequest(err.Error())
+	}
+	existing, err := getLocal(ctx, key)
+	if err != nil {
+		return nil, false, err
+	}
+	if rv := unstr.GetResourceVersion(); rv != "" && rv != existing.GetResourceVersion() {
+		gvr, _, _ := metaserver.ParseKey(key)
+		return nil, false, errors.NewConflict(gvr.GroupResource(), existing.GetName(),
+			fmt.Errorf("the object has been modified; please apply your changes to the latest version and try again"))
+	}
+
+	verb := application.Update
+	newObj := unstr.DeepCopy()
+	if info, _ := apirequest.RequestInfoFrom(ctx); info != nil && info.Subresource == "status" {
+		// only the status is updated by status subresource
+		verb = application.UpdateStatus
+		newObj = existing.DeepCopy()
+		if status, ok := unstr.Object["status"]; ok {
+			newObj.Object["status"] = status
+		}
+	}
+	newObj.SetUID(existing.GetUID())
+	newObj.SetCreationTimestamp(existing.GetCreationTimestamp())
+	newObj.SetResourceVersion(nextResourceVersion())
+	baseRV, _ := imitator.Versioner.ObjectResourceVersion(existing)
+	journal := &v2.MetaV2Journal{
+		Key:                 key,
+		Verb:                string(verb),
+		Option:              string(toBytes(options)),
+		ReqBody:             string(toBytes(unstr)),
+		BaseResourceVersion: baseRV,
+	}
+	if err := writeLocal(ctx, watch.Modified, newObj, journal); err != nil {
+		return nil, false, err
+	}
+	return newObj, false, nil
+}
+
+func (r *REST) patchLocal(ctx context.Context, pi application.PatchInfo) (runtime.Object, error) {
+	key, err := metaserver.KeyFuncReq(ctx, "")
+	if err != nil {
+		return nil, errors.NewBadRequest(err.Error())
+	}
+	existing, err := getLocal(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	newObj, err := applyPatch(existing, pi)
+	if err != nil {
+		return nil, err
+	}
+	newObj.SetUID(existing.GetUID())
+	newObj.SetResourceVersion(nextResourceVersion())
+	baseRV, _ := imitator.Versioner.ObjectResourceVersion(existing)
+	journal := &v2.MetaV2Journal{
+		Key:                 key,
+		Verb:                string(application.Patch),
+		Option:              string(toBytes(pi)),
+		BaseResourceVersion: baseRV,
+	}
+	if err := writeLocal(ctx, watch.Modified, newObj, journal); err != nil {
+		return nil, err
+	}
+	return newObj, nil
+}
+
+func (r *REST) deleteLocal(ctx context.Context, options *metav1.DeleteOptions) (runtime.Object, bool, error) {
+	key, err := metaserver.KeyFuncReq(ctx, "")
+	if err != nil {
+		return nil, false, errors.NewBadRequest(err.Error())
+	}
+	existing, err := getLocal(ctx, key)
+	if err != nil {
+		return nil, false, err
+	}
+	if options != nil && options.Preconditions != nil {
+		if uid := options.Preconditions.UID; uid != nil && *uid != existing.GetUID() {
+			gvr, _, _ := metaserver.ParseKey(key)
+			return nil, false, errors.NewConflict(gvr.GroupResource(), existing.GetName(),
+				fmt.Errorf("precondition failed: UID in precondition: %v, UID in object meta: %v", *uid, existing.GetUID()))
+		}
+		if rv := options.Preconditions.ResourceVersion; rv != nil && *rv != existing.GetResourceVersion() {
+			gvr, _, _ := metaserver.ParseKey(key)
+			return nil, false, errors.NewConflict(gvr.GroupResource(), existing.GetName(),
+				fmt.Errorf("precondition failed: ResourceVersion in precondition: %v, ResourceVersion in object meta: %v", *rv, existing.GetResourceVersion()))
+		}
+	}
+
+	newObj := existing.DeepCopy()
+	newObj.SetResourceVersion(nextResourceVersion())
+	baseRV, _ := imitator.Versioner.ObjectResourceVersion(existing)
+	journal := &v2.MetaV2Journal{
+		Key:                 key,
+		Verb:                string(application.Delete),
+		Option:              string(toBytes(options)),
+		BaseResourceVersion: baseRV,
+	}
+	if err := writeLocal(ctx, watch.Deleted, newObj, journal); err != nil {
+		return nil, false, err
+	}
+	return newObj, true, nil
+}
+
+// applyPatch applies the patch to obj, strategic merge patch is only supported for
+// the types registered in client-go scheme
+func applyPatch(obj *unstructured.Unstructured, pi application.PatchInfo) (*unstructured.Unstructured, error) {
+	original, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+	var patched []byte
+	switch pi.PatchType {
+	case types.JSONPatchType:
+		patch, err := jsonpatch.DecodePatch(pi.Data)
+		if err != nil {
+			return nil, errors.NewBadRequest(err.Error())
+		}
+		patched, err = patch.Apply(original)
+		if err != nil {
+			return nil, errors.NewBadRequest(err.Error())
+		}
+	case types.MergePatchType:
+		patched, err = jsonpatch.MergePatch(original, pi.Data)
+		if err != nil {
+			return nil, errors.NewBadRequest(err.Error())
+		}
+	case types.StrategicMergePatchType:
+		dataStruct, err := scheme.Scheme.New(obj.GroupVersionKind())
+		if err != nil {
+			return nil, errors.NewBadRequest(fmt.Sprintf("strategic merge patch is not supported for %v offline", obj.GroupVersionKind()))
+		}
+		patched, err = strategicpatch.StrategicMergePatch(original, pi.Data, dataStruct)
+		if err != nil {
+			return nil, errors.NewBadRequest(err.Error())
+		}
+	default:
+		return nil, errors.NewBadRequest(fmt.Sprintf("unsupported patch type %v", pi.PatchType))
+	}
+	newObj := new(unstructured.Unstructured)
+	if err := newObj.UnmarshalJSON(patched); err != nil {
+		return nil, errors.NewBadRequest(err.Error())
+	}
+	if newObj.GetName() != obj.GetName() || newObj.GetNamespace() != obj.GetNamespace() {
+		return nil, errors.NewBadRequest("the name and namespace of object can not be changed by patch")
+	}
+	return newObj, nil
+}
+
+// requestInfoFor build the request info to access the object of key
+func requestInfoFor(key string, verb string) *apirequest.RequestInfo {
+	gvr, ns, name := metaserver.ParseKey(key)
+	info := &apirequest.RequestInfo{
+		IsResourceRequest: true,
+		Verb:              verb,
+		APIPrefix:         "apis",
+		APIGroup:          gvr.Group,
+		APIVersion:        gvr.Version,
+		Namespace:         ns,
+		Resource:          gvr.Resource,
+		Name:              name,
+	}
+	if gvr.Group == "" {
+		info.APIPrefix = "api"
+	}
+	return info
+}
+
+func groupResource(key string) schema.GroupResource {
+	gvr, _, _ := metaserver.ParseKey(key)
+	return gvr.GroupResource()
+}
+
+func toBytes(i interface{}) []byte {
+	if i == nil {
+		return nil
+	}
+	bytes, err := json.Marshal(i)
+	if err != nil {
+		klog.Errorf("marshal content to []byte failed, err: %v", err)
+	}
+	return bytes
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/kubeedge/kubeedge/cloud/pkg/dynamiccontroller/application"
+	"github.com/kubeedge/kubeedge/pkg/metaserver"
+)
+
+func newConfigMap() *unstructured.Unstructured {
+	obj := new(unstructured.Unstructured)
+	obj.SetAPIVersion("v1")
+	obj.SetKind("ConfigMap")
+	obj.SetNamespace("default")
+	obj.SetName("test")
+	obj.SetLabels(map[string]string{"app": "test"})
+	return obj
+}
+
+func TestApplyPatch(t *testing.T) {
+	cases := []struct {
+		name       string
+		pi         application.PatchInfo
+		label      string
+		badRequest bool
+	}{
+		{
+			name:  "json patch",
+			pi:    application.PatchInfo{PatchType: types.JSONPatchType, Data: []byte(`[{"op":"replace","path":"/metadata/labels/app","value":"json"}]`)},
+			label: "json",
+		},
+		{
+			name:  "merge patch",
+			pi:    application.PatchInfo{PatchType: types.MergePatchType, Data: []byte(`{"metadata":{"labels":{"app":"merge"}}}`)},
+			label: "merge",
+		},
+		{
+			name:  "strategic merge patch",
+			pi:    application.PatchInfo{PatchType: types.StrategicMergePatchType, Data: []byte(`{"metadata":{"labels":{"app":"strategic"}}}`)},
+			label: "strategic",
+		},
+		{
+			name:       "rename by patch",
+			pi:         application.PatchInfo{PatchType: types.MergePatchType, Data: []byte(`{"metadata":{"name":"other"}}`)},
+			badRequest: true,
+		},
+		{
+			name:       "apply patch",
+			pi:         application.PatchInfo{PatchType: types.ApplyPatchType, Data: []byte(`{}`)},
+			badRequest: true,
+		},
+	}
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			obj, err := applyPatch(newConfigMap(), test.pi)
+			if test.badRequest {
+				if !errors.IsBadRequest(err) {
+					t.Errorf("expected bad request, but got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to apply patch: %v", err)
+			}
+			if label := obj.GetLabels()["app"]; label != test.label {
+				t.Errorf("expected label %v, but got %v", test.label, label)
+			}
+		})
+	}
+}
+
+func TestCreateKey(t *testing.T) {
+	obj := newConfigMap()
+	obj.SetNamespace("")
+	ctx := apirequest.WithNamespace(context.Background(), "ns1")
+	key, err := createKey(ctx, obj)
+	if err != nil {
+		t.Fatalf("createKey() error = %v", err)
+	}
+	expected, _ := metaserver.KeyFuncObj(func() *unstructured.Unstructured {
+		o := newConfigMap()
+		o.SetNamespace("ns1")
+		return o
+	}())
+	if key != expected {
+		t.Errorf("expected key %v, but got %v", expected, key)
+	}
+	if obj.GetNamespace() != "" {
+		t.Errorf("expected the obj requested unchanged, but got namespace %v", obj.GetNamespace())
+	}
+}
+
+func TestNextResourceVersion(t *testing.T) {
+	const writers = 100
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	versions := make(map[string]bool)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rv := nextResourceVersion()
+			lock.Lock()
+			defer lock.Unlock()
+			versions[rv] = true
+		}()
+	}
+	wg.Wait()
+	if len(versions) != writers {
+		t.Errorf("expected %d unique resource versions, but got %d", writers, len(versions))
+	}
+}
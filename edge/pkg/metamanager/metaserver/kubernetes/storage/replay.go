@@ -0,0 +1,302 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/klog/v2"
+
+	"github.com/kubeedge/kubeedge/cloud/pkg/dynamiccontroller/application"
+	commontypes "github.com/kubeedge/kubeedge/common/types"
+	metaManagerConfig "github.com/kubeedge/kubeedge/edge/pkg/metamanager/config"
+	v2 "github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao/v2"
+	metaserverconfig "github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/config"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/kubernetes/storage/sqlite/imitator"
+	"github.com/kubeedge/kubeedge/pkg/metaserver"
+	"github.com/kubeedge/kubeedge/pkg/metaserver/util"
+)
+
+const (
+	// journalReplayPeriod is the period to check and replay the offline write journals
+	journalReplayPeriod = 10 * time.Second
+
+	// OfflineWriteConflictReason is the reason of the event reported when an offline write is
+	// dropped because of conflicting with or being rejected by cloud
+	OfflineWriteConflictReason = "OfflineWriteConflict"
+	offlineWriteEventSource    = "metaserver"
+)
+
+// replayJournals replays the offline write journals to cloud in the order they were recorded
+func (r *REST) replayJournals() {
+	if !metaManagerConfig.Connected {
+		return
+	}
+	journals, err := v2.ListJournals()
+	if err != nil {
+		klog.Errorf("[metaserver/replay] failed to list journals: %v", err)
+		return
+	}
+	// the keys whose journals have been dropped in this round
+	dropped := make(map[string]bool)
+	for i := range *journals {
+		j := &(*journals)[i]
+		if dropped[j.Key] {
+			continue
+		}
+		newRV, err := r.replayJournal(j)
+		if err == nil {
+			rebase(j, newRV, (*journals)[i+1:])
+			continue
+		}
+		if _, ok := err.(errors.APIStatus); !ok {
+			// failed to access cloud, try again later
+			klog.Warningf("[metaserver/replay] failed to replay journal %v (%v %v): %v", j.ID, j.Verb, j.Key, err)
+			return
+		}
+		klog.Errorf("[metaserver/replay] journal %v (%v %v) is rejected by cloud: %v", j.ID, j.Verb, j.Key, err)
+		dropped[j.Key] = true
+		r.dropJournals(j, err)
+	}
+}
+
+// replayJournal sends the write request recorded in journal to cloud, and replaces the local
+// object with the one returned by cloud, the resource version assigned by cloud is returned
+func (r *REST) replayJournal(j *v2.MetaV2Journal) (uint64, error) {
+	info := new(apirequest.RequestInfo)
+	if err := json.Unmarshal([]byte(j.RequestInfo), info); err != nil {
+		return 0, errors.NewBadRequest(fmt.Sprintf("invalid request info: %v", err))
+	}
+	ctx := journalContext(info, j.Token)
+
+	var option interface{}
+	var reqObj runtime.Object
+	baseRV := fmt.Sprint(j.BaseResourceVersion)
+	verb := application.Get
+	switch j.Verb {
+	case string(application.Create):
+		verb = application.Create
+		var opts metav1.CreateOptions
+		if err := unmarshalJournal(j.Option, &opts); err != nil {
+			return 0, err
+		}
+		obj := new(unstructured.Unstructured)
+		if err := unmarshalJournal(j.ReqBody, obj); err != nil {
+			return 0, err
+		}
+		option, reqObj = opts, obj
+	case string(application.Update), string(application.UpdateStatus):
+		verb = application.Update
+		if j.Verb == string(application.UpdateStatus) {
+			verb = application.UpdateStatus
+		}
+		var opts metav1.UpdateOptions
+		if err := unmarshalJournal(j.Option, &opts); err != nil {
+			return 0, err
+		}
+		obj := new(unstructured.Unstructured)
+		if err := unmarshalJournal(j.ReqBody, obj); err != nil {
+			return 0, err
+		}
+		// the update is based on the object we have seen, so it must not override
+		// the changes made by others in cloud
+		obj.SetResourceVersion(baseRV)
+		option, reqObj = opts, obj
+	case string(application.Patch):
+		verb = application.Patch
+		var pi application.PatchInfo
+		if err := unmarshalJournal(j.Option, &pi); err != nil {
+			return 0, err
+		}
+		// patch has no precondition, so check the resource version in cloud first
+		current, err := r.getFromCloud(j.Key, j.Token)
+		if err != nil {
+			return 0, err
+		}
+		if current.GetResourceVersion() != baseRV {
+			return 0, errors.NewConflict(groupResource(j.Key), current.GetName(),
+				fmt.Errorf("the object has been modified in cloud while node was offline"))
+		}
+		option = pi
+	case string(application.Delete):
+		verb = application.Delete
+		opts := new(metav1.DeleteOptions)
+		if err := unmarshalJournal(j.Option, opts); err != nil {
+			return 0, err
+		}
+		if opts.Preconditions == nil {
+			opts.Preconditions = new(metav1.Preconditions)
+		}
+		opts.Preconditions.ResourceVersion = &baseRV
+		option = opts
+	default:
+		return 0, errors.NewBadRequest(fmt.Sprintf("unsupported verb %v", j.Verb))
+	}
+
+	app, err := r.Agent.Generate(ctx, verb, option, reqObj)
+	if err != nil {
+		return 0, errors.NewBadRequest(err.Error())
+	}
+	defer app.Close()
+	if err := r.Agent.Apply(app); err != nil {
+		return 0, err
+	}
+
+	var newRV uint64
+	if verb != application.Delete {
+		retObj := new(unstructured.Unstructured)
+		if err := json.Unmarshal(app.RespBody, retObj); err != nil {
+			return 0, errors.NewInternalError(err)
+		}
+		newRV, err = imitator.Versioner.ObjectResourceVersion(retObj)
+		if err != nil {
+			return 0, errors.NewInternalError(err)
+		}
+		if err := imitator.DefaultV2Client.InjectEvent(watch.Event{Type: watch.Modified, Object: retObj}); err != nil {
+			klog.Errorf("[metaserver/replay] failed to save %v: %v", j.Key, err)
+		}
+	}
+	if err := v2.DeleteJournal(j.ID); err != nil {
+		klog.Errorf("[metaserver/replay] failed to delete journal %v: %v", j.ID, err)
+	}
+	klog.Infof("[metaserver/replay] successfully replay journal %v (%v %v) to cloud", j.ID, j.Verb, j.Key)
+	return newRV, nil
+}
+
+// rebase makes the following journals of the same object based on the resource version assigned by cloud
+func rebase(j *v2.MetaV2Journal, newRV uint64, pending []v2.MetaV2Journal) {
+	if newRV == 0 {
+		return
+	}
+	if err := v2.RebaseJournals(j.Key, j.ResourceVersion, newRV); err != nil {
+		klog.Errorf("[metaserver/replay] failed to rebase journals of %v: %v", j.Key, err)
+		return
+	}
+	for i := range pending {
+		if pending[i].Key == j.Key && pending[i].BaseResourceVersion == j.ResourceVersion {
+			pending[i].BaseResourceVersion = newRV
+		}
+	}
+}
+
+// dropJournals drops the journal and the following journals of the same object, recovers the
+// local object from cloud and reports an event to tell the user the offline write is lost
+func (r *REST) dropJournals(j *v2.MetaV2Journal, reason error) {
+	if err := v2.DeleteJournalsByKey(j.Key); err != nil {
+		klog.Errorf("[metaserver/replay] failed to delete journals of %v: %v", j.Key, err)
+	}
+
+	obj, err := r.getFromCloud(j.Key, j.Token)
+	switch {
+	case err == nil:
+		err = imitator.DefaultV2Client.InjectEvent(watch.Event{Type: watch.Modified, Object: obj})
+	case errors.IsNotFound(err):
+		local, getErr := getLocal(context.TODO(), j.Key)
+		if getErr != nil {
+			err = nil
+			break
+		}
+		local.SetResourceVersion(nextResourceVersion())
+		err = imitator.DefaultV2Client.InjectEvent(watch.Event{Type: watch.Deleted, Object: local})
+	}
+	if err != nil {
+		klog.Errorf("[metaserver/replay] failed to recover %v from cloud: %v", j.Key, err)
+	}
+
+	r.reportConflict(j, reason)
+}
+
+// getFromCloud get the object of key from cloud
+func (r *REST) getFromCloud(key string, token string) (*unstructured.Unstructured, error) {
+	ctx := journalContext(requestInfoFor(key, "get"), token)
+	app, err := r.Agent.Generate(ctx, application.Get, metav1.GetOptions{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer app.Close()
+	if err := r.Agent.Apply(app); err != nil {
+		return nil, err
+	}
+	obj := new(unstructured.Unstructured)
+	if err := json.Unmarshal(app.RespBody, obj); err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+	return obj, nil
+}
+
+// reportConflict create a warning event for the object whose offline write is dropped
+func (r *REST) reportConflict(j *v2.MetaV2Journal, reason error) {
+	gvr, ns, name := metaserver.ParseKey(j.Key)
+	if ns == "" {
+		ns = metav1.NamespaceDefault
+	}
+	now := metav1.Now()
+	event := &corev1.Event{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Event"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%v.%x", name, now.UnixNano()),
+			Namespace: ns,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: gvr.GroupVersion().String(),
+			Kind:       util.UnsafeResourceToKind(gvr.Resource),
+			Namespace:  ns,
+			Name:       name,
+		},
+		Reason:         OfflineWriteConflictReason,
+		Message:        fmt.Sprintf("%v request made while node was offline is dropped: %v", j.Verb, reason),
+		Type:           corev1.EventTypeWarning,
+		Source:         corev1.EventSource{Component: offlineWriteEventSource, Host: metaserverconfig.Config.NodeName},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	unstr, err := runtime.DefaultUnstructuredConverter.ToUnstructured(event)
+	if err != nil {
+		klog.Errorf("[metaserver/replay] failed to convert event: %v", err)
+		return
+	}
+	info := &apirequest.RequestInfo{
+		IsResourceRequest: true,
+		Verb:              "create",
+		APIPrefix:         "api",
+		APIVersion:        "v1",
+		Namespace:         ns,
+		Resource:          "events",
+	}
+	ctx := journalContext(info, j.Token)
+	app, err := r.Agent.Generate(ctx, application.Create, metav1.CreateOptions{}, &unstructured.Unstructured{Object: unstr})
+	if err != nil {
+		klog.Errorf("[metaserver/replay] failed to generate application: %v", err)
+		return
+	}
+	defer app.Close()
+	if err := r.Agent.Apply(app); err != nil {
+		klog.Errorf("[metaserver/replay] failed to report event for %v: %v", j.Key, err)
+	}
+}
+
+// journalContext rebuild the request context from the request info and token in journal
+func journalContext(info *apirequest.RequestInfo, token string) context.Context {
+	ctx := apirequest.WithRequestInfo(context.Background(), info)
+	ctx = apirequest.WithNamespace(ctx, info.Namespace)
+	return context.WithValue(ctx, commontypes.AuthorizationKey, token)
+}
+
+func unmarshalJournal(data string, v interface{}) error {
+	if data == "" || data == "null" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(data), v); err != nil {
+		return errors.NewBadRequest(fmt.Sprintf("invalid journal content: %v", err))
+	}
+	return nil
+}
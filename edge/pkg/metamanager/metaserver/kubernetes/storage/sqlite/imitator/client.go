@@ -11,8 +11,8 @@ import (
 	"k8s.io/apiserver/pkg/storage/etcd3"
 
 	"github.com/kubeedge/beehive/pkg/core/model"
-	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
 	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao/v2"
+	metaserverconfig "github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/config"
 )
 
 // DefaultV2Client is the only one client. Because of v2Client
@@ -25,6 +25,8 @@ type Client interface {
 	// This set of functions is for metamanager
 	// Inject the msg to the backend storage
 	Inject(msg model.Message)
+	// InjectEvent save the obj of event to the backend storage and notify watchers
+	InjectEvent(e watch.Event) error
 	InsertOrUpdateObj(ctx context.Context, obj runtime.Object) error
 	DeleteObj(ctx context.Context, obj runtime.Object) error
 
@@ -34,7 +36,7 @@ type Client interface {
 	// This set of functions for upper storage
 	List(ctx context.Context, key string) (Resp, error)
 	Get(ctx context.Context, key string) (Resp, error)
-	Watch(ctx context.Context, key string, ResourceVersion uint64) <-chan watch.Event
+	Watch(ctx context.Context, key string, ResourceVersion uint64) (<-chan watch.Event, error)
 }
 
 type Resp struct {
@@ -54,9 +56,9 @@ func newV2Client() Client {
 
 // StorageInit must be called before using imitator storage (run metaserver or metamanager)
 func StorageInit() {
-	m := new(v2.MetaV2)
-	// get the most recent record as the init resource version
-	_, err := dbm.DBAccess.QueryTable(v2.NewMetaTableName).OrderBy("-" + v2.RV).Limit(1).All(m)
-	utilruntime.Must(err)
-	DefaultV2Client.SetRevision(m.ResourceVersion)
+	s, ok := DefaultV2Client.(*imitator)
+	if !ok {
+		return
+	}
+	utilruntime.Must(s.initHistory(int64(metaserverconfig.Config.WatchEventHistorySize)))
 }
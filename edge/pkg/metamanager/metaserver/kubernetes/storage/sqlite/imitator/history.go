@@ -0,0 +1,228 @@
+package imitator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/klog/v2"
+
+	v2 "github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao/v2"
+	"github.com/kubeedge/kubeedge/pkg/metaserver"
+)
+
+// initHistory restores the revision and the compacted revision from meta_v2 and meta_v2_event,
+// so that watchers can resume from a resource version after edgecore restarts
+func (s *imitator) initHistory(historySize int64) error {
+	// get the most recent record as the init resource version
+	maxMetaRV, err := v2.MaxMetaRV()
+	if err != nil {
+		return err
+	}
+	maxEventRV, err := v2.MaxEventRV()
+	if err != nil {
+		return err
+	}
+	minEventRV, exist, err := v2.MinEventRV()
+	if err != nil {
+		return err
+	}
+	eventCount, err := v2.CountEvents()
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.historySize = historySize
+	s.eventCount = eventCount
+	s.revision = maxMetaRV
+	if maxEventRV > s.revision {
+		s.revision = maxEventRV
+	}
+	// events before the oldest recorded event are unknown
+	s.compactedRevision = s.revision
+	if exist && historySize > 0 {
+		s.compactedRevision = minEventRV - 1
+	}
+	klog.Infof("[metaserver]init storage revision: %v, compacted revision: %v", s.revision, s.compactedRevision)
+	return nil
+}
+
+// recordEvent saves the event to meta_v2_event and bumps the revision,
+// it must be called before the event is triggered to the watch hooks
+func (s *imitator) recordEvent(e watch.Event) error {
+	objRv, err := s.versioner.ObjectResourceVersion(e.Object)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if objRv > s.revision {
+		s.revision = objRv
+	}
+	if s.historySize <= 0 {
+		// no history, so a watcher can only watch from now on
+		s.compactedRevision = s.revision
+		return nil
+	}
+
+	key, err := metaserver.KeyFuncObj(e.Object)
+	if err != nil {
+		return err
+	}
+	gvr, ns, name := metaserver.ParseKey(key)
+	buf := bytes.NewBuffer(nil)
+	if err := s.codec.Encode(e.Object, buf); err != nil {
+		return err
+	}
+	event := v2.MetaV2Event{
+		Key:                  key,
+		GroupVersionResource: gvr.String(),
+		Namespace:            ns,
+		Name:                 name,
+		ResourceVersion:      objRv,
+		Type:                 string(e.Type),
+		Value:                buf.String(),
+	}
+	if err := v2.InsertEvent(&event); err != nil {
+		return err
+	}
+	s.eventCount++
+
+	// compact in batch to avoid counting the table for each event
+	if s.eventCount <= s.historySize+s.historySize/10 {
+		return nil
+	}
+	compacted, err := v2.CompactEvents(s.historySize)
+	if err != nil {
+		klog.Errorf("[metaserver]failed to compact watch events: %v", err)
+		return nil
+	}
+	if compacted > s.compactedRevision {
+		s.compactedRevision = compacted
+	}
+	s.eventCount = s.historySize
+	klog.V(4).Infof("[metaserver]compact watch events to revision %v", s.compactedRevision)
+	return nil
+}
+
+// checkRevision returns an expired error if the events after rev have been compacted
+func (s *imitator) checkRevision(rev uint64) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if rev != 0 && rev < s.compactedRevision {
+		return errors.NewResourceExpired(fmt.Sprintf("too old resource version: %d (%d)", rev, s.compactedRevision))
+	}
+	return nil
+}
+
+// replay sends the recorded events of key happened after rev to the receiver
+func (s *imitator) replay(ctx context.Context, key string, rev uint64, receiver *replayReceiver) error {
+	gvr, ns, name := metaserver.ParseKey(key)
+	events, err := v2.RawEventsAfterRV(gvr, ns, name, rev)
+	if err != nil {
+		return err
+	}
+	for _, e := range *events {
+		obj := new(unstructured.Unstructured)
+		if err := runtime.DecodeInto(s.codec, []byte(e.Value), obj); err != nil {
+			klog.Errorf("[metaserver]failed to decode watch event %v: %v", e.Key, err)
+			continue
+		}
+		if !receiver.replay(ctx, watch.Event{Type: watch.EventType(e.Type), Object: obj}, e.Key, e.ResourceVersion) {
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// replayReceiver implements watchhook.Receiver. The events triggered by hook are
+// held until the history events are replayed, so the hook is not blocked meanwhile,
+// and the events that have been replayed will not be sent again.
+type replayReceiver struct {
+	ch   chan<- watch.Event
+	lock sync.Mutex
+	// replaying is true until replay done, the events triggered meanwhile are held in pending
+	replaying bool
+	pending   []watch.Event
+	// replayed records the key and resource version of replayed events
+	replayed map[string]struct{}
+}
+
+func newReplayReceiver(ch chan<- watch.Event) *replayReceiver {
+	return &replayReceiver{
+		ch:        ch,
+		replaying: true,
+		replayed:  make(map[string]struct{}),
+	}
+}
+
+func (r *replayReceiver) Receive(event watch.Event) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.replaying {
+		r.pending = append(r.pending, event)
+		return nil
+	}
+	if r.isReplayed(event) {
+		return nil
+	}
+	r.ch <- event
+	return nil
+}
+
+func (r *replayReceiver) replay(ctx context.Context, event watch.Event, key string, rv uint64) bool {
+	select {
+	case r.ch <- event:
+		r.lock.Lock()
+		r.replayed[replayedKey(key, rv)] = struct{}{}
+		r.lock.Unlock()
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// done sends the events held during replay, the events triggered by hook are sent directly then
+func (r *replayReceiver) done(ctx context.Context) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, event := range r.pending {
+		if r.isReplayed(event) {
+			continue
+		}
+		select {
+		case r.ch <- event:
+		case <-ctx.Done():
+		}
+	}
+	r.pending = nil
+	r.replaying = false
+}
+
+// isReplayed reports whether the event has been replayed, r.lock must be held
+func (r *replayReceiver) isReplayed(event watch.Event) bool {
+	if len(r.replayed) == 0 {
+		return false
+	}
+	key, err := metaserver.KeyFuncObj(event.Object)
+	if err != nil {
+		return false
+	}
+	rv, err := Versioner.ObjectResourceVersion(event.Object)
+	if err != nil {
+		return false
+	}
+	_, ok := r.replayed[replayedKey(key, rv)]
+	return ok
+}
+
+func replayedKey(key string, rv uint64) string {
+	return fmt.Sprintf("%s@%d", key, rv)
+}
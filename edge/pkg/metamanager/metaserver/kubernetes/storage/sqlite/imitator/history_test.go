@@ -0,0 +1,69 @@
+package imitator
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func newPod(name, rv string) *unstructured.Unstructured {
+	obj := new(unstructured.Unstructured)
+	obj.SetAPIVersion("v1")
+	obj.SetKind("Pod")
+	obj.SetNamespace("default")
+	obj.SetName(name)
+	obj.SetResourceVersion(rv)
+	return obj
+}
+
+func TestCheckRevision(t *testing.T) {
+	s := &imitator{revision: 200, compactedRevision: 100}
+	cases := []struct {
+		name    string
+		rev     uint64
+		expired bool
+	}{
+		{name: "watch from now on", rev: 0, expired: false},
+		{name: "too old", rev: 99, expired: true},
+		{name: "compacted revision", rev: 100, expired: false},
+		{name: "newer than revision", rev: 300, expired: false},
+	}
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			err := s.checkRevision(test.rev)
+			if errors.IsResourceExpired(err) != test.expired {
+				t.Errorf("expected expired %v, but got error %v", test.expired, err)
+			}
+		})
+	}
+}
+
+func TestReplayReceiver(t *testing.T) {
+	ch := make(chan watch.Event, 10)
+	r := newReplayReceiver(ch)
+
+	// the events triggered during replay are held without blocking the hook
+	_ = r.Receive(watch.Event{Type: watch.Modified, Object: newPod("a", "10")})
+	_ = r.Receive(watch.Event{Type: watch.Modified, Object: newPod("b", "11")})
+	if len(ch) != 0 {
+		t.Fatalf("expected events held until replay done, but got %d sent", len(ch))
+	}
+
+	if !r.replay(context.TODO(), watch.Event{Type: watch.Added, Object: newPod("a", "10")}, "/core/v1/pods/default/a", 10) {
+		t.Fatalf("failed to replay event")
+	}
+	r.done(context.TODO())
+	_ = r.Receive(watch.Event{Type: watch.Modified, Object: newPod("a", "12")})
+	close(ch)
+
+	var rvs []string
+	for e := range ch {
+		rvs = append(rvs, e.Object.(*unstructured.Unstructured).GetResourceVersion())
+	}
+	if len(rvs) != 3 || rvs[0] != "10" || rvs[1] != "11" || rvs[2] != "12" {
+		t.Errorf("expected events with resource version [10 11 12], but got %v", rvs)
+	}
+}
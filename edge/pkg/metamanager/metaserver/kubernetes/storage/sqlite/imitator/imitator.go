@@ -17,7 +17,6 @@ import (
 
 	"github.com/kubeedge/beehive/pkg/core/model"
 	"github.com/kubeedge/kubeedge/common/constants"
-	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
 	"github.com/kubeedge/kubeedge/edge/pkg/common/modules"
 	v2 "github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao/v2"
 	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/kubernetes/storage/sqlite/imitator/watchhook"
@@ -30,6 +29,13 @@ type imitator struct {
 	// The Revision is the current revision of client
 	// It is set when client inits or a bigger resourceversion obj was saved into meta_v2
 	revision uint64
+	// The compactedRevision is the revision that the events at or before it are no longer
+	// recorded in meta_v2_event, a watcher can not resume from a revision smaller than it
+	compactedRevision uint64
+	// historySize is the max number of events recorded in meta_v2_event, 0 means no history
+	historySize int64
+	// eventCount is the number of events recorded in meta_v2_event
+	eventCount int64
 	// to parse obj resource version from string to int64
 	versioner storage.Versioner
 	// to co/decoder obj
@@ -40,24 +46,35 @@ type imitator struct {
 // and trigger the corresponding hook to serve watch
 func (s *imitator) Inject(msg model.Message) {
 	for _, e := range s.Event(&msg) {
-		// save to meta_v2
-		var err error
-		switch e.Type {
-		case watch.Added, watch.Modified:
-			err = s.InsertOrUpdateObj(context.TODO(), e.Object)
-		case watch.Deleted:
-			err = s.DeleteObj(context.TODO(), e.Object)
-		}
-		if err != nil {
+		if err := s.InjectEvent(e); err != nil {
 			key := metaserver.KeyFunc(e.Object)
-			klog.Errorf("failed to serve event {type:%v,key:%v}", e.Type, key)
-			continue
+			klog.Errorf("failed to serve event {type:%v,key:%v}: %v", e.Type, key, err)
 		}
-		// TODO: move Trigger inside InsertOrUpdateObj and DeleteObj
-		watchhook.Trigger(e)
 	}
 }
 
+// InjectEvent save the obj of event to table meta_v2 and trigger the corresponding hook to serve watch
+func (s *imitator) InjectEvent(e watch.Event) error {
+	// save to meta_v2
+	var err error
+	switch e.Type {
+	case watch.Added, watch.Modified:
+		err = s.InsertOrUpdateObj(context.TODO(), e.Object)
+	case watch.Deleted:
+		err = s.DeleteObj(context.TODO(), e.Object)
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.recordEvent(e); err != nil {
+		key := metaserver.KeyFunc(e.Object)
+		klog.Errorf("failed to record event {type:%v,key:%v}: %v", e.Type, key, err)
+	}
+	// TODO: move Trigger inside InsertOrUpdateObj and DeleteObj
+	watchhook.Trigger(e)
+	return nil
+}
+
 //TODO: filter out insert or update req that the obj's rev is smaller than the stored
 func (s *imitator) InsertOrUpdateObj(ctx context.Context, obj runtime.Object) error {
 	key, err := metaserver.KeyFuncObj(obj)
@@ -85,27 +102,24 @@ func (s *imitator) InsertOrUpdateObj(ctx context.Context, obj runtime.Object) er
 	}
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	_, err = dbm.DBAccess.Raw("INSERT OR REPLACE INTO meta_v2 (key, groupversionresource, namespace,name,resourceversion,value) VALUES (?,?,?,?,?,?)", m.Key, m.GroupVersionResource, m.Namespace, m.Name, m.ResourceVersion, m.Value).Exec()
+	err = v2.InsertOrUpdateMetaV2(&m)
 	var maxRetryTimes = 3
 	for i := 1; err != nil; i++ {
 		klog.Errorf("failed to access database:%v", err)
 		if i == maxRetryTimes {
 			return fmt.Errorf("failed to access database after %v times try", i)
 		}
-		_, err = dbm.DBAccess.Raw("INSERT OR REPLACE INTO meta_v2 (key, groupversionresource, namespace,name,resourceversion,value) VALUES (?,?,?,?,?,?)", m.Key, m.GroupVersionResource, m.Namespace, m.Name, m.ResourceVersion, m.Value).Exec()
+		err = v2.InsertOrUpdateMetaV2(&m)
 	}
-	if objRv > s.GetRevision() {
-		s.SetRevision(objRv)
+	if objRv > s.revision {
+		s.revision = objRv
 	}
 	klog.V(4).Infof("[metaserver]successfully insert or update obj:%v", key)
 	return nil
 }
 func (s *imitator) Delete(ctx context.Context, key string) error {
-	m := v2.MetaV2{
-		Key: key,
-	}
 	s.lock.Lock()
-	_, err := dbm.DBAccess.Delete(&m)
+	err := v2.DeleteMetaV2ByKey(key)
 	if err != nil {
 		klog.Errorf("[imitator] delete error: %v", err)
 	}
@@ -157,42 +171,60 @@ func (s *imitator) List(ctx context.Context, key string) (Resp, error) {
 }
 
 func (s *imitator) GetRevision() uint64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	return s.revision
 }
 
 func (s *imitator) SetRevision(version interface{}) {
+	var rv uint64
 	switch v := version.(type) {
 	case int64:
-		s.revision = uint64(v)
+		rv = uint64(v)
 	case uint64:
-		s.revision = v
+		rv = v
 	case string:
-		rv, err := strconv.ParseUint(v, 10, 64)
+		var err error
+		rv, err = strconv.ParseUint(v, 10, 64)
 		if err != nil {
 			klog.Error(err)
 			return
 		}
-		s.revision = rv
 	default:
 		klog.Error("unsupported type when parse version")
+		return
 	}
+	s.lock.Lock()
+	s.revision = rv
+	s.lock.Unlock()
 }
 
-func (s *imitator) Watch(ctx context.Context, key string, rev uint64) <-chan watch.Event {
+// Watch returns the events of key happened after rev. If rev is non-zero, the recorded events
+// after rev are replayed first, and an expired error is returned if they have been compacted.
+func (s *imitator) Watch(ctx context.Context, key string, rev uint64) (<-chan watch.Event, error) {
+	if err := s.checkRevision(rev); err != nil {
+		return nil, err
+	}
 	wch := make(chan watch.Event)
-	receiver := watchhook.NewChanReceiver(wch)
+	receiver := newReplayReceiver(wch)
 	wh, err := watchhook.NewWatchHook(key, rev, receiver)
 	if err != nil {
 		klog.Errorf("add hook for %s failed, %v", key, err)
-		return nil
+		return nil, err
 	}
 
 	go func() {
+		if rev != 0 {
+			if err := s.replay(ctx, key, rev, receiver); err != nil {
+				klog.Errorf("replay events of %s after %v failed, %v", key, rev, err)
+			}
+		}
+		receiver.done(ctx)
 		<-ctx.Done()
 		wh.Stop()
 		close(wch)
 	}()
-	return wch
+	return wch, nil
 }
 
 // Event transform the message to watch.event
@@ -25,8 +25,11 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/apiserver/pkg/storage"
@@ -35,39 +38,51 @@ import (
 	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao/v2"
 	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/kubernetes/storage/sqlite/imitator"
 	"github.com/kubeedge/kubeedge/pkg/metaserver"
+	"github.com/kubeedge/kubeedge/pkg/metaserver/util"
 )
 
 const (
 	// We have set a buffer in order to reduce times of context switches.
 	incomingBufSize = 100
 	outgoingBufSize = 100
+
+	// bookmarkFrequency defines how frequently watch bookmarks should be send
+	bookmarkFrequency = time.Minute
 )
 
 type watcher struct {
-	client imitator.Client
-	codec  runtime.Codec
+	client    imitator.Client
+	codec     runtime.Codec
+	versioner storage.Versioner
 }
 
 // watchChan implements watch.Interface.
 type watchChan struct {
 	watcher           *watcher
 	key               string
-	initialRev        int64
 	recursive         bool
 	internalPred      storage.SelectionPredicate
 	ctx               context.Context
 	cancel            context.CancelFunc
 	incomingEventChan chan *watch.Event
-	// added is map show an obj whether it has been added to watch chan befor
-	added      map[string]bool
+	// added is map show an obj whether it has been added to watch chan befor,
+	// the obj absent is unknown if the watch is resumed
+	added map[string]bool
+	// lastRev is the max resource version of events that have been processed
+	lastRev    uint64
 	resultChan chan watch.Event
 	errChan    chan error
+	// initialRev is set by sync when the watch starts from now on, it must be accessed atomically
+	initialRev int64
+	// resumed is true if the watch resumes from a resource version, the objects sent before are unknown
+	resumed bool
 }
 
 func newWatcher(client imitator.Client, codec runtime.Codec) *watcher {
 	return &watcher{
-		client: client,
-		codec:  codec,
+		client:    client,
+		codec:     codec,
+		versioner: imitator.Versioner,
 	}
 }
 
@@ -85,11 +100,6 @@ func (wc *watchChan) Receive(e watch.Event) error {
 // If recursive is true, it watches any children and directories under the key, excluding the root key itself.
 // pred must be non-nil. Only if pred matches the change, it will be returned.
 func (w *watcher) Watch(ctx context.Context, key string, rev int64, recursive bool, pred storage.SelectionPredicate) (watch.Interface, error) {
-	// TODO: support rev != 0
-	if rev != 0 {
-		klog.Warningf("base storage now only support rev == 0, but get rev == %v, force set to 0!", rev)
-		rev = 0
-	}
 	wc := w.createWatchChan(ctx, key, rev, recursive, pred)
 	go wc.run()
 	return wc, nil
@@ -100,6 +110,7 @@ func (w *watcher) createWatchChan(ctx context.Context, key string, rev int64, re
 		watcher:           w,
 		key:               key,
 		initialRev:        rev,
+		resumed:           rev != 0,
 		recursive:         recursive,
 		internalPred:      pred,
 		incomingEventChan: make(chan *watch.Event, incomingBufSize),
@@ -171,7 +182,7 @@ func (wc *watchChan) sync() error {
 			}
 			wc.sendEvent(event)
 		}
-		wc.initialRev = int64(resp.Revision)
+		atomic.StoreInt64(&wc.initialRev, int64(resp.Revision))
 	case false: /*get*/
 		resp, err := wc.watcher.client.List(context.TODO(), wc.key)
 		if err != nil {
@@ -188,9 +199,9 @@ func (wc *watchChan) sync() error {
 			}
 			wc.sendEvent(event)
 		}
-		wc.initialRev = int64(resp.Revision)
+		atomic.StoreInt64(&wc.initialRev, int64(resp.Revision))
 	}
-	klog.Infof("get storage revision:%v", wc.initialRev)
+	klog.Infof("get storage revision:%v", atomic.LoadInt64(&wc.initialRev))
 	return nil
 }
 
@@ -207,6 +218,54 @@ func (wc *watchChan) parseMeta(kv *v2.MetaV2) (*watch.Event, error) {
 	}, nil
 }
 
+// seedAdded records the objects sent to the client before the resumed watch, which are the objects
+// matched and not changed since initialRev. The other objects are unknown, they may have been sent.
+func (wc *watchChan) seedAdded() {
+	resp, err := wc.watcher.client.List(context.TODO(), wc.key)
+	if err != nil {
+		klog.Errorf("failed to list objects of resumed watch %v: %v", wc.key, err)
+		return
+	}
+	initialRev := uint64(atomic.LoadInt64(&wc.initialRev))
+	for _, kv := range *resp.Kvs {
+		if kv.ResourceVersion > initialRev {
+			continue
+		}
+		obj, err := runtime.Decode(wc.watcher.codec, []byte(kv.Value))
+		if err != nil {
+			klog.Errorf("parse meta failed, %v", err)
+			continue
+		}
+		wc.added[kv.Key] = wc.filter(obj)
+	}
+}
+
+// addedAtInitialRev reports whether the obj of key matched at initialRev, which means it has been
+// sent to the client before the resumed watch. The obj is resolved from the newest event recorded
+// at initialRev, an expired error is returned if the event has been pruned from the history.
+func (wc *watchChan) addedAtInitialRev(key string, e *watch.Event) (bool, error) {
+	if e.Type == watch.Added {
+		// the obj is created after initialRev
+		return false, nil
+	}
+	initialRev := uint64(atomic.LoadInt64(&wc.initialRev))
+	event, exist, err := v2.RawLastEventAtRV(key, initialRev)
+	if err != nil {
+		return false, err
+	}
+	if !exist {
+		return false, apierrors.NewResourceExpired(fmt.Sprintf("too old resource version: %d, the history of %s has been pruned", initialRev, key))
+	}
+	if event.Type == string(watch.Deleted) {
+		return false, nil
+	}
+	obj, err := runtime.Decode(wc.watcher.codec, []byte(event.Value))
+	if err != nil {
+		return false, err
+	}
+	return wc.filter(obj), nil
+}
+
 // logWatchChannelErr checks whether the error is about mvcc revision compaction which is regarded as warning
 func logWatchChannelErr(err error) {
 	if !strings.Contains(err.Error(), "mvcc: required revision has been compacted") {
@@ -220,17 +279,25 @@ func logWatchChannelErr(err error) {
 // - get current objects if initialRev=0; set initialRev to current rev
 // - watch on given key and send events to process.
 func (wc *watchChan) startWatching(watchClosedCh chan struct{}) {
-	klog.Infof("start watching, rev:%v", wc.initialRev)
-	if wc.initialRev == 0 {
+	klog.Infof("start watching, rev:%v", atomic.LoadInt64(&wc.initialRev))
+	if !wc.resumed {
 		if err := wc.sync(); err != nil {
 			klog.Errorf("failed to sync with latest state: %v", err)
 			wc.sendError(err)
 			return
 		}
 	}
-	wch := wc.watcher.client.Watch(wc.ctx, wc.key, uint64(wc.initialRev+1))
+	initialRev := atomic.LoadInt64(&wc.initialRev)
+	wch, err := wc.watcher.client.Watch(wc.ctx, wc.key, uint64(initialRev))
+	if err != nil {
+		klog.Errorf("failed to watch from revision %v: %v", initialRev, err)
+		wc.sendError(err)
+		return
+	}
 	for wres := range wch {
-		wc.sendEvent(&wres)
+		// the events are buffered, so each one is sent by its own copy
+		event := wres
+		wc.sendEvent(&event)
 	}
 	wc.sendError(fmt.Errorf("stop to watch sqlite/meta_v2"))
 	close(watchClosedCh)
@@ -240,8 +307,20 @@ func (wc *watchChan) startWatching(watchClosedCh chan struct{}) {
 func (wc *watchChan) processEvent(wg *sync.WaitGroup) {
 	defer wg.Done()
 
+	var bookmarkC <-chan time.Time
+	if wc.internalPred.AllowWatchBookmarks {
+		ticker := time.NewTicker(bookmarkFrequency)
+		defer ticker.Stop()
+		bookmarkC = ticker.C
+	}
+	if wc.resumed {
+		wc.seedAdded()
+	}
+
 	for {
 		select {
+		case <-bookmarkC:
+			wc.sendBookmark()
 		case e := <-wc.incomingEventChan:
 			var res = e
 			key, err := metaserver.KeyFuncObj(e.Object)
@@ -249,7 +328,18 @@ func (wc *watchChan) processEvent(wg *sync.WaitGroup) {
 				klog.Errorf("failed to get key from obj:%v", err)
 				continue
 			}
-			hasBeenAdded := wc.added[key]
+			if rev, err := wc.watcher.versioner.ObjectResourceVersion(e.Object); err == nil && rev > wc.lastRev {
+				wc.lastRev = rev
+			}
+			hasBeenAdded, known := wc.added[key]
+			if !known && wc.resumed {
+				// the obj may have been sent before the resumed watch, find it out from the history
+				hasBeenAdded, err = wc.addedAtInitialRev(key, e)
+				if err != nil {
+					wc.sendError(err)
+					return
+				}
+			}
 			matched := wc.filter(e.Object) //drop if not matched
 			switch {
 			case hasBeenAdded && !matched:
@@ -293,6 +383,29 @@ func (wc *watchChan) processEvent(wg *sync.WaitGroup) {
 	}
 }
 
+// sendBookmark sends a bookmark event with the max resource version that has been processed,
+// so that the client can resume the watch from it.
+func (wc *watchChan) sendBookmark() {
+	rev := wc.lastRev
+	if initialRev := uint64(atomic.LoadInt64(&wc.initialRev)); initialRev > rev {
+		rev = initialRev
+	}
+	if rev == 0 {
+		return
+	}
+	gvr, _, _ := metaserver.ParseKey(wc.key)
+	obj := new(unstructured.Unstructured)
+	obj.SetGroupVersionKind(gvr.GroupVersion().WithKind(util.UnsafeResourceToKind(gvr.Resource)))
+	if err := wc.watcher.versioner.UpdateObject(obj, rev); err != nil {
+		klog.Errorf("failed to set resource version of bookmark: %v", err)
+		return
+	}
+	select {
+	case wc.resultChan <- watch.Event{Type: watch.Bookmark, Object: obj}:
+	case <-wc.ctx.Done():
+	}
+}
+
 func (wc *watchChan) filter(obj runtime.Object) bool {
 	if wc.internalPred.Empty() {
 		return true
@@ -307,6 +420,9 @@ func (wc *watchChan) acceptAll() bool {
 
 func transformErrorToEvent(err error) *watch.Event {
 	status := apierrors.NewInternalError(err).Status()
+	if statusErr, ok := err.(apierrors.APIStatus); ok {
+		status = statusErr.Status()
+	}
 	return &watch.Event{
 		Type:   watch.Error,
 		Object: &status,
@@ -0,0 +1,132 @@
+package sqlite
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/storage"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao/v2"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/kubernetes/storage/sqlite/imitator"
+	"github.com/kubeedge/kubeedge/pkg/metaserver"
+)
+
+// fakeClient lists the objects stored and watches the events given
+type fakeClient struct {
+	imitator.Client
+	kvs    []v2.MetaV2
+	events chan watch.Event
+}
+
+func (c *fakeClient) List(ctx context.Context, key string) (imitator.Resp, error) {
+	return imitator.Resp{Kvs: &c.kvs, Revision: 10}, nil
+}
+
+func (c *fakeClient) Watch(ctx context.Context, key string, rev uint64) (<-chan watch.Event, error) {
+	return c.events, nil
+}
+
+func newPod(name, rv, app string) *unstructured.Unstructured {
+	obj := new(unstructured.Unstructured)
+	obj.SetAPIVersion("v1")
+	obj.SetKind("Pod")
+	obj.SetNamespace("default")
+	obj.SetName(name)
+	obj.SetResourceVersion(rv)
+	obj.SetLabels(map[string]string{"app": app})
+	return obj
+}
+
+func newMetaV2(t *testing.T, obj *unstructured.Unstructured, rv uint64) v2.MetaV2 {
+	buf := bytes.NewBuffer(nil)
+	if err := unstructured.UnstructuredJSONScheme.Encode(obj, buf); err != nil {
+		t.Fatalf("failed to encode %v: %v", obj, err)
+	}
+	return v2.MetaV2{Key: metaserver.KeyFunc(obj), ResourceVersion: rv, Value: buf.String()}
+}
+
+func TestWatchResumed(t *testing.T) {
+	kv, err := dbm.NewBoltStore(filepath.Join(t.TempDir(), "edgecore.bolt"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer kv.Close()
+	dbm.KV = kv
+	defer func() { dbm.KV = nil }()
+
+	// c matched at the resource version resumed from, so it has been sent
+	c := newMetaV2(t, newPod("c", "8", "foo"), 8)
+	if err := v2.InsertEvent(&v2.MetaV2Event{Key: c.Key, ResourceVersion: 8, Type: string(watch.Modified), Value: c.Value}); err != nil {
+		t.Fatalf("InsertEvent() error = %v", err)
+	}
+
+	client := &fakeClient{events: make(chan watch.Event, 10)}
+	client.kvs = []v2.MetaV2{
+		newMetaV2(t, newPod("a", "5", "foo"), 5),
+		newMetaV2(t, newPod("b", "5", "bar"), 5),
+	}
+	pred := storage.SelectionPredicate{
+		Label: labels.SelectorFromSet(labels.Set{"app": "foo"}),
+		Field: fields.Everything(),
+		GetAttrs: func(obj runtime.Object) (labels.Set, fields.Set, error) {
+			accessor, err := meta.Accessor(obj)
+			if err != nil {
+				return nil, nil, err
+			}
+			return accessor.GetLabels(), nil, nil
+		},
+	}
+	w := newWatcher(client, unstructured.UnstructuredJSONScheme)
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	wi, err := w.Watch(ctx, "/core/v1/pods/default", 10, true, pred)
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	// b was not sent before, so its update is dropped
+	client.events <- watch.Event{Type: watch.Modified, Object: newPod("b", "11", "baz")}
+	// a was sent before and stops matching
+	client.events <- watch.Event{Type: watch.Modified, Object: newPod("a", "12", "baz")}
+	// e is created after the resource version resumed from
+	client.events <- watch.Event{Type: watch.Added, Object: newPod("e", "13", "foo")}
+	// c was sent before and stops matching, then it is unknown to the client
+	client.events <- watch.Event{Type: watch.Modified, Object: newPod("c", "14", "baz")}
+	client.events <- watch.Event{Type: watch.Modified, Object: newPod("c", "15", "qux")}
+	// the history of d has been pruned, so the watch is expired
+	client.events <- watch.Event{Type: watch.Modified, Object: newPod("d", "16", "foo")}
+
+	var got []string
+	for len(got) < 4 {
+		select {
+		case e := <-wi.ResultChan():
+			if e.Type == watch.Error {
+				if status, ok := e.Object.(*metav1.Status); ok && status.Code == http.StatusGone {
+					got = append(got, "EXPIRED")
+					continue
+				}
+				t.Fatalf("unexpected error %v", e.Object)
+			}
+			got = append(got, string(e.Type)+"/"+e.Object.(*unstructured.Unstructured).GetName())
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out, got %v", got)
+		}
+	}
+	want := []string{"DELETED/a", "ADDED/e", "DELETED/c", "EXPIRED"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, but got %v", want, got)
+	}
+}
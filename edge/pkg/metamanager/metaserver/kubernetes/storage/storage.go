@@ -12,6 +12,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 	apirequest "k8s.io/apiserver/pkg/endpoints/request"
 	genericregistry "k8s.io/apiserver/pkg/registry/generic/registry"
@@ -19,9 +20,14 @@ import (
 	"k8s.io/apiserver/pkg/storage"
 	"k8s.io/klog/v2"
 
+	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
 	"github.com/kubeedge/kubeedge/cloud/pkg/dynamiccontroller/application"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/auth"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/kubernetes/storage/cacher"
 	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/kubernetes/storage/sqlite"
 	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/kubernetes/storage/sqlite/imitator"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/kubernetes/storage/sqlite/imitator/watchhook"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/kubernetes/tableconvertor"
 	kefeatures "github.com/kubeedge/kubeedge/pkg/features"
 	"github.com/kubeedge/kubeedge/pkg/metaserver"
 	"github.com/kubeedge/kubeedge/pkg/metaserver/util"
@@ -31,6 +37,7 @@ import (
 type REST struct {
 	*genericregistry.Store
 	*application.Agent
+	crds *crdCache
 }
 
 // NewREST returns a RESTStorage object that will work against all resources
@@ -59,10 +66,33 @@ func NewREST() (*REST, error) {
 		}
 	}
 
-	store.Storage.Storage = sqlite.New()
+	// list and watch are served by the shared watch cache, other requests go to sqlite directly
+	store.Storage.Storage = cacher.NewCacher(beehiveContext.GetContext(), sqlite.New(), imitator.DefaultV2Client)
 	store.Storage.Codec = unstructured.UnstructuredJSONScheme
 
-	return &REST{store, application.NewApplicationAgent()}, nil
+	r := &REST{store, application.NewApplicationAgent(), newCRDCache()}
+	store.TableConvertor = tableconvertor.New(r.getCRD)
+	// drop the cached crd once it is changed, e.g. its printer columns are updated
+	if _, err := watchhook.NewWatchHook(crdKeyRoot, 0, r.crds); err != nil {
+		klog.Warningf("[metaserver/reststorage] failed to watch crds, the crds are refreshed after %v: %v", crdCacheTTL, err)
+	}
+	if kefeatures.DefaultFeatureGate.Enabled(kefeatures.MetaServerOfflineWrite) {
+		go wait.Until(r.replayJournals, journalReplayPeriod, beehiveContext.Done())
+	}
+	return r, nil
+}
+
+// authorizeLocal checks whether the request can be served by local store after failing to access cloud.
+// With requireAuthorization, the error from cloud is returned if the request is rejected by cloud,
+// otherwise the request is authorized with the rbac rules cached at edge.
+func authorizeLocal(ctx context.Context, cloudErr error) error {
+	if !kefeatures.DefaultFeatureGate.Enabled(kefeatures.RequireAuthorization) {
+		return nil
+	}
+	if _, ok := cloudErr.(errors.APIStatus); ok {
+		return cloudErr
+	}
+	return auth.Authorize(ctx)
 }
 
 // decorateList set list's gvk if it's gvk is empty
@@ -105,7 +135,10 @@ func (r *REST) Get(ctx context.Context, name string, options *metav1.GetOptions)
 		return obj, nil
 	}()
 	// try local
-	if err != nil && !kefeatures.DefaultFeatureGate.Enabled(kefeatures.RequireAuthorization) {
+	if err != nil {
+		if authErr := authorizeLocal(ctx, err); authErr != nil {
+			return nil, authErr
+		}
 		obj, err = r.Store.Get(ctx, "", options) // name is needless, we get all key information from ctx
 		if err != nil {
 			return nil, errors.NewNotFound(schema.GroupResource{Group: info.APIGroup, Resource: info.Resource}, info.Name)
@@ -141,8 +174,8 @@ func (r *REST) List(ctx context.Context, options *metainternalversion.ListOption
 
 	// try local if error occurs
 	if err != nil {
-		if kefeatures.DefaultFeatureGate.Enabled(kefeatures.RequireAuthorization) {
-			return nil, err
+		if authErr := authorizeLocal(ctx, err); authErr != nil {
+			return nil, authErr
 		}
 		list, err = r.Store.List(ctx, options)
 		if err != nil {
@@ -168,7 +201,7 @@ func (r *REST) Watch(ctx context.Context, options *metainternalversion.ListOptio
 		defer app.Close()
 		if err != nil {
 			klog.Errorf("[metaserver/reststorage] failed to apply for a watch listener from cloud: %v", err)
-			return nil, errors.NewInternalError(err)
+			return nil, err
 		}
 		klog.Infof("[metaserver/reststorage] successfully apply for a watch listener (%v) through cloud", path)
 		return nil, nil
@@ -176,13 +209,19 @@ func (r *REST) Watch(ctx context.Context, options *metainternalversion.ListOptio
 	if err != nil {
 		klog.Errorf("[metaserver/reststorage] failed to get a approved application for watch(%v) from cloud application center, %v", path, err)
 		// do not return here, although err occurs, we can still get watch event if a watch application is approved before,
+		if authErr := authorizeLocal(ctx, err); authErr != nil {
+			return nil, authErr
+		}
 	}
 
 	return r.Store.Watch(ctx, options)
 }
 
 func (r *REST) Create(ctx context.Context, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
-	obj, err := func() (runtime.Object, error) {
+	if key, err := createKey(ctx, obj); err == nil && hasPendingJournals(key) {
+		return r.createLocal(ctx, obj, options)
+	}
+	retObj, err := func() (runtime.Object, error) {
 		app, err := r.Agent.Generate(ctx, application.Create, *options, obj)
 		if err != nil {
 			klog.Errorf("[metaserver/reststorage] failed to generate application: %v", err)
@@ -201,16 +240,22 @@ func (r *REST) Create(ctx context.Context, obj runtime.Object, createValidation
 		}
 		return retObj, nil
 	}()
+	if offlineWritable(err) {
+		return r.createLocal(ctx, obj, options)
+	}
 	if err != nil {
 		klog.Errorf("[metaserver/reststorage] failed to create (%v)", metaserver.KeyFunc(obj))
 		return nil, err
 	}
-	klog.Infof("[metaserver/reststorage] successfully create (%v)", metaserver.KeyFunc(obj))
-	return obj, nil
+	klog.Infof("[metaserver/reststorage] successfully create (%v)", metaserver.KeyFunc(retObj))
+	return retObj, nil
 }
 
 func (r *REST) Delete(ctx context.Context, name string, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions) (runtime.Object, bool, error) {
 	key, _ := metaserver.KeyFuncReq(ctx, "")
+	if hasPendingJournals(key) {
+		return r.deleteLocal(ctx, options)
+	}
 	app, err := r.Agent.Generate(ctx, application.Delete, options, nil)
 	if err != nil {
 		klog.Errorf("[metaserver/reststorage] failed to generate application: %v", err)
@@ -218,6 +263,9 @@ func (r *REST) Delete(ctx context.Context, name string, deleteValidation rest.Va
 	}
 	err = r.Agent.Apply(app)
 	defer app.Close()
+	if offlineWritable(err) {
+		return r.deleteLocal(ctx, options)
+	}
 	if err != nil {
 		klog.Errorf("[metaserver/reststorage] failed to delete (%v) through cloud", key)
 		return nil, false, err
@@ -231,6 +279,9 @@ func (r *REST) Update(ctx context.Context, name string, objInfo rest.UpdatedObje
 	if err != nil {
 		return nil, false, errors.NewInternalError(err)
 	}
+	if key, err := metaserver.KeyFuncReq(ctx, ""); err == nil && hasPendingJournals(key) {
+		return r.updateLocal(ctx, obj, options)
+	}
 
 	reqInfo, _ := apirequest.RequestInfoFrom(ctx)
 	var app *application.Application
@@ -245,6 +296,9 @@ func (r *REST) Update(ctx context.Context, name string, objInfo rest.UpdatedObje
 	}
 	defer app.Close()
 	if err := r.Agent.Apply(app); err != nil {
+		if offlineWritable(err) {
+			return r.updateLocal(ctx, obj, options)
+		}
 		return nil, false, err
 	}
 	retObj := new(unstructured.Unstructured)
@@ -255,6 +309,9 @@ func (r *REST) Update(ctx context.Context, name string, objInfo rest.UpdatedObje
 }
 
 func (r *REST) Patch(ctx context.Context, pi application.PatchInfo) (runtime.Object, error) {
+	if key, err := metaserver.KeyFuncReq(ctx, ""); err == nil && hasPendingJournals(key) {
+		return r.patchLocal(ctx, pi)
+	}
 	app, err := r.Agent.Generate(ctx, application.Patch, pi, nil)
 	if err != nil {
 		klog.Errorf("[metaserver/reststorage] failed to generate application: %v", err)
@@ -262,6 +319,9 @@ func (r *REST) Patch(ctx context.Context, pi application.PatchInfo) (runtime.Obj
 	}
 	defer app.Close()
 	if err := r.Agent.Apply(app); err != nil {
+		if offlineWritable(err) {
+			return r.patchLocal(ctx, pi)
+		}
 		return nil, err
 	}
 	retObj := new(unstructured.Unstructured)
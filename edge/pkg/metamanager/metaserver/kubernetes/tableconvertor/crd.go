@@ -0,0 +1,188 @@
+package tableconvertor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+var swaggerMetadataDescriptions = metav1.ObjectMeta{}.SwaggerDoc()
+
+// crdConvertor prints the additionalPrinterColumns of a CRD version,
+// it works the same as the table convertor of apiextensions-apiserver
+type crdConvertor struct {
+	headers           []metav1.TableColumnDefinition
+	additionalColumns []*jsonpath.JSONPath
+}
+
+// newCRDConvertor builds the convertor of the printer columns, the Age column is printed
+// by default if the CRD version has no additionalPrinterColumns
+func newCRDConvertor(columns []apiextensionsv1.CustomResourceColumnDefinition) (*crdConvertor, error) {
+	if len(columns) == 0 {
+		columns = []apiextensionsv1.CustomResourceColumnDefinition{
+			{Name: "Age", Type: "date", Description: swaggerMetadataDescriptions["creationTimestamp"], JSONPath: ".metadata.creationTimestamp"},
+		}
+	}
+	c := &crdConvertor{
+		headers: []metav1.TableColumnDefinition{
+			{Name: "Name", Type: "string", Format: "name", Description: swaggerMetadataDescriptions["name"]},
+		},
+	}
+	for _, col := range columns {
+		path := jsonpath.New(col.Name)
+		if err := path.Parse(fmt.Sprintf("{%s}", col.JSONPath)); err != nil {
+			return nil, fmt.Errorf("unrecognized column definition %q", col.JSONPath)
+		}
+		path.AllowMissingKeys(true)
+
+		desc := fmt.Sprintf("Custom resource definition column (in JSONPath format): %s", col.JSONPath)
+		if len(col.Description) > 0 {
+			desc = col.Description
+		}
+		c.additionalColumns = append(c.additionalColumns, path)
+		c.headers = append(c.headers, metav1.TableColumnDefinition{
+			Name:        col.Name,
+			Type:        col.Type,
+			Format:      col.Format,
+			Description: desc,
+			Priority:    col.Priority,
+		})
+	}
+	return c, nil
+}
+
+func (c *crdConvertor) ConvertToTable(ctx context.Context, obj runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
+	table := &metav1.Table{}
+	if opt, ok := tableOptions.(*metav1.TableOptions); !ok || opt == nil || !opt.NoHeaders {
+		table.ColumnDefinitions = c.headers
+	}
+	if m, err := meta.ListAccessor(obj); err == nil {
+		table.ResourceVersion = m.GetResourceVersion()
+		table.Continue = m.GetContinue()
+		table.RemainingItemCount = m.GetRemainingItemCount()
+	} else if m, err := meta.CommonAccessor(obj); err == nil {
+		table.ResourceVersion = m.GetResourceVersion()
+	}
+
+	fn := func(obj runtime.Object) error {
+		cells, err := c.cells(obj)
+		if err != nil {
+			return err
+		}
+		table.Rows = append(table.Rows, metav1.TableRow{
+			Cells:  cells,
+			Object: runtime.RawExtension{Object: obj},
+		})
+		return nil
+	}
+	if meta.IsListType(obj) {
+		if err := meta.EachListItem(obj, fn); err != nil {
+			return nil, err
+		}
+		return table, nil
+	}
+	if err := fn(obj); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+func (c *crdConvertor) cells(obj runtime.Object) ([]interface{}, error) {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	cells := make([]interface{}, 1, 1+len(c.additionalColumns))
+	cells[0] = m.GetName()
+	customHeaders := c.headers[1:]
+
+	us, ok := obj.(runtime.Unstructured)
+	if !ok {
+		for range c.additionalColumns {
+			cells = append(cells, nil)
+		}
+		return cells, nil
+	}
+	buf := &bytes.Buffer{}
+	for i, column := range c.additionalColumns {
+		results, err := column.FindResults(us.UnstructuredContent())
+		if err != nil || len(results) == 0 || len(results[0]) == 0 {
+			cells = append(cells, nil)
+			continue
+		}
+		// only simple JSON path is supported, so there is only one result
+		value := results[0][0].Interface()
+		if customHeaders[i].Type == "string" {
+			if err := column.PrintResults(buf, []reflect.Value{reflect.ValueOf(value)}); err == nil {
+				cells = append(cells, buf.String())
+				buf.Reset()
+			} else {
+				cells = append(cells, nil)
+			}
+			continue
+		}
+		cells = append(cells, cellForJSONValue(customHeaders[i].Type, value))
+	}
+	return cells, nil
+}
+
+func cellForJSONValue(headerType string, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	switch headerType {
+	case "integer":
+		switch typed := value.(type) {
+		case int64:
+			return typed
+		case float64:
+			return int64(typed)
+		case json.Number:
+			if i64, err := typed.Int64(); err == nil {
+				return i64
+			}
+		}
+	case "number":
+		switch typed := value.(type) {
+		case int64:
+			return float64(typed)
+		case float64:
+			return typed
+		case json.Number:
+			if f, err := typed.Float64(); err == nil {
+				return f
+			}
+		}
+	case "boolean":
+		if b, ok := value.(bool); ok {
+			return b
+		}
+	case "string":
+		if s, ok := value.(string); ok {
+			return s
+		}
+	case "date":
+		if typed, ok := value.(string); ok {
+			var timestamp metav1.Time
+			if err := timestamp.UnmarshalQueryParameter(typed); err != nil {
+				return "<invalid>"
+			}
+			if timestamp.IsZero() {
+				return "<unknown>"
+			}
+			return duration.HumanDuration(time.Since(timestamp.Time))
+		}
+	}
+	return nil
+}
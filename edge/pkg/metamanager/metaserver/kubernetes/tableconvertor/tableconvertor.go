@@ -0,0 +1,166 @@
+package tableconvertor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/api/legacyscheme"
+	"k8s.io/kubernetes/pkg/printers"
+	printersinternal "k8s.io/kubernetes/pkg/printers/internalversion"
+	printerstorage "k8s.io/kubernetes/pkg/printers/storage"
+)
+
+// CRDGetter get the CustomResourceDefinition of the custom resource
+type CRDGetter func(ctx context.Context, gr schema.GroupResource) (*apiextensionsv1.CustomResourceDefinition, error)
+
+// TableConvertor converts the unstructured objects served by metaserver to tables, it prints
+// the same columns as kube-apiserver does:
+// 1. for built-in types, the objects are converted to internal types and printed by kubernetes printers
+// 2. for custom resources, the additionalPrinterColumns of CRD are printed
+// 3. otherwise only name and creation timestamp are printed
+type TableConvertor struct {
+	builtin   rest.TableConvertor
+	getCRD    CRDGetter
+	crdTables sync.Map // key: name/version of crd, value: *crdTable
+}
+
+// crdTable is the convertor built from the CRD of uid and resourceVersion, it is replaced
+// once the CRD is changed, so that there is at most one convertor for each version of CRD
+type crdTable struct {
+	uid             string
+	resourceVersion string
+	convertor       rest.TableConvertor
+}
+
+// New returns a TableConvertor, getCRD is used to find the printer columns of custom resources
+func New(getCRD CRDGetter) *TableConvertor {
+	return &TableConvertor{
+		builtin: printerstorage.TableConvertor{TableGenerator: printers.NewTableGenerator().With(printersinternal.AddHandlers)},
+		getCRD:  getCRD,
+	}
+}
+
+func (c *TableConvertor) ConvertToTable(ctx context.Context, obj runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
+	gvk := objectKind(obj)
+	if legacyscheme.Scheme.Recognizes(gvk) {
+		table, err := c.convertBuiltin(ctx, gvk, obj, tableOptions)
+		if err == nil {
+			return table, nil
+		}
+		klog.Warningf("[metaserver/tableconvertor] failed to print %v, fall back to default columns: %v", gvk, err)
+	} else if convertor := c.crdConvertor(ctx, gvk); convertor != nil {
+		return convertor.ConvertToTable(ctx, obj, tableOptions)
+	}
+
+	gr := schema.GroupResource{Group: gvk.Group}
+	if info, ok := apirequest.RequestInfoFrom(ctx); ok {
+		gr = schema.GroupResource{Group: info.APIGroup, Resource: info.Resource}
+	}
+	return rest.NewDefaultTableConvertor(gr).ConvertToTable(ctx, obj, tableOptions)
+}
+
+// convertBuiltin converts obj to internal type and prints it with kubernetes printers
+func (c *TableConvertor) convertBuiltin(ctx context.Context, gvk schema.GroupVersionKind, obj runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
+	unstr, ok := obj.(runtime.Unstructured)
+	if !ok {
+		return c.builtin.ConvertToTable(ctx, obj, tableOptions)
+	}
+
+	typedGVK := gvk
+	if meta.IsListType(obj) {
+		typedGVK.Kind += "List"
+	}
+	typed, err := legacyscheme.Scheme.New(typedGVK)
+	if err != nil {
+		return nil, err
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstr.UnstructuredContent(), typed); err != nil {
+		return nil, err
+	}
+	internal, err := legacyscheme.Scheme.ConvertToVersion(typed, schema.GroupVersion{Group: gvk.Group, Version: runtime.APIVersionInternal})
+	if err != nil {
+		return nil, err
+	}
+	table, err := c.builtin.ConvertToTable(ctx, internal, tableOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	// the rows refer to internal objects, which can not be serialized to client,
+	// so replace them with the original objects
+	var items []runtime.Object
+	if meta.IsListType(obj) {
+		items, err = meta.ExtractList(obj)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		items = []runtime.Object{obj}
+	}
+	if len(items) != len(table.Rows) {
+		return nil, fmt.Errorf("got %d rows for %d objects", len(table.Rows), len(items))
+	}
+	for i := range table.Rows {
+		table.Rows[i].Object = runtime.RawExtension{Object: items[i]}
+	}
+	return table, nil
+}
+
+// crdConvertor returns the convertor built from the additionalPrinterColumns of CRD,
+// nil is returned if the CRD is not found
+func (c *TableConvertor) crdConvertor(ctx context.Context, gvk schema.GroupVersionKind) rest.TableConvertor {
+	info, ok := apirequest.RequestInfoFrom(ctx)
+	if !ok || c.getCRD == nil || gvk.Group == "" {
+		return nil
+	}
+	crd, err := c.getCRD(ctx, schema.GroupResource{Group: info.APIGroup, Resource: info.Resource})
+	if err != nil {
+		klog.V(4).Infof("[metaserver/tableconvertor] failed to get crd of %v: %v", gvk, err)
+		return nil
+	}
+
+	key := fmt.Sprintf("%s/%s", crd.Name, gvk.Version)
+	if v, ok := c.crdTables.Load(key); ok {
+		table := v.(*crdTable)
+		if table.uid == string(crd.UID) && table.resourceVersion == crd.ResourceVersion {
+			return table.convertor
+		}
+	}
+	for _, version := range crd.Spec.Versions {
+		if version.Name != gvk.Version {
+			continue
+		}
+		convertor, err := newCRDConvertor(version.AdditionalPrinterColumns)
+		if err != nil {
+			klog.Errorf("[metaserver/tableconvertor] invalid printer columns of crd %v: %v", crd.Name, err)
+			return nil
+		}
+		c.crdTables.Store(key, &crdTable{uid: string(crd.UID), resourceVersion: crd.ResourceVersion, convertor: convertor})
+		return convertor
+	}
+	return nil
+}
+
+// objectKind returns the kind of obj, or the kind of items if obj is a list
+func objectKind(obj runtime.Object) schema.GroupVersionKind {
+	if list, ok := obj.(*unstructured.UnstructuredList); ok {
+		if len(list.Items) > 0 {
+			return list.Items[0].GroupVersionKind()
+		}
+		gvk := list.GroupVersionKind()
+		gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+		return gvk
+	}
+	return obj.GetObjectKind().GroupVersionKind()
+}
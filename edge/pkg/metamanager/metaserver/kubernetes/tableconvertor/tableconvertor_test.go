@@ -0,0 +1,167 @@
+package tableconvertor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func newObj(apiVersion, kind, name string) *unstructured.Unstructured {
+	obj := new(unstructured.Unstructured)
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace("default")
+	obj.SetName(name)
+	return obj
+}
+
+func newCRD() *apiextensionsv1.CustomResourceDefinition {
+	return &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "devices.devices.kubeedge.io", UID: "uid", ResourceVersion: "1"},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: "devices.kubeedge.io",
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{{
+				Name: "v1alpha2",
+				AdditionalPrinterColumns: []apiextensionsv1.CustomResourceColumnDefinition{
+					{Name: "Model", Type: "string", JSONPath: ".spec.deviceModelRef.name"},
+					{Name: "Replicas", Type: "integer", JSONPath: ".spec.replicas", Priority: 1},
+				},
+			}},
+		},
+	}
+}
+
+func columnNames(defs []metav1.TableColumnDefinition) []string {
+	var names []string
+	for _, def := range defs {
+		names = append(names, def.Name)
+	}
+	return names
+}
+
+func TestConvertBuiltin(t *testing.T) {
+	pod := newObj("v1", "Pod", "pod")
+	pod.Object["spec"] = map[string]interface{}{"nodeName": "edge-node"}
+	pod.Object["status"] = map[string]interface{}{"phase": "Running"}
+	list := new(unstructured.UnstructuredList)
+	list.SetAPIVersion("v1")
+	list.SetKind("PodList")
+	list.Items = []unstructured.Unstructured{*pod, *pod}
+
+	ctx := apirequest.WithRequestInfo(context.TODO(), &apirequest.RequestInfo{APIVersion: "v1", Resource: "pods"})
+	c := New(nil)
+	for _, obj := range []runtime.Object{pod, list} {
+		table, err := c.ConvertToTable(ctx, obj, &metav1.TableOptions{})
+		if err != nil {
+			t.Fatalf("failed to convert %T to table: %v", obj, err)
+		}
+		names := columnNames(table.ColumnDefinitions)
+		if fmt.Sprint(names[:3]) != "[Name Ready Status]" {
+			t.Errorf("unexpected columns %v", names)
+		}
+		for _, row := range table.Rows {
+			if row.Cells[2] != "Running" {
+				t.Errorf("expected status Running, but got %v", row.Cells[2])
+			}
+			if _, ok := row.Object.Object.(*unstructured.Unstructured); !ok {
+				t.Errorf("expected unstructured object in row, but got %T", row.Object.Object)
+			}
+		}
+	}
+}
+
+func TestConvertCustomResource(t *testing.T) {
+	device := newObj("devices.kubeedge.io/v1alpha2", "Device", "device")
+	device.Object["spec"] = map[string]interface{}{
+		"deviceModelRef": map[string]interface{}{"name": "sensor"},
+		"replicas":       int64(2),
+	}
+	ctx := apirequest.WithRequestInfo(context.TODO(), &apirequest.RequestInfo{
+		APIGroup: "devices.kubeedge.io", APIVersion: "v1alpha2", Resource: "devices"})
+
+	c := New(func(ctx context.Context, gr schema.GroupResource) (*apiextensionsv1.CustomResourceDefinition, error) {
+		return newCRD(), nil
+	})
+	table, err := c.ConvertToTable(ctx, device, &metav1.TableOptions{})
+	if err != nil {
+		t.Fatalf("failed to convert to table: %v", err)
+	}
+	if names := columnNames(table.ColumnDefinitions); fmt.Sprint(names) != "[Name Model Replicas]" {
+		t.Errorf("unexpected columns %v", names)
+	}
+	if fmt.Sprint(table.Rows[0].Cells) != "[device sensor 2]" {
+		t.Errorf("unexpected cells %v", table.Rows[0].Cells)
+	}
+
+	// the Age column is printed if the crd has no printer columns
+	c = New(func(ctx context.Context, gr schema.GroupResource) (*apiextensionsv1.CustomResourceDefinition, error) {
+		crd := newCRD()
+		crd.Spec.Versions[0].AdditionalPrinterColumns = nil
+		return crd, nil
+	})
+	device.SetCreationTimestamp(metav1.NewTime(time.Now().Add(-2 * time.Hour)))
+	table, err = c.ConvertToTable(ctx, device, &metav1.TableOptions{})
+	if err != nil {
+		t.Fatalf("failed to convert to table: %v", err)
+	}
+	if names := columnNames(table.ColumnDefinitions); fmt.Sprint(names) != "[Name Age]" {
+		t.Errorf("unexpected columns %v", names)
+	}
+	if fmt.Sprint(table.Rows[0].Cells) != "[device 120m]" {
+		t.Errorf("unexpected cells %v", table.Rows[0].Cells)
+	}
+
+	// fall back to default columns if crd is not found
+	c = New(func(ctx context.Context, gr schema.GroupResource) (*apiextensionsv1.CustomResourceDefinition, error) {
+		return nil, fmt.Errorf("not found")
+	})
+	table, err = c.ConvertToTable(ctx, device, &metav1.TableOptions{})
+	if err != nil {
+		t.Fatalf("failed to convert to table: %v", err)
+	}
+	if names := columnNames(table.ColumnDefinitions); fmt.Sprint(names) != "[Name Created At]" {
+		t.Errorf("unexpected columns %v", names)
+	}
+}
+
+func TestCRDConvertorReplaced(t *testing.T) {
+	device := newObj("devices.kubeedge.io/v1alpha2", "Device", "device")
+	ctx := apirequest.WithRequestInfo(context.TODO(), &apirequest.RequestInfo{
+		APIGroup: "devices.kubeedge.io", APIVersion: "v1alpha2", Resource: "devices"})
+
+	crd := newCRD()
+	c := New(func(ctx context.Context, gr schema.GroupResource) (*apiextensionsv1.CustomResourceDefinition, error) {
+		return crd, nil
+	})
+	for rv := 1; rv <= 3; rv++ {
+		crd = newCRD()
+		crd.ResourceVersion = fmt.Sprint(rv)
+		crd.Spec.Versions[0].AdditionalPrinterColumns = crd.Spec.Versions[0].AdditionalPrinterColumns[:rv%2+1]
+		table, err := c.ConvertToTable(ctx, device, &metav1.TableOptions{})
+		if err != nil {
+			t.Fatalf("failed to convert to table: %v", err)
+		}
+		// the columns follow the crd changed
+		if len(table.ColumnDefinitions) != rv%2+2 {
+			t.Errorf("expected %d columns of resource version %d, but got %v", rv%2+2, rv, columnNames(table.ColumnDefinitions))
+		}
+	}
+
+	// only the convertor of the latest crd is kept
+	count := 0
+	c.crdTables.Range(func(key, value interface{}) bool {
+		count++
+		return true
+	})
+	if count != 1 {
+		t.Errorf("expected 1 convertor, but got %d", count)
+	}
+}
@@ -6,8 +6,10 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -22,18 +24,43 @@ import (
 	"k8s.io/apiserver/pkg/server"
 	genericfilters "k8s.io/apiserver/pkg/server/filters"
 	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/component-base/metrics/legacyregistry"
 	"k8s.io/klog/v2"
 
 	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
 	commontypes "github.com/kubeedge/kubeedge/common/types"
 	"github.com/kubeedge/kubeedge/edge/pkg/common/modules"
 	"github.com/kubeedge/kubeedge/edge/pkg/edgehub"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/auth"
 	metaserverconfig "github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/config"
 	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/handlerfactory"
 	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/kubernetes/serializer"
 	kefeatures "github.com/kubeedge/kubeedge/pkg/features"
 )
 
+// nonResourcePaths are the non-resource paths served by metaserver, which are required by
+// clients such as kubectl and controller-runtime before accessing resources
+var nonResourcePaths = []string{"/api", "/apis", "/version", "/openapi/v2", "/openapi/v3"}
+
+func isNonResourcePath(path string) bool {
+	for _, p := range nonResourcePaths {
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// isLoopbackRequest reports whether req comes from the loopback address
+func isLoopbackRequest(req *http.Request) bool {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
 // MetaServer is simplification of server.GenericAPIServer
 type MetaServer struct {
 	HandlerChainWaitGroup *utilwaitgroup.SafeWaitGroup
@@ -196,6 +223,20 @@ func (ls *MetaServer) BuildBasicHandler() http.Handler {
 			}
 			return
 		}
+		if ok && reqInfo.Verb == "get" && reqInfo.Path == "/metrics" {
+			// the metrics are not authorized, so they are only served to the clients on this host
+			if !isLoopbackRequest(req) {
+				err := fmt.Errorf("metrics are only served to loopback clients")
+				responsewriters.ErrorNegotiated(errors.NewForbidden(schema.GroupResource{}, reqInfo.Path, err), ls.NegotiatedSerializer, schema.GroupVersion{}, w, req)
+				return
+			}
+			legacyregistry.Handler().ServeHTTP(w, req)
+			return
+		}
+		if ok && reqInfo.Verb == "get" && isNonResourcePath(reqInfo.Path) {
+			ls.Factory.NonResource().ServeHTTP(w, req)
+			return
+		}
 
 		err := fmt.Errorf("not a resource req")
 		responsewriters.ErrorNegotiated(errors.NewInternalError(err), ls.NegotiatedSerializer, schema.GroupVersion{}, w, req)
@@ -217,7 +258,14 @@ func BuildHandlerChain(handler http.Handler, ls *MetaServer) http.Handler {
 func WithAuthorizationHeader(handler http.Handler) http.Handler {
 	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
 		token := request.Header.Get(commontypes.AuthorizationKey)
-		request = request.WithContext(context.WithValue(request.Context(), commontypes.AuthorizationKey, token))
+		ctx := context.WithValue(request.Context(), commontypes.AuthorizationKey, token)
+		if kefeatures.DefaultFeatureGate.Enabled(kefeatures.RequireAuthorization) {
+			// the user is used to authorize the request at edge when cloud is unreachable
+			if u, ok := auth.AuthenticateRequest(request); ok {
+				ctx = apirequest.WithUser(ctx, u)
+			}
+		}
+		request = request.WithContext(ctx)
 		handler.ServeHTTP(writer, request)
 	})
 }
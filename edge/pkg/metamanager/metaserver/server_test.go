@@ -0,0 +1,24 @@
+package metaserver
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestIsLoopbackRequest(t *testing.T) {
+	cases := []struct {
+		remoteAddr string
+		want       bool
+	}{
+		{remoteAddr: "127.0.0.1:34567", want: true},
+		{remoteAddr: "[::1]:34567", want: true},
+		{remoteAddr: "169.254.30.10:34567", want: false},
+		{remoteAddr: "192.168.1.10:34567", want: false},
+		{remoteAddr: "invalid", want: false},
+	}
+	for _, c := range cases {
+		if got := isLoopbackRequest(&http.Request{RemoteAddr: c.remoteAddr}); got != c.want {
+			t.Errorf("isLoopbackRequest(%v) = %v, want %v", c.remoteAddr, got, c.want)
+		}
+	}
+}
@@ -8,6 +8,9 @@ import (
 
 	authenticationv1 "k8s.io/api/authentication/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/klog/v2"
 
 	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
@@ -20,6 +23,7 @@ import (
 	metaManagerConfig "github.com/kubeedge/kubeedge/edge/pkg/metamanager/config"
 	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
 	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/metaserver/kubernetes/storage/sqlite/imitator"
+	"github.com/kubeedge/kubeedge/pkg/metaserver/util"
 )
 
 //Constants to check metamanager processes
@@ -120,6 +124,9 @@ func (m *metaManager) processInsert(message model.Message) {
 		feedbackError(err, "Error to save meta to DB", message)
 		return
 	}
+	if message.GetSource() != modules.EdgedModuleName {
+		remoteCache.touch(resKey)
+	}
 
 	if (resType == model.ResourceTypeNode || resType == model.ResourceTypeLease) && message.GetSource() == modules.EdgedModuleName {
 		sendToCloud(&message)
@@ -163,6 +170,9 @@ func (m *metaManager) processUpdate(message model.Message) {
 	}
 
 	msgSource := message.GetSource()
+	if msgSource != modules.EdgedModuleName {
+		remoteCache.touch(resKey)
+	}
 	switch msgSource {
 	case modules.EdgedModuleName:
 		sendToCloud(&message)
@@ -270,6 +280,7 @@ func (m *metaManager) processDelete(message model.Message) {
 			return
 		}
 	}
+	remoteCache.forget(message.GetResource())
 
 	msgSource := message.GetSource()
 	if msgSource == cloudmodules.DeviceControllerModuleName {
@@ -383,6 +394,10 @@ func (m *metaManager) processQuery(message model.Message) {
 			return
 		}
 		metas, err = dao.QueryMeta("key", resKey)
+		if err == nil && remoteCache.enabled(resType) {
+			m.processCachedQuery(message, resType, resKey, *metas)
+			return
+		}
 		if err != nil || len(*metas) == 0 || resType == model.ResourceTypeNode || resType == constants.ResourceTypeVolumeAttachment || resType == model.ResourceTypeLease {
 			m.processRemoteQuery(message)
 		} else {
@@ -409,6 +424,26 @@ func (m *metaManager) processQuery(message model.Message) {
 	}
 }
 
+// processCachedQuery answers the query of resources which require remote query by the cache policy of resType,
+// metas is the resource of resKey in local meta
+func (m *metaManager) processCachedQuery(message model.Message, resType, resKey string, metas []string) {
+	switch remoteCache.lookup(resType, resKey, len(metas) != 0) {
+	case cacheMiss:
+		m.processRemoteQuery(message)
+		return
+	case cacheNegativeHit:
+		sendToEdged(notFoundResponse(message), message.IsSync())
+		return
+	case cacheStale:
+		if remoteCache.startRevalidate(resKey) {
+			m.revalidate(message, resKey)
+		}
+	}
+	resp := message.NewRespByMessage(&message, metas)
+	resp.SetRoute(modules.MetaManagerModuleName, resp.GetGroup())
+	sendToEdged(resp, message.IsSync())
+}
+
 func (m *metaManager) processRemoteQuery(message model.Message) {
 	go func() {
 		// TODO: retry
@@ -425,35 +460,101 @@ func (m *metaManager) processRemoteQuery(message model.Message) {
 		}
 
 		klog.V(4).Infof("process remote query: req[%s], resp[%s]", msgDebugInfo(&message), msgDebugInfo(&resp))
-		content, err := resp.GetContentData()
-		if err != nil {
+		if err := saveRemoteQueryResponse(message, resp); err != nil {
 			klog.Errorf("get remote query response content data failed, %s", msgDebugInfo(&resp))
 			feedbackError(err, "Error to get remote query response message content data", message)
 			return
 		}
+		if _, resType, _ := parseResource(message.GetResource()); remoteCache.enabled(resType) && isNotFoundResponse(resp) {
+			// cloud answers the reason in text, it is answered as the status the client can decode
+			resp = *notFoundResponse(message)
+		}
+		resp.BuildHeader(resp.GetID(), originalID, resp.GetTimestamp())
 
-		resKey, resType, _ := parseResource(message.GetResource())
-		resKey, err = getSpecialResourceKey(resType, resKey, message)
+		sendToEdged(&resp, message.IsSync())
+
+		if resp.GetOperation() != model.ResponseErrorOperation {
+			respToCloud := message.NewRespByMessage(&resp, OK)
+			sendToCloud(respToCloud)
+		}
+	}()
+}
+
+// revalidate queries the resource of resKey from cloud in background, the local meta is updated
+// without notifying edged since edged has been answered by the stale one
+func (m *metaManager) revalidate(message model.Message, resKey string) {
+	go func() {
+		defer remoteCache.finishRevalidate(resKey)
+		message.UpdateID()
+		resp, err := beehiveContext.SendSync(
+			string(metaManagerConfig.Config.ContextSendModule),
+			message,
+			time.Duration(metaManagerConfig.Config.RemoteQueryTimeout)*time.Second)
 		if err != nil {
-			klog.Errorf("get remote query response content data failed, %s", msgDebugInfo(&resp))
-			feedbackError(err, "Error to get remote query response message content data", message)
+			klog.Warningf("revalidate %s failed: %v", resKey, err)
 			return
 		}
-		meta := &dao.Meta{
-			Key:   resKey,
-			Type:  resType,
-			Value: string(content)}
-		err = dao.InsertOrUpdate(meta)
-		if err != nil {
-			klog.Errorf("update meta failed, %s", msgDebugInfo(&resp))
+		if err := saveRemoteQueryResponse(message, resp); err != nil {
+			klog.Warningf("revalidate %s failed: %v", resKey, err)
+			return
 		}
-		resp.BuildHeader(resp.GetID(), originalID, resp.GetTimestamp())
+		if resp.GetOperation() != model.ResponseErrorOperation {
+			respToCloud := message.NewRespByMessage(&resp, OK)
+			sendToCloud(respToCloud)
+		}
+	}()
+}
 
-		sendToEdged(&resp, message.IsSync())
+// saveRemoteQueryResponse saves the resource in the response of remote query to local meta, the resource
+// is removed from local meta if it is answered as not found by cloud and resType has a cache policy
+func saveRemoteQueryResponse(message, resp model.Message) error {
+	resKey, resType, _ := parseResource(message.GetResource())
+	resKey, err := getSpecialResourceKey(resType, resKey, message)
+	if err != nil {
+		return err
+	}
+	if resp.GetOperation() == model.ResponseErrorOperation {
+		if remoteCache.enabled(resType) && isNotFoundResponse(resp) {
+			remoteCache.setNotFound(resKey)
+			if err := dao.DeleteMetaByKey(resKey); err != nil {
+				klog.Errorf("delete meta %s not found in cloud failed: %v", resKey, err)
+			}
+		}
+		return nil
+	}
 
-		respToCloud := message.NewRespByMessage(&resp, OK)
-		sendToCloud(respToCloud)
-	}()
+	content, err := resp.GetContentData()
+	if err != nil {
+		return err
+	}
+	meta := &dao.Meta{
+		Key:   resKey,
+		Type:  resType,
+		Value: string(content)}
+	err = dao.InsertOrUpdate(meta)
+	if err != nil {
+		klog.Errorf("update meta failed, %s", msgDebugInfo(&resp))
+		return nil
+	}
+	remoteCache.touch(resKey)
+	return nil
+}
+
+// isNotFoundResponse reports whether resp is the answer of cloud that the queried resource is not found
+func isNotFoundResponse(resp model.Message) bool {
+	content, ok := resp.GetContent().(string)
+	return ok && strings.HasPrefix(content, string(metav1.StatusReasonNotFound))
+}
+
+// notFoundResponse answers the query of message with the NotFound status,
+// so that the client can check it by apierrors.IsNotFound
+func notFoundResponse(message model.Message) *model.Message {
+	_, resType, resID := parseResource(message.GetResource())
+	status := apierrors.NewNotFound(schema.GroupResource{Resource: util.UnsafeKindToResource(resType)}, resID).Status()
+	resp := message.NewRespByMessage(&message, status)
+	resp.SetResourceOperation(message.GetResource(), model.ResponseErrorOperation)
+	resp.SetRoute(modules.MetaManagerModuleName, resp.GetGroup())
+	return resp
 }
 
 func (m *metaManager) processNodeConnection(message model.Message) {
@@ -466,6 +567,17 @@ func (m *metaManager) processNodeConnection(message model.Message) {
 	}
 }
 
+// processBackpressure logs the state of the outbound queue, the messages of metamanager are
+// coalesced by resource in the queue, so they are not held here
+func (m *metaManager) processBackpressure(message model.Message) {
+	content, _ := message.GetContent().(string)
+	if content == messagepkg.OutboundQueueFull {
+		klog.Warningf("outbound queue is full, messages of %s to cloud are rejected", message.GetResource())
+		return
+	}
+	klog.Infof("outbound queue event occur: %s", content)
+}
+
 func (m *metaManager) processFunctionAction(message model.Message) {
 	content, err := message.GetContentData()
 	if err != nil {
@@ -543,6 +655,8 @@ func (m *metaManager) process(message model.Message) {
 		m.processResponse(message)
 	case messagepkg.OperationNodeConnection:
 		m.processNodeConnection(message)
+	case messagepkg.OperationOutboundQueue:
+		m.processBackpressure(message)
 	case OperationFunctionAction:
 		m.processFunctionAction(message)
 	case OperationFunctionActionResult:
@@ -24,6 +24,8 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/kubeedge/beehive/pkg/common"
 	"github.com/kubeedge/beehive/pkg/core"
@@ -764,3 +766,27 @@ func TestProcessFunctionActionResult(t *testing.T) {
 		}
 	})
 }
+
+// TestNotFoundResponse tests the query answered as not found by cloud is responded with the NotFound status
+func TestNotFoundResponse(t *testing.T) {
+	query := model.NewMessage("").BuildRouter(ModuleNameEdged, GroupResource, "default/"+model.ResourceTypeConfigmap+"/cm1", model.QueryOperation)
+	cloudResp := model.NewMessage(query.GetID()).
+		BuildRouter(ModuleNameController, GroupResource, "node/edge-1/default/configmap/cm1", model.ResponseErrorOperation).
+		FillBody(`NotFound: configmaps "cm1" not found`)
+	if !isNotFoundResponse(*cloudResp) {
+		t.Fatalf("expected the response of cloud is not found")
+	}
+
+	resp := notFoundResponse(*query)
+	if resp.GetOperation() != model.ResponseErrorOperation || resp.GetParentID() != query.GetID() {
+		t.Errorf("unexpected response %+v", resp.Header)
+	}
+	status, ok := resp.GetContent().(metav1.Status)
+	if !ok {
+		t.Fatalf("expected the status responded, but got %T", resp.GetContent())
+	}
+	err := &apierrors.StatusError{ErrStatus: status}
+	if !apierrors.IsNotFound(err) || err.Error() != `configmaps "cm1" not found` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
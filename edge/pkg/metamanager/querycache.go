@@ -0,0 +1,162 @@
+package metamanager
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha2"
+)
+
+// results of looking up the remote query cache
+const (
+	// cacheHit means the local meta is younger than TTL and served without querying cloud
+	cacheHit = "hit"
+	// cacheStale means the local meta is older than TTL, it is served and revalidated in background
+	cacheStale = "stale"
+	// cacheNegativeHit means the resource was not found in cloud within NegativeTTL
+	cacheNegativeHit = "negative_hit"
+	// cacheMiss means the resource is queried from cloud before responding
+	cacheMiss = "miss"
+)
+
+var (
+	remoteQueryCacheRequests = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      "metamanager",
+			Name:           "remote_query_cache_requests_total",
+			Help:           "Number of the queries of resources which require remote query, partitioned by resource type and cache result.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"resource", "result"},
+	)
+	registerMetricsOnce sync.Once
+)
+
+// remoteCache is nil if the remote query cache is disabled
+var remoteCache *remoteQueryCache
+
+// remoteQueryCache records when the resources in local meta were fetched from cloud and which
+// resources were not found in cloud, so that the queries can be answered locally by the policies
+type remoteQueryCache struct {
+	lock     sync.Mutex
+	policies map[string]v1alpha2.RemoteQueryCachePolicy
+	// fetched is the time the resource of key was fetched from cloud
+	fetched map[string]time.Time
+	// notFound is the time the resource of key was answered as not found by cloud
+	notFound map[string]time.Time
+	// revalidating is the keys being revalidated in background
+	revalidating map[string]bool
+	now          func() time.Time
+}
+
+func newRemoteQueryCache(c *v1alpha2.RemoteQueryCache) *remoteQueryCache {
+	if c == nil || !c.Enable {
+		return nil
+	}
+	registerMetricsOnce.Do(func() {
+		legacyregistry.MustRegister(remoteQueryCacheRequests)
+	})
+	cache := &remoteQueryCache{
+		policies:     make(map[string]v1alpha2.RemoteQueryCachePolicy),
+		fetched:      make(map[string]time.Time),
+		notFound:     make(map[string]time.Time),
+		revalidating: make(map[string]bool),
+		now:          time.Now,
+	}
+	for _, p := range c.Policies {
+		cache.policies[strings.ToLower(p.ResourceType)] = p
+	}
+	return cache
+}
+
+// enabled reports whether resType has a cache policy
+func (c *remoteQueryCache) enabled(resType string) bool {
+	if c == nil {
+		return false
+	}
+	_, ok := c.policies[resType]
+	return ok
+}
+
+// lookup returns the cache result of the resource of key, found indicates whether it is in local meta
+func (c *remoteQueryCache) lookup(resType, key string, found bool) string {
+	p := c.policies[resType]
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	result := cacheMiss
+	switch {
+	case found:
+		if t, ok := c.fetched[key]; ok && c.now().Sub(t) < p.TTL.Duration {
+			result = cacheHit
+		} else {
+			// the resources in local meta are served even if stale, which was the behavior without cache
+			result = cacheStale
+		}
+	case p.NegativeTTL.Duration > 0:
+		if t, ok := c.notFound[key]; ok {
+			if c.now().Sub(t) < p.NegativeTTL.Duration {
+				result = cacheNegativeHit
+			} else {
+				delete(c.notFound, key)
+			}
+		}
+	}
+	remoteQueryCacheRequests.WithLabelValues(resType, result).Inc()
+	return result
+}
+
+// startRevalidate reports whether the caller should revalidate the resource of key,
+// it is false if the resource is being revalidated by others
+func (c *remoteQueryCache) startRevalidate(key string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.revalidating[key] {
+		return false
+	}
+	c.revalidating[key] = true
+	return true
+}
+
+func (c *remoteQueryCache) finishRevalidate(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.revalidating, key)
+}
+
+// touch records the resource of key is fetched from cloud just now
+func (c *remoteQueryCache) touch(key string) {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.fetched[key] = c.now()
+	delete(c.notFound, key)
+}
+
+// setNotFound records the resource of key is not found in cloud just now
+func (c *remoteQueryCache) setNotFound(key string) {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.notFound[key] = c.now()
+	delete(c.fetched, key)
+}
+
+// forget removes the records of the resource of key
+func (c *remoteQueryCache) forget(key string) {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.fetched, key)
+	delete(c.notFound, key)
+}
@@ -0,0 +1,59 @@
+package metamanager
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha2"
+)
+
+func TestRemoteQueryCache(t *testing.T) {
+	if newRemoteQueryCache(&v1alpha2.RemoteQueryCache{Enable: false}).enabled("configmap") {
+		t.Fatalf("expected no policy when cache is disabled")
+	}
+
+	cache := newRemoteQueryCache(&v1alpha2.RemoteQueryCache{
+		Enable: true,
+		Policies: []v1alpha2.RemoteQueryCachePolicy{{
+			ResourceType: "ConfigMap",
+			TTL:          metav1.Duration{Duration: time.Minute},
+			NegativeTTL:  metav1.Duration{Duration: 10 * time.Second},
+		}},
+	})
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	if !cache.enabled("configmap") || cache.enabled("secret") {
+		t.Fatalf("unexpected policies %v", cache.policies)
+	}
+
+	const key = "default/configmap/a"
+	cases := []struct {
+		name    string
+		prepare func()
+		found   bool
+		want    string
+	}{
+		{"not fetched", func() {}, true, cacheStale},
+		{"fresh", func() { cache.touch(key) }, true, cacheHit},
+		{"expired", func() { now = now.Add(time.Minute) }, true, cacheStale},
+		{"not found", func() { cache.setNotFound(key) }, false, cacheNegativeHit},
+		{"not found expired", func() { now = now.Add(10 * time.Second) }, false, cacheMiss},
+		{"deleted", func() { cache.touch(key); cache.forget(key) }, false, cacheMiss},
+	}
+	for _, c := range cases {
+		c.prepare()
+		if got := cache.lookup("configmap", key, c.found); got != c.want {
+			t.Errorf("%s: expected %s, but got %s", c.name, c.want, got)
+		}
+	}
+
+	if !cache.startRevalidate(key) || cache.startRevalidate(key) {
+		t.Errorf("expected only one revalidation of %s at a time", key)
+	}
+	cache.finishRevalidate(key)
+	if !cache.startRevalidate(key) {
+		t.Errorf("expected revalidation started after the previous one finished")
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dao
+
+import (
+	"github.com/astaxie/beego/orm"
+	"k8s.io/klog/v2"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+)
+
+// urlStore is the storage of target_urls on the backend selected
+type urlStore interface {
+	// insert saves the url, the existing one is replaced
+	insert(url string) error
+	// deleteByKey deletes the url
+	deleteByKey(url string) error
+	// count returns the number of urls
+	count() (int64, error)
+	// get returns the url, orm.ErrNoRows is returned if it does not exist
+	get(url string) (*TargetUrls, error)
+}
+
+// getURLStore returns the storage of the backend selected, the key-value store if it is opened
+// or the sqlite3 database accessed by orm otherwise
+func getURLStore() urlStore {
+	if dbm.KV != nil {
+		return kvURLStore{kv: dbm.KV}
+	}
+	return ormURLStore{}
+}
+
+// ormURLStore is the urlStore on the sqlite3 database accessed by orm
+type ormURLStore struct{}
+
+func (ormURLStore) insert(url string) error {
+	_, err := dbm.DBAccess.Raw("INSERT OR REPLACE INTO target_urls (url) VALUES (?)", url).Exec()
+	klog.V(4).Infof("INSERT result %v", err)
+	return err
+}
+
+func (ormURLStore) deleteByKey(url string) error {
+	num, err := dbm.DBAccess.QueryTable(TargetUrlsName).Filter("url", url).Delete()
+	klog.V(4).Infof("Delete affected Num: %d, %v", num, err)
+	return err
+}
+
+func (ormURLStore) count() (int64, error) {
+	return dbm.DBAccess.QueryTable(TargetUrlsName).Count()
+}
+
+func (ormURLStore) get(url string) (*TargetUrls, error) {
+	targetUrls := new(TargetUrls)
+	if err := dbm.DBAccess.QueryTable(TargetUrlsName).Filter("url", url).One(targetUrls); err != nil {
+		return nil, err
+	}
+	return targetUrls, nil
+}
+
+// kvURLStore is the urlStore on the key-value store, the urls are keyed by themselves
+type kvURLStore struct {
+	kv dbm.KVStore
+}
+
+func (s kvURLStore) insert(url string) error {
+	return s.kv.Save(TargetUrlsName, &TargetUrls{URL: url})
+}
+
+func (s kvURLStore) deleteByKey(url string) error {
+	return s.kv.Delete(TargetUrlsName, &TargetUrls{URL: url})
+}
+
+func (s kvURLStore) count() (int64, error) {
+	return dbm.KVCount(s.kv, TargetUrlsName, new(TargetUrls))
+}
+
+func (s kvURLStore) get(url string) (*TargetUrls, error) {
+	targetUrls := &TargetUrls{URL: url}
+	found, err := s.kv.Get(TargetUrlsName, targetUrls)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, orm.ErrNoRows
+	}
+	return targetUrls, nil
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dao
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/astaxie/beego/orm"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+)
+
+func TestKVURLStore(t *testing.T) {
+	kv, err := dbm.NewBoltStore(filepath.Join(t.TempDir(), "edgecore.bolt"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer kv.Close()
+	s := kvURLStore{kv: kv}
+
+	if count, err := s.count(); err != nil || count != 0 {
+		t.Errorf("expected no urls, but got %d, %v", count, err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := s.insert(testURL.URL); err != nil {
+			t.Fatalf("failed to insert: %v", err)
+		}
+	}
+	if count, err := s.count(); err != nil || count != 1 {
+		t.Errorf("expected 1 url, but got %d, %v", count, err)
+	}
+	if got, err := s.get(testURL.URL); err != nil || got.URL != testURL.URL {
+		t.Errorf("expected url %s, but got %+v, %v", testURL.URL, got, err)
+	}
+	if err := s.deleteByKey(testURL.URL); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if _, err := s.get(testURL.URL); err != orm.ErrNoRows {
+		t.Errorf("expected ErrNoRows, but got %v", err)
+	}
+}
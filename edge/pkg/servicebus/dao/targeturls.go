@@ -15,12 +15,6 @@ limitations under the License.
 */
 package dao
 
-import (
-	"k8s.io/klog/v2"
-
-	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
-)
-
 const (
 	TargetUrlsName = "target_urls"
 )
@@ -31,30 +25,19 @@ type TargetUrls struct {
 
 // InsertUrls insert target_urls
 func InsertUrls(url string) error {
-	_, err := dbm.DBAccess.Raw("INSERT OR REPLACE INTO target_urls (url) VALUES (?)", url).Exec()
-	klog.V(4).Infof("INSERT result %v", err)
-	return err
+	return getURLStore().insert(url)
 }
 
 // DeleteUrlsByKey delete target_urls by key
 func DeleteUrlsByKey(key string) error {
-	num, err := dbm.DBAccess.QueryTable(TargetUrlsName).Filter("url", key).Delete()
-	klog.V(4).Infof("Delete affected Num: %d, %v", num, err)
-	return err
+	return getURLStore().deleteByKey(key)
 }
 
 func IsTableEmpty() bool {
-	var count int64
-	if count, _ = dbm.DBAccess.QueryTable(TargetUrlsName).Count(); count > 0 {
-		return false
-	}
-	return true
+	count, _ := getURLStore().count()
+	return count == 0
 }
 
 func GetUrlsByKey(key string) (result *TargetUrls, err error) {
-	targetUrls := new(TargetUrls)
-	if err := dbm.DBAccess.QueryTable(TargetUrlsName).Filter("url", key).One(targetUrls); err != nil {
-		return nil, err
-	}
-	return targetUrls, nil
+	return getURLStore().get(key)
 }
@@ -3,6 +3,7 @@ package cloud
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -10,6 +11,7 @@ import (
 	"github.com/kubeedge/kubeedge/common/constants"
 	"github.com/kubeedge/kubeedge/keadm/cmd/keadm/app/cmd/common"
 	"github.com/kubeedge/kubeedge/keadm/cmd/keadm/app/cmd/util"
+	"github.com/kubeedge/kubeedge/pkg/util/bootstraptoken"
 )
 
 var (
@@ -17,11 +19,19 @@ var (
 "keadm gettoken" command prints the token to use for establishing bidirectional trust between edge nodes and cloudcore.
 A token can be used when a edge node is about to join the cluster. With this token the cloudcore then approve the
 certificate request.
+With the node-name flag, a bootstrap token scoped to the node name or the shell pattern of node names is created
+instead of printing the cluster token, it expires after the ttl and enrolls at most usage-limit nodes.
 `
 	gettokenExample = `
 keadm gettoken --kube-config /root/.kube/config
 - kube-config is the absolute path of kubeconfig which used to build secure connectivity between keadm and kube-apiserver
 to get the token.
+
+keadm gettoken --node-name edge-node-1 --ttl 24h --usage-limit 1
+- create a single-use bootstrap token for the node edge-node-1 which expires in 24 hours
+
+keadm gettoken --node-name "factory-a-*" --ttl 168h --usage-limit 50
+- create a bootstrap token for up to 50 nodes whose names start with factory-a-
 `
 )
 
@@ -35,6 +45,14 @@ func NewGettoken() *cobra.Command {
 		Long:    gettokenLongDescription,
 		Example: gettokenExample,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if init.NodeName != "" {
+				token, err := createBootstrapToken(init)
+				if err != nil {
+					fmt.Printf("failed to create bootstrap token, err is %s\n", err)
+					return err
+				}
+				return showToken(token)
+			}
 			token, err := queryToken(constants.SystemNamespace, common.TokenSecretName, init.Kubeconfig)
 			if err != nil {
 				fmt.Printf("failed to get token, err is %s\n", err)
@@ -50,12 +68,20 @@ func NewGettoken() *cobra.Command {
 func addGettokenFlags(cmd *cobra.Command, gettokenOptions *common.GettokenOptions) {
 	cmd.Flags().StringVar(&gettokenOptions.Kubeconfig, common.KubeConfig, gettokenOptions.Kubeconfig,
 		"Use this key to set kube-config path, eg: $HOME/.kube/config")
+	cmd.Flags().StringVar(&gettokenOptions.NodeName, "node-name", gettokenOptions.NodeName,
+		"Use this key to create a bootstrap token scoped to the node name or the shell pattern of node names")
+	cmd.Flags().DurationVar(&gettokenOptions.TTL, "ttl", gettokenOptions.TTL,
+		"Use this key to set the duration before the bootstrap token expires, 0 never expires")
+	cmd.Flags().IntVar(&gettokenOptions.UsageLimit, "usage-limit", gettokenOptions.UsageLimit,
+		"Use this key to set the max number of nodes enrolled by the bootstrap token, 0 is unlimited")
 }
 
 // newGettokenOptions return common options
 func newGettokenOptions() *common.GettokenOptions {
 	opts := &common.GettokenOptions{}
 	opts.Kubeconfig = common.DefaultKubeConfig
+	opts.TTL = 24 * time.Hour
+	opts.UsageLimit = 1
 	return opts
 }
 
@@ -80,3 +106,32 @@ func showToken(data []byte) error {
 	}
 	return nil
 }
+
+// createBootstrapToken creates the bootstrap token secret and returns the token signed by the CA key
+func createBootstrapToken(opts *common.GettokenOptions) ([]byte, error) {
+	client, err := util.KubeClient(opts.Kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	caSecret, err := client.CoreV1().Secrets(constants.SystemNamespace).Get(context.Background(), common.CaSecretName, metaV1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := bootstraptoken.New(opts.NodeName, opts.TTL, opts.UsageLimit)
+	if err != nil {
+		return nil, err
+	}
+	tokenString, err := token.Sign(caSecret.Data[common.CaDataName], caSecret.Data[common.CaKeyDataName])
+	if err != nil {
+		return nil, err
+	}
+	secret, err := token.ToSecret(nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := client.CoreV1().Secrets(constants.SystemNamespace).Create(context.Background(), secret, metaV1.CreateOptions{}); err != nil {
+		return nil, err
+	}
+	return []byte(tokenString), nil
+}
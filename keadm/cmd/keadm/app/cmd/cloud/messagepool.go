@@ -0,0 +1,216 @@
+package cloud
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/kubeedge/kubeedge/common/constants"
+	"github.com/kubeedge/kubeedge/keadm/cmd/keadm/app/cmd/common"
+	"github.com/kubeedge/kubeedge/pkg/hubadmin"
+)
+
+var (
+	messagePoolLongDescription = `
+"keadm messagepool" command shows the sessions and the message pools of the edge nodes connected to a cloudcore,
+and purges or re-sends the messages held for a node. The requests are sent to the CloudHub https server of the
+cloudcore with the bearer token credentials of the kubeconfig, such as the token of a service account or the token
+of an exec plugin. CloudHub authenticates the token with TokenReview and authorizes the user on the request path
+with SubjectAccessReview, so the user must be allowed the non-resource URLs "/admin/*" with the verbs "get" and "post".
+With multiple cloudcore replicas, each replica holds the message pools of the nodes connected to it.
+`
+	messagePoolExample = `
+keadm messagepool list --cloudcore-ipport 10.10.102.78:10002 --kube-config /root/.kube/config
+- cloudcore-ipport is the address of the CloudHub https server
+- list the connected nodes with the session states, the keepalive ages and the queue depths
+
+keadm messagepool messages edge-node-1 --cloudcore-ipport 10.10.102.78:10002
+- show the messages held for the node edge-node-1 by resource and operation
+
+keadm messagepool resend edge-node-1 --cloudcore-ipport 10.10.102.78:10002
+- queue the messages held for the node edge-node-1 to send again
+
+keadm messagepool purge edge-node-1 --cloudcore-ipport 10.10.102.78:10002
+- delete the messages held for the node edge-node-1, the objects are synced to the node again by synccontroller
+`
+)
+
+// NewMessagePool manages the message pools of the edge nodes in CloudHub
+func NewMessagePool() *cobra.Command {
+	opts := newMessagePoolOptions()
+
+	cmd := &cobra.Command{
+		Use:     "messagepool",
+		Short:   "To show and manage the message pools of the edge nodes in CloudHub",
+		Long:    messagePoolLongDescription,
+		Example: messagePoolExample,
+	}
+	addMessagePoolFlags(cmd, opts)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the connected nodes with the session states and the queue depths",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var nodes []hubadmin.NodeStatus
+			if err := requestAdmin(opts, http.MethodGet, hubadmin.NodesPath, &nodes); err != nil {
+				return err
+			}
+			printNodes(nodes, time.Now())
+			return nil
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "messages NODE",
+		Short: "Show the messages held for the node by resource and operation",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var messages []hubadmin.Message
+			if err := requestAdmin(opts, http.MethodGet, nodePath(args[0], hubadmin.MessagesSubPath), &messages); err != nil {
+				return err
+			}
+			printMessages(messages)
+			return nil
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "purge NODE",
+		Short: "Delete the messages held for the node",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var result hubadmin.Result
+			if err := requestAdmin(opts, http.MethodPost, nodePath(args[0], hubadmin.PurgeSubPath), &result); err != nil {
+				return err
+			}
+			fmt.Printf("%d messages of node %s are purged\n", result.Messages, result.NodeID)
+			return nil
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "resend NODE",
+		Short: "Queue the messages held for the node to send again",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var result hubadmin.Result
+			if err := requestAdmin(opts, http.MethodPost, nodePath(args[0], hubadmin.ResendSubPath), &result); err != nil {
+				return err
+			}
+			fmt.Printf("%d messages of node %s are queued to send again\n", result.Messages, result.NodeID)
+			return nil
+		},
+	})
+	return cmd
+}
+
+func addMessagePoolFlags(cmd *cobra.Command, messagePoolOptions *common.MessagePoolOptions) {
+	cmd.PersistentFlags().StringVar(&messagePoolOptions.Kubeconfig, common.KubeConfig, messagePoolOptions.Kubeconfig,
+		"Use this key to set kube-config path, eg: $HOME/.kube/config")
+	cmd.PersistentFlags().StringVar(&messagePoolOptions.CloudCoreIPPort, common.CloudCoreIPPort, messagePoolOptions.CloudCoreIPPort,
+		"Use this key to set the IP and port of the CloudHub https server of the cloudcore, eg: 10.10.102.78:10002")
+}
+
+// newMessagePoolOptions return common options
+func newMessagePoolOptions() *common.MessagePoolOptions {
+	opts := &common.MessagePoolOptions{}
+	opts.Kubeconfig = common.DefaultKubeConfig
+	return opts
+}
+
+func nodePath(nodeName, subPath string) string {
+	return strings.Join([]string{hubadmin.NodesPath, url.PathEscape(nodeName), subPath}, "/")
+}
+
+// requestAdmin sends the request to the admin API and decodes the response into out, the server
+// certificate is verified by the CA certificate in the CA secret, and the request carries the
+// bearer token credentials of the kubeconfig
+func requestAdmin(opts *common.MessagePoolOptions, method, path string, out interface{}) error {
+	if opts.CloudCoreIPPort == "" {
+		return fmt.Errorf("%s must be given", common.CloudCoreIPPort)
+	}
+	config, err := clientcmd.BuildConfigFromFlags("", opts.Kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig: %v", err)
+	}
+	// the client certificates of the kubeconfig are not accepted by CloudHub
+	if config.BearerToken == "" && config.BearerTokenFile == "" && config.ExecProvider == nil && config.AuthProvider == nil {
+		return fmt.Errorf("kubeconfig %s has no bearer token credentials for the admin API", opts.Kubeconfig)
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	caSecret, err := client.CoreV1().Secrets(constants.SystemNamespace).Get(context.Background(), common.CaSecretName, metaV1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get the CA secret: %v", err)
+	}
+	caCert, err := x509.ParseCertificate(caSecret.Data[common.CaDataName])
+	if err != nil {
+		return fmt.Errorf("failed to parse the CA certificate: %v", err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	// the wrappers set the authorization header by the credentials of the kubeconfig
+	transport, err := rest.HTTPWrappersForConfig(config, &http.Transport{
+		TLSClientConfig: &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12},
+	})
+	if err != nil {
+		return err
+	}
+	httpClient := &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: transport,
+	}
+	req, err := http.NewRequest(method, "https://"+opts.CloudCoreIPPort+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, constants.MaxRespBodyLength))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s failed: %s, %s", method, path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, out)
+}
+
+func printNodes(nodes []hubadmin.NodeStatus, now time.Time) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tSTATE\tKEEPALIVE AGE\tACK QUEUE\tNOACK QUEUE\tACK STORE\tNOACK STORE\tWAITING ACK")
+	for _, n := range nodes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%d\t%d\n", n.NodeID, n.State,
+			now.Sub(n.LastKeepalive).Truncate(time.Second), n.AckQueueDepth, n.NoAckQueueDepth,
+			n.AckStoreSize, n.NoAckStoreSize, n.WaitingAck)
+	}
+	w.Flush()
+}
+
+func printMessages(messages []hubadmin.Message) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "RESOURCE\tOPERATION\tQUEUE\tRESOURCE VERSION\tID")
+	for _, m := range messages {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", m.Resource, m.Operation, m.Queue, m.ResourceVersion, m.ID)
+	}
+	w.Flush()
+}
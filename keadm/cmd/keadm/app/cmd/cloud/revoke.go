@@ -0,0 +1,163 @@
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/kubeedge/kubeedge/common/constants"
+	"github.com/kubeedge/kubeedge/keadm/cmd/keadm/app/cmd/common"
+	"github.com/kubeedge/kubeedge/keadm/cmd/keadm/app/cmd/util"
+)
+
+var (
+	revokeLongDescription = `
+"keadm revoke" command revokes the certificates of an edge node, or the certificates of the given serial numbers.
+CloudHub rejects the revoked certificates during the TLS handshake and terminates the sessions holding them.
+All the certificates issued to a revoked node are rejected, and no certificate is issued to it even with a valid
+token, so the node is locked out until the revocation is removed with "--undo".
+`
+	revokeExample = `
+keadm revoke --node-name edge-node-1 --kube-config /root/.kube/config
+- node-name is the name of the edge node to revoke
+
+keadm revoke --serial 1a:2b:3c:4d:5e:6f:70:81
+- serial is the serial number of the certificate to revoke in hex, such as printed by "openssl x509 -serial"
+
+keadm revoke --node-name edge-node-1 --undo
+- remove the revocation of the edge node edge-node-1, so that it can join the cluster again
+`
+)
+
+// NewRevoke revokes the certificates of edge nodes
+func NewRevoke() *cobra.Command {
+	opts := newRevokeOptions()
+
+	cmd := &cobra.Command{
+		Use:     "revoke",
+		Short:   "To revoke the certificates of an edge node",
+		Long:    revokeLongDescription,
+		Example: revokeExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := revokedEntries(opts, time.Now())
+			if err != nil {
+				return err
+			}
+			if opts.Undo {
+				if err := removeRevokedEntries(constants.SystemNamespace, constants.RevokedCertsSecretName, opts.Kubeconfig, data); err != nil {
+					fmt.Printf("failed to remove revocations, err is %s\n", err)
+					return err
+				}
+				for key := range data {
+					fmt.Printf("revocation of %s is removed\n", key)
+				}
+				return nil
+			}
+			if err := addRevokedEntries(constants.SystemNamespace, constants.RevokedCertsSecretName, opts.Kubeconfig, data); err != nil {
+				fmt.Printf("failed to revoke certificates, err is %s\n", err)
+				return err
+			}
+			for key := range data {
+				fmt.Printf("%s is revoked\n", key)
+			}
+			return nil
+		},
+	}
+	addRevokeFlags(cmd, opts)
+	return cmd
+}
+
+func addRevokeFlags(cmd *cobra.Command, revokeOptions *common.RevokeOptions) {
+	cmd.Flags().StringVar(&revokeOptions.Kubeconfig, common.KubeConfig, revokeOptions.Kubeconfig,
+		"Use this key to set kube-config path, eg: $HOME/.kube/config")
+	cmd.Flags().StringVar(&revokeOptions.NodeName, "node-name", revokeOptions.NodeName,
+		"Use this key to set the name of the edge node to revoke")
+	cmd.Flags().StringSliceVar(&revokeOptions.Serials, "serial", revokeOptions.Serials,
+		"Use this key to set the serial numbers in hex of the certificates to revoke")
+	cmd.Flags().BoolVar(&revokeOptions.Undo, "undo", revokeOptions.Undo,
+		"Use this key to remove the revocations of the node or the certificates instead")
+}
+
+// newRevokeOptions return common options
+func newRevokeOptions() *common.RevokeOptions {
+	opts := &common.RevokeOptions{}
+	opts.Kubeconfig = common.DefaultKubeConfig
+	return opts
+}
+
+// revokedEntries returns the entries of the revocation list secret for the options
+func revokedEntries(opts *common.RevokeOptions, now time.Time) (map[string]string, error) {
+	if opts.NodeName == "" && len(opts.Serials) == 0 {
+		return nil, fmt.Errorf("node-name or serial must be given")
+	}
+	revokedAt := now.UTC().Format(time.RFC3339)
+	data := make(map[string]string)
+	if opts.NodeName != "" {
+		data[constants.RevokedNodeKeyPrefix+opts.NodeName] = revokedAt
+	}
+	for _, s := range opts.Serials {
+		serial, ok := new(big.Int).SetString(strings.ReplaceAll(strings.ToLower(s), ":", ""), 16)
+		if !ok {
+			return nil, fmt.Errorf("invalid serial number %s", s)
+		}
+		data[constants.RevokedSerialKeyPrefix+serial.Text(16)] = revokedAt
+	}
+	return data, nil
+}
+
+// removeRevokedEntries removes the entries from the revocation list secret
+func removeRevokedEntries(namespace, name, kubeConfigPath string, data map[string]string) error {
+	client, err := util.KubeClient(kubeConfigPath)
+	if err != nil {
+		return err
+	}
+	removed := make(map[string]interface{}, len(data))
+	for key := range data {
+		removed[key] = nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{"data": removed})
+	if err != nil {
+		return err
+	}
+	_, err = client.CoreV1().Secrets(namespace).Patch(context.Background(), name, types.MergePatchType, patch, metaV1.PatchOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// addRevokedEntries adds the entries to the revocation list secret, the secret is created if not found
+func addRevokedEntries(namespace, name, kubeConfigPath string, data map[string]string) error {
+	client, err := util.KubeClient(kubeConfigPath)
+	if err != nil {
+		return err
+	}
+	secrets := client.CoreV1().Secrets(namespace)
+
+	patch, err := json.Marshal(map[string]interface{}{"stringData": data})
+	if err != nil {
+		return err
+	}
+	_, err = secrets.Patch(context.Background(), name, types.MergePatchType, patch, metaV1.PatchOptions{})
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+	_, err = secrets.Create(context.Background(), &corev1.Secret{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		StringData: data,
+		Type:       corev1.SecretTypeOpaque,
+	}, metaV1.CreateOptions{})
+	return err
+}
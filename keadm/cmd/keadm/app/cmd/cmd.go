@@ -74,6 +74,8 @@ func NewKubeedgeCommand() *cobra.Command {
 
 	cmds.AddCommand(NewCmdVersion())
 	cmds.AddCommand(cloud.NewGettoken())
+	cmds.AddCommand(cloud.NewRevoke())
+	cmds.AddCommand(cloud.NewMessagePool())
 	cmds.AddCommand(debug.NewEdgeDebug())
 
 	// recommended cmds
@@ -86,6 +88,7 @@ func NewKubeedgeCommand() *cobra.Command {
 	// beta cmds
 	cmds.AddCommand(beta.NewBeta())
 	cmds.AddCommand(edge.NewEdgeUpgrade())
+	cmds.AddCommand(edge.NewEdge())
 
 	return cmds
 }
@@ -68,6 +68,12 @@ const (
 
 	TokenDataName = "tokendata"
 
+	CaSecretName = "casecret"
+
+	CaDataName = "cadata"
+
+	CaKeyDataName = "cakeydata"
+
 	DomainName = "domainname"
 
 	Labels = "labels"
@@ -17,6 +17,8 @@ limitations under the License.
 package common
 
 import (
+	"time"
+
 	"github.com/blang/semver"
 )
 
@@ -93,6 +95,24 @@ type ResetOptions struct {
 
 type GettokenOptions struct {
 	Kubeconfig string
+	// NodeName is the node name or the shell pattern of the node names to create a bootstrap token for
+	NodeName   string
+	TTL        time.Duration
+	UsageLimit int
+}
+
+// RevokeOptions has the kubeconfig and the node name or certificate serial numbers to revoke or to undo the revocation of
+type RevokeOptions struct {
+	Kubeconfig string
+	NodeName   string
+	Serials    []string
+	Undo       bool
+}
+
+// MessagePoolOptions has the kubeconfig and the CloudHub https server to manage the message pools on
+type MessagePoolOptions struct {
+	Kubeconfig      string
+	CloudCoreIPPort string
 }
 
 type DiagnoseOptions struct {
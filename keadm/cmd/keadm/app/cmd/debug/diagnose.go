@@ -175,7 +175,11 @@ func DiagnosePod(ops *common.DiagnoseOptions, podName string) error {
 	if ops.DBPath == "" {
 		ops.DBPath = v1alpha2.DataBaseDataSource
 	}
-	err := InitDB(v1alpha2.DataBaseDriverName, v1alpha2.DataBaseAliasName, ops.DBPath)
+	driverName, err := detectDriverName(ops.DBPath)
+	if err != nil {
+		return err
+	}
+	err = InitDB(driverName, v1alpha2.DataBaseAliasName, ops.DBPath)
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %v ", err)
 	}
@@ -17,6 +17,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -231,7 +232,11 @@ func (g *GetOptions) Validate(args []string) error {
 		return fmt.Errorf("edgeCore database file %v not exist. ", g.DataPath)
 	}
 
-	if err := InitDB(edgecoreCfg.DataBaseDriverName, edgecoreCfg.DataBaseAliasName, g.DataPath); err != nil {
+	driverName, err := detectDriverName(g.DataPath)
+	if err != nil {
+		return err
+	}
+	if err := InitDB(driverName, edgecoreCfg.DataBaseAliasName, g.DataPath); err != nil {
 		return fmt.Errorf("failed to initialize database: %v ", err)
 	}
 	if len(*g.PrintFlags.OutputFormat) > 0 {
@@ -453,8 +458,37 @@ func isFileExist(path string) bool {
 	return err == nil || os.IsExist(err)
 }
 
+// sqliteHeader is the header string every sqlite3 database file starts with
+const sqliteHeader = "SQLite format 3\x00"
+
+// detectDriverName returns the driver of the EdgeCore database file, sqlite3 or bbolt
+func detectDriverName(dataSource string) (string, error) {
+	f, err := os.Open(dataSource)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	header := make([]byte, len(sqliteHeader))
+	if _, err := io.ReadFull(f, header); err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", fmt.Errorf("failed to read database file %s: %v", dataSource, err)
+	}
+	if string(header) == sqliteHeader {
+		return edgecoreCfg.DataBaseDriverName, nil
+	}
+	return edgecoreCfg.DataBaseDriverNameBolt, nil
+}
+
 // InitDB Init DB info
 func InitDB(driverName, dbName, dataSource string) error {
+	if driverName == edgecoreCfg.DataBaseDriverNameBolt {
+		// bbolt allows only one process to open the file, so EdgeCore must be stopped
+		kv, err := dbm.NewBoltStore(dataSource)
+		if err != nil {
+			return fmt.Errorf("failed to open db, stop EdgeCore and retry: %v ", err)
+		}
+		dbm.KV = kv
+		return nil
+	}
 	if err := orm.RegisterDriver(driverName, orm.DRSqlite); err != nil {
 		return fmt.Errorf("failed to register driver: %v ", err)
 	}
@@ -14,8 +14,13 @@ limitations under the License.
 package debug
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+	edgecoreCfg "github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha2"
 )
 
 func TestSplitSelectorParameters(t *testing.T) {
@@ -67,3 +72,29 @@ func TestSplitSelectorParameters(t *testing.T) {
 		})
 	}
 }
+
+func TestDetectDriverName(t *testing.T) {
+	dir := t.TempDir()
+	sqlitePath := filepath.Join(dir, "edgecore.db")
+	if err := os.WriteFile(sqlitePath, []byte(sqliteHeader+"data"), 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	boltPath := filepath.Join(dir, "edgecore.bolt")
+	kv, err := dbm.NewBoltStore(boltPath)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	kv.Close()
+
+	for path, expected := range map[string]string{
+		sqlitePath: edgecoreCfg.DataBaseDriverName,
+		boltPath:   edgecoreCfg.DataBaseDriverNameBolt,
+	} {
+		if got, err := detectDriverName(path); err != nil || got != expected {
+			t.Errorf("expected driver %s of %s, but got %s, %v", expected, path, got, err)
+		}
+	}
+	if _, err := detectDriverName(filepath.Join(dir, "unknown")); err == nil {
+		t.Errorf("expected error for the file not exist")
+	}
+}
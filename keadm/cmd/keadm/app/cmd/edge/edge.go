@@ -0,0 +1,34 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package edge
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// NewEdge returns the command which groups the maintenance commands of edge node
+func NewEdge() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "edge",
+		Short: "Maintain the local data of edge node",
+		Long:  `"keadm edge" command provides subcommands to maintain the local data of edge node, edgecore should be stopped before running them.`,
+	}
+
+	cmd.AddCommand(NewMigrateDB())
+	cmd.AddCommand(NewSnapshot())
+	return cmd
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package edge
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/astaxie/beego/orm"
+	"github.com/spf13/cobra"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+	"github.com/kubeedge/kubeedge/edge/pkg/devicetwin/dtclient"
+	eventbusdao "github.com/kubeedge/kubeedge/edge/pkg/eventbus/dao"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+	v2 "github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao/v2"
+	servicebusdao "github.com/kubeedge/kubeedge/edge/pkg/servicebus/dao"
+	"github.com/kubeedge/kubeedge/keadm/cmd/keadm/app/cmd/util"
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha2"
+)
+
+var (
+	migrateDBLongDescription = `
+"keadm edge migrate-db" copies all the records of edgecore database from one backend to another,
+for example from sqlite3 to bbolt. Stop edgecore before migrating, and set database.driverName and
+database.dataSource in edgecore.yaml to the target after that.
+`
+	migrateDBExample = `
+keadm edge migrate-db --from=sqlite3 --source=/var/lib/kubeedge/edgecore.db --to=bbolt --target=/var/lib/kubeedge/edgecore.bolt
+`
+)
+
+// migrationTables are the tables of edgecore database
+var migrationTables = []struct {
+	name  string
+	model interface{}
+}{
+	{name: dao.MetaTableName, model: new(dao.Meta)},
+	{name: v2.NewMetaTableName, model: new(v2.MetaV2)},
+	{name: v2.EventTableName, model: new(v2.MetaV2Event)},
+	{name: v2.JournalTableName, model: new(v2.MetaV2Journal)},
+	{name: dtclient.DeviceTableName, model: new(dtclient.Device)},
+	{name: dtclient.DeviceAttrTableName, model: new(dtclient.DeviceAttr)},
+	{name: dtclient.DeviceTwinTableName, model: new(dtclient.DeviceTwin)},
+	{name: eventbusdao.SubTopicsName, model: new(eventbusdao.SubTopics)},
+	{name: servicebusdao.TargetUrlsName, model: new(servicebusdao.TargetUrls)},
+}
+
+// MigrateDBOptions is the options of migrate-db command
+type MigrateDBOptions struct {
+	From   string
+	Source string
+	To     string
+	Target string
+}
+
+// NewMigrateDB returns KubeEdge edge migrate-db command.
+func NewMigrateDB() *cobra.Command {
+	opts := &MigrateDBOptions{
+		From:   dbm.DriverSqlite,
+		Source: v1alpha2.DataBaseDataSource,
+		To:     dbm.DriverBolt,
+	}
+	cmd := &cobra.Command{
+		Use:     "migrate-db",
+		Short:   "Migrate edgecore database between sqlite3 and bbolt",
+		Long:    migrateDBLongDescription,
+		Example: migrateDBExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.migrate()
+		},
+	}
+	cmd.Flags().StringVar(&opts.From, "from", opts.From, "The database driver to migrate from, sqlite3 or bbolt")
+	cmd.Flags().StringVar(&opts.Source, "source", opts.Source, "The data source of the database to migrate from")
+	cmd.Flags().StringVar(&opts.To, "to", opts.To, "The database driver to migrate to, sqlite3 or bbolt")
+	cmd.Flags().StringVar(&opts.Target, "target", opts.Target, "The data source of the database to migrate to, it must not exist")
+	return cmd
+}
+
+func (o *MigrateDBOptions) migrate() error {
+	if !util.FileExists(o.Source) {
+		return fmt.Errorf("source database %s not found", o.Source)
+	}
+	if o.Target == "" || util.FileExists(o.Target) {
+		return fmt.Errorf("target database %q must be given and not exist", o.Target)
+	}
+
+	var ormer orm.Ormer
+	var kv dbm.KVStore
+	var err error
+	switch {
+	case o.From == dbm.DriverSqlite && o.To == dbm.DriverBolt:
+		if ormer, err = openSqlite(o.Source, false); err != nil {
+			return err
+		}
+		if kv, err = dbm.NewBoltStore(o.Target); err != nil {
+			return err
+		}
+		defer kv.Close()
+		for _, table := range migrationTables {
+			if err := sqliteToKV(ormer, kv, table.name, table.model); err != nil {
+				return fmt.Errorf("failed to migrate table %s: %v", table.name, err)
+			}
+		}
+	case o.From == dbm.DriverBolt && o.To == dbm.DriverSqlite:
+		if kv, err = dbm.NewBoltStore(o.Source); err != nil {
+			return err
+		}
+		defer kv.Close()
+		if ormer, err = openSqlite(o.Target, true); err != nil {
+			return err
+		}
+		for _, table := range migrationTables {
+			if err := kvToSqlite(kv, ormer, table.name, table.model); err != nil {
+				return fmt.Errorf("failed to migrate table %s: %v", table.name, err)
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported migration from %q to %q", o.From, o.To)
+	}
+	fmt.Printf("database %s(%s) is migrated to %s(%s)\n", o.Source, o.From, o.Target, o.To)
+	return nil
+}
+
+func openSqlite(dataSource string, create bool) (orm.Ormer, error) {
+	if err := orm.RegisterDriver(dbm.DriverSqlite, orm.DRSqlite); err != nil {
+		return nil, fmt.Errorf("failed to register driver: %v", err)
+	}
+	if err := orm.RegisterDataBase("default", dbm.DriverSqlite, dataSource); err != nil {
+		return nil, fmt.Errorf("failed to register db: %v", err)
+	}
+	for _, table := range migrationTables {
+		orm.RegisterModel(table.model)
+	}
+	if create {
+		if err := orm.RunSyncdb("default", false, false); err != nil {
+			return nil, fmt.Errorf("failed to create tables: %v", err)
+		}
+	}
+	return orm.NewOrm(), nil
+}
+
+func sqliteToKV(ormer orm.Ormer, kv dbm.KVStore, table string, model interface{}) error {
+	list := reflect.New(reflect.SliceOf(reflect.TypeOf(model).Elem()))
+	num, err := ormer.QueryTable(table).Limit(-1).All(list.Interface())
+	if err != nil {
+		return err
+	}
+	err = kv.Update(func(tx dbm.KVStore) error {
+		for i := 0; i < list.Elem().Len(); i++ {
+			// the auto increment primary keys are kept
+			if err := tx.Insert(table, list.Elem().Index(i).Addr().Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		fmt.Printf("%d records of table %s migrated\n", num, table)
+	}
+	return err
+}
+
+func kvToSqlite(kv dbm.KVStore, ormer orm.Ormer, table string, model interface{}) (err error) {
+	if err := ormer.Begin(); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			dbm.RollbackTransaction(ormer)
+		} else {
+			err = ormer.Commit()
+		}
+	}()
+	var num int
+	// the auto increment primary keys are kept, since orm only skips the empty ones
+	err = kv.ForEach(table, model, func(obj interface{}) error {
+		num++
+		_, err := ormer.Insert(obj)
+		return err
+	})
+	if err == nil {
+		fmt.Printf("%d records of table %s migrated\n", num, table)
+	}
+	return err
+}
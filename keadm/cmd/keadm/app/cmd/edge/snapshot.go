@@ -0,0 +1,477 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package edge
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"time"
+
+	"github.com/astaxie/beego/orm"
+	"github.com/spf13/cobra"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+	"github.com/kubeedge/kubeedge/edge/pkg/devicetwin/dtclient"
+	eventbusdao "github.com/kubeedge/kubeedge/edge/pkg/eventbus/dao"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+	v2 "github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao/v2"
+	servicebusdao "github.com/kubeedge/kubeedge/edge/pkg/servicebus/dao"
+	"github.com/kubeedge/kubeedge/keadm/cmd/keadm/app/cmd/common"
+	"github.com/kubeedge/kubeedge/keadm/cmd/keadm/app/cmd/util"
+)
+
+var (
+	snapshotLongDescription = `
+"keadm edge snapshot" saves the cached metadata of edge node, including pods, configmaps, secrets,
+device twins and eventbus subscriptions, to an archive, and restores it on a replacement node with
+the same node name. Stop edgecore before saving or restoring a snapshot. The watch history and the
+offline writes not synced to cloud yet are cleared on restore.
+
+The resources encrypted at rest are saved as they are, copy the key file and the data key file of
+metaManager.encryption to the replacement node as well.
+`
+	snapshotExample = `
+keadm edge snapshot save --file=/tmp/edge-node-1.snapshot
+keadm edge snapshot restore --file=/tmp/edge-node-1.snapshot
+`
+)
+
+const (
+	// snapshotVersion is the version of snapshot archive format
+	snapshotVersion = 1
+
+	snapshotManifestFile = "manifest.json"
+	snapshotTableDir     = "tables"
+)
+
+// snapshotTables are the tables saved in snapshot, the watch events and journals
+// are not saved since they are only meaningful to the running metaserver
+var snapshotTables = []struct {
+	name  string
+	model interface{}
+}{
+	{name: dao.MetaTableName, model: new(dao.Meta)},
+	{name: v2.NewMetaTableName, model: new(v2.MetaV2)},
+	{name: dtclient.DeviceTableName, model: new(dtclient.Device)},
+	{name: dtclient.DeviceAttrTableName, model: new(dtclient.DeviceAttr)},
+	{name: dtclient.DeviceTwinTableName, model: new(dtclient.DeviceTwin)},
+	{name: eventbusdao.SubTopicsName, model: new(eventbusdao.SubTopics)},
+	{name: servicebusdao.TargetUrlsName, model: new(servicebusdao.TargetUrls)},
+}
+
+// snapshotClearedTables are the tables cleared on restore. The watch events and the journals left on
+// the node don't match the restored objects, so the resumed watches are expired to list again, and the
+// offline writes recorded before are dropped instead of being replayed on top of the restored objects.
+var snapshotClearedTables = []struct {
+	name  string
+	model interface{}
+}{
+	{name: v2.EventTableName, model: new(v2.MetaV2Event)},
+	{name: v2.JournalTableName, model: new(v2.MetaV2Journal)},
+}
+
+// snapshotManifest describes the content of snapshot archive
+type snapshotManifest struct {
+	Version  int                  `json:"version"`
+	NodeName string               `json:"nodeName"`
+	Created  time.Time            `json:"created"`
+	Tables   []snapshotTableEntry `json:"tables"`
+}
+
+// snapshotTableEntry describes a table file in snapshot archive
+type snapshotTableEntry struct {
+	Name    string `json:"name"`
+	File    string `json:"file"`
+	Records int    `json:"records"`
+	SHA256  string `json:"sha256"`
+}
+
+// SnapshotOptions is the options of snapshot command
+type SnapshotOptions struct {
+	Config string
+	File   string
+}
+
+// NewSnapshot returns KubeEdge edge snapshot command.
+func NewSnapshot() *cobra.Command {
+	opts := &SnapshotOptions{
+		Config: common.EdgecoreConfigPath,
+	}
+	cmd := &cobra.Command{
+		Use:     "snapshot",
+		Short:   "Save or restore the cached metadata of edge node",
+		Long:    snapshotLongDescription,
+		Example: snapshotExample,
+	}
+	cmd.PersistentFlags().StringVarP(&opts.Config, common.EdgecoreConfig, "c", opts.Config,
+		fmt.Sprintf("Specify configuration file, default is %s", common.EdgecoreConfigPath))
+	cmd.PersistentFlags().StringVar(&opts.File, "file", opts.File, "The path of snapshot archive")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "save",
+		Short: "Save the cached metadata of edge node to a snapshot archive",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.save()
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "restore",
+		Short: "Restore the cached metadata of edge node from a snapshot archive",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.restore()
+		},
+	})
+	return cmd
+}
+
+func (o *SnapshotOptions) save() error {
+	if o.File == "" || util.FileExists(o.File) {
+		return fmt.Errorf("snapshot file %q must be given and not exist", o.File)
+	}
+	config, err := util.ParseEdgecoreConfig(o.Config)
+	if err != nil {
+		return fmt.Errorf("failed to parse edgecore config %s: %v", o.Config, err)
+	}
+	if !util.FileExists(config.DataBase.DataSource) {
+		return fmt.Errorf("database %s not found", config.DataBase.DataSource)
+	}
+
+	tables := make(map[string][]byte)
+	switch config.DataBase.DriverName {
+	case dbm.DriverBolt:
+		kv, err := dbm.NewBoltStore(config.DataBase.DataSource)
+		if err != nil {
+			return err
+		}
+		defer kv.Close()
+		// all the tables are read in a transaction to get a consistent snapshot
+		err = kv.Update(func(tx dbm.KVStore) error {
+			return readSnapshotTables(tables, func(table string, model interface{}) (interface{}, error) {
+				return readKVTable(tx, table, model)
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read database: %v", err)
+		}
+	default:
+		ormer, err := openSqlite(config.DataBase.DataSource, false)
+		if err != nil {
+			return err
+		}
+		if err := ormer.Begin(); err != nil {
+			return err
+		}
+		err = readSnapshotTables(tables, func(table string, model interface{}) (interface{}, error) {
+			return readSqliteTable(ormer, table, model)
+		})
+		dbm.RollbackTransaction(ormer)
+		if err != nil {
+			return fmt.Errorf("failed to read database: %v", err)
+		}
+	}
+
+	if err := writeSnapshot(o.File, config.Modules.Edged.HostnameOverride, tables); err != nil {
+		return err
+	}
+	fmt.Printf("snapshot of node %s is saved to %s\n", config.Modules.Edged.HostnameOverride, o.File)
+	return nil
+}
+
+func (o *SnapshotOptions) restore() error {
+	if !util.FileExists(o.File) {
+		return fmt.Errorf("snapshot file %q not found", o.File)
+	}
+	config, err := util.ParseEdgecoreConfig(o.Config)
+	if err != nil {
+		return fmt.Errorf("failed to parse edgecore config %s: %v", o.Config, err)
+	}
+	manifest, tables, err := readSnapshot(o.File)
+	if err != nil {
+		return err
+	}
+	if manifest.NodeName != config.Modules.Edged.HostnameOverride {
+		return fmt.Errorf("snapshot is saved from node %q, but the node name is %q", manifest.NodeName, config.Modules.Edged.HostnameOverride)
+	}
+
+	switch config.DataBase.DriverName {
+	case dbm.DriverBolt:
+		kv, err := dbm.NewBoltStore(config.DataBase.DataSource)
+		if err != nil {
+			return err
+		}
+		defer kv.Close()
+		err = kv.Update(func(tx dbm.KVStore) error {
+			return restoreSnapshotTables(tables, func(table string, model interface{}, records reflect.Value) error {
+				return restoreKVTable(tx, table, model, records)
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("failed to restore snapshot: %v", err)
+		}
+	default:
+		ormer, err := openSqlite(config.DataBase.DataSource, true)
+		if err != nil {
+			return err
+		}
+		if err := restoreSqlite(ormer, tables); err != nil {
+			return fmt.Errorf("failed to restore snapshot: %v", err)
+		}
+	}
+	fmt.Printf("snapshot of node %s created at %s is restored to %s\n", manifest.NodeName,
+		manifest.Created.Format(time.RFC3339), config.DataBase.DataSource)
+	return nil
+}
+
+// readSnapshotTables reads the snapshot tables by read and encodes the records into tables
+func readSnapshotTables(tables map[string][]byte, read func(table string, model interface{}) (interface{}, error)) error {
+	for _, table := range snapshotTables {
+		records, err := read(table.name, table.model)
+		if err != nil {
+			return fmt.Errorf("failed to read table %s: %v", table.name, err)
+		}
+		data, err := json.Marshal(records)
+		if err != nil {
+			return err
+		}
+		tables[table.name] = data
+	}
+	return nil
+}
+
+func readKVTable(kv dbm.KVStore, table string, model interface{}) (interface{}, error) {
+	list := reflect.New(reflect.SliceOf(reflect.TypeOf(model).Elem()))
+	if _, err := dbm.KVQuery(kv, table, list.Interface()); err != nil {
+		return nil, err
+	}
+	return list.Interface(), nil
+}
+
+func readSqliteTable(ormer orm.Ormer, table string, model interface{}) (interface{}, error) {
+	list := reflect.New(reflect.SliceOf(reflect.TypeOf(model).Elem()))
+	if _, err := ormer.QueryTable(table).Limit(-1).All(list.Interface()); err != nil {
+		return nil, err
+	}
+	return list.Interface(), nil
+}
+
+// restoreSnapshotTables decodes the records of the snapshot tables and restores them by restore
+func restoreSnapshotTables(tables map[string][]byte, restore func(table string, model interface{}, records reflect.Value) error) error {
+	for _, table := range snapshotTables {
+		list := reflect.New(reflect.SliceOf(reflect.TypeOf(table.model).Elem()))
+		if err := json.Unmarshal(tables[table.name], list.Interface()); err != nil {
+			return fmt.Errorf("failed to decode table %s: %v", table.name, err)
+		}
+		if err := restore(table.name, table.model, list.Elem()); err != nil {
+			return fmt.Errorf("failed to restore table %s: %v", table.name, err)
+		}
+		fmt.Printf("%d records of table %s restored\n", list.Elem().Len(), table.name)
+	}
+	for _, table := range snapshotClearedTables {
+		// restoring no records clears the table
+		records := reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(table.model).Elem()), 0, 0)
+		if err := restore(table.name, table.model, records); err != nil {
+			return fmt.Errorf("failed to clear table %s: %v", table.name, err)
+		}
+		fmt.Printf("table %s cleared\n", table.name)
+	}
+	return nil
+}
+
+// restoreKVTable replaces the records of table with records
+func restoreKVTable(tx dbm.KVStore, table string, model interface{}, records reflect.Value) error {
+	if _, err := dbm.KVDelete(tx, table, model); err != nil {
+		return err
+	}
+	for i := 0; i < records.Len(); i++ {
+		if err := tx.Insert(table, records.Index(i).Addr().Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func restoreSqlite(ormer orm.Ormer, tables map[string][]byte) (err error) {
+	if err := ormer.Begin(); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			dbm.RollbackTransaction(ormer)
+		} else {
+			err = ormer.Commit()
+		}
+	}()
+	return restoreSnapshotTables(tables, func(table string, model interface{}, records reflect.Value) error {
+		if _, err := ormer.Raw("DELETE FROM " + table).Exec(); err != nil {
+			return err
+		}
+		for i := 0; i < records.Len(); i++ {
+			// the auto increment primary keys are kept, since orm only skips the empty ones
+			if _, err := ormer.Insert(records.Index(i).Addr().Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// writeSnapshot writes the manifest and the table files to a gzipped tar archive
+func writeSnapshot(file, nodeName string, tables map[string][]byte) (err error) {
+	manifest := snapshotManifest{
+		Version:  snapshotVersion,
+		NodeName: nodeName,
+		Created:  time.Now().UTC(),
+	}
+	for _, table := range snapshotTables {
+		data := tables[table.name]
+		var records []json.RawMessage
+		if err := json.Unmarshal(data, &records); err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		manifest.Tables = append(manifest.Tables, snapshotTableEntry{
+			Name:    table.name,
+			File:    path.Join(snapshotTableDir, table.name+".json"),
+			Records: len(records),
+			SHA256:  hex.EncodeToString(sum[:]),
+		})
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// the archive is written to a temporary file first, so that a broken one is never left
+	tmp := file + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(tmp)
+		}
+	}()
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	if err := writeTarFile(tw, snapshotManifestFile, manifestData); err != nil {
+		return err
+	}
+	for _, entry := range manifest.Tables {
+		if err := writeTarFile(tw, entry.File, tables[entry.Name]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+func writeTarFile(tw *tar.Writer, name string, data []byte) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// readSnapshot reads the snapshot archive and verifies the version and the checksums
+func readSnapshot(file string) (*snapshotManifest, map[string][]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read snapshot %s: %v", file, err)
+	}
+	defer gr.Close()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read snapshot %s: %v", file, err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s in snapshot %s: %v", header.Name, file, err)
+		}
+		files[header.Name] = data
+	}
+
+	data, ok := files[snapshotManifestFile]
+	if !ok {
+		return nil, nil, fmt.Errorf("%s not found in snapshot %s", snapshotManifestFile, file)
+	}
+	manifest := &snapshotManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode %s in snapshot %s: %v", snapshotManifestFile, file, err)
+	}
+	if manifest.Version != snapshotVersion {
+		return nil, nil, fmt.Errorf("unsupported snapshot version %d, %d is expected", manifest.Version, snapshotVersion)
+	}
+
+	tables := make(map[string][]byte)
+	for _, entry := range manifest.Tables {
+		data, ok := files[entry.File]
+		if !ok {
+			return nil, nil, fmt.Errorf("%s of table %s not found in snapshot %s", entry.File, entry.Name, file)
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != entry.SHA256 {
+			return nil, nil, fmt.Errorf("checksum of table %s mismatch, the snapshot %s is corrupted", entry.Name, file)
+		}
+		tables[entry.Name] = data
+	}
+	for _, table := range snapshotTables {
+		if _, ok := tables[table.name]; !ok {
+			return nil, nil, fmt.Errorf("table %s not found in snapshot %s", table.name, file)
+		}
+	}
+	return manifest, tables, nil
+}
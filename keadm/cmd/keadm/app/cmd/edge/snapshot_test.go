@@ -0,0 +1,158 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package edge
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+	v2 "github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao/v2"
+)
+
+func TestSnapshotBolt(t *testing.T) {
+	dir := t.TempDir()
+	source, err := dbm.NewBoltStore(filepath.Join(dir, "source.bolt"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer source.Close()
+	metas := []dao.Meta{
+		{Key: "default/configmap/a", Type: "configmap", Value: "{}"},
+		{Key: "default/secret/b", Type: "secret", Value: "{}"},
+	}
+	for i := range metas {
+		if err := source.Insert(dao.MetaTableName, &metas[i]); err != nil {
+			t.Fatalf("failed to insert: %v", err)
+		}
+	}
+
+	tables := make(map[string][]byte)
+	err = readSnapshotTables(tables, func(table string, model interface{}) (interface{}, error) {
+		return readKVTable(source, table, model)
+	})
+	if err != nil {
+		t.Fatalf("failed to read tables: %v", err)
+	}
+	file := filepath.Join(dir, "snapshot")
+	if err := writeSnapshot(file, "node-1", tables); err != nil {
+		t.Fatalf("failed to write snapshot: %v", err)
+	}
+
+	manifest, restored, err := readSnapshot(file)
+	if err != nil {
+		t.Fatalf("failed to read snapshot: %v", err)
+	}
+	if manifest.NodeName != "node-1" || manifest.Version != snapshotVersion || len(manifest.Tables) != len(snapshotTables) {
+		t.Errorf("unexpected manifest %+v", manifest)
+	}
+
+	target, err := dbm.NewBoltStore(filepath.Join(dir, "target.bolt"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer target.Close()
+	// the existing records are replaced
+	if err := target.Insert(dao.MetaTableName, &dao.Meta{Key: "default/configmap/c"}); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+	// the watch events and journals are cleared
+	if err := target.Insert(v2.EventTableName, &v2.MetaV2Event{Key: "/core/v1/configmaps/default/c"}); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+	if err := target.Insert(v2.JournalTableName, &v2.MetaV2Journal{Key: "/core/v1/configmaps/default/c"}); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+	err = target.Update(func(tx dbm.KVStore) error {
+		return restoreSnapshotTables(restored, func(table string, model interface{}, records reflect.Value) error {
+			return restoreKVTable(tx, table, model, records)
+		})
+	})
+	if err != nil {
+		t.Fatalf("failed to restore: %v", err)
+	}
+	got := new([]dao.Meta)
+	if _, err := dbm.KVQuery(target, dao.MetaTableName, got); err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+	if !reflect.DeepEqual(*got, metas) {
+		t.Errorf("expected %v restored, but got %v", metas, *got)
+	}
+	for _, table := range snapshotClearedTables {
+		list := reflect.New(reflect.SliceOf(reflect.TypeOf(table.model).Elem()))
+		if _, err := dbm.KVQuery(target, table.name, list.Interface()); err != nil {
+			t.Fatalf("failed to query: %v", err)
+		}
+		if n := list.Elem().Len(); n != 0 {
+			t.Errorf("expected table %s cleared, but got %d records", table.name, n)
+		}
+	}
+}
+
+func TestSnapshotChecksum(t *testing.T) {
+	dir := t.TempDir()
+	tables := make(map[string][]byte)
+	for _, table := range snapshotTables {
+		tables[table.name] = []byte("[]")
+	}
+	file := filepath.Join(dir, "snapshot")
+	if err := writeSnapshot(file, "node-1", tables); err != nil {
+		t.Fatalf("failed to write snapshot: %v", err)
+	}
+	manifest, _, err := readSnapshot(file)
+	if err != nil {
+		t.Fatalf("failed to read snapshot: %v", err)
+	}
+
+	// rewrite the archive with a table file changed but the manifest kept
+	tampered := filepath.Join(dir, "tampered")
+	f, err := os.Create(tampered)
+	if err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("failed to encode manifest: %v", err)
+	}
+	if err := writeTarFile(tw, snapshotManifestFile, data); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	for i, entry := range manifest.Tables {
+		content := []byte("[]")
+		if i == 0 {
+			content = []byte(`[{"Key":"x"}]`)
+		}
+		if err := writeTarFile(tw, entry.File, content); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+	}
+	tw.Close()
+	gw.Close()
+	f.Close()
+
+	if _, _, err := readSnapshot(tampered); err == nil {
+		t.Errorf("expected checksum mismatch error")
+	}
+}
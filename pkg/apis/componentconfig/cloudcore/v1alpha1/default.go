@@ -76,6 +76,28 @@ func NewDefaultCloudCoreConfig() *CloudCoreConfig {
 					Port:    10002,
 					Address: "0.0.0.0",
 				},
+				Compression: &CloudHubCompression{
+					Enable:    false,
+					Threshold: constants.DefaultCompressionThreshold,
+				},
+				Admission: &CloudHubAdmission{
+					Enable:             false,
+					MaxConcurrentSyncs: constants.DefaultAdmissionMaxConcurrentSyncs,
+					InitialSyncTimeout: constants.DefaultAdmissionInitialSyncTimeout,
+					RetryAfter:         constants.DefaultAdmissionRetryAfter,
+				},
+				Sharding: &CloudHubSharding{
+					Enable:        false,
+					LeaseDuration: constants.DefaultShardingLeaseDuration,
+					RenewInterval: constants.DefaultShardingRenewInterval,
+					VirtualNodes:  constants.DefaultShardingVirtualNodes,
+				},
+				Spill: &CloudHubSpill{
+					Enable:            false,
+					DBPath:            constants.DefaultSpillDBPath,
+					AckMessageLimit:   constants.DefaultSpillAckMessageLimit,
+					NoAckMessageLimit: constants.DefaultSpillNoAckMessageLimit,
+				},
 			},
 			EdgeController: &EdgeController{
 				Enable:              true,
@@ -177,6 +199,13 @@ func NewDefaultCloudCoreConfig() *CloudCoreConfig {
 				Enable: true,
 				Mode:   InternalMode,
 			},
+			LeaderElection: &ModuleLeaderElection{
+				Enable:        false,
+				Modules:       []string{"synccontroller"},
+				LeaseDuration: constants.DefaultLeaderElectionLeaseDuration,
+				RenewDeadline: constants.DefaultLeaderElectionRenewDeadline,
+				RetryPeriod:   constants.DefaultLeaderElectionRetryPeriod,
+			},
 		},
 	}
 	return c
@@ -86,6 +86,30 @@ type Modules struct {
 	Router *Router `json:"router,omitempty"`
 	// IptablesManager indicates iptables module config
 	IptablesManager *IptablesManager `json:"iptablesManager,omitempty"`
+	// LeaderElection indicates the leader election of the singleton modules, so they run on exactly
+	// one of the cloudcore replicas
+	LeaderElection *ModuleLeaderElection `json:"leaderElection,omitempty"`
+}
+
+// ModuleLeaderElection indicates the leader election config of the singleton modules. Each module
+// elects its leader by its own Lease, the modules not listed run on all replicas.
+type ModuleLeaderElection struct {
+	// Enable indicates whether to run the singleton modules only on the replica leading them
+	// default false
+	Enable bool `json:"enable"`
+	// Modules indicates the names of the modules electing leaders, only synccontroller and router can be elected
+	// default ["synccontroller"]
+	Modules []string `json:"modules,omitempty"`
+	// LeaseDuration indicates how long the replicas not leading wait to take over the leadership
+	// after it was renewed last time (second)
+	// default 15
+	LeaseDuration int32 `json:"leaseDuration,omitempty"`
+	// RenewDeadline indicates how long the leader retries renewing the leadership before giving up (second)
+	// default 10
+	RenewDeadline int32 `json:"renewDeadline,omitempty"`
+	// RetryPeriod indicates the interval to try to acquire or renew the leadership (second)
+	// default 2
+	RetryPeriod int32 `json:"retryPeriod,omitempty"`
 }
 
 // CloudHub indicates the config of CloudHub module.
@@ -137,6 +161,90 @@ type CloudHub struct {
 	// TokenRefreshDuration indicates the interval of cloudcore token refresh, unit is hour
 	// default 12h
 	TokenRefreshDuration time.Duration `json:"tokenRefreshDuration,omitempty"`
+	// DisableClusterToken indicates whether to reject the cluster token when edge nodes apply for certificates,
+	// only the bootstrap tokens scoped to node names are accepted if it is true
+	// default false
+	DisableClusterToken bool `json:"disableClusterToken,omitempty"`
+	// Compression indicates the payload compression config of websocket and quic servers,
+	// the payloads are compressed only if the edge supports compression too
+	Compression *CloudHubCompression `json:"compression,omitempty"`
+	// Admission indicates the admission control of the edge nodes connecting, it limits the nodes
+	// handshaking and syncing initially at the same time
+	Admission *CloudHubAdmission `json:"admission,omitempty"`
+	// Sharding indicates the sharding of the edge nodes among the cloudcore replicas, each replica
+	// serves and controls only the nodes it owns
+	Sharding *CloudHubSharding `json:"sharding,omitempty"`
+	// Spill indicates the disk storage of the messages queued for the edge nodes, the messages
+	// survive the restarts of cloudcore
+	Spill *CloudHubSpill `json:"spill,omitempty"`
+}
+
+// CloudHubSpill indicates the disk storage of the message pools of CloudHub. The messages queued for
+// an edge node are kept on disk until they are sent, or acknowledged if they require acknowledgment,
+// and they are queued again when the message pool of the node is initialized after cloudcore restarts.
+// Each cloudcore replica keeps the messages of the nodes it serves on its own disk.
+type CloudHubSpill struct {
+	// Enable indicates whether to keep the messages queued for the edge nodes on disk
+	// default false
+	Enable bool `json:"enable"`
+	// DBPath indicates the path of the spill database file
+	// default "/var/lib/kubeedge/cloudhub-spill.db"
+	DBPath string `json:"dbPath,omitempty"`
+	// AckMessageLimit indicates the max number of the messages requiring acknowledgment kept for each node,
+	// the new ones are rejected when the limit is reached and the objects are synced by synccontroller later
+	// default 10000
+	AckMessageLimit int32 `json:"ackMessageLimit,omitempty"`
+	// NoAckMessageLimit indicates the max number of the resource and twin updates not requiring acknowledgment
+	// kept for each node, the new ones are dropped when the limit is reached. The stream tunnel data, the responses
+	// and the other transient messages are kept in memory only and not counted
+	// default 1000
+	NoAckMessageLimit int32 `json:"noAckMessageLimit,omitempty"`
+}
+
+// CloudHubSharding indicates the sharding config of CloudHub. The replicas register in the membership
+// of Leases and own the edge nodes by consistent hashing, the nodes connecting to a replica not owning
+// them are redirected to the owner.
+type CloudHubSharding struct {
+	// Enable indicates whether to shard the edge nodes among the cloudcore replicas
+	// default false
+	Enable bool `json:"enable"`
+	// LeaseDuration indicates how long a replica is considered alive after it renewed its Lease (second)
+	// default 30
+	LeaseDuration int32 `json:"leaseDuration,omitempty"`
+	// RenewInterval indicates the interval to renew the Lease and refresh the membership (second)
+	// default 10
+	RenewInterval int32 `json:"renewInterval,omitempty"`
+	// VirtualNodes indicates the number of points each replica has on the hash ring,
+	// more points spread the edge nodes more evenly
+	// default 100
+	VirtualNodes int32 `json:"virtualNodes,omitempty"`
+}
+
+// CloudHubAdmission indicates the admission control of CloudHub, the edge nodes not admitted
+// are rejected during the handshake with a hint when to connect again
+type CloudHubAdmission struct {
+	// Enable indicates whether to limit the nodes handshaking and syncing initially at the same time
+	// default false
+	Enable bool `json:"enable"`
+	// MaxConcurrentSyncs indicates the max number of the nodes handshaking and syncing initially at the same time
+	// default 100
+	MaxConcurrentSyncs int32 `json:"maxConcurrentSyncs,omitempty"`
+	// InitialSyncTimeout indicates how long a node is counted as syncing initially at most (second)
+	// default 60
+	InitialSyncTimeout int32 `json:"initialSyncTimeout,omitempty"`
+	// RetryAfter indicates how long the nodes not admitted are hinted to wait before connecting again (second)
+	// default 10
+	RetryAfter int32 `json:"retryAfter,omitempty"`
+}
+
+// CloudHubCompression indicates the payload compression config of CloudHub
+type CloudHubCompression struct {
+	// Enable indicates whether to compress the payloads of messages
+	// default false
+	Enable bool `json:"enable"`
+	// Threshold indicates the min size of the payloads to compress (bytes)
+	// default 1024
+	Threshold int32 `json:"threshold,omitempty"`
 }
 
 // CloudHubQUIC indicates the quic server config
@@ -23,6 +23,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/klog/v2"
 
@@ -38,6 +39,9 @@ func ValidateCloudCoreConfiguration(c *v1alpha1.CloudCoreConfig) field.ErrorList
 	allErrs = append(allErrs, ValidateModuleEdgeController(*c.Modules.EdgeController)...)
 	allErrs = append(allErrs, ValidateModuleDeviceController(*c.Modules.DeviceController)...)
 	allErrs = append(allErrs, ValidateModuleSyncController(*c.Modules.SyncController)...)
+	if c.Modules.LeaderElection != nil {
+		allErrs = append(allErrs, ValidateModuleLeaderElection(*c.Modules.LeaderElection)...)
+	}
 	allErrs = append(allErrs, ValidateModuleDynamicController(*c.Modules.DynamicController)...)
 	allErrs = append(allErrs, ValidateModuleCloudStream(*c.Modules.CloudStream)...)
 	return allErrs
@@ -97,6 +101,84 @@ func ValidateModuleCloudHub(c v1alpha1.CloudHub) field.ErrorList {
 		allErrs = append(allErrs, field.Invalid(field.NewPath("TokenRefreshDuration"),
 			c.TokenRefreshDuration, "TokenRefreshDuration must be positive"))
 	}
+	if c.Compression != nil && c.Compression.Enable && c.Compression.Threshold <= 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("compression", "threshold"),
+			c.Compression.Threshold, "threshold of compression must be positive"))
+	}
+	if a := c.Admission; a != nil && a.Enable {
+		if a.MaxConcurrentSyncs <= 0 {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("admission", "maxConcurrentSyncs"),
+				a.MaxConcurrentSyncs, "maxConcurrentSyncs of admission must be positive"))
+		}
+		if a.InitialSyncTimeout <= 0 {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("admission", "initialSyncTimeout"),
+				a.InitialSyncTimeout, "initialSyncTimeout of admission must be positive"))
+		}
+		if a.RetryAfter <= 0 {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("admission", "retryAfter"),
+				a.RetryAfter, "retryAfter of admission must be positive"))
+		}
+	}
+	if sh := c.Sharding; sh != nil && sh.Enable {
+		if sh.RenewInterval <= 0 {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("sharding", "renewInterval"),
+				sh.RenewInterval, "renewInterval of sharding must be positive"))
+		}
+		if sh.LeaseDuration <= sh.RenewInterval {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("sharding", "leaseDuration"),
+				sh.LeaseDuration, "leaseDuration of sharding must be greater than renewInterval"))
+		}
+		if sh.VirtualNodes <= 0 {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("sharding", "virtualNodes"),
+				sh.VirtualNodes, "virtualNodes of sharding must be positive"))
+		}
+	}
+	if sp := c.Spill; sp != nil && sp.Enable {
+		if sp.DBPath == "" {
+			allErrs = append(allErrs, field.Required(field.NewPath("spill", "dbPath"),
+				"dbPath of spill must be given"))
+		}
+		if sp.AckMessageLimit <= 0 {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spill", "ackMessageLimit"),
+				sp.AckMessageLimit, "ackMessageLimit of spill must be positive"))
+		}
+		if sp.NoAckMessageLimit <= 0 {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spill", "noAckMessageLimit"),
+				sp.NoAckMessageLimit, "noAckMessageLimit of spill must be positive"))
+		}
+	}
+	return allErrs
+}
+
+// electableModules are the modules of cloudcore which can be elected
+var electableModules = sets.NewString("synccontroller", "router")
+
+// ValidateModuleLeaderElection validates `l` and returns an errorList if it is invalid
+func ValidateModuleLeaderElection(l v1alpha1.ModuleLeaderElection) field.ErrorList {
+	if !l.Enable {
+		return field.ErrorList{}
+	}
+	allErrs := field.ErrorList{}
+	for i, module := range l.Modules {
+		// only the modules which can be stopped once the leadership is lost are elected,
+		// the modules serving the sessions of the edge nodes run on all replicas
+		if !electableModules.Has(module) {
+			allErrs = append(allErrs, field.NotSupported(field.NewPath("leaderElection", "modules").Index(i),
+				module, electableModules.List()))
+		}
+	}
+	if l.RetryPeriod <= 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("leaderElection", "retryPeriod"),
+			l.RetryPeriod, "retryPeriod of leaderElection must be positive"))
+	}
+	if l.RenewDeadline <= l.RetryPeriod {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("leaderElection", "renewDeadline"),
+			l.RenewDeadline, "renewDeadline of leaderElection must be greater than retryPeriod"))
+	}
+	if l.LeaseDuration <= l.RenewDeadline {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("leaderElection", "leaseDuration"),
+			l.LeaseDuration, "leaseDuration of leaderElection must be greater than renewDeadline"))
+	}
 	return allErrs
 }
 
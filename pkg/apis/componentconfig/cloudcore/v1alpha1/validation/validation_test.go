@@ -332,6 +332,58 @@ func TestValidateModuleSyncController(t *testing.T) {
 	}
 }
 
+func TestValidateModuleLeaderElection(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    v1alpha1.ModuleLeaderElection
+		expected field.ErrorList
+	}{
+		{
+			name: "case1 not enabled",
+			input: v1alpha1.ModuleLeaderElection{
+				Enable:  false,
+				Modules: []string{"cloudhub"},
+			},
+			expected: field.ErrorList{},
+		},
+		{
+			name: "case2 all ok",
+			input: v1alpha1.ModuleLeaderElection{
+				Enable:        true,
+				Modules:       []string{"synccontroller", "router"},
+				LeaseDuration: 15,
+				RenewDeadline: 10,
+				RetryPeriod:   2,
+			},
+			expected: field.ErrorList{},
+		},
+		{
+			name: "case3 modules can't be elected and invalid durations",
+			input: v1alpha1.ModuleLeaderElection{
+				Enable:        true,
+				Modules:       []string{"nodeupgradejobcontroller", "cloudhub"},
+				LeaseDuration: 10,
+				RenewDeadline: 10,
+				RetryPeriod:   2,
+			},
+			expected: field.ErrorList{
+				field.NotSupported(field.NewPath("leaderElection", "modules").Index(0), "nodeupgradejobcontroller",
+					[]string{"router", "synccontroller"}),
+				field.NotSupported(field.NewPath("leaderElection", "modules").Index(1), "cloudhub",
+					[]string{"router", "synccontroller"}),
+				field.Invalid(field.NewPath("leaderElection", "leaseDuration"), int32(10),
+					"leaseDuration of leaderElection must be greater than renewDeadline"),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		if result := ValidateModuleLeaderElection(c.input); !reflect.DeepEqual(result, c.expected) {
+			t.Errorf("%v: expected %v, but got %v", c.name, c.expected, result)
+		}
+	}
+}
+
 func TestValidateModuleDynamicController(t *testing.T) {
 	cases := []struct {
 		name     string
@@ -39,6 +39,8 @@ const (
 const (
 	// DataBaseDriverName is sqlite3
 	DataBaseDriverName = "sqlite3"
+	// DataBaseDriverNameBolt is bbolt, the embedded key-value store
+	DataBaseDriverNameBolt = "bbolt"
 	// DataBaseAliasName is default
 	DataBaseAliasName = "default"
 	// DataBaseDataSource is edge.db
@@ -63,7 +65,8 @@ type EdgeCoreConfig struct {
 
 // DataBase indicates the database info
 type DataBase struct {
-	// DriverName indicates database driver name
+	// DriverName indicates database driver name, "sqlite3" or "bbolt".
+	// bbolt is an embedded key-value store which writes less to the disk
 	// default "sqlite3"
 	DriverName string `json:"driverName,omitempty"`
 	// AliasName indicates alias name
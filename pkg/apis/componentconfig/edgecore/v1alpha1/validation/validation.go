@@ -47,6 +47,12 @@ func ValidateEdgeCoreConfiguration(c *v1alpha1.EdgeCoreConfig) field.ErrorList {
 // ValidateDataBase validates `db` and returns an errorList if it is invalid
 func ValidateDataBase(db v1alpha1.DataBase) field.ErrorList {
 	allErrs := field.ErrorList{}
+	switch db.DriverName {
+	case v1alpha1.DataBaseDriverName, v1alpha1.DataBaseDriverNameBolt:
+	default:
+		allErrs = append(allErrs, field.Invalid(field.NewPath("DriverName"), db.DriverName,
+			fmt.Sprintf("DriverName must be %s or %s", v1alpha1.DataBaseDriverName, v1alpha1.DataBaseDriverNameBolt)))
+	}
 	sourceDir := path.Dir(db.DataSource)
 	if !utilvalidation.FileIsExist(sourceDir) {
 		if err := os.MkdirAll(sourceDir, os.ModePerm); err != nil {
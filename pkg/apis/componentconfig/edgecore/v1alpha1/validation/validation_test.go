@@ -52,6 +52,7 @@ func TestValidateDataBase(t *testing.T) {
 	ef, err := os.CreateTemp(dir, "FileIsExist")
 	if err == nil {
 		db := v1alpha1.DataBase{
+			DriverName: v1alpha1.DataBaseDriverName,
 			DataSource: ef.Name(),
 		}
 		if errs := ValidateDataBase(db); len(errs) > 0 {
@@ -63,12 +64,18 @@ func TestValidateDataBase(t *testing.T) {
 	nonexistentFile := filepath.Join(nonexistentDir, "not_exist_file")
 
 	db := v1alpha1.DataBase{
+		DriverName: v1alpha1.DataBaseDriverNameBolt,
 		DataSource: nonexistentFile,
 	}
 
 	if errs := ValidateDataBase(db); len(errs) > 0 {
 		t.Errorf("file %v should not created, err is %v", nonexistentFile, errs)
 	}
+
+	db.DriverName = "mysql"
+	if errs := ValidateDataBase(db); len(errs) != 1 {
+		t.Errorf("driver %v should be invalid, but got errors %v", db.DriverName, errs)
+	}
 }
 
 func TestValidateModuleEdged(t *testing.T) {
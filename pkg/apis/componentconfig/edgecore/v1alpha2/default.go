@@ -121,6 +121,23 @@ func NewDefaultEdgeCoreConfig() *EdgeCoreConfig {
 				}).String(),
 				Token:              "",
 				RotateCertificates: true,
+				Compression: &EdgeHubCompression{
+					Enable:    false,
+					Threshold: constants.DefaultCompressionThreshold,
+				},
+				OutboundQueue: &EdgeHubOutboundQueue{
+					Enable:            false,
+					DBPath:            constants.DefaultOutboundQueueDBPath,
+					DefaultGroupLimit: constants.DefaultOutboundQueueGroupLimit,
+				},
+				Proxy: &EdgeHubProxy{
+					Enable: false,
+				},
+				MeteredLink: &EdgeHubMeteredLink{
+					Enable:         false,
+					DeferredGroups: []string{"user"},
+					StatePath:      constants.DefaultMeteredLinkStatePath,
+				},
 			},
 			EventBus: &EventBus{
 				Enable:               true,
@@ -147,11 +164,45 @@ func NewDefaultEdgeCoreConfig() *EdgeCoreConfig {
 				ContextSendModule:  metaconfig.ModuleNameEdgeHub,
 				RemoteQueryTimeout: constants.DefaultRemoteQueryTimeout,
 				MetaServer: &MetaServer{
-					Enable:            false,
-					Server:            constants.DefaultMetaServerAddr,
-					TLSCaFile:         constants.DefaultCAFile,
-					TLSCertFile:       constants.DefaultCertFile,
-					TLSPrivateKeyFile: constants.DefaultKeyFile,
+					Enable:                false,
+					Server:                constants.DefaultMetaServerAddr,
+					TLSCaFile:             constants.DefaultCAFile,
+					TLSCertFile:           constants.DefaultCertFile,
+					TLSPrivateKeyFile:     constants.DefaultKeyFile,
+					WatchEventHistorySize: constants.DefaultWatchEventHistorySize,
+				},
+				RemoteQueryCache: &RemoteQueryCache{
+					Enable: false,
+					Policies: []RemoteQueryCachePolicy{
+						{
+							ResourceType: "configmap",
+							TTL:          metav1.Duration{Duration: constants.DefaultRemoteQueryCacheTTL},
+							NegativeTTL:  metav1.Duration{Duration: constants.DefaultRemoteQueryCacheNegativeTTL},
+						},
+						{
+							ResourceType: "secret",
+							TTL:          metav1.Duration{Duration: constants.DefaultRemoteQueryCacheTTL},
+							NegativeTTL:  metav1.Duration{Duration: constants.DefaultRemoteQueryCacheNegativeTTL},
+						},
+						{
+							ResourceType: "persistentvolume",
+							TTL:          metav1.Duration{Duration: constants.DefaultRemoteQueryCacheTTL},
+							NegativeTTL:  metav1.Duration{Duration: constants.DefaultRemoteQueryCacheNegativeTTL},
+						},
+						{
+							ResourceType: "persistentvolumeclaim",
+							TTL:          metav1.Duration{Duration: constants.DefaultRemoteQueryCacheTTL},
+							NegativeTTL:  metav1.Duration{Duration: constants.DefaultRemoteQueryCacheNegativeTTL},
+						},
+					},
+				},
+				Encryption: &MetaEncryption{
+					Enable:                false,
+					Resources:             []string{"secret", "serviceaccounttoken"},
+					KeyProvider:           constants.DefaultEncryptionKeyProvider,
+					KeyFile:               constants.DefaultEncryptionKeyFile,
+					DataKeyFile:           constants.DefaultEncryptionDataKeyFile,
+					DataKeyRotationPeriod: metav1.Duration{Duration: constants.DefaultDataKeyRotationPeriod},
 				},
 			},
 			ServiceBus: &ServiceBus{
@@ -175,6 +226,7 @@ func NewDefaultEdgeCoreConfig() *EdgeCoreConfig {
 				ReadDeadline:            15,
 				TunnelServer:            net.JoinHostPort("127.0.0.1", strconv.Itoa(constants.DefaultTunnelPort)),
 				WriteDeadline:           15,
+				TunnelOverCloudHub:      false,
 			},
 		},
 	}
@@ -30,6 +30,13 @@ const (
 	MqttModeExternal MqttMode = 2
 )
 
+const (
+	// ProtocolWebSocket is the protocol of websocket endpoints of EdgeHub
+	ProtocolWebSocket = "websocket"
+	// ProtocolQuic is the protocol of quic endpoints of EdgeHub
+	ProtocolQuic = "quic"
+)
+
 const (
 	CGroupDriverCGroupFS = "cgroupfs"
 	CGroupDriverSystemd  = "systemd"
@@ -38,6 +45,8 @@ const (
 const (
 	// DataBaseDriverName is sqlite3
 	DataBaseDriverName = "sqlite3"
+	// DataBaseDriverNameBolt is bbolt, the embedded key-value store
+	DataBaseDriverNameBolt = "bbolt"
 	// DataBaseAliasName is default
 	DataBaseAliasName = "default"
 	// DataBaseDataSource is edge.db
@@ -62,7 +71,8 @@ type EdgeCoreConfig struct {
 
 // DataBase indicates the database info
 type DataBase struct {
-	// DriverName indicates database driver name
+	// DriverName indicates database driver name, "sqlite3" or "bbolt".
+	// bbolt is an embedded key-value store which writes less to the disk
 	// default "sqlite3"
 	DriverName string `json:"driverName,omitempty"`
 	// AliasName indicates alias name
@@ -296,6 +306,12 @@ type EdgeHub struct {
 	// WebSocket indicates websocket config for EdgeHub module
 	// Optional if quic is configured
 	WebSocket *EdgeHubWebSocket `json:"websocket,omitempty"`
+	// Endpoints indicates several cloudcore endpoints to connect, websocket and quic can be mixed.
+	// If set, the servers of websocket and quic are ignored, while the other settings of them such as
+	// the handshake timeout are still used by the endpoints of the protocol. EdgeHub connects to
+	// the healthy endpoint with the highest priority, and fails over to another one on disconnect.
+	// Optional
+	Endpoints []EdgeHubEndpoint `json:"endpoints,omitempty"`
 	// Token indicates the priority of joining the cluster for the edge
 	Token string `json:"token"`
 	// HTTPServer indicates the server for edge to apply for the certificate.
@@ -303,6 +319,112 @@ type EdgeHub struct {
 	// RotateCertificates indicates whether edge certificate can be rotated
 	// default true
 	RotateCertificates bool `json:"rotateCertificates,omitempty"`
+	// Compression indicates the payload compression config,
+	// the payloads are compressed only if cloudcore supports compression too
+	Compression *EdgeHubCompression `json:"compression,omitempty"`
+	// OutboundQueue indicates the config of the persistent queue of the messages sent to cloud
+	OutboundQueue *EdgeHubOutboundQueue `json:"outboundQueue,omitempty"`
+	// Proxy indicates the proxy to connect cloudcore, it is used by the websocket connection,
+	// the certificate requests and the stream tunnel of EdgeStream. Quic can not be proxied,
+	// so the proxy can not be enabled with quic endpoints.
+	Proxy *EdgeHubProxy `json:"proxy,omitempty"`
+	// MeteredLink indicates the byte budgets of the messages sent to cloud on a metered link
+	MeteredLink *EdgeHubMeteredLink `json:"meteredLink,omitempty"`
+}
+
+// EdgeHubMeteredLink indicates the byte budgets of the messages sent to cloud.
+// The bytes of all messages are counted, while only the messages of DeferredGroups are deferred
+// once the budgets of them are used up, until the next window or enough tokens in the bucket.
+// The usage of the budgets is reported to the node annotation "edgehub.kubeedge.io/metered-link".
+type EdgeHubMeteredLink struct {
+	// Enable indicates whether to limit the bytes sent to cloud
+	// default false
+	Enable bool `json:"enable"`
+	// HourlyBytes indicates the bytes allowed to send in each hour, 0 means unlimited
+	HourlyBytes int64 `json:"hourlyBytes,omitempty"`
+	// DailyBytes indicates the bytes allowed to send in each day from the local midnight, 0 means unlimited
+	DailyBytes int64 `json:"dailyBytes,omitempty"`
+	// BytesPerSecond indicates the rate of the token bucket in bytes, 0 means unlimited
+	BytesPerSecond int64 `json:"bytesPerSecond,omitempty"`
+	// BurstBytes indicates the size of the token bucket in bytes
+	// default BytesPerSecond
+	BurstBytes int64 `json:"burstBytes,omitempty"`
+	// GroupShares indicates the percentage of the budgets for the message groups such as
+	// "resource", "twin" and "user", the groups not listed are limited by the whole budgets only
+	GroupShares map[string]int32 `json:"groupShares,omitempty"`
+	// DeferredGroups indicates the message groups deferred once their budgets are used up
+	// default ["user"]
+	DeferredGroups []string `json:"deferredGroups,omitempty"`
+	// StatePath indicates the file keeping the usage of the budget windows,
+	// so the budgets are not reset by restarting edgecore. The usage is kept in memory only if it is empty.
+	// default "/var/lib/kubeedge/edgehub-metered-link.json"
+	StatePath string `json:"statePath,omitempty"`
+}
+
+// EdgeHubProxy indicates the HTTP CONNECT or SOCKS5 proxy to connect cloudcore
+type EdgeHubProxy struct {
+	// Enable indicates whether to connect cloudcore through the proxy
+	// default false
+	Enable bool `json:"enable"`
+	// URL indicates the url of the proxy, the scheme can be http, https or socks5.
+	// If not set, the proxy is taken from the environment variables HTTPS_PROXY and NO_PROXY
+	// or the lowercase versions of them.
+	URL string `json:"url,omitempty"`
+	// Username indicates the username to authenticate to the proxy
+	Username string `json:"username,omitempty"`
+	// Password indicates the password to authenticate to the proxy
+	Password string `json:"password,omitempty"`
+	// NoProxy indicates the hosts to connect directly, such as "10.0.0.1", "10.0.0.0/8",
+	// "example.com" which matches its subdomains too, or "*" which matches all hosts
+	NoProxy []string `json:"noProxy,omitempty"`
+}
+
+// EdgeHubOutboundQueue indicates the persistent queue of the messages sent to cloud.
+// The messages are kept on disk while the cloud is disconnected and sent in the order of
+// priority on reconnect: node status and lease first, then the other resources, and device
+// twin and user messages last. The superseded status updates of the same resource are coalesced.
+type EdgeHubOutboundQueue struct {
+	// Enable indicates whether to queue the messages sent to cloud on disk
+	// default false
+	Enable bool `json:"enable"`
+	// DBPath indicates the path of the queue database file
+	// default "/var/lib/kubeedge/edgehub-queue.db"
+	DBPath string `json:"dbPath,omitempty"`
+	// GroupLimits indicates the max number of queued messages of the message groups such as
+	// "resource", "twin" and "user", the groups not listed are limited by DefaultGroupLimit.
+	// When a group is full, its oldest message of a lower priority is dropped for a new one,
+	// otherwise the new one is rejected and an error is responded if the producer waits for it.
+	GroupLimits map[string]int32 `json:"groupLimits,omitempty"`
+	// DefaultGroupLimit indicates the max number of queued messages of the groups not in GroupLimits
+	// default 10000
+	DefaultGroupLimit int32 `json:"defaultGroupLimit,omitempty"`
+}
+
+// EdgeHubCompression indicates the payload compression config of EdgeHub
+type EdgeHubCompression struct {
+	// Enable indicates whether to compress the payloads of messages
+	// default false
+	Enable bool `json:"enable"`
+	// Threshold indicates the min size of the payloads to compress (bytes)
+	// default 1024
+	Threshold int32 `json:"threshold,omitempty"`
+}
+
+// EdgeHubEndpoint indicates a cloudcore endpoint EdgeHub can connect to
+type EdgeHubEndpoint struct {
+	// Protocol indicates the protocol of the endpoint, "websocket" or "quic"
+	// default "websocket"
+	Protocol string `json:"protocol,omitempty"`
+	// Server indicates the server address (ip:port)
+	// +Required
+	Server string `json:"server"`
+	// Priority indicates the priority of the endpoint, the endpoints with smaller value are preferred
+	// default 0
+	Priority int32 `json:"priority,omitempty"`
+	// Weight indicates the weight of the endpoint among the ones with the same priority,
+	// an endpoint is chosen randomly in proportion to the weights
+	// default 1
+	Weight int32 `json:"weight,omitempty"`
 }
 
 // EdgeHubQUIC indicates the quic client config
@@ -420,8 +542,60 @@ type MetaManager struct {
 	// RemoteQueryTimeout indicates remote query timeout (second)
 	// default 60
 	RemoteQueryTimeout int32 `json:"remoteQueryTimeout,omitempty"`
+	// RemoteQueryCache indicates the config of caching the resources queried from cloud
+	RemoteQueryCache *RemoteQueryCache `json:"remoteQueryCache,omitempty"`
 	// The config of MetaServer
 	MetaServer *MetaServer `json:"metaServer,omitempty"`
+	// Encryption indicates the config of encrypting resources at rest in edgecore.db
+	Encryption *MetaEncryption `json:"encryption,omitempty"`
+}
+
+// MetaEncryption indicates the config of envelope encryption, the resources are encrypted by
+// a data key of the node, and the data key is sealed by a key provider
+type MetaEncryption struct {
+	// Enable indicates whether the resources are encrypted
+	// default false
+	Enable bool `json:"enable"`
+	// Resources indicates the resource types to encrypt
+	// default ["secret", "serviceaccounttoken"]
+	Resources []string `json:"resources,omitempty"`
+	// KeyProvider indicates the provider which seals the data key, only "file" is supported now
+	// default "file"
+	KeyProvider string `json:"keyProvider,omitempty"`
+	// KeyFile indicates the key file of the file key provider, it is generated if not exist
+	// default "/etc/kubeedge/encryption/kek"
+	KeyFile string `json:"keyFile,omitempty"`
+	// DataKeyFile indicates the file the sealed data keys are saved in
+	// default "/var/lib/kubeedge/datakeys.json"
+	DataKeyFile string `json:"dataKeyFile,omitempty"`
+	// DataKeyRotationPeriod indicates how long a data key is used before it is rotated,
+	// the existing resources are re-encrypted by the new data key, 0 means never
+	// default 720h
+	DataKeyRotationPeriod metav1.Duration `json:"dataKeyRotationPeriod,omitempty"`
+}
+
+// RemoteQueryCache indicates the cache policies of the resources which are queried from cloud
+// while edgecore is connected, such as configmaps and secrets
+type RemoteQueryCache struct {
+	// Enable indicates whether the cache policies take effect
+	// default false
+	Enable bool `json:"enable"`
+	// Policies indicates the cache policy of each resource type,
+	// the resource types without policy are queried from cloud as before
+	Policies []RemoteQueryCachePolicy `json:"policies,omitempty"`
+}
+
+// RemoteQueryCachePolicy indicates the cache policy of a resource type
+type RemoteQueryCachePolicy struct {
+	// ResourceType indicates the resource type, such as configmap, secret and node
+	ResourceType string `json:"resourceType"`
+	// TTL indicates how long the resource in local meta is served without querying cloud after
+	// it is fetched, the resource older than TTL is served and revalidated from cloud in background
+	TTL metav1.Duration `json:"ttl,omitempty"`
+	// NegativeTTL indicates how long the resource not found in cloud is answered as not found
+	// without querying cloud again, 0 means not found answers are not cached.
+	// Cloud answers not found only if the feature gate remoteQueryNotFound of cloudcore is enabled
+	NegativeTTL metav1.Duration `json:"negativeTTL,omitempty"`
 }
 
 type MetaServer struct {
@@ -430,6 +604,12 @@ type MetaServer struct {
 	TLSCaFile         string `json:"tlsCaFile"`
 	TLSCertFile       string `json:"tlsCertFile"`
 	TLSPrivateKeyFile string `json:"tlsPrivateKeyFile"`
+	// WatchEventHistorySize indicates the max number of watch events persisted in edgecore.db,
+	// they are replayed to clients that resume a watch from a resourceVersion, 0 means disabled.
+	// Each event is a write to edgecore.db, so size it by the number of events the clients may miss
+	// while reconnecting, e.g. 1000
+	// default 0
+	WatchEventHistorySize int32 `json:"watchEventHistorySize,omitempty"`
 }
 
 // ServiceBus indicates the ServiceBus module config
@@ -491,4 +671,8 @@ type EdgeStream struct {
 	// WriteDeadline indicates write dead line (second)
 	// default 15
 	WriteDeadline int32 `json:"writeDeadline,omitempty"`
+	// TunnelOverCloudHub indicates whether the tunnel is carried over the CloudHub connection of EdgeHub
+	// instead of the connection to TunnelServer, so only the CloudHub port needs to be reachable
+	// default false
+	TunnelOverCloudHub bool `json:"tunnelOverCloudHub,omitempty"`
 }
@@ -18,6 +18,7 @@ package validation
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path"
 
@@ -26,6 +27,7 @@ import (
 	"k8s.io/kubernetes/pkg/apis/core/validation"
 
 	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha2"
+	"github.com/kubeedge/kubeedge/pkg/features"
 	utilvalidation "github.com/kubeedge/kubeedge/pkg/util/validation"
 )
 
@@ -41,12 +43,35 @@ func ValidateEdgeCoreConfiguration(c *v1alpha2.EdgeCoreConfig) field.ErrorList {
 	allErrs = append(allErrs, ValidateModuleDeviceTwin(*c.Modules.DeviceTwin)...)
 	allErrs = append(allErrs, ValidateModuleDBTest(*c.Modules.DBTest)...)
 	allErrs = append(allErrs, ValidateModuleEdgeStream(*c.Modules.EdgeStream)...)
+	allErrs = append(allErrs, ValidateMetaServerOfflineWrite(c.FeatureGates, c.Modules.MetaManager.Encryption)...)
+	return allErrs
+}
+
+// ValidateMetaServerOfflineWrite validates the offline write of metaserver and returns an errorList if it is invalid.
+// The tokens of the offline writes are saved only if they can be encrypted, the offline writes can't be replayed
+// without their tokens if the authorization is required.
+func ValidateMetaServerOfflineWrite(featureGates map[string]bool, encryption *v1alpha2.MetaEncryption) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if !featureGates[string(features.MetaServerOfflineWrite)] || !featureGates[string(features.RequireAuthorization)] {
+		return allErrs
+	}
+	if encryption == nil || !encryption.Enable {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("Modules", "MetaManager", "Encryption", "Enable"), false,
+			fmt.Sprintf("encryption must be enabled to replay the offline writes when feature gates %s and %s are enabled",
+				features.MetaServerOfflineWrite, features.RequireAuthorization)))
+	}
 	return allErrs
 }
 
 // ValidateDataBase validates `db` and returns an errorList if it is invalid
 func ValidateDataBase(db v1alpha2.DataBase) field.ErrorList {
 	allErrs := field.ErrorList{}
+	switch db.DriverName {
+	case v1alpha2.DataBaseDriverName, v1alpha2.DataBaseDriverNameBolt:
+	default:
+		allErrs = append(allErrs, field.Invalid(field.NewPath("DriverName"), db.DriverName,
+			fmt.Sprintf("DriverName must be %s or %s", v1alpha2.DataBaseDriverName, v1alpha2.DataBaseDriverNameBolt)))
+	}
 	sourceDir := path.Dir(db.DataSource)
 	if !utilvalidation.FileIsExist(sourceDir) {
 		if err := os.MkdirAll(sourceDir, os.ModePerm); err != nil {
@@ -86,11 +111,26 @@ func ValidateModuleEdgeHub(h v1alpha2.EdgeHub) field.ErrorList {
 	}
 	allErrs := field.ErrorList{}
 
-	if h.WebSocket.Enable == h.Quic.Enable {
+	if len(h.Endpoints) == 0 && h.WebSocket.Enable == h.Quic.Enable {
 		allErrs = append(allErrs, field.Invalid(field.NewPath("enable"),
 			h.Quic.Enable, "websocket.enable and quic.enable cannot be true and false at the same time"))
 	}
 
+	for i, ep := range h.Endpoints {
+		path := field.NewPath("endpoints").Index(i)
+		if ep.Protocol != "" && ep.Protocol != v1alpha2.ProtocolWebSocket && ep.Protocol != v1alpha2.ProtocolQuic {
+			allErrs = append(allErrs, field.NotSupported(path.Child("protocol"), ep.Protocol,
+				[]string{v1alpha2.ProtocolWebSocket, v1alpha2.ProtocolQuic}))
+		}
+		if ep.Server == "" {
+			allErrs = append(allErrs, field.Required(path.Child("server"), "server of endpoint must be given"))
+		}
+		if ep.Weight < 0 {
+			allErrs = append(allErrs, field.Invalid(path.Child("weight"), ep.Weight,
+				"weight of endpoint must not be a negative number"))
+		}
+	}
+
 	if h.MessageQPS < 0 {
 		allErrs = append(allErrs, field.Invalid(field.NewPath("messageQPS"), h.MessageQPS,
 			"MessageQPS must not be a negative number"))
@@ -101,6 +141,94 @@ func ValidateModuleEdgeHub(h v1alpha2.EdgeHub) field.ErrorList {
 			"MessageBurst must not be a negative number"))
 	}
 
+	if h.Compression != nil && h.Compression.Enable && h.Compression.Threshold <= 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("compression", "threshold"), h.Compression.Threshold,
+			"threshold of compression must be a positive number"))
+	}
+
+	if q := h.OutboundQueue; q != nil && q.Enable {
+		if q.DBPath == "" {
+			allErrs = append(allErrs, field.Required(field.NewPath("outboundQueue", "dbPath"),
+				"dbPath of outbound queue must be given"))
+		}
+		if q.DefaultGroupLimit <= 0 {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("outboundQueue", "defaultGroupLimit"),
+				q.DefaultGroupLimit, "defaultGroupLimit must be a positive number"))
+		}
+		for group, limit := range q.GroupLimits {
+			if limit <= 0 {
+				allErrs = append(allErrs, field.Invalid(field.NewPath("outboundQueue", "groupLimits").Key(group),
+					limit, "limit of group must be a positive number"))
+			}
+		}
+	}
+
+	// quic runs over udp, which is not tunneled by the HTTP CONNECT or SOCKS5 proxy
+	if p := h.Proxy; p != nil && p.Enable {
+		for i, ep := range h.Endpoints {
+			if ep.Protocol == v1alpha2.ProtocolQuic {
+				allErrs = append(allErrs, field.Invalid(field.NewPath("endpoints").Index(i).Child("protocol"),
+					ep.Protocol, "quic endpoint can't be connected through proxy"))
+			}
+		}
+		if len(h.Endpoints) == 0 && h.Quic != nil && h.Quic.Enable {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("proxy", "enable"), p.Enable,
+				"quic can't be connected through proxy"))
+		}
+	}
+
+	if p := h.Proxy; p != nil && p.Enable && p.URL != "" {
+		u, err := url.Parse(p.URL)
+		switch {
+		case err != nil:
+			allErrs = append(allErrs, field.Invalid(field.NewPath("proxy", "url"), p.URL, err.Error()))
+		case u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5":
+			allErrs = append(allErrs, field.NotSupported(field.NewPath("proxy", "url"), u.Scheme,
+				[]string{"http", "https", "socks5"}))
+		case u.Host == "":
+			allErrs = append(allErrs, field.Invalid(field.NewPath("proxy", "url"), p.URL, "host of proxy must be given"))
+		}
+	}
+
+	if m := h.MeteredLink; m != nil && m.Enable {
+		allErrs = append(allErrs, validateMeteredLink(*m)...)
+	}
+
+	return allErrs
+}
+
+// validateMeteredLink validates the byte budgets of the metered link
+func validateMeteredLink(m v1alpha2.EdgeHubMeteredLink) field.ErrorList {
+	allErrs := field.ErrorList{}
+	path := field.NewPath("meteredLink")
+	budgets := map[string]int64{
+		"hourlyBytes":    m.HourlyBytes,
+		"dailyBytes":     m.DailyBytes,
+		"bytesPerSecond": m.BytesPerSecond,
+		"burstBytes":     m.BurstBytes,
+	}
+	for name, value := range budgets {
+		if value < 0 {
+			allErrs = append(allErrs, field.Invalid(path.Child(name), value, name+" must not be a negative number"))
+		}
+	}
+	if m.HourlyBytes == 0 && m.DailyBytes == 0 && m.BytesPerSecond == 0 {
+		allErrs = append(allErrs, field.Required(path,
+			"one of hourlyBytes, dailyBytes and bytesPerSecond must be given"))
+	}
+
+	var total int32
+	for group, share := range m.GroupShares {
+		if share <= 0 || share > 100 {
+			allErrs = append(allErrs, field.Invalid(path.Child("groupShares").Key(group), share,
+				"share of group must be a percentage between 1 and 100"))
+		}
+		total += share
+	}
+	if total > 100 {
+		allErrs = append(allErrs, field.Invalid(path.Child("groupShares"), total,
+			"sum of the shares must not be greater than 100"))
+	}
 	return allErrs
 }
 
@@ -53,6 +53,7 @@ func TestValidateDataBase(t *testing.T) {
 	ef, err := os.CreateTemp(dir, "FileIsExist")
 	if err == nil {
 		db := v1alpha2.DataBase{
+			DriverName: v1alpha2.DataBaseDriverName,
 			DataSource: ef.Name(),
 		}
 		if errs := ValidateDataBase(db); len(errs) > 0 {
@@ -64,12 +65,18 @@ func TestValidateDataBase(t *testing.T) {
 	nonexistentFile := filepath.Join(nonexistentDir, "not_exist_file")
 
 	db := v1alpha2.DataBase{
+		DriverName: v1alpha2.DataBaseDriverNameBolt,
 		DataSource: nonexistentFile,
 	}
 
 	if errs := ValidateDataBase(db); len(errs) > 0 {
 		t.Errorf("file %v should not created, err is %v", nonexistentFile, errs)
 	}
+
+	db.DriverName = "mysql"
+	if errs := ValidateDataBase(db); len(errs) != 1 {
+		t.Errorf("driver %v should be invalid, but got errors %v", db.DriverName, errs)
+	}
 }
 
 func TestValidateModuleEdged(t *testing.T) {
@@ -204,6 +211,44 @@ func TestValidateModuleEdgeHub(t *testing.T) {
 			result: field.ErrorList{field.Invalid(field.NewPath("messageBurst"),
 				int32(-1), "MessageBurst must not be a negative number")},
 		},
+		{
+			name: "case6 quic can not be proxied",
+			input: v1alpha2.EdgeHub{
+				Enable: true,
+				WebSocket: &v1alpha2.EdgeHubWebSocket{
+					Enable: false,
+				},
+				Quic: &v1alpha2.EdgeHubQUIC{
+					Enable: true,
+				},
+				Proxy: &v1alpha2.EdgeHubProxy{
+					Enable: true,
+				},
+			},
+			result: field.ErrorList{field.Invalid(field.NewPath("proxy", "enable"),
+				true, "quic can't be connected through proxy")},
+		},
+		{
+			name: "case7 quic endpoint can not be proxied",
+			input: v1alpha2.EdgeHub{
+				Enable: true,
+				WebSocket: &v1alpha2.EdgeHubWebSocket{
+					Enable: true,
+				},
+				Quic: &v1alpha2.EdgeHubQUIC{
+					Enable: false,
+				},
+				Endpoints: []v1alpha2.EdgeHubEndpoint{
+					{Protocol: v1alpha2.ProtocolWebSocket, Server: "127.0.0.1:10000"},
+					{Protocol: v1alpha2.ProtocolQuic, Server: "127.0.0.1:10001"},
+				},
+				Proxy: &v1alpha2.EdgeHubProxy{
+					Enable: true,
+				},
+			},
+			result: field.ErrorList{field.Invalid(field.NewPath("endpoints").Index(1).Child("protocol"),
+				v1alpha2.ProtocolQuic, "quic endpoint can't be connected through proxy")},
+		},
 	}
 
 	for _, c := range cases {
@@ -282,6 +327,43 @@ func TestValidateModuleMetaManager(t *testing.T) {
 	}
 }
 
+func TestValidateMetaServerOfflineWrite(t *testing.T) {
+	gates := map[string]bool{"metaServerOfflineWrite": true, "requireAuthorization": true}
+	cases := []struct {
+		name         string
+		featureGates map[string]bool
+		encryption   *v1alpha2.MetaEncryption
+		invalid      bool
+	}{
+		{
+			name:         "authorization not required",
+			featureGates: map[string]bool{"metaServerOfflineWrite": true},
+		},
+		{
+			name:         "authorization required without encryption",
+			featureGates: gates,
+			invalid:      true,
+		},
+		{
+			name:         "authorization required with encryption disabled",
+			featureGates: gates,
+			encryption:   &v1alpha2.MetaEncryption{Enable: false},
+			invalid:      true,
+		},
+		{
+			name:         "authorization required with encryption enabled",
+			featureGates: gates,
+			encryption:   &v1alpha2.MetaEncryption{Enable: true},
+		},
+	}
+
+	for _, c := range cases {
+		if errs := ValidateMetaServerOfflineWrite(c.featureGates, c.encryption); (len(errs) > 0) != c.invalid {
+			t.Errorf("%v: expected invalid %v, but got %v", c.name, c.invalid, errs)
+		}
+	}
+}
+
 func TestValidateModuleServiceBus(t *testing.T) {
 	cases := []struct {
 		name     string
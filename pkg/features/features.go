@@ -26,11 +26,26 @@ const (
 	// alpha: v1.12
 	// owner: @vincentgoat
 	RequireAuthorization featuregate.Feature = "requireAuthorization"
+
+	// MetaServerOfflineWrite supports writing to meta server when node is offline.
+	// Create, update, patch and delete requests are applied to local host db and recorded in a journal,
+	// which will be replayed to kube-apiserver through dynamiccontroller when node is online again.
+	// alpha: v1.12
+	MetaServerOfflineWrite featuregate.Feature = "metaServerOfflineWrite"
+
+	// RemoteQueryNotFound answers the queries from edge with NotFound if the resources are not found in
+	// kube-apiserver, instead of leaving the queries to time out. The answer is only cached and converted
+	// to the NotFound status by the edge nodes with the remote query cache enabled, so enable it only if
+	// all edge nodes are upgraded to handle it.
+	// alpha: v1.12
+	RemoteQueryNotFound featuregate.Feature = "remoteQueryNotFound"
 )
 
 // defaultFeatureGates consists of all known Kubeedge-specific feature keys.
 // To add a new feature, define a key for it above and add it here. The features will be
 // available throughout Kubeedge binaries.
 var defaultFeatureGates = map[featuregate.Feature]featuregate.FeatureSpec{
-	RequireAuthorization: {Default: false, PreRelease: featuregate.Alpha},
+	RequireAuthorization:   {Default: false, PreRelease: featuregate.Alpha},
+	MetaServerOfflineWrite: {Default: false, PreRelease: featuregate.Alpha},
+	RemoteQueryNotFound:    {Default: false, PreRelease: featuregate.Alpha},
 }
@@ -0,0 +1,92 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package hubadmin defines the admin API of CloudHub shared by cloudcore and keadm. The API
+// shows the sessions and the message pools of the edge nodes connected to a cloudcore,
+// purges or re-sends the messages of a node, and serves the metrics of CloudHub. It is
+// served on the CloudHub https server, the bearer tokens of the requests are authenticated by
+// TokenReview and the users are authorized on the paths of the requests by SubjectAccessReview.
+package hubadmin
+
+import (
+	"time"
+)
+
+const (
+	// NodesPath lists the connected nodes, the operations of a node are under NodesPath/<node>
+	NodesPath = "/admin/nodes"
+	// MessagesSubPath lists the messages held in the message pool of a node
+	MessagesSubPath = "messages"
+	// PurgeSubPath deletes the messages held in the message pool of a node
+	PurgeSubPath = "purge"
+	// ResendSubPath queues the messages held in the message pool of a node to send again
+	ResendSubPath = "resend"
+	// MetricsPath serves the metrics of CloudHub in the Prometheus text format
+	MetricsPath = "/admin/metrics"
+)
+
+// the states of the node sessions
+const (
+	// StateSyncing is the state of the session before the node synced initially
+	StateSyncing = "Syncing"
+	// StateSynced is the state of the session after the node synced initially
+	StateSynced = "Synced"
+	// StateTerminating is the state of the session terminated and being cleaned up
+	StateTerminating = "Terminating"
+)
+
+// the queues of the message pool
+const (
+	AckQueue   = "ack"
+	NoAckQueue = "noack"
+)
+
+// NodeStatus is the status of the session and the message pool of a connected node
+type NodeStatus struct {
+	NodeID    string `json:"nodeID"`
+	ProjectID string `json:"projectID,omitempty"`
+	State     string `json:"state"`
+	// LastKeepalive is the time the last keepalive message was received, or the node connected
+	LastKeepalive time.Time `json:"lastKeepalive"`
+	// AckQueueDepth and NoAckQueueDepth are the numbers of the messages waiting to be sent
+	AckQueueDepth   int `json:"ackQueueDepth"`
+	NoAckQueueDepth int `json:"noAckQueueDepth"`
+	// AckStoreSize and NoAckStoreSize are the numbers of the messages held in the message pool,
+	// the latest message of each resource is held in the ack store after it is acknowledged
+	AckStoreSize   int `json:"ackStoreSize"`
+	NoAckStoreSize int `json:"noAckStoreSize"`
+	// WaitingAck is the number of the messages sent and not acknowledged yet
+	WaitingAck int `json:"waitingAck"`
+}
+
+// Message is the summary of a message held in the message pool of a node
+type Message struct {
+	Queue           string `json:"queue"`
+	ID              string `json:"id"`
+	Group           string `json:"group"`
+	Resource        string `json:"resource"`
+	Operation       string `json:"operation"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+	// Timestamp is the time the message was created in milliseconds
+	Timestamp int64 `json:"timestamp"`
+}
+
+// Result is the result of purging or re-sending the messages of a node
+type Result struct {
+	NodeID string `json:"nodeID"`
+	// Messages is the number of the messages purged or queued to send again
+	Messages int `json:"messages"`
+}
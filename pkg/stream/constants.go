@@ -12,4 +12,16 @@ const (
 	MessageTypeData
 	MessageTypeRemoveConnect
 	MessageTypeCloseConnect
+	// the messages below are only used by the tunnel over the CloudHub connection
+	MessageTypeWindowUpdate
+	MessageTypePing
+	MessageTypePong
+)
+
+// the model messages carrying the tunnel over the CloudHub connection
+const (
+	HubGroup            = "stream"
+	HubResourceTunnel   = "tunnel"
+	HubOperationConnect = "connect"
+	HubOperationTunnel  = "tunnel"
 )
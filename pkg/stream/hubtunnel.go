@@ -0,0 +1,246 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stream
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"k8s.io/klog/v2"
+)
+
+var (
+	// HubWindowSize is the bytes of data a stream connection sends over the CloudHub connection
+	// before the peer consumes them, so a stream never floods the connection shared with the
+	// other messages
+	HubWindowSize = 256 * 1024
+
+	// HubIdleTimeout closes the tunnel if nothing is received from the peer, the edge pings the
+	// cloud every 5 seconds and the cloud answers
+	HubIdleTimeout = 30 * time.Second
+
+	// ErrHubTunnelClosed is returned after the tunnel over the CloudHub connection is closed
+	ErrHubTunnelClosed = errors.New("tunnel over cloudhub connection is closed")
+)
+
+// ConnectInfo is the content of the message the edge opens the tunnel over the CloudHub connection with
+type ConnectInfo struct {
+	HostNameOverride string `json:"hostNameOverride"`
+	InternalIP       string `json:"internalIP"`
+}
+
+// HubTunnel is the tunnel multiplexing the stream connections over the CloudHub connection,
+// the data of each stream connection is flow controlled by a window of HubWindowSize bytes.
+// The messages from the peer are delivered to the tunnel by Deliver, and the messages to the
+// peer are sent by the send function.
+type HubTunnel struct {
+	send func(*Message) error
+
+	lock sync.Mutex
+	// cond is signaled when the windows change or the tunnel is closed
+	cond *sync.Cond
+	// queue is the messages received from the peer and not read yet
+	queue  []*Message
+	notify chan struct{}
+	// windows is the bytes each stream connection can send before the peer consumes them
+	windows map[uint64]int
+	// last is the message read last, its bytes are granted back to the peer on the next read
+	last     *Message
+	received time.Time
+	closed   bool
+	done     chan struct{}
+}
+
+var _ SafeWriteTunneler = &HubTunnel{}
+
+// NewHubTunnel returns the tunnel sending the messages to the peer by send
+func NewHubTunnel(send func(*Message) error) *HubTunnel {
+	t := &HubTunnel{
+		send:     send,
+		notify:   make(chan struct{}, 1),
+		windows:  make(map[uint64]int),
+		received: time.Now(),
+		done:     make(chan struct{}),
+	}
+	t.cond = sync.NewCond(&t.lock)
+	return t
+}
+
+// Deliver delivers the message received from the peer
+func (t *HubTunnel) Deliver(m *Message) {
+	t.lock.Lock()
+	if t.closed {
+		t.lock.Unlock()
+		return
+	}
+	t.received = time.Now()
+	switch m.MessageType {
+	case MessageTypeWindowUpdate:
+		n, _ := binary.Uvarint(m.Data)
+		if w, ok := t.windows[m.ConnectID]; ok {
+			t.windows[m.ConnectID] = w + int(n)
+			t.cond.Broadcast()
+		}
+		t.lock.Unlock()
+		return
+	case MessageTypePing:
+		t.lock.Unlock()
+		if err := t.send(NewMessage(0, MessageTypePong, nil)); err != nil {
+			klog.Errorf("failed to answer ping of tunnel over cloudhub: %v", err)
+		}
+		return
+	case MessageTypePong:
+		t.lock.Unlock()
+		return
+	case MessageTypeRemoveConnect:
+		delete(t.windows, m.ConnectID)
+		t.cond.Broadcast()
+	}
+	t.queue = append(t.queue, m)
+	t.lock.Unlock()
+
+	select {
+	case t.notify <- struct{}{}:
+	default:
+	}
+}
+
+// NextReader returns the next message received from the peer, the bytes of the previous
+// message are granted back to the peer since it has been consumed
+func (t *HubTunnel) NextReader() (int, io.Reader, error) {
+	t.grant()
+
+	timer := time.NewTimer(HubIdleTimeout)
+	defer timer.Stop()
+	for {
+		t.lock.Lock()
+		if t.closed {
+			t.lock.Unlock()
+			return 0, nil, ErrHubTunnelClosed
+		}
+		if len(t.queue) > 0 {
+			m := t.queue[0]
+			t.queue[0] = nil
+			t.queue = t.queue[1:]
+			t.last = m
+			t.lock.Unlock()
+			return websocket.TextMessage, bytes.NewReader(m.Bytes()), nil
+		}
+		idle := time.Since(t.received)
+		t.lock.Unlock()
+
+		if idle >= HubIdleTimeout {
+			t.Close()
+			return 0, nil, errors.New("tunnel over cloudhub connection is idle")
+		}
+		timer.Reset(HubIdleTimeout - idle)
+		select {
+		case <-t.notify:
+		case <-t.done:
+		case <-timer.C:
+		}
+	}
+}
+
+// grant grants the bytes of the data message read last back to the peer
+func (t *HubTunnel) grant() {
+	t.lock.Lock()
+	last := t.last
+	t.last = nil
+	t.lock.Unlock()
+	if last == nil || last.MessageType != MessageTypeData || len(last.Data) == 0 {
+		return
+	}
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, uint64(len(last.Data)))
+	if err := t.send(NewMessage(last.ConnectID, MessageTypeWindowUpdate, buf[:n])); err != nil {
+		klog.Errorf("failed to update window of %s: %v", last.String(), err)
+	}
+}
+
+// WriteMessage sends the message to the peer, the data waits until the window of its stream connection allows
+func (t *HubTunnel) WriteMessage(m *Message) error {
+	switch m.MessageType {
+	case MessageTypeData:
+		if err := t.acquire(m.ConnectID, len(m.Data)); err != nil {
+			return err
+		}
+	case MessageTypeRemoveConnect:
+		t.lock.Lock()
+		delete(t.windows, m.ConnectID)
+		t.lock.Unlock()
+	}
+	return t.send(m)
+}
+
+// acquire takes size bytes from the window of the stream connection, the data larger than
+// the window waits for the full window
+func (t *HubTunnel) acquire(id uint64, size int) error {
+	need := size
+	if need > HubWindowSize {
+		need = HubWindowSize
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for {
+		if t.closed {
+			return ErrHubTunnelClosed
+		}
+		w, ok := t.windows[id]
+		if !ok {
+			w = HubWindowSize
+			t.windows[id] = w
+		}
+		if w >= need {
+			t.windows[id] = w - size
+			return nil
+		}
+		t.cond.Wait()
+	}
+}
+
+// WriteControl sends the ping to the peer, the other control messages are not needed over the CloudHub connection
+func (t *HubTunnel) WriteControl(messageType int, data []byte, deadline time.Time) error {
+	t.lock.Lock()
+	closed := t.closed
+	t.lock.Unlock()
+	if closed {
+		return ErrHubTunnelClosed
+	}
+	if messageType != websocket.PingMessage {
+		return nil
+	}
+	return t.send(NewMessage(0, MessageTypePing, data))
+}
+
+// Close closes the tunnel, the stream connections waiting for the windows and the reader are released
+func (t *HubTunnel) Close() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.closed {
+		return nil
+	}
+	t.closed = true
+	close(t.done)
+	t.cond.Broadcast()
+	return nil
+}
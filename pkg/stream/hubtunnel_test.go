@@ -0,0 +1,131 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stream
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newHubTunnelPair returns the tunnels delivering the messages to each other
+func newHubTunnelPair() (*HubTunnel, *HubTunnel) {
+	var a, b *HubTunnel
+	a = NewHubTunnel(func(m *Message) error {
+		b.Deliver(m)
+		return nil
+	})
+	b = NewHubTunnel(func(m *Message) error {
+		a.Deliver(m)
+		return nil
+	})
+	return a, b
+}
+
+func readMessage(t *testing.T, tunnel *HubTunnel) *Message {
+	typ, r, err := tunnel.NextReader()
+	if err != nil {
+		t.Fatalf("NextReader() error = %v", err)
+	}
+	if typ != websocket.TextMessage {
+		t.Fatalf("expected text message, but got %d", typ)
+	}
+	m, err := ReadMessageFromTunnel(r)
+	if err != nil {
+		t.Fatalf("ReadMessageFromTunnel() error = %v", err)
+	}
+	return m
+}
+
+func TestHubTunnelWindow(t *testing.T) {
+	defer func(size int) { HubWindowSize = size }(HubWindowSize)
+	HubWindowSize = 8
+	a, b := newHubTunnelPair()
+
+	if err := a.WriteMessage(NewMessage(1, MessageTypeData, []byte("12345678"))); err != nil {
+		t.Fatalf("WriteMessage() error = %v", err)
+	}
+	if m := readMessage(t, b); !bytes.Equal(m.Data, []byte("12345678")) {
+		t.Fatalf("unexpected message %s", m.String())
+	}
+
+	// the window is used up until the first message is consumed
+	written := make(chan error)
+	go func() {
+		written <- a.WriteMessage(NewMessage(1, MessageTypeData, []byte("9")))
+	}()
+	select {
+	case err := <-written:
+		t.Fatalf("expected write to wait for the window, but got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// the other stream connections have their own windows
+	if err := a.WriteMessage(NewMessage(2, MessageTypeData, []byte("abc"))); err != nil {
+		t.Fatalf("WriteMessage() error = %v", err)
+	}
+
+	if m := readMessage(t, b); m.ConnectID != 2 {
+		t.Fatalf("unexpected message %s", m.String())
+	}
+	if err := <-written; err != nil {
+		t.Fatalf("WriteMessage() error = %v", err)
+	}
+	if m := readMessage(t, b); m.ConnectID != 1 || !bytes.Equal(m.Data, []byte("9")) {
+		t.Fatalf("unexpected message %s", m.String())
+	}
+}
+
+func TestHubTunnelClose(t *testing.T) {
+	defer func(size int) { HubWindowSize = size }(HubWindowSize)
+	HubWindowSize = 1
+	a, _ := newHubTunnelPair()
+
+	if err := a.WriteMessage(NewMessage(1, MessageTypeData, []byte("1"))); err != nil {
+		t.Fatalf("WriteMessage() error = %v", err)
+	}
+	written := make(chan error)
+	go func() {
+		written <- a.WriteMessage(NewMessage(1, MessageTypeData, []byte("2")))
+	}()
+	a.Close()
+	if err := <-written; err != ErrHubTunnelClosed {
+		t.Errorf("expected %v, but got %v", ErrHubTunnelClosed, err)
+	}
+	if _, _, err := a.NextReader(); err != ErrHubTunnelClosed {
+		t.Errorf("expected %v, but got %v", ErrHubTunnelClosed, err)
+	}
+}
+
+func TestHubTunnelIdle(t *testing.T) {
+	defer func(timeout time.Duration) { HubIdleTimeout = timeout }(HubIdleTimeout)
+	HubIdleTimeout = 100 * time.Millisecond
+	a, b := newHubTunnelPair()
+
+	// the pong is not read as a message but keeps the tunnel alive
+	if err := a.WriteControl(websocket.PingMessage, []byte("ping"), time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("WriteControl() error = %v", err)
+	}
+	if len(a.queue) != 0 || len(b.queue) != 0 {
+		t.Fatalf("expected ping and pong not queued")
+	}
+	if _, _, err := a.NextReader(); err == nil {
+		t.Errorf("expected error of idle tunnel")
+	}
+}
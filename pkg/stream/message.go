@@ -41,6 +41,14 @@ func (m MessageType) String() string {
 		return "DATA"
 	case MessageTypeRemoveConnect:
 		return "REMOVE_CONNECT"
+	case MessageTypeCloseConnect:
+		return "CLOSE_CONNECT"
+	case MessageTypeWindowUpdate:
+		return "WINDOW_UPDATE"
+	case MessageTypePing:
+		return "PING"
+	case MessageTypePong:
+		return "PONG"
 	}
 	return "UNKNOWN"
 }
@@ -0,0 +1,232 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package bootstraptoken implements the bootstrap tokens scoped to node names, modeled on
+// the bootstrap tokens of kubeadm. A token is a JWT signed by the CA key carrying the token ID,
+// the token ID refers to a secret storing the node name pattern, the expiration, the usage
+// limit and the enrollments of the token, so deleting the secret invalidates the token.
+package bootstraptoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubeedge/kubeedge/common/constants"
+)
+
+const (
+	// SecretNamePrefix is the prefix of the names of the bootstrap token secrets
+	SecretNamePrefix = "bootstrap-token-"
+	// SecretType is the type of the bootstrap token secrets
+	SecretType corev1.SecretType = "bootstrap.kubeedge.io/token"
+
+	// the data keys of the bootstrap token secrets
+	KeyTokenID     = "token-id"
+	KeyNodeName    = "node-name"
+	KeyExpiration  = "expiration"
+	KeyUsageLimit  = "usage-limit"
+	KeyUsageCount  = "usage-count"
+	KeyEnrollments = "enrollments"
+
+	// maxEnrollments limits the enrollments recorded in the secret
+	maxEnrollments = 100
+
+	idLength = 6
+	idChars  = "abcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+// Token is a bootstrap token scoped to the node names
+type Token struct {
+	ID string
+	// NodeName is the node name or the shell pattern of the node names allowed to join by the token
+	NodeName string
+	// Expiration is the time the token expires at, zero never expires
+	Expiration time.Time
+	// UsageLimit is the max number of the nodes enrolled by the token, 0 is unlimited
+	UsageLimit int
+	// UsageCount is the number of the nodes enrolled by the token
+	UsageCount int
+	// Enrollments are the latest nodes enrolled by the token
+	Enrollments []Enrollment
+}
+
+// Enrollment is the audit record of a node enrolled by a token
+type Enrollment struct {
+	NodeName   string    `json:"nodeName"`
+	RemoteAddr string    `json:"remoteAddr"`
+	Time       time.Time `json:"time"`
+}
+
+// New returns a token with a random ID
+func New(nodeName string, ttl time.Duration, usageLimit int) (*Token, error) {
+	if _, err := path.Match(nodeName, ""); err != nil || nodeName == "" {
+		return nil, fmt.Errorf("invalid node name pattern %q", nodeName)
+	}
+	id := make([]byte, idLength)
+	for i := range id {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(idChars))))
+		if err != nil {
+			return nil, err
+		}
+		id[i] = idChars[n.Int64()]
+	}
+	t := &Token{
+		ID:         string(id),
+		NodeName:   nodeName,
+		UsageLimit: usageLimit,
+	}
+	if ttl > 0 {
+		t.Expiration = time.Now().Add(ttl).UTC().Truncate(time.Second)
+	}
+	return t, nil
+}
+
+// SecretName returns the name of the secret of the token ID
+func SecretName(id string) string {
+	return SecretNamePrefix + id
+}
+
+// CAHash returns the hash of the CA certificate the edge nodes validate the CA with
+func CAHash(caDER []byte) string {
+	digest := sha256.Sum256(caDER)
+	return hex.EncodeToString(digest[:])
+}
+
+// Sign returns the token string for the edge nodes to join, it consists of the CA hash and the JWT
+func (t *Token) Sign(caDER, caKeyDER []byte) (string, error) {
+	claims := jwt.StandardClaims{Id: t.ID}
+	if !t.Expiration.IsZero() {
+		claims.ExpiresAt = t.Expiration.Unix()
+	}
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(caKeyDER)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign bootstrap token: %v", err)
+	}
+	return strings.Join([]string{CAHash(caDER), tokenString}, "."), nil
+}
+
+// ID returns the bootstrap token ID of the verified JWT, it is empty for the cluster token
+func ID(token *jwt.Token) string {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return ""
+	}
+	id, _ := claims["jti"].(string)
+	return id
+}
+
+// Validate returns an error if the node is not allowed to join by the token
+func (t *Token) Validate(nodeName string, now time.Time) error {
+	if nodeName == "" {
+		return fmt.Errorf("bootstrap token %s requires the node name", t.ID)
+	}
+	if !t.Expiration.IsZero() && now.After(t.Expiration) {
+		return fmt.Errorf("bootstrap token %s expired at %s", t.ID, t.Expiration.Format(time.RFC3339))
+	}
+	if t.UsageLimit > 0 && t.UsageCount >= t.UsageLimit {
+		return fmt.Errorf("bootstrap token %s reached usage limit %d", t.ID, t.UsageLimit)
+	}
+	if ok, _ := path.Match(t.NodeName, nodeName); !ok {
+		return fmt.Errorf("bootstrap token %s is not allowed to join node %s", t.ID, nodeName)
+	}
+	return nil
+}
+
+// Enroll records the node enrolled by the token
+func (t *Token) Enroll(nodeName, remoteAddr string, now time.Time) {
+	t.UsageCount++
+	t.Enrollments = append(t.Enrollments, Enrollment{
+		NodeName:   nodeName,
+		RemoteAddr: remoteAddr,
+		Time:       now.UTC().Truncate(time.Second),
+	})
+	if len(t.Enrollments) > maxEnrollments {
+		t.Enrollments = t.Enrollments[len(t.Enrollments)-maxEnrollments:]
+	}
+}
+
+// FromSecret returns the token stored in the secret
+func FromSecret(secret *corev1.Secret) (*Token, error) {
+	if secret.Type != SecretType {
+		return nil, fmt.Errorf("secret %s is not a bootstrap token", secret.Name)
+	}
+	t := &Token{
+		ID:       string(secret.Data[KeyTokenID]),
+		NodeName: string(secret.Data[KeyNodeName]),
+	}
+	var err error
+	if v := secret.Data[KeyExpiration]; len(v) > 0 {
+		if t.Expiration, err = time.Parse(time.RFC3339, string(v)); err != nil {
+			return nil, fmt.Errorf("invalid expiration of bootstrap token %s: %v", t.ID, err)
+		}
+	}
+	if v := secret.Data[KeyUsageLimit]; len(v) > 0 {
+		if t.UsageLimit, err = strconv.Atoi(string(v)); err != nil {
+			return nil, fmt.Errorf("invalid usage limit of bootstrap token %s: %v", t.ID, err)
+		}
+	}
+	if v := secret.Data[KeyUsageCount]; len(v) > 0 {
+		if t.UsageCount, err = strconv.Atoi(string(v)); err != nil {
+			return nil, fmt.Errorf("invalid usage count of bootstrap token %s: %v", t.ID, err)
+		}
+	}
+	if v := secret.Data[KeyEnrollments]; len(v) > 0 {
+		if err = json.Unmarshal(v, &t.Enrollments); err != nil {
+			return nil, fmt.Errorf("invalid enrollments of bootstrap token %s: %v", t.ID, err)
+		}
+	}
+	return t, nil
+}
+
+// ToSecret stores the token in the secret, a new secret is returned if secret is nil
+func (t *Token) ToSecret(secret *corev1.Secret) (*corev1.Secret, error) {
+	if secret == nil {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SecretName(t.ID),
+				Namespace: constants.SystemNamespace,
+			},
+			Type: SecretType,
+		}
+	}
+	enrollments, err := json.Marshal(t.Enrollments)
+	if err != nil {
+		return nil, err
+	}
+	secret.Data = map[string][]byte{
+		KeyTokenID:     []byte(t.ID),
+		KeyNodeName:    []byte(t.NodeName),
+		KeyUsageLimit:  []byte(strconv.Itoa(t.UsageLimit)),
+		KeyUsageCount:  []byte(strconv.Itoa(t.UsageCount)),
+		KeyEnrollments: enrollments,
+	}
+	if !t.Expiration.IsZero() {
+		secret.Data[KeyExpiration] = []byte(t.Expiration.Format(time.RFC3339))
+	}
+	return secret, nil
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstraptoken
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func TestSign(t *testing.T) {
+	caKey := []byte("ca-key")
+	token, err := New("edge-*", time.Hour, 1)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	tokenString, err := token.Sign([]byte("ca"), caKey)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	// the edge nodes split the CA hash and the JWT
+	parts := strings.Split(tokenString, ".")
+	if len(parts) != 4 || parts[0] != CAHash([]byte("ca")) {
+		t.Fatalf("unexpected token %s", tokenString)
+	}
+	parsed, err := jwt.Parse(strings.Join(parts[1:], "."), func(*jwt.Token) (interface{}, error) {
+		return caKey, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to parse token: %v", err)
+	}
+	if id := ID(parsed); id != token.ID {
+		t.Errorf("expected token ID %s, but got %s", token.ID, id)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name     string
+		token    Token
+		nodeName string
+		valid    bool
+	}{
+		{name: "exact node name", token: Token{NodeName: "edge-1", UsageLimit: 1}, nodeName: "edge-1", valid: true},
+		{name: "other node name", token: Token{NodeName: "edge-1"}, nodeName: "edge-2"},
+		{name: "node name pattern", token: Token{NodeName: "factory-a-*"}, nodeName: "factory-a-12", valid: true},
+		{name: "empty node name", token: Token{NodeName: "*"}, nodeName: ""},
+		{name: "expired", token: Token{NodeName: "edge-1", Expiration: now.Add(-time.Second)}, nodeName: "edge-1"},
+		{name: "usage limit reached", token: Token{NodeName: "edge-1", UsageLimit: 1, UsageCount: 1}, nodeName: "edge-1"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.token.Validate(c.nodeName, now)
+			if (err == nil) != c.valid {
+				t.Errorf("Validate() error = %v, expected valid %v", err, c.valid)
+			}
+		})
+	}
+}
+
+func TestSecret(t *testing.T) {
+	now := time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+	token := &Token{ID: "abc123", NodeName: "edge-*", Expiration: now.Add(time.Hour), UsageLimit: 2}
+	token.Enroll("edge-1", "10.0.0.1:40000", now)
+
+	secret, err := token.ToSecret(nil)
+	if err != nil {
+		t.Fatalf("ToSecret() error = %v", err)
+	}
+	if secret.Name != SecretName("abc123") || secret.Type != SecretType {
+		t.Errorf("unexpected secret %s of type %s", secret.Name, secret.Type)
+	}
+	got, err := FromSecret(secret)
+	if err != nil {
+		t.Fatalf("FromSecret() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, token) {
+		t.Errorf("expected token %+v, but got %+v", token, got)
+	}
+	if err := got.Validate("edge-2", now); err != nil {
+		t.Errorf("expected token valid for the second node, but got %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package util
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/kubeedge/viaduct/pkg/packer"
+)
+
+// CompressionCollectors returns the metrics of the payload compression of viaduct in the subsystem,
+// the bytes saved are the raw bytes minus the compressed bytes
+func CompressionCollectors(subsystem string) []prometheus.Collector {
+	stats := map[string]func() packer.CompressionStats{
+		"sent": func() packer.CompressionStats {
+			sent, _ := packer.GetCompressionStats()
+			return sent
+		},
+		"received": func() packer.CompressionStats {
+			_, received := packer.GetCompressionStats()
+			return received
+		},
+	}
+	var collectors []prometheus.Collector
+	for direction, get := range stats {
+		get := get
+		labels := prometheus.Labels{"direction": direction}
+		collectors = append(collectors,
+			prometheus.NewCounterFunc(prometheus.CounterOpts{
+				Subsystem:   subsystem,
+				Name:        "compression_raw_bytes_total",
+				Help:        "Size of the compressed message payloads before compression, partitioned by direction.",
+				ConstLabels: labels,
+			}, func() float64 { return float64(get().RawBytes) }),
+			prometheus.NewCounterFunc(prometheus.CounterOpts{
+				Subsystem:   subsystem,
+				Name:        "compression_compressed_bytes_total",
+				Help:        "Size of the compressed message payloads after compression, partitioned by direction.",
+				ConstLabels: labels,
+			}, func() float64 { return float64(get().CompressedBytes) }),
+		)
+	}
+	return collectors
+}
@@ -0,0 +1,26 @@
+package util
+
+import (
+	"github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/viaduct/pkg/api"
+)
+
+// the groups of the messages sent between cloud and edge
+const (
+	groupTwin   = "twin"
+	groupUser   = "user"
+	groupStream = "stream"
+)
+
+// ClassifyMessage returns the traffic class of the message sent between cloud and edge.
+// The device twin and user messages, such as device telemetry and the messages routed by
+// the router, are bulk traffic, the others such as pod operations and lease renewals are
+// control plane traffic.
+func ClassifyMessage(msg *model.Message) api.TrafficClass {
+	switch msg.GetGroup() {
+	// the stream tunnel messages such as container logs are bulk traffic as well
+	case groupTwin, groupUser, groupStream:
+		return api.TrafficClassBulk
+	}
+	return api.TrafficClassControl
+}
@@ -0,0 +1,31 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/viaduct/pkg/api"
+)
+
+func TestClassifyMessage(t *testing.T) {
+	cases := []struct {
+		name     string
+		group    string
+		resource string
+		expected api.TrafficClass
+	}{
+		{name: "pod deletion", group: "resource", resource: "default/pod/nginx", expected: api.TrafficClassControl},
+		{name: "lease renewal", group: "resource", resource: "kube-node-lease/lease/edge-node", expected: api.TrafficClassControl},
+		{name: "device twin", group: "twin", resource: "$hw/events/device/sensor/twin/update", expected: api.TrafficClassBulk},
+		{name: "upload records", group: "user", resource: "SYS/dis/upload_records", expected: api.TrafficClassBulk},
+		{name: "stream tunnel", group: "stream", resource: "tunnel", expected: api.TrafficClassBulk},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			msg := model.NewMessage("").BuildRouter("edgehub", c.group, c.resource, model.UpdateOperation)
+			if got := ClassifyMessage(msg); got != c.expected {
+				t.Errorf("ClassifyMessage() = %v, want %v", got, c.expected)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"net/http"
+	"net/url"
 
 	"github.com/gorilla/websocket"
 )
@@ -26,4 +27,6 @@ type WSClientOption struct {
 	Header http.Header
 	// called after dialing
 	Callback WSClientCallback
+	// returns the proxy to dial, connect directly if nil
+	Proxy func(*http.Request) (*url.URL, error)
 }
@@ -1,6 +1,21 @@
 package api
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+)
+
+// AdmitFunc is called with the headers of the client when it handshakes, the client is
+// rejected with the retry-after hint if it is not admitted
+type AdmitFunc func(header http.Header) (retryAfter time.Duration, admitted bool)
+
+// ReleaseFunc is called with the headers of the client admitted when it fails to connect
+// before the connection is notified, so that the place of the client can be released
+type ReleaseFunc func(header http.Header)
+
+// RedirectFunc is called with the headers of the client when it handshakes, the client is
+// redirected to the server at location if it should connect to another server
+type RedirectFunc func(header http.Header) (location string, redirected bool)
 
 // quic server option
 // including the extend options when getting server instance
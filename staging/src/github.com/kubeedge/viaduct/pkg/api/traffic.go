@@ -0,0 +1,20 @@
+package api
+
+import "github.com/kubeedge/beehive/pkg/core/model"
+
+// TrafficClass is the class of the messages sharing a connection,
+// the messages of a class never wait behind the ones of a lower class
+type TrafficClass int
+
+const (
+	// TrafficClassControl is the class of the control plane messages,
+	// such as pod operations and lease renewals
+	TrafficClassControl TrafficClass = iota
+	// TrafficClassBulk is the class of the bulk messages, such as device telemetry
+	TrafficClassBulk
+	// NumTrafficClasses is the number of traffic classes
+	NumTrafficClasses
+)
+
+// ClassifyFunc returns the traffic class of the message
+type ClassifyFunc func(msg *model.Message) TrafficClass
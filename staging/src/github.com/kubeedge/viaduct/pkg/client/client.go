@@ -38,6 +38,12 @@ type Options struct {
 	HandshakeTimeout time.Duration
 	// consumer for raw data
 	Consumer io.Writer
+	// the payloads of messages not smaller than CompressThreshold are compressed
+	// if the server supports compression, compression is disabled if it is 0
+	CompressThreshold int
+	// Classify returns the traffic class of the messages, all messages are
+	// of the control class if it is nil
+	Classify api.ClassifyFunc
 }
 
 // client including common options and extend options
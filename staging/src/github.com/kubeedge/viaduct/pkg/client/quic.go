@@ -1,7 +1,9 @@
 package client
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"sync"
 
 	"github.com/lucas-clemente/quic-go"
@@ -12,6 +14,7 @@ import (
 	"github.com/kubeedge/viaduct/pkg/comm"
 	"github.com/kubeedge/viaduct/pkg/conn"
 	"github.com/kubeedge/viaduct/pkg/lane"
+	"github.com/kubeedge/viaduct/pkg/packer"
 )
 
 // the client based on quic
@@ -66,28 +69,43 @@ func (c *QuicClient) getControlLane(s quic.Session) error {
 }
 
 // send the headers
+// returns whether the server accepts compression, or the retry-after error if the server is busy
 // TODO: add timeout?
-func (c *QuicClient) sendHeader() error {
+func (c *QuicClient) sendHeader() (bool, error) {
+	if c.options.CompressThreshold > 0 {
+		c.exOpts.Header.Set(comm.HeaderCompression, packer.CompressionZstd)
+	}
 	msg := model.NewMessage("").
 		BuildRouter("", "", comm.ControlTypeHeader, comm.ControlTypeHeader).
 		FillBody(c.exOpts.Header)
 	err := c.ctrlLane.WriteMessage(msg)
 	if err != nil {
 		klog.Errorf("failed to write message, error: %+v", err)
-		return err
+		return false, err
 	}
 
 	// receive the response
-	// ignore the response
-	// TODO: check the response content
+	// the server supporting compression responds the headers accepted,
+	// the others respond ack
 	var response model.Message
 	err = c.ctrlLane.ReadMessage(&response)
 	if err != nil {
 		klog.Errorf("failed to read message, error: %+v", err)
-		return err
+		return false, err
 	}
 	klog.Infof("get response: %+v", response)
-	return nil
+	headers := make(http.Header)
+	content, ok := response.GetContent().([]byte)
+	if !ok || json.Unmarshal(content, &headers) != nil {
+		return false, nil
+	}
+	if location := headers.Get(comm.HeaderLocation); location != "" {
+		return false, &comm.RedirectError{Location: location}
+	}
+	if retryAfter, ok := comm.ParseRetryAfter(headers.Get(comm.HeaderRetryAfter)); ok {
+		return false, &comm.RetryAfterError{RetryAfter: retryAfter}
+	}
+	return packer.AcceptCompression(headers.Get(comm.HeaderCompression)), nil
 }
 
 // try to dial server and get connection interface for operations
@@ -107,9 +125,17 @@ func (c *QuicClient) Connect() (conn.Connection, error) {
 	}
 
 	// send headers
-	err = c.sendHeader()
+	compressThreshold := 0
+	compressionAccepted, err := c.sendHeader()
+	switch err.(type) {
+	case *comm.RetryAfterError, *comm.RedirectError:
+		session.Close()
+		return nil, err
+	}
 	if err != nil {
 		klog.Warningf("failed to send headers, error: %+v", err)
+	} else if compressionAccepted {
+		compressThreshold = c.options.CompressThreshold
 	}
 
 	klog.Info("connect remote peer successfully")
@@ -124,6 +150,8 @@ func (c *QuicClient) Connect() (conn.Connection, error) {
 			State:   api.StatConnected,
 			Headers: c.exOpts.Header,
 		},
-		AutoRoute: c.options.AutoRoute,
+		AutoRoute:         c.options.AutoRoute,
+		CompressThreshold: compressThreshold,
+		Classify:          c.options.Classify,
 	}), nil
 }
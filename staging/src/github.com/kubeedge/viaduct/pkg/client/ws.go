@@ -3,6 +3,7 @@ package client
 import (
 	"fmt"
 	"io"
+	"net/http"
 
 	"github.com/gorilla/websocket"
 	"k8s.io/klog/v2"
@@ -11,6 +12,7 @@ import (
 	"github.com/kubeedge/viaduct/pkg/comm"
 	"github.com/kubeedge/viaduct/pkg/conn"
 	"github.com/kubeedge/viaduct/pkg/lane"
+	"github.com/kubeedge/viaduct/pkg/packer"
 )
 
 // the client based on websocket
@@ -33,6 +35,7 @@ func NewWSClient(options Options, exOpts interface{}) *WSClient {
 		dialer: &websocket.Dialer{
 			TLSClientConfig:  options.TLSConfig,
 			HandshakeTimeout: options.HandshakeTimeout,
+			Proxy:            extendOption.Proxy,
 		},
 	}
 }
@@ -41,10 +44,19 @@ func NewWSClient(options Options, exOpts interface{}) *WSClient {
 func (c *WSClient) Connect() (conn.Connection, error) {
 	header := c.exOpts.Header
 	header.Add("ConnectionUse", string(c.options.ConnUse))
+	if c.options.CompressThreshold > 0 {
+		header.Set(comm.HeaderCompression, packer.CompressionZstd)
+	}
 	wsConn, resp, err := c.dialer.Dial(c.options.Addr, header)
 	if err == nil {
 		klog.Infof("dial %s successfully", c.options.Addr)
 
+		// compress only if the server accepts
+		compressThreshold := 0
+		if packer.AcceptCompression(resp.Header.Get(comm.HeaderCompression)) {
+			compressThreshold = c.options.CompressThreshold
+		}
+
 		// do user's processing on connection or response
 		if c.exOpts.Callback != nil {
 			c.exOpts.Callback(wsConn, resp)
@@ -60,12 +72,26 @@ func (c *WSClient) Connect() (conn.Connection, error) {
 				State:   api.StatConnected,
 				Headers: c.exOpts.Header.Clone(),
 			},
-			AutoRoute: c.options.AutoRoute,
+			AutoRoute:         c.options.AutoRoute,
+			CompressThreshold: compressThreshold,
+			Classify:          c.options.Classify,
 		}), nil
 	}
 
 	// something wrong!!
 	var respMsg string
+	if resp != nil && resp.StatusCode == http.StatusTemporaryRedirect {
+		if location := resp.Header.Get(comm.HeaderLocation); location != "" {
+			resp.Body.Close()
+			return nil, &comm.RedirectError{Location: location}
+		}
+	}
+	if resp != nil && resp.StatusCode == http.StatusServiceUnavailable {
+		if retryAfter, ok := comm.ParseRetryAfter(resp.Header.Get(comm.HeaderRetryAfter)); ok {
+			resp.Body.Close()
+			return nil, &comm.RetryAfterError{RetryAfter: retryAfter}
+		}
+	}
 	if resp != nil {
 		body, errRead := io.ReadAll(io.LimitReader(resp.Body, comm.MaxReadLength))
 		if errRead == nil {
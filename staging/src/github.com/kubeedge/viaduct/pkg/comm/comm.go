@@ -22,4 +22,16 @@ const (
 
 	// MaxReadLength is the max length of http response body
 	MaxReadLength = 1 << 20 // 1 MiB
+
+	// HeaderCompression is the header to negotiate the compression algorithm of payloads,
+	// the client sends the algorithms it supports and the server replies the one accepted
+	HeaderCompression = "Viaduct-Compression"
+
+	// HeaderRetryAfter is the header the server rejecting the handshake hints the client
+	// when to connect again with, the value is in seconds
+	HeaderRetryAfter = "Retry-After"
+
+	// HeaderLocation is the header the server redirecting the handshake tells the client
+	// the address of the server to connect instead with
+	HeaderLocation = "Location"
 )
@@ -0,0 +1,13 @@
+package comm
+
+import "fmt"
+
+// RedirectError is returned when the server redirects the client to another server,
+// the client should connect the server at Location instead
+type RedirectError struct {
+	Location string
+}
+
+func (e *RedirectError) Error() string {
+	return fmt.Sprintf("redirected to %s", e.Location)
+}
@@ -0,0 +1,36 @@
+package comm
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// RetryAfterError is returned when the server is too busy to admit the client,
+// the client should not connect again before RetryAfter
+type RetryAfterError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("server is busy, retry after %s", e.RetryAfter)
+}
+
+// FormatRetryAfter formats the retry-after hint in seconds as the Retry-After header of HTTP
+func FormatRetryAfter(d time.Duration) string {
+	seconds := int64(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.FormatInt(seconds, 10)
+}
+
+// ParseRetryAfter parses the retry-after hint in seconds, false is returned if it is invalid
+func ParseRetryAfter(value string) (time.Duration, bool) {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
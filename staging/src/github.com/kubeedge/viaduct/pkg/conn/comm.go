@@ -8,14 +8,15 @@ import (
 )
 
 type responseWriter struct {
-	Type string
-	Van  interface{}
+	Type              string
+	Van               interface{}
+	CompressThreshold int
 }
 
 // write response
 func (r *responseWriter) WriteResponse(msg *model.Message, content interface{}) {
 	response := msg.NewRespByMessage(msg, content)
-	err := lane.NewLane(r.Type, r.Van).WriteMessage(response)
+	err := lane.NewLaneWithCompression(r.Type, r.Van, r.CompressThreshold).WriteMessage(response)
 	if err != nil {
 		klog.Errorf("failed to write response, error: %+v", err)
 	}
@@ -24,7 +25,7 @@ func (r *responseWriter) WriteResponse(msg *model.Message, content interface{})
 // write error
 func (r *responseWriter) WriteError(msg *model.Message, errMsg string) {
 	response := model.NewErrorMessage(msg, errMsg)
-	err := lane.NewLane(r.Type, r.Van).WriteMessage(response)
+	err := lane.NewLaneWithCompression(r.Type, r.Van, r.CompressThreshold).WriteMessage(response)
 	if err != nil {
 		klog.Errorf("failed to write error, error: %+v", err)
 	}
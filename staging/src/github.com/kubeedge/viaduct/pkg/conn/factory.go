@@ -30,6 +30,12 @@ type ConnectionOptions struct {
 	AutoRoute bool
 	// OnReadTransportErr
 	OnReadTransportErr func(nodeID, projectID string)
+	// the payloads of messages not smaller than CompressThreshold are compressed,
+	// compression is disabled if it is 0. Set it only if the peer supports compression
+	CompressThreshold int
+	// Classify returns the traffic class of the messages, the messages of different classes
+	// are written independently. All messages are of the control class if it is nil
+	Classify api.ClassifyFunc
 }
 
 // get connection interface by ConnTye
@@ -43,6 +43,16 @@ type QuicConnection struct {
 	autoRoute          bool
 	OnReadTransportErr func(nodeID, projectID string)
 	locker             sync.Mutex
+	compressThreshold  int
+	classify           api.ClassifyFunc
+	// the dedicated streams of the traffic classes for the async messages
+	classStreams [api.NumTrafficClasses]classStream
+}
+
+// classStream is the stream to write the async messages of a traffic class in order
+type classStream struct {
+	lock   sync.Mutex
+	stream quic.Stream
 }
 
 // NewQuicConn new quic connection
@@ -60,6 +70,8 @@ func NewQuicConn(options *ConnectionOptions) *QuicConnection {
 		messageFifo:        fifo.NewMessageFifo(),
 		OnReadTransportErr: options.OnReadTransportErr,
 		streamManager:      smgr.NewStreamManager(smgr.NumStreamsMax, autoFree, quicSession),
+		compressThreshold:  options.CompressThreshold,
+		classify:           options.Classify,
 	}
 }
 
@@ -217,7 +229,7 @@ func (conn *QuicConnection) handleRawData(stream *smgr.Stream) {
 func (conn *QuicConnection) handleMessage(stream *smgr.Stream) {
 	msg := &model.Message{}
 	for {
-		err := lane.NewLane(api.ProtocolTypeQuic, stream.Stream).ReadMessage(msg)
+		err := lane.NewLaneWithCompression(api.ProtocolTypeQuic, stream.Stream, conn.compressThreshold).ReadMessage(msg)
 		if err != nil {
 			if err != io.EOF {
 				klog.Errorf("failed to read message, error: %+v", err)
@@ -246,8 +258,9 @@ func (conn *QuicConnection) handleMessage(stream *smgr.Stream) {
 			Header:  conn.state.Headers,
 			Message: msg,
 		}, &responseWriter{
-			Type: api.ProtocolTypeQuic,
-			Van:  stream.Stream,
+			Type:              api.ProtocolTypeQuic,
+			Van:               stream.Stream,
+			CompressThreshold: conn.compressThreshold,
 		})
 	}
 }
@@ -275,7 +288,7 @@ func (conn *QuicConnection) WriteMessageSync(msg *model.Message) (*model.Message
 	}
 	defer conn.streamManager.ReleaseStream(api.UseTypeMessage, stream)
 
-	lane := lane.NewLane(api.ProtocolTypeQuic, stream)
+	lane := lane.NewLaneWithCompression(api.ProtocolTypeQuic, stream, conn.compressThreshold)
 	_ = lane.SetWriteDeadline(conn.writeDeadline)
 	msg.Header.Sync = true
 	conn.locker.Lock()
@@ -296,6 +309,9 @@ func (conn *QuicConnection) WriteMessageAsync(msg *model.Message) error {
 		klog.Error("bad connection session")
 		return fmt.Errorf("bad connection session")
 	}
+	if conn.classify != nil {
+		return conn.writeClassMessage(msg)
+	}
 
 	stream, err := conn.streamManager.GetStream(api.UseTypeMessage, true, conn.openStreamSync)
 	if err != nil {
@@ -304,7 +320,7 @@ func (conn *QuicConnection) WriteMessageAsync(msg *model.Message) error {
 	}
 	defer conn.streamManager.ReleaseStream(api.UseTypeMessage, stream)
 
-	lane := lane.NewLane(api.ProtocolTypeQuic, stream)
+	lane := lane.NewLaneWithCompression(api.ProtocolTypeQuic, stream, conn.compressThreshold)
 	_ = lane.SetWriteDeadline(conn.writeDeadline)
 	msg.Header.Sync = false
 
@@ -313,6 +329,33 @@ func (conn *QuicConnection) WriteMessageAsync(msg *model.Message) error {
 	return lane.WriteMessage(msg)
 }
 
+// writeClassMessage writes the async message to the dedicated stream of its traffic class,
+// so the messages of a class are kept in order and never wait behind the other classes
+func (conn *QuicConnection) writeClassMessage(msg *model.Message) error {
+	cs := &conn.classStreams[classOf(conn.classify, msg)]
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+
+	if cs.stream == nil {
+		stream, err := conn.openStreamSync(api.UseTypeMessage, true)
+		if err != nil {
+			return fmt.Errorf("failed to open stream, error:%+v", err)
+		}
+		cs.stream = stream.Stream
+	}
+
+	lane := lane.NewLaneWithCompression(api.ProtocolTypeQuic, cs.stream, conn.compressThreshold)
+	_ = lane.SetWriteDeadline(conn.writeDeadline)
+	msg.Header.Sync = false
+	err := lane.WriteMessage(msg)
+	if err != nil {
+		// the stream may be broken, write the next message to a new one
+		_ = cs.stream.CancelWrite(quic.ErrorCode(comm.StatusCodeFreeStream))
+		cs.stream = nil
+	}
+	return err
+}
+
 // ReadMessage read message from fifo
 // it will blocked when no message received
 func (conn *QuicConnection) ReadMessage(msg *model.Message) error {
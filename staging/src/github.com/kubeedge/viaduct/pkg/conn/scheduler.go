@@ -0,0 +1,71 @@
+package conn
+
+import (
+	"sync"
+
+	"github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/viaduct/pkg/api"
+)
+
+// the number of control writers served for each bulk writer when both are waiting
+const controlWeight = 8
+
+// classOf returns the traffic class of the message
+func classOf(classify api.ClassifyFunc, msg *model.Message) api.TrafficClass {
+	if classify == nil {
+		return api.TrafficClassControl
+	}
+	class := classify(msg)
+	if class < 0 || class >= api.NumTrafficClasses {
+		return api.TrafficClassControl
+	}
+	return class
+}
+
+// writeScheduler serializes the writers of a connection. The waiting writers of a class are
+// served in order, and the control writers are served before the bulk ones, except that
+// a bulk writer is served after every controlWeight control writers to avoid starvation.
+type writeScheduler struct {
+	lock    sync.Mutex
+	busy    bool
+	waiting [api.NumTrafficClasses][]chan struct{}
+	// the number of control writers served in a row while bulk writers are waiting
+	served int
+}
+
+// acquire blocks until the writer of the class is allowed to write
+func (s *writeScheduler) acquire(class api.TrafficClass) {
+	s.lock.Lock()
+	if !s.busy {
+		s.busy = true
+		s.lock.Unlock()
+		return
+	}
+	ready := make(chan struct{})
+	s.waiting[class] = append(s.waiting[class], ready)
+	s.lock.Unlock()
+	<-ready
+}
+
+// release passes the turn to write to the next waiting writer
+func (s *writeScheduler) release() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	control := len(s.waiting[api.TrafficClassControl])
+	bulk := len(s.waiting[api.TrafficClassBulk])
+	class := api.TrafficClassControl
+	switch {
+	case control == 0 && bulk == 0:
+		s.busy = false
+		return
+	case bulk > 0 && (control == 0 || s.served >= controlWeight):
+		class = api.TrafficClassBulk
+		s.served = 0
+	case bulk > 0:
+		s.served++
+	}
+	ready := s.waiting[class][0]
+	s.waiting[class] = s.waiting[class][1:]
+	close(ready)
+}
@@ -0,0 +1,68 @@
+package conn
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubeedge/viaduct/pkg/api"
+)
+
+// TestWriteScheduler is function to test the order of the writers served by writeScheduler.
+func TestWriteScheduler(t *testing.T) {
+	var s writeScheduler
+	var lock sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+
+	// enqueue waits until the writer is waiting for its turn
+	enqueue := func(name string, class api.TrafficClass) {
+		s.lock.Lock()
+		waiting := len(s.waiting[class])
+		s.lock.Unlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.acquire(class)
+			lock.Lock()
+			order = append(order, name)
+			lock.Unlock()
+			s.release()
+		}()
+		for {
+			s.lock.Lock()
+			n := len(s.waiting[class])
+			s.lock.Unlock()
+			if n > waiting {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	s.acquire(api.TrafficClassBulk)
+	enqueue("bulk-1", api.TrafficClassBulk)
+	enqueue("bulk-2", api.TrafficClassBulk)
+	var want []string
+	for i := 1; i <= controlWeight+1; i++ {
+		name := fmt.Sprintf("control-%d", i)
+		enqueue(name, api.TrafficClassControl)
+		want = append(want, name)
+		// a bulk writer is served after controlWeight control writers
+		if i == controlWeight {
+			want = append(want, "bulk-1")
+		}
+	}
+	want = append(want, "bulk-2")
+	s.release()
+	wg.Wait()
+
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("expected order %v, but got %v", want, order)
+	}
+	if s.busy {
+		t.Errorf("expected idle scheduler after all writers released")
+	}
+}
@@ -3,7 +3,6 @@ package conn
 import (
 	"io"
 	"net"
-	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -29,8 +28,10 @@ type WSConnection struct {
 	consumer           io.Writer
 	autoRoute          bool
 	messageFifo        *fifo.MessageFifo
-	locker             sync.Mutex
+	writer             writeScheduler
 	OnReadTransportErr func(nodeID, projectID string)
+	compressThreshold  int
+	classify           api.ClassifyFunc
 }
 
 func NewWSConn(options *ConnectionOptions) *WSConnection {
@@ -43,6 +44,8 @@ func NewWSConn(options *ConnectionOptions) *WSConnection {
 		autoRoute:          options.AutoRoute,
 		messageFifo:        fifo.NewMessageFifo(),
 		OnReadTransportErr: options.OnReadTransportErr,
+		compressThreshold:  options.CompressThreshold,
+		classify:           options.Classify,
 	}
 }
 
@@ -69,9 +72,9 @@ func (conn *WSConnection) filterControlMessage(msg *model.Message) bool {
 
 	// feedback the response
 	resp := msg.NewRespByMessage(msg, comm.RespTypeAck)
-	conn.locker.Lock()
-	err := lane.NewLane(api.ProtocolTypeWS, conn.wsConn).WriteMessage(resp)
-	conn.locker.Unlock()
+	conn.writer.acquire(api.TrafficClassControl)
+	err := lane.NewLaneWithCompression(api.ProtocolTypeWS, conn.wsConn, conn.compressThreshold).WriteMessage(resp)
+	conn.writer.release()
 	if err != nil {
 		klog.Errorf("failed to send response back, error:%+v", err)
 	}
@@ -101,7 +104,7 @@ func (conn *WSConnection) handleRawData() {
 func (conn *WSConnection) handleMessage() {
 	for {
 		msg := &model.Message{}
-		err := lane.NewLane(api.ProtocolTypeWS, conn.wsConn).ReadMessage(msg)
+		err := lane.NewLaneWithCompression(api.ProtocolTypeWS, conn.wsConn, conn.compressThreshold).ReadMessage(msg)
 		if err != nil {
 			if err != io.EOF {
 				klog.Errorf("failed to read message, error: %+v", err)
@@ -141,8 +144,9 @@ func (conn *WSConnection) handleMessage() {
 			Header:  conn.state.Headers,
 			Message: msg,
 		}, &responseWriter{
-			Type: api.ProtocolTypeWS,
-			Van:  conn.wsConn,
+			Type:              api.ProtocolTypeWS,
+			Van:               conn.wsConn,
+			CompressThreshold: conn.compressThreshold,
 		})
 	}
 }
@@ -166,22 +170,22 @@ func (conn *WSConnection) Write(raw []byte) (int, error) {
 }
 
 func (conn *WSConnection) WriteMessageAsync(msg *model.Message) error {
-	lane := lane.NewLane(api.ProtocolTypeWS, conn.wsConn)
+	lane := lane.NewLaneWithCompression(api.ProtocolTypeWS, conn.wsConn, conn.compressThreshold)
 	_ = lane.SetWriteDeadline(conn.WriteDeadline)
 	msg.Header.Sync = false
-	conn.locker.Lock()
-	defer conn.locker.Unlock()
+	conn.writer.acquire(classOf(conn.classify, msg))
+	defer conn.writer.release()
 	return lane.WriteMessage(msg)
 }
 
 func (conn *WSConnection) WriteMessageSync(msg *model.Message) (*model.Message, error) {
-	lane := lane.NewLane(api.ProtocolTypeWS, conn.wsConn)
+	lane := lane.NewLaneWithCompression(api.ProtocolTypeWS, conn.wsConn, conn.compressThreshold)
 	// send msg
 	_ = lane.SetWriteDeadline(conn.WriteDeadline)
 	msg.Header.Sync = true
-	conn.locker.Lock()
+	conn.writer.acquire(classOf(conn.classify, msg))
 	err := lane.WriteMessage(msg)
-	conn.locker.Unlock()
+	conn.writer.release()
 	if err != nil {
 		klog.Errorf("write message error(%+v)", err)
 		return nil, err
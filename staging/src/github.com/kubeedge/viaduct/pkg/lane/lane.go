@@ -19,11 +19,25 @@ type Lane interface {
 }
 
 func NewLane(protoType string, van interface{}) Lane {
+	return NewLaneWithCompression(protoType, van, 0)
+}
+
+// NewLaneWithCompression returns the lane which compresses the messages not smaller than
+// compressThreshold, it must be used only if the peer supports compression
+func NewLaneWithCompression(protoType string, van interface{}, compressThreshold int) Lane {
 	switch protoType {
 	case api.ProtocolTypeQuic:
-		return NewQuicLane(van)
+		if l := NewQuicLane(van); l != nil {
+			l.compressThreshold = compressThreshold
+			return l
+		}
+		return nil
 	case api.ProtocolTypeWS:
-		return NewWSLaneWithoutPack(van)
+		if l := NewWSLaneWithoutPack(van); l != nil {
+			l.compressThreshold = compressThreshold
+			return l
+		}
+		return nil
 	}
 	klog.Errorf("bad protocol type(%s)", protoType)
 	return nil
@@ -15,6 +15,8 @@ type QuicLane struct {
 	writeDeadline time.Time
 	readDeadline  time.Time
 	stream        quic.Stream
+	// the messages not smaller than compressThreshold are compressed
+	compressThreshold int
 }
 
 func NewQuicLane(van interface{}) *QuicLane {
@@ -47,7 +49,7 @@ func (l *QuicLane) WriteMessage(msg *model.Message) error {
 		return err
 	}
 
-	_, err = packer.NewWriter(l.stream).Write(rawData)
+	_, err = packer.NewWriter(l.stream).WithCompression(l.compressThreshold).Write(rawData)
 	return err
 }
 
@@ -1,6 +1,8 @@
 package lane
 
 import (
+	"bytes"
+	"encoding/json"
 	"io"
 	"time"
 
@@ -8,12 +10,16 @@ import (
 	"k8s.io/klog/v2"
 
 	"github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/viaduct/pkg/packer"
 )
 
 type WSLaneWithoutPack struct {
 	writeDeadline time.Time
 	readDeadline  time.Time
 	conn          *websocket.Conn
+	// the messages not smaller than compressThreshold are packed with compression
+	// and sent as binary messages, the others are sent as json text messages
+	compressThreshold int
 }
 
 func NewWSLaneWithoutPack(van interface{}) *WSLaneWithoutPack {
@@ -37,7 +43,24 @@ func (l *WSLaneWithoutPack) Read(p []byte) (int, error) {
 }
 
 func (l *WSLaneWithoutPack) ReadMessage(msg *model.Message) error {
-	return l.conn.ReadJSON(msg)
+	messageType, r, err := l.conn.NextReader()
+	if err != nil {
+		return err
+	}
+	if messageType == websocket.BinaryMessage {
+		// binary messages are packed json, which may be compressed
+		data, err := packer.NewReader(r).Read()
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(data, msg)
+	}
+	err = json.NewDecoder(r).Decode(msg)
+	if err == io.EOF {
+		// one value is expected in the message
+		err = io.ErrUnexpectedEOF
+	}
+	return err
 }
 
 func (l *WSLaneWithoutPack) Write(p []byte) (int, error) {
@@ -50,7 +73,21 @@ func (l *WSLaneWithoutPack) Write(p []byte) (int, error) {
 }
 
 func (l *WSLaneWithoutPack) WriteMessage(msg *model.Message) error {
-	return l.conn.WriteJSON(msg)
+	if l.compressThreshold <= 0 {
+		return l.conn.WriteJSON(msg)
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if len(data) < l.compressThreshold {
+		return l.conn.WriteMessage(websocket.TextMessage, data)
+	}
+	var buffer bytes.Buffer
+	if _, err := packer.NewWriter(&buffer).WithCompression(l.compressThreshold).Write(data); err != nil {
+		return err
+	}
+	return l.conn.WriteMessage(websocket.BinaryMessage, buffer.Bytes())
 }
 
 func (l *WSLaneWithoutPack) SetReadDeadline(t time.Time) error {
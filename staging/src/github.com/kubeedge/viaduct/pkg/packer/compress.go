@@ -0,0 +1,91 @@
+package packer
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// CompressionZstd is the compression algorithm of the payloads
+	CompressionZstd = "zstd"
+
+	// the max size of a decompressed payload
+	maxDecompressedSize = 64 << 20
+)
+
+// CompressionStats records the size of the compressed payloads
+type CompressionStats struct {
+	// RawBytes is the size of the payloads before compression
+	RawBytes uint64
+	// CompressedBytes is the size of the payloads after compression
+	CompressedBytes uint64
+}
+
+var (
+	encoder      *zstd.Encoder
+	decoder      *zstd.Decoder
+	initCodec    sync.Once
+	sentStats    CompressionStats
+	receiveStats CompressionStats
+)
+
+func codec() (*zstd.Encoder, *zstd.Decoder) {
+	initCodec.Do(func() {
+		// the options are valid, so errors are impossible
+		encoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+		decoder, _ = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(maxDecompressedSize))
+	})
+	return encoder, decoder
+}
+
+// AcceptCompression reports whether the compression algorithms in value,
+// which are separated by commas, include the one supported
+func AcceptCompression(value string) bool {
+	for _, algorithm := range strings.Split(value, ",") {
+		if strings.TrimSpace(algorithm) == CompressionZstd {
+			return true
+		}
+	}
+	return false
+}
+
+// Compress compresses the payload, ok is false if the payload can not be smaller
+func Compress(data []byte) (compressed []byte, ok bool) {
+	enc, _ := codec()
+	compressed = enc.EncodeAll(data, make([]byte, 0, len(data)/2))
+	if len(compressed) >= len(data) {
+		return nil, false
+	}
+	atomic.AddUint64(&sentStats.RawBytes, uint64(len(data)))
+	atomic.AddUint64(&sentStats.CompressedBytes, uint64(len(compressed)))
+	return compressed, true
+}
+
+// Decompress decompresses the payload compressed by Compress
+func Decompress(compressed []byte) ([]byte, error) {
+	_, dec := codec()
+	data, err := dec.DecodeAll(compressed, nil)
+	if err != nil {
+		return nil, err
+	}
+	atomic.AddUint64(&receiveStats.RawBytes, uint64(len(data)))
+	atomic.AddUint64(&receiveStats.CompressedBytes, uint64(len(compressed)))
+	return data, nil
+}
+
+// GetCompressionStats returns the size of the payloads compressed for sending and
+// the ones decompressed after receiving since the process started
+func GetCompressionStats() (sent, received CompressionStats) {
+	sent = CompressionStats{
+		RawBytes:        atomic.LoadUint64(&sentStats.RawBytes),
+		CompressedBytes: atomic.LoadUint64(&sentStats.CompressedBytes),
+	}
+	received = CompressionStats{
+		RawBytes:        atomic.LoadUint64(&receiveStats.RawBytes),
+		CompressedBytes: atomic.LoadUint64(&receiveStats.CompressedBytes),
+	}
+	return sent, received
+}
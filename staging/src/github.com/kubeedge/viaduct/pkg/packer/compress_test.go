@@ -0,0 +1,61 @@
+package packer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestWriteCompressed is function to test Writer.Write() and Reader.Read() with compression.
+func TestWriteCompressed(t *testing.T) {
+	large := []byte(strings.Repeat(`{"kind":"ConfigMap","data":{"key":"value"}}`, 100))
+	tests := []struct {
+		name       string
+		data       []byte
+		threshold  int
+		compressed bool
+	}{
+		{name: "compression disabled", data: large, threshold: 0},
+		{name: "smaller than threshold", data: []byte(`{"kind":"ConfigMap"}`), threshold: 1024},
+		{name: "compressed", data: large, threshold: 1024, compressed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer bytes.Buffer
+			if _, err := NewWriter(&buffer).WithCompression(tt.threshold).Write(tt.data); err != nil {
+				t.Fatalf("Writer.Write() error = %v", err)
+			}
+			header := PackageHeader{}
+			header.Unpack(buffer.Bytes()[:HeaderSize])
+			if compressed := header.GetFlags()&FlagCompressed != 0; compressed != tt.compressed {
+				t.Errorf("compressed = %v, want %v", compressed, tt.compressed)
+			}
+			if tt.compressed && int(header.GetPayloadLen()) >= len(tt.data) {
+				t.Errorf("payload length %d is not smaller than %d", header.GetPayloadLen(), len(tt.data))
+			}
+
+			got, err := NewReader(&buffer).Read()
+			if err != nil {
+				t.Fatalf("Reader.Read() error = %v", err)
+			}
+			if !bytes.Equal(got, tt.data) {
+				t.Errorf("Reader.Read() = %s, want %s", got, tt.data)
+			}
+		})
+	}
+}
+
+// TestAcceptCompression is function to test AcceptCompression().
+func TestAcceptCompression(t *testing.T) {
+	tests := map[string]bool{
+		"":           false,
+		"gzip":       false,
+		"zstd":       true,
+		"gzip, zstd": true,
+	}
+	for value, want := range tests {
+		if got := AcceptCompression(value); got != want {
+			t.Errorf("AcceptCompression(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
@@ -20,6 +20,7 @@ func NewReader(r io.Reader) *Reader {
 // 1)read the package header
 // 2)unpack the package header and get the payload length
 // 3)read the payload
+// 4)decompress the payload if it is compressed
 func (r *Reader) Read() ([]byte, error) {
 	if r.reader == nil {
 		klog.Error("bad io reader")
@@ -47,5 +48,8 @@ func (r *Reader) Read() ([]byte, error) {
 		return nil, err
 	}
 
+	if header.GetFlags()&FlagCompressed != 0 {
+		return Decompress(payloadBuffer)
+	}
 	return payloadBuffer, nil
 }
@@ -9,6 +9,9 @@ import (
 
 type Writer struct {
 	writer io.Writer
+	// the payloads not smaller than compressThreshold are compressed,
+	// compression is disabled if it is 0
+	compressThreshold int
 }
 
 // new Writer instance
@@ -16,6 +19,12 @@ func NewWriter(w io.Writer) *Writer {
 	return &Writer{writer: w}
 }
 
+// WithCompression enables compression of the payloads not smaller than threshold
+func (w *Writer) WithCompression(threshold int) *Writer {
+	w.compressThreshold = threshold
+	return w
+}
+
 // Write message raw data
 // steps:
 // 1) packer the package header
@@ -29,7 +38,14 @@ func (w *Writer) Write(data []byte) (int, error) {
 
 	// packing header
 	header := NewPackageHeader(Message)
-	header.SetPayloadLen(uint32(len(data)))
+	payload := data
+	if w.compressThreshold > 0 && len(data) >= w.compressThreshold {
+		if compressed, ok := Compress(data); ok {
+			payload = compressed
+			header.SetFlags(header.GetFlags() | FlagCompressed)
+		}
+	}
+	header.SetPayloadLen(uint32(len(payload)))
 	var headerBuffer []byte
 	header.Pack(&headerBuffer)
 
@@ -41,7 +57,7 @@ func (w *Writer) Write(data []byte) (int, error) {
 	}
 
 	// write payload
-	_, err = w.writer.Write(data)
+	_, err = w.writer.Write(payload)
 	if err != nil {
 		klog.Error("failed to write payload")
 		return 0, err
@@ -2,9 +2,11 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/lucas-clemente/quic-go"
 	"k8s.io/klog/v2"
@@ -14,6 +16,7 @@ import (
 	"github.com/kubeedge/viaduct/pkg/comm"
 	"github.com/kubeedge/viaduct/pkg/conn"
 	"github.com/kubeedge/viaduct/pkg/lane"
+	"github.com/kubeedge/viaduct/pkg/packer"
 )
 
 type QuicServer struct {
@@ -69,18 +72,22 @@ func (srv *QuicServer) acceptControlStream(session quic.Session) quic.Stream {
 	return stream
 }
 
+// errNotAdmitted is returned when the client handshaking is not admitted or redirected
+var errNotAdmitted = errors.New("client is not admitted")
+
 // receive header from control lane
-func (srv *QuicServer) receiveHeader(lane lane.Lane) (http.Header, error) {
+// returns whether the client supports compression
+func (srv *QuicServer) receiveHeader(lane lane.Lane) (http.Header, bool, error) {
 	var msg model.Message
 	// read control message
 	err := lane.ReadMessage(&msg)
 	if err != nil {
 		klog.Error("failed read control message")
-		return nil, err
+		return nil, false, err
 	}
 
 	// process control message
-	result := comm.RespTypeAck
+	var result interface{} = comm.RespTypeAck
 	headers := make(http.Header)
 	err = json.Unmarshal(msg.GetContent().([]byte), &headers)
 	if err != nil {
@@ -88,14 +95,42 @@ func (srv *QuicServer) receiveHeader(lane lane.Lane) (http.Header, error) {
 		result = comm.RespTypeNack
 	}
 
+	// respond the headers accepted instead of ack if compression is negotiated
+	compression := err == nil && srv.options.CompressThreshold > 0 &&
+		packer.AcceptCompression(headers.Get(comm.HeaderCompression))
+	if compression {
+		result = http.Header{comm.HeaderCompression: []string{packer.CompressionZstd}}
+	}
+	// respond the location of the server to connect instead if the client is redirected
+	admitted := true
+	if err == nil && srv.options.Redirect != nil {
+		if location, redirected := srv.options.Redirect(headers); redirected {
+			admitted = false
+			result = http.Header{comm.HeaderLocation: []string{location}}
+		}
+	}
+	// respond the retry-after hint instead if the client is not admitted
+	if err == nil && admitted && srv.options.Admit != nil {
+		var retryAfter time.Duration
+		if retryAfter, admitted = srv.options.Admit(headers); !admitted {
+			result = http.Header{comm.HeaderRetryAfter: []string{comm.FormatRetryAfter(retryAfter)}}
+		}
+	}
+
 	// feedback the response
 	resp := msg.NewRespByMessage(&msg, result)
 	err = lane.WriteMessage(resp)
 	if err != nil {
 		klog.Errorf("failed to send response back, error:%+v", err)
-		return nil, err
+		if admitted && srv.options.Release != nil {
+			srv.options.Release(headers)
+		}
+		return nil, false, err
+	}
+	if !admitted {
+		return nil, false, errNotAdmitted
 	}
-	return headers, nil
+	return headers, compression, nil
 }
 
 // handle session
@@ -111,7 +146,16 @@ func (srv *QuicServer) handleSession(session quic.Session) {
 	}
 
 	ctrlLane := lane.NewLane(api.ProtocolTypeQuic, ctrlStream)
-	header, err := srv.receiveHeader(ctrlLane)
+	compressThreshold := 0
+	header, compression, err := srv.receiveHeader(ctrlLane)
+	if compression {
+		compressThreshold = srv.options.CompressThreshold
+	}
+	if err == errNotAdmitted {
+		klog.Warningf("client %s is not admitted", session.RemoteAddr())
+		// the client closes the session after reading the response
+		return
+	}
 	if err != nil {
 		klog.Errorf("failed to complete get header, error: %+v", err)
 	}
@@ -130,6 +174,8 @@ func (srv *QuicServer) handleSession(session quic.Session) {
 		},
 		AutoRoute:          srv.options.AutoRoute,
 		OnReadTransportErr: srv.options.OnReadTransportErr,
+		CompressThreshold:  compressThreshold,
+		Classify:           srv.options.Classify,
 	})
 
 	// connection callback
@@ -33,6 +33,11 @@ type Options struct {
 	HandshakeTimeout   time.Duration
 	Handler            mux.Handler
 	Consumer           io.Writer
+	CompressThreshold  int
+	Classify           api.ClassifyFunc
+	Admit              api.AdmitFunc
+	Release            api.ReleaseFunc
+	Redirect           api.RedirectFunc
 }
 
 type Server struct {
@@ -56,6 +61,20 @@ type Server struct {
 	Handler mux.Handler
 	// consumer for raw data
 	Consumer io.Writer
+	// the payloads of messages not smaller than CompressThreshold are compressed
+	// if the client supports compression, compression is disabled if it is 0
+	CompressThreshold int
+	// Classify returns the traffic class of the messages, all messages are
+	// of the control class if it is nil
+	Classify api.ClassifyFunc
+	// Admit decides whether to admit the client handshaking, all clients are admitted if it is nil
+	Admit api.AdmitFunc
+	// Release is called when the client admitted fails to connect before ConnNotify,
+	// nothing is released if it is nil
+	Release api.ReleaseFunc
+	// Redirect decides whether to redirect the client handshaking to another server,
+	// no client is redirected if it is nil
+	Redirect api.RedirectFunc
 	// extend options
 	ExOpts interface{}
 
@@ -120,6 +139,11 @@ func (s *Server) ListenAndServeTLS(certFile, keyFile string) error {
 		Handler:            s.Handler,
 		Consumer:           s.Consumer,
 		OnReadTransportErr: s.OnReadTransportErr,
+		CompressThreshold:  s.CompressThreshold,
+		Classify:           s.Classify,
+		Admit:              s.Admit,
+		Release:            s.Release,
+		Redirect:           s.Redirect,
 	})
 	if err != nil {
 		return err
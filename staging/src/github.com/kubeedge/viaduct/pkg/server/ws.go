@@ -1,6 +1,7 @@
 package server
 
 import (
+	"crypto/x509"
 	glog "log"
 	"net/http"
 	"os"
@@ -10,8 +11,10 @@ import (
 	"k8s.io/klog/v2"
 
 	"github.com/kubeedge/viaduct/pkg/api"
+	"github.com/kubeedge/viaduct/pkg/comm"
 	"github.com/kubeedge/viaduct/pkg/conn"
 	"github.com/kubeedge/viaduct/pkg/lane"
+	"github.com/kubeedge/viaduct/pkg/packer"
 )
 
 // websocket protocol server
@@ -54,11 +57,11 @@ func NewWSServer(opts Options, exOpts interface{}) *WSServer {
 	return wsServer
 }
 
-func (srv *WSServer) upgrade(w http.ResponseWriter, r *http.Request) *websocket.Conn {
+func (srv *WSServer) upgrade(w http.ResponseWriter, r *http.Request, responseHeader http.Header) *websocket.Conn {
 	upgrader := websocket.Upgrader{
 		HandshakeTimeout: srv.options.HandshakeTimeout,
 	}
-	conn, err := upgrader.Upgrade(w, r, nil)
+	conn, err := upgrader.Upgrade(w, r, responseHeader)
 	if err != nil {
 		klog.Error("failed to upgrade to websocket")
 		return nil
@@ -74,11 +77,45 @@ func (srv *WSServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		}
 	}
 
-	wsConn := srv.upgrade(w, req)
+	if srv.options.Redirect != nil {
+		if location, redirected := srv.options.Redirect(req.Header); redirected {
+			klog.Infof("client %s is redirected to %s", req.RemoteAddr, location)
+			w.Header().Set(comm.HeaderLocation, location)
+			w.WriteHeader(http.StatusTemporaryRedirect)
+			return
+		}
+	}
+
+	if srv.options.Admit != nil {
+		if retryAfter, admitted := srv.options.Admit(req.Header); !admitted {
+			klog.Warningf("client %s is not admitted, retry after %s", req.RemoteAddr, retryAfter)
+			w.Header().Set(comm.HeaderRetryAfter, comm.FormatRetryAfter(retryAfter))
+			http.Error(w, "server is busy", http.StatusServiceUnavailable)
+			return
+		}
+	}
+
+	// compress only if the client supports
+	compressThreshold := 0
+	responseHeader := make(http.Header)
+	if srv.options.CompressThreshold > 0 && packer.AcceptCompression(req.Header.Get(comm.HeaderCompression)) {
+		compressThreshold = srv.options.CompressThreshold
+		responseHeader.Set(comm.HeaderCompression, packer.CompressionZstd)
+	}
+
+	wsConn := srv.upgrade(w, req, responseHeader)
 	if wsConn == nil {
+		if srv.options.Release != nil {
+			srv.options.Release(req.Header)
+		}
 		return
 	}
 
+	var peerCertificates []*x509.Certificate
+	if req.TLS != nil {
+		peerCertificates = req.TLS.PeerCertificates
+	}
+
 	conn := conn.NewConnection(&conn.ConnectionOptions{
 		ConnType: api.ProtocolTypeWS,
 		Base:     wsConn,
@@ -87,11 +124,14 @@ func (srv *WSServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		Handler:  srv.options.Handler,
 		CtrlLane: lane.NewLane(api.ProtocolTypeWS, wsConn),
 		State: &conn.ConnectionState{
-			State:   api.StatConnected,
-			Headers: req.Header.Clone(),
+			State:            api.StatConnected,
+			Headers:          req.Header.Clone(),
+			PeerCertificates: peerCertificates,
 		},
 		AutoRoute:          srv.options.AutoRoute,
 		OnReadTransportErr: srv.options.OnReadTransportErr,
+		CompressThreshold:  compressThreshold,
+		Classify:           srv.options.Classify,
 	})
 
 	// connection callback